@@ -5,16 +5,20 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/skyhook-io/radar/internal/helm"
+	"github.com/skyhook-io/radar/internal/images"
 	"github.com/skyhook-io/radar/internal/k8s"
 	"github.com/skyhook-io/radar/internal/server"
 	"github.com/skyhook-io/radar/internal/static"
@@ -28,13 +32,26 @@ var (
 	version = "dev"
 )
 
+// restartExitCode is the exit code after a shutdown requested through the
+// admin endpoint (EX_TEMPFAIL), so the container is restarted rather than
+// treated as completed
+const restartExitCode = 75
+
 func main() {
 	// Parse flags
 	kubeconfig := flag.String("kubeconfig", "", "Path to kubeconfig file (default: ~/.kube/config)")
 	kubeconfigDir := flag.String("kubeconfig-dir", "", "Comma-separated directories containing kubeconfig files (mutually exclusive with --kubeconfig)")
-	namespace := flag.String("namespace", "", "Initial namespace filter (empty = all namespaces)")
-	port := flag.Int("port", 9280, "Server port")
+	namespace := flag.String("namespace", "", "Initial namespace filter (default: the kubeconfig context's namespace; empty = all namespaces)")
+	port := flag.Int("port", 9280, "Server port (0 = any free port)")
+	autoPort := flag.Bool("auto-port", false, "Use a free port if --port is already taken")
+	bind := flag.String("bind", "127.0.0.1", "Address to listen on (use 0.0.0.0 for all interfaces)")
+	basePath := flag.String("base-path", "", "URL path prefix when served behind a reverse proxy, e.g. /explorer")
+	tlsCert := flag.String("tls-cert", "", "Path to TLS certificate file (PEM); serves HTTPS together with --tls-key")
+	tlsKey := flag.String("tls-key", "", "Path to TLS private key file (PEM)")
+	tlsSelfSigned := flag.Bool("tls-self-signed", false, "Serve HTTPS with a generated self-signed certificate (ignored if --tls-cert is set)")
+	readOnly := flag.Bool("read-only", false, "Disable all write operations (Helm and GitOps actions, edit/delete, exec, port-forward) regardless of RBAC")
 	noBrowser := flag.Bool("no-browser", false, "Don't auto-open browser")
+	browser := flag.String("browser", "", "Command to open the browser with, \"%s\" is replaced by the URL (default: $BROWSER, else the platform opener; empty = don't open)")
 	devMode := flag.Bool("dev", false, "Development mode (serve frontend from filesystem)")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	historyLimit := flag.Int("history-limit", 10000, "Maximum number of events to retain in timeline")
@@ -42,11 +59,49 @@ func main() {
 	// Timeline storage options
 	timelineStorage := flag.String("timeline-storage", "memory", "Timeline storage backend: memory or sqlite")
 	timelineDBPath := flag.String("timeline-db", "", "Path to timeline database file (default: ~/.radar/timeline.db)")
+	prewarmImages := flag.Int("prewarm-images", 0, "Inspect the N most common running images in the background on startup (0 = disabled)")
+	imageRegistryAllow := flag.String("image-registry-allowlist", "", "Comma-separated registries images may be inspected from (empty = all), e.g. gcr.io,*.corp.example.com")
+	imageRegistryDeny := flag.String("image-registry-denylist", "", "Comma-separated registries images may never be inspected from")
+	cacheDir := flag.String("cache-dir", "", "Directory for the image layer cache, e.g. a mounted volume (default: system temp dir)")
+	persistCache := flag.Bool("persist-cache", false, "Keep the image layer cache across restarts, validating existing entries on startup instead of wiping them (use with --cache-dir on a persistent volume)")
+	imageCacheTTL := flag.Duration("image-cache-ttl", 0, "How long a cached image stays valid (default: 5m, or 24h with --persist-cache)")
+	imageCacheCleanup := flag.Duration("image-cache-cleanup-interval", time.Minute, "How often expired images are removed from the layer cache")
+	imageAuthOrder := flag.String("image-auth-order", strings.Join(images.DefaultKeychainOrder, ","), "Order in which registry credential sources are tried, comma-separated pull-secrets, cloud, docker-config; sources left out are disabled")
+	imageRateLimit := flag.Int("image-rate-limit", 0, "Maximum image inspection requests per minute per client (0 = unlimited)")
+	imagePullTimeout := flag.Duration("image-pull-timeout", 2*time.Minute, "Maximum time an image request may spend fetching the manifest and downloading layers from the registry (0 = no limit)")
+	imageInspectTimeout := flag.Duration("image-inspect-timeout", 10*time.Minute, "Maximum time for a whole image request, including building the file tree (0 = no limit)")
+	trafficExclude := flag.String("traffic-exclude", "kube-system,health-checks,dns", "Background traffic hidden from flow results unless a request overrides it: comma-separated kube-system, health-checks, dns, or none (Hubble only)")
+	trafficFlowLimit := flag.Int("traffic-flow-limit", traffic.DefaultFlowLimit, "Flows fetched per request when the request doesn't set a limit")
+	trafficMaxFlowLimit := flag.Int("traffic-max-flow-limit", traffic.DefaultMaxFlowLimit, "Most flows a single request may fetch; larger limits are clamped to it")
+	adminToken := flag.String("admin-token", os.Getenv("RADAR_ADMIN_TOKEN"), "Bearer token enabling the admin endpoints, e.g. POST /api/admin/shutdown (default: $RADAR_ADMIN_TOKEN; empty = disabled)")
+	enablePprof := flag.Bool("pprof", false, "Serve Go runtime profiles (net/http/pprof) under /debug/pprof for diagnosing memory and CPU use")
 	flag.Parse()
 
 	// Set debug mode for event tracking
 	k8s.DebugEvents = *debugEvents
 
+	// Restrict which registries the image inspector may pull from
+	images.SetRegistryPolicy(splitList(*imageRegistryAllow), splitList(*imageRegistryDeny))
+	images.SetRateLimit(*imageRateLimit)
+	images.SetTimeouts(*imagePullTimeout, *imageInspectTimeout)
+	images.SetPersistentCache(*persistCache)
+	images.SetCacheLifetime(*imageCacheTTL, *imageCacheCleanup)
+	if order, err := images.ParseKeychainOrder(*imageAuthOrder); err != nil {
+		log.Fatalf("Invalid --image-auth-order: %v", err)
+	} else {
+		images.SetKeychainOrder(order)
+	}
+
+	// Noise hidden from flow results unless a request picks its own exclusions
+	if exclusions, err := traffic.ParseNoiseExclusions(*trafficExclude); err != nil {
+		log.Fatalf("Invalid --traffic-exclude: %v", err)
+	} else {
+		traffic.SetDefaultNoiseExclusions(exclusions)
+	}
+	if err := traffic.SetFlowLimits(*trafficFlowLimit, *trafficMaxFlowLimit); err != nil {
+		log.Fatalf("Invalid traffic flow limits: %v", err)
+	}
+
 	if *showVersion {
 		fmt.Printf("radar %s\n", version)
 		os.Exit(0)
@@ -65,18 +120,18 @@ func main() {
 	if *kubeconfig != "" && *kubeconfigDir != "" {
 		log.Fatalf("--kubeconfig and --kubeconfig-dir are mutually exclusive")
 	}
-
-	// Parse kubeconfig directories if provided
-	var kubeconfigDirs []string
-	if *kubeconfigDir != "" {
-		for _, dir := range strings.Split(*kubeconfigDir, ",") {
-			dir = strings.TrimSpace(dir)
-			if dir != "" {
-				kubeconfigDirs = append(kubeconfigDirs, dir)
-			}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		log.Fatalf("--tls-cert and --tls-key must be set together")
+	}
+	if *cacheDir != "" {
+		if err := images.CheckCacheDir(*cacheDir); err != nil {
+			log.Fatalf("--cache-dir %s is not writable: %v", *cacheDir, err)
 		}
 	}
 
+	// Parse kubeconfig directories if provided
+	kubeconfigDirs := splitList(*kubeconfigDir)
+
 	// Initialize K8s client
 	err := k8s.Initialize(k8s.InitOptions{
 		KubeconfigPath: *kubeconfig,
@@ -94,6 +149,19 @@ func main() {
 		log.Printf("Using in-cluster config")
 	}
 
+	// Like kubectl, default to the namespace of the current context unless
+	// --namespace was given, even as empty for all namespaces
+	namespaceSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "namespace" {
+			namespaceSet = true
+		}
+	})
+	if ns := k8s.GetContextNamespace(); !namespaceSet && ns != "" {
+		*namespace = ns
+		log.Printf("Using namespace %s from context %s", ns, k8s.GetContextName())
+	}
+
 	// Preflight check: verify cluster connectivity before starting informers
 	if err := checkClusterAccess(); err != nil {
 		// Error already printed with helpful message
@@ -161,6 +229,9 @@ func main() {
 		log.Printf("Warning: Failed to initialize traffic manager: %v", err)
 	}
 
+	// Resolve external flow IPs to cluster objects via the resource cache
+	traffic.SetIPLookup(k8s.LookupIP)
+
 	// Register traffic reset/reinit functions for context switching
 	k8s.RegisterTrafficFuncs(traffic.Reset, func() error {
 		return traffic.ReinitializeWithConfig(k8s.GetClient(), k8s.GetConfig(), k8s.GetContextName())
@@ -169,12 +240,47 @@ func main() {
 	// Create and start server
 	cfg := server.Config{
 		Port:       *port,
+		AutoPort:   *autoPort,
+		Bind:       *bind,
+		BasePath:   *basePath,
 		DevMode:    *devMode,
 		StaticFS:   static.FS,
 		StaticRoot: "dist",
+
+		TLSCertFile:   *tlsCert,
+		TLSKeyFile:    *tlsKey,
+		TLSSelfSigned: *tlsSelfSigned,
+
+		ReadOnly: *readOnly,
+
+		PrewarmImages: *prewarmImages,
+		ImageCacheDir: *cacheDir,
+
+		AdminToken: *adminToken,
+
+		EnablePprof: *enablePprof,
 	}
 
-	srv := server.New(cfg)
+	var srv *server.Server
+	var shutdownOnce sync.Once
+	shutdown := func(code int) {
+		shutdownOnce.Do(func() {
+			log.Println("Shutting down...")
+			srv.Stop()
+			if cache := k8s.GetResourceCache(); cache != nil {
+				cache.Stop()
+			}
+			if dynCache := k8s.GetDynamicResourceCache(); dynCache != nil {
+				dynCache.Stop()
+			}
+			// Close timeline store
+			timeline.ResetStore()
+			os.Exit(code)
+		})
+	}
+	cfg.OnShutdown = func() { shutdown(restartExitCode) }
+
+	srv = server.New(cfg)
 
 	// Handle shutdown signals
 	sigCh := make(chan os.Signal, 1)
@@ -182,26 +288,59 @@ func main() {
 
 	go func() {
 		<-sigCh
-		log.Println("Shutting down...")
-		srv.Stop()
-		if cache := k8s.GetResourceCache(); cache != nil {
-			cache.Stop()
-		}
-		if dynCache := k8s.GetDynamicResourceCache(); dynCache != nil {
-			dynCache.Stop()
+		shutdown(0)
+	}()
+
+	// SIGHUP reloads the kubeconfig and rebuilds the caches without restarting
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+
+	go func() {
+		for range hupCh {
+			log.Println("Received SIGHUP, reloading kubeconfig...")
+			server.StopAllSessions()
+			if err := k8s.PerformReload(); err != nil {
+				log.Printf("Reload failed: %v", err)
+				continue
+			}
+			log.Println("Reload complete")
 		}
-		// Close timeline store
-		timeline.ResetStore()
-		os.Exit(0)
 	}()
 
+	// Bind before opening the browser so it targets the port actually in use
+	boundPort, err := srv.Listen()
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	if boundPort != *port {
+		log.Printf("Listening on port %d", boundPort)
+	}
+
 	// Open browser unless disabled
 	if !*noBrowser {
-		url := fmt.Sprintf("http://localhost:%d", *port)
+		scheme := "http"
+		if *tlsCert != "" || *tlsSelfSigned {
+			scheme = "https"
+		}
+		urlPath := "/"
+		if trimmed := strings.Trim(*basePath, "/"); trimmed != "" {
+			urlPath = "/" + trimmed + "/"
+		}
+		url := fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(browserHost(*bind), strconv.Itoa(boundPort)), urlPath)
 		if *namespace != "" {
 			url += fmt.Sprintf("?namespace=%s", *namespace)
 		}
-		go openBrowser(url)
+		// --browser wins over $BROWSER; either one set to empty disables opening
+		browserCmd, browserSet := *browser, false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "browser" {
+				browserSet = true
+			}
+		})
+		if !browserSet {
+			browserCmd, browserSet = os.LookupEnv("BROWSER")
+		}
+		go openBrowser(url, browserCmd, browserSet)
 	}
 
 	// Start server (blocks)
@@ -210,18 +349,43 @@ func main() {
 	}
 }
 
-func openBrowser(url string) {
-	var cmd *exec.Cmd
+// splitList parses a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
 
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", url)
-	case "linux":
-		cmd = exec.Command("xdg-open", url)
-	case "windows":
-		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
-	default:
-		log.Printf("Cannot open browser on %s, please open manually: %s", runtime.GOOS, url)
+// browserHost returns the host to open in the browser for a bind address.
+// Wildcard and loopback binds are reachable via localhost.
+func browserHost(bind string) string {
+	if ip := net.ParseIP(bind); bind == "" || (ip != nil && (ip.IsUnspecified() || ip.IsLoopback())) {
+		return "localhost"
+	}
+	return bind
+}
+
+// openBrowser opens url with the given command, or the platform default when
+// none is configured. A configured but empty command disables opening.
+func openBrowser(url, command string, configured bool) {
+	if configured && strings.TrimSpace(command) == "" {
+		log.Printf("Browser command is empty, not opening a browser. Open manually: %s", url)
+		return
+	}
+
+	var cmd *exec.Cmd
+	if configured {
+		cmd = customBrowserCommand(command, url)
+	} else {
+		cmd = defaultBrowserCommand(url)
+	}
+	if cmd == nil {
+		log.Printf("No browser found, please open manually: %s", url)
 		return
 	}
 
@@ -231,6 +395,76 @@ func openBrowser(url string) {
 	}
 }
 
+// customBrowserCommand builds the command for a --browser or $BROWSER value.
+// Like $BROWSER, the value may list several commands separated by colons, and
+// the first one on the PATH is used (except on Windows, where colons belong to
+// drive letters). "%s" in a command is replaced by the URL; otherwise the URL
+// is appended.
+func customBrowserCommand(command, url string) *exec.Cmd {
+	candidates := []string{command}
+	if runtime.GOOS != "windows" {
+		candidates = strings.Split(command, ":")
+	}
+	for _, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			continue
+		}
+
+		args := fields[1:]
+		substituted := false
+		for idx, arg := range args {
+			if strings.Contains(arg, "%s") {
+				args[idx] = strings.ReplaceAll(arg, "%s", url)
+				substituted = true
+			}
+		}
+		if !substituted {
+			args = append(args, url)
+		}
+		return exec.Command(fields[0], args...)
+	}
+	return nil
+}
+
+// defaultBrowserCommand returns the platform's URL opener, or nil if there is
+// none (e.g. a Linux host reached over SSH without xdg-open)
+func defaultBrowserCommand(url string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "linux":
+		// WSL has no desktop of its own; hand the URL to the Windows browser
+		if isWSL() {
+			if _, err := exec.LookPath("wslview"); err == nil {
+				return exec.Command("wslview", url)
+			}
+			if _, err := exec.LookPath("cmd.exe"); err == nil {
+				// Escape & so cmd doesn't treat it as a command separator
+				return exec.Command("cmd.exe", "/c", "start", "", strings.ReplaceAll(url, "&", "^&"))
+			}
+		}
+		if _, err := exec.LookPath("xdg-open"); err == nil {
+			return exec.Command("xdg-open", url)
+		}
+	}
+	return nil
+}
+
+// isWSL reports whether we're running under Windows Subsystem for Linux
+func isWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
 // checkClusterAccess verifies connectivity to the Kubernetes cluster before starting informers.
 // Returns a user-friendly error if authentication or connection fails.
 func checkClusterAccess() error {
@@ -2,6 +2,7 @@ package helm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -17,6 +18,7 @@ import (
 
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
@@ -72,6 +74,7 @@ func ResetClient() {
 
 	globalClient = nil
 	clientOnce = sync.Once{}
+	clearReleaseCache()
 }
 
 // ReinitClient reinitializes the Helm client after a context switch
@@ -113,6 +116,16 @@ func (c *Client) getActionConfig(namespace string) (*action.Configuration, error
 
 // ListReleases returns all Helm releases, optionally filtered by namespace
 func (c *Client) ListReleases(namespace string) ([]HelmRelease, error) {
+	return c.listReleases(namespace, true)
+}
+
+// ListReleasesWithoutHealth is ListReleases without the resource health
+// fields, which need each release's manifest parsed and resources looked up
+func (c *Client) ListReleasesWithoutHealth(namespace string) ([]HelmRelease, error) {
+	return c.listReleases(namespace, false)
+}
+
+func (c *Client) listReleases(namespace string, withHealth bool) ([]HelmRelease, error) {
 	actionConfig, err := c.getActionConfig(namespace)
 	if err != nil {
 		return nil, err
@@ -130,7 +143,7 @@ func (c *Client) ListReleases(namespace string) ([]HelmRelease, error) {
 
 	result := make([]HelmRelease, 0, len(releases))
 	for _, rel := range releases {
-		result = append(result, toHelmRelease(rel))
+		result = append(result, toHelmRelease(rel, withHealth))
 	}
 
 	// Sort by namespace, then name
@@ -209,57 +222,63 @@ func (c *Client) GetRelease(namespace, name string) (*HelmReleaseDetail, error)
 		Readme:       readme,
 		Dependencies: dependencies,
 	}
+	detail.ResourceHealth, detail.HealthIssue, detail.HealthSummary = computeResourceHealth(resources)
+	detail.HealthMessage = healthMessage(detail.ResourceHealth, resources)
 
 	return detail, nil
 }
 
 // GetManifest returns the rendered manifest for a release at a specific revision
 func (c *Client) GetManifest(namespace, name string, revision int) (string, error) {
-	actionConfig, err := c.getActionConfig(namespace)
+	rel, err := c.getRelease(namespace, name, revision)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to get helm release manifest: %w", err)
 	}
 
-	getAction := action.NewGet(actionConfig)
-	if revision > 0 {
-		getAction.Version = revision
-	}
+	return rel.Manifest, nil
+}
 
-	rel, err := getAction.Run(name)
+// GetNotes returns the rendered NOTES.txt for a release at a specific revision
+func (c *Client) GetNotes(namespace, name string, revision int) (string, error) {
+	rel, err := c.getRelease(namespace, name, revision)
 	if err != nil {
-		return "", fmt.Errorf("failed to get helm release manifest: %w", err)
+		return "", fmt.Errorf("failed to get helm release notes: %w", err)
 	}
 
-	return rel.Manifest, nil
+	if rel.Info == nil {
+		return "", nil
+	}
+	return rel.Info.Notes, nil
 }
 
-// GetValues returns the values for a release
-func (c *Client) GetValues(namespace, name string, allValues bool) (*HelmValues, error) {
-	actionConfig, err := c.getActionConfig(namespace)
+// GetReadme returns the chart README for a release at a specific revision
+func (c *Client) GetReadme(namespace, name string, revision int) (string, error) {
+	rel, err := c.getRelease(namespace, name, revision)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to get helm release readme: %w", err)
 	}
 
-	getValuesAction := action.NewGetValues(actionConfig)
-	getValuesAction.AllValues = allValues
+	return extractReadme(rel), nil
+}
 
-	values, err := getValuesAction.Run(name)
+// GetValues returns the values for a release
+func (c *Client) GetValues(namespace, name string, allValues bool) (*HelmValues, error) {
+	rel, err := c.getRelease(namespace, name, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get helm release values: %w", err)
 	}
 
 	result := &HelmValues{
-		UserSupplied: values,
+		UserSupplied: rel.Config,
 	}
 
-	// If allValues requested, also get just user-supplied for comparison
+	// As helm get values --all does, coalescing into a copy of the user values
 	if allValues {
-		getValuesAction.AllValues = false
-		userValues, err := getValuesAction.Run(name)
-		if err == nil {
-			result.UserSupplied = userValues
-			result.Computed = values
+		computed, err := chartutil.CoalesceValues(rel.Chart, rel.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get helm release values: %w", err)
 		}
+		result.Computed = computed
 	}
 
 	return result, nil
@@ -287,8 +306,9 @@ func (c *Client) GetManifestDiff(namespace, name string, revision1, revision2 in
 	}, nil
 }
 
-// toHelmRelease converts a helm release to our API type
-func toHelmRelease(rel *release.Release) HelmRelease {
+// toHelmRelease converts a helm release to our API type, with the health
+// summary of its owned resources if withHealth is set
+func toHelmRelease(rel *release.Release, withHealth bool) HelmRelease {
 	hr := HelmRelease{
 		Name:         rel.Name,
 		Namespace:    rel.Namespace,
@@ -299,6 +319,9 @@ func toHelmRelease(rel *release.Release) HelmRelease {
 		Revision:     rel.Version,
 		Updated:      rel.Info.LastDeployed.Time,
 	}
+	if !withHealth {
+		return hr
+	}
 
 	// Compute health from owned resources
 	resources := parseManifestResources(rel.Manifest, rel.Namespace)
@@ -307,6 +330,7 @@ func toHelmRelease(rel *release.Release) HelmRelease {
 	hr.ResourceHealth = health
 	hr.HealthIssue = issue
 	hr.HealthSummary = summary
+	hr.HealthMessage = healthMessage(health, resources)
 
 	return hr
 }
@@ -400,6 +424,72 @@ func computeResourceHealth(resources []OwnedResource) (health, issue, summary st
 	return health, issue, summary
 }
 
+// healthMessage builds a one-line rollup of the release health, e.g.
+// "Degraded: 1 deployment not ready" or "Healthy: 3 workloads ready"
+func healthMessage(health string, resources []OwnedResource) string {
+	notReady := make(map[string]int)
+	var kinds []string
+	var workloads, unknown int
+
+	for _, r := range resources {
+		var ok bool
+		switch r.Kind {
+		case "Deployment", "DaemonSet", "StatefulSet", "ReplicaSet":
+			ok = r.Issue == "" && workloadReady(r.Ready)
+		case "Pod":
+			ok = r.Issue == "" && (r.Status == "Running" || r.Status == "Succeeded")
+		default:
+			continue
+		}
+		workloads++
+		if r.Status == resourceStatusUnknown {
+			unknown++
+			continue
+		}
+		if !ok {
+			kind := strings.ToLower(r.Kind)
+			if notReady[kind] == 0 {
+				kinds = append(kinds, kind)
+			}
+			notReady[kind]++
+		}
+	}
+
+	if workloads == 0 {
+		return ""
+	}
+
+	label := strings.ToUpper(health[:1]) + health[1:]
+	if len(kinds) == 0 && unknown == 0 {
+		return fmt.Sprintf("%s: %d %s ready", label, workloads, pluralize("workload", workloads))
+	}
+
+	parts := make([]string, 0, len(kinds)+1)
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s not ready", notReady[kind], pluralize(kind, notReady[kind])))
+	}
+	if unknown > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s could not be checked", unknown, pluralize("workload", unknown)))
+	}
+	return label + ": " + strings.Join(parts, ", ")
+}
+
+// workloadReady reports whether a ready string like "2/3" has all replicas ready
+func workloadReady(ready string) bool {
+	var current, desired int
+	if _, err := fmt.Sscanf(ready, "%d/%d", &current, &desired); err != nil {
+		return ready == ""
+	}
+	return current >= desired
+}
+
+func pluralize(word string, count int) string {
+	if count == 1 {
+		return word
+	}
+	return word + "s"
+}
+
 // toHelmRevision converts a helm release to a revision entry
 func toHelmRevision(rel *release.Release) HelmRevision {
 	return HelmRevision{
@@ -462,15 +552,23 @@ func parseManifestResources(manifest, defaultNamespace string) []OwnedResource {
 	return resources
 }
 
-// enrichResourcesWithStatus adds live status from k8s cache to resources
+// resourceStatusUnknown marks an owned resource whose live status couldn't be
+// looked up; its Message holds the lookup error
+const resourceStatusUnknown = "Unknown"
+
+// enrichResourcesWithStatus adds live status from k8s cache to resources.
+// A failed lookup (resource deleted, kind not readable, cache down) marks
+// that resource Unknown with the reason rather than leaving it blank.
 func enrichResourcesWithStatus(resources []OwnedResource) {
 	cache := k8s.GetResourceCache()
-	if cache == nil {
-		return
-	}
 
 	for i := range resources {
-		status := cache.GetResourceStatus(resources[i].Kind, resources[i].Namespace, resources[i].Name)
+		status, err := cache.LookupResourceStatus(resources[i].Kind, resources[i].Namespace, resources[i].Name)
+		if err != nil {
+			resources[i].Status = resourceStatusUnknown
+			resources[i].Message = err.Error()
+			continue
+		}
 		if status != nil {
 			resources[i].Status = status.Status
 			resources[i].Ready = status.Ready
@@ -639,27 +737,31 @@ func extractHooks(rel *release.Release) []HelmHook {
 
 	hooks := make([]HelmHook, 0, len(rel.Hooks))
 	for _, h := range rel.Hooks {
-		events := make([]string, 0, len(h.Events))
-		for _, e := range h.Events {
-			events = append(events, string(e))
-		}
+		hooks = append(hooks, toHelmHook(h))
+	}
 
-		hook := HelmHook{
-			Name:   h.Name,
-			Kind:   h.Kind,
-			Events: events,
-			Weight: h.Weight,
-		}
+	return hooks
+}
 
-		// Add status if available
-		if h.LastRun.Phase != "" {
-			hook.Status = string(h.LastRun.Phase)
-		}
+// toHelmHook converts a helm hook to our API type
+func toHelmHook(h *release.Hook) HelmHook {
+	events := make([]string, 0, len(h.Events))
+	for _, e := range h.Events {
+		events = append(events, string(e))
+	}
 
-		hooks = append(hooks, hook)
+	hook := HelmHook{
+		Name:   h.Name,
+		Kind:   h.Kind,
+		Events: events,
+		Weight: h.Weight,
 	}
 
-	return hooks
+	// Add status if available
+	if h.LastRun.Phase != "" {
+		hook.Status = string(h.LastRun.Phase)
+	}
+	return hook
 }
 
 // extractReadme extracts the README content from chart files
@@ -746,7 +848,7 @@ func (c *Client) CheckForUpgrade(namespace, name string) (*UpgradeInfo, error) {
 	for _, r := range f.Repositories {
 		// Load the index file for this repo
 		indexPath := filepath.Join(cacheDir, fmt.Sprintf("%s-index.yaml", r.Name))
-		indexFile, err := repo.LoadIndexFile(indexPath)
+		indexFile, err := loadRepoIndex(indexPath)
 		if err != nil {
 			// Skip repos with missing/invalid index
 			continue
@@ -813,17 +915,36 @@ func compareVersions(v1, v2 string) int {
 	return 0
 }
 
+// defaultActionTimeout bounds how long a Helm write action waits for its
+// resources to become ready
+const defaultActionTimeout = 300 * time.Second
+
+// actionTimeout returns the wait timeout for a Helm action, shortened to the
+// context's deadline when it has one
+func actionTimeout(ctx context.Context) time.Duration {
+	timeout := defaultActionTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	return timeout
+}
+
 // Rollback rolls back a release to a previous revision
-func (c *Client) Rollback(namespace, name string, revision int) error {
+func (c *Client) Rollback(ctx context.Context, namespace, name string, revision int) error {
 	actionConfig, err := c.getActionConfig(namespace)
 	if err != nil {
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
+	// Helm's rollback takes no context: once started it can only be bounded
+	// by its wait timeout, which is capped to the context deadline
 	rollbackAction := action.NewRollback(actionConfig)
 	rollbackAction.Version = revision
 	rollbackAction.Wait = true
-	rollbackAction.Timeout = 300 * time.Second
+	rollbackAction.Timeout = actionTimeout(ctx)
 
 	if err := rollbackAction.Run(name); err != nil {
 		return fmt.Errorf("rollback failed: %w", err)
@@ -832,16 +953,97 @@ func (c *Client) Rollback(namespace, name string, revision int) error {
 	return nil
 }
 
+// PreviewRollback computes what rolling back to a revision would change,
+// without applying it
+func (c *Client) PreviewRollback(namespace, name string, revision int) (*RollbackPreview, error) {
+	actionConfig, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := action.NewGet(actionConfig).Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current release: %w", err)
+	}
+
+	getAction := action.NewGet(actionConfig)
+	getAction.Version = revision
+	target, err := getAction.Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision %d: %w", revision, err)
+	}
+
+	preview := &RollbackPreview{
+		CurrentRevision:     current.Version,
+		TargetRevision:      target.Version,
+		CurrentChartVersion: current.Chart.Metadata.Version,
+		TargetChartVersion:  target.Chart.Metadata.Version,
+		ManifestDiff:        computeDiff(current.Manifest, target.Manifest, current.Version, target.Version),
+	}
+	preview.Added, preview.Removed, preview.Changed = compareManifestResources(current.Manifest, target.Manifest, namespace)
+
+	return preview, nil
+}
+
+// compareManifestResources groups the resources of two manifests into those
+// only in the new one, only in the old one, and present in both but different
+func compareManifestResources(oldManifest, newManifest, defaultNamespace string) (added, removed, changed []OwnedResource) {
+	oldDocs := manifestDocsByResource(oldManifest, defaultNamespace)
+	newDocs := manifestDocsByResource(newManifest, defaultNamespace)
+
+	for key, doc := range newDocs {
+		oldDoc, ok := oldDocs[key]
+		if !ok {
+			added = append(added, key)
+		} else if oldDoc != doc {
+			changed = append(changed, key)
+		}
+	}
+	for key := range oldDocs {
+		if _, ok := newDocs[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	for _, list := range [][]OwnedResource{added, removed, changed} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Kind != list[j].Kind {
+				return list[i].Kind < list[j].Kind
+			}
+			if list[i].Namespace != list[j].Namespace {
+				return list[i].Namespace < list[j].Namespace
+			}
+			return list[i].Name < list[j].Name
+		})
+	}
+	return added, removed, changed
+}
+
+// manifestDocsByResource maps each resource in a manifest to its document
+func manifestDocsByResource(manifest, defaultNamespace string) map[OwnedResource]string {
+	docs := make(map[OwnedResource]string)
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		for _, res := range parseManifestResources(doc, defaultNamespace) {
+			docs[res] = strings.TrimSpace(doc)
+		}
+	}
+	return docs
+}
+
 // Uninstall removes a release
-func (c *Client) Uninstall(namespace, name string) error {
+func (c *Client) Uninstall(ctx context.Context, namespace, name string) error {
 	actionConfig, err := c.getActionConfig(namespace)
 	if err != nil {
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
+	// Like rollback, uninstall takes no context; cap its wait instead
 	uninstallAction := action.NewUninstall(actionConfig)
 	uninstallAction.Wait = true
-	uninstallAction.Timeout = 300 * time.Second
+	uninstallAction.Timeout = actionTimeout(ctx)
 
 	_, err = uninstallAction.Run(name)
 	if err != nil {
@@ -852,7 +1054,7 @@ func (c *Client) Uninstall(namespace, name string) error {
 }
 
 // Upgrade upgrades a release to a new version
-func (c *Client) Upgrade(namespace, name, targetVersion string) error {
+func (c *Client) Upgrade(ctx context.Context, namespace, name, targetVersion string) error {
 	actionConfig, err := c.getActionConfig(namespace)
 	if err != nil {
 		return err
@@ -881,7 +1083,7 @@ func (c *Client) Upgrade(namespace, name, targetVersion string) error {
 	var chartPath string
 	for _, r := range repos.Repositories {
 		indexPath := filepath.Join(repoCache, r.Name+"-index.yaml")
-		idx, err := repo.LoadIndexFile(indexPath)
+		idx, err := loadRepoIndex(indexPath)
 		if err != nil {
 			continue
 		}
@@ -915,7 +1117,7 @@ func (c *Client) Upgrade(namespace, name, targetVersion string) error {
 	upgradeAction := action.NewUpgrade(actionConfig)
 	upgradeAction.Namespace = namespace
 	upgradeAction.Wait = true
-	upgradeAction.Timeout = 300 * time.Second
+	upgradeAction.Timeout = actionTimeout(ctx)
 	upgradeAction.ReuseValues = true // Keep existing values
 
 	// Download and load the chart
@@ -936,7 +1138,7 @@ func (c *Client) Upgrade(namespace, name, targetVersion string) error {
 	}
 
 	// Run the upgrade
-	_, err = upgradeAction.Run(name, chart, rel.Config)
+	_, err = upgradeAction.RunWithContext(ctx, name, chart, rel.Config)
 	if err != nil {
 		return fmt.Errorf("upgrade failed: %w", err)
 	}
@@ -944,94 +1146,6 @@ func (c *Client) Upgrade(namespace, name, targetVersion string) error {
 	return nil
 }
 
-// BatchCheckUpgrades checks for upgrades for all releases at once (more efficient)
-func (c *Client) BatchCheckUpgrades(namespace string) (*BatchUpgradeInfo, error) {
-	// Get all releases
-	releases, err := c.ListReleases(namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list releases: %w", err)
-	}
-
-	result := &BatchUpgradeInfo{
-		Releases: make(map[string]*UpgradeInfo),
-	}
-
-	if len(releases) == 0 {
-		return result, nil
-	}
-
-	// Load repo indexes once
-	repoFile := c.settings.RepositoryConfig
-	f, err := repo.LoadFile(repoFile)
-	if err != nil {
-		// No repos configured - return empty results with error
-		for _, rel := range releases {
-			key := rel.Namespace + "/" + rel.Name
-			result.Releases[key] = &UpgradeInfo{
-				CurrentVersion: rel.ChartVersion,
-				Error:          "no helm repositories configured",
-			}
-		}
-		return result, nil
-	}
-
-	// Build a map of chart name -> latest version info from all repos
-	chartLatestVersions := make(map[string]struct {
-		version  string
-		repoName string
-	})
-
-	cacheDir := c.settings.RepositoryCache
-	for _, r := range f.Repositories {
-		indexPath := filepath.Join(cacheDir, fmt.Sprintf("%s-index.yaml", r.Name))
-		indexFile, err := repo.LoadIndexFile(indexPath)
-		if err != nil {
-			continue
-		}
-
-		for chartName, versions := range indexFile.Entries {
-			if len(versions) == 0 {
-				continue
-			}
-			// versions[0] is typically the latest
-			latestInRepo := versions[0].Version
-			for _, v := range versions {
-				if compareVersions(v.Version, latestInRepo) > 0 {
-					latestInRepo = v.Version
-				}
-			}
-
-			existing, exists := chartLatestVersions[chartName]
-			if !exists || compareVersions(latestInRepo, existing.version) > 0 {
-				chartLatestVersions[chartName] = struct {
-					version  string
-					repoName string
-				}{latestInRepo, r.Name}
-			}
-		}
-	}
-
-	// Check each release against the chart versions map
-	for _, rel := range releases {
-		key := rel.Namespace + "/" + rel.Name
-		info := &UpgradeInfo{
-			CurrentVersion: rel.ChartVersion,
-		}
-
-		if latest, ok := chartLatestVersions[rel.Chart]; ok {
-			info.LatestVersion = latest.version
-			info.RepositoryName = latest.repoName
-			info.UpdateAvailable = compareVersions(latest.version, rel.ChartVersion) > 0
-		} else {
-			info.Error = "chart not found in configured repositories"
-		}
-
-		result.Releases[key] = info
-	}
-
-	return result, nil
-}
-
 // PreviewValuesChange previews the effect of new values on a release via dry-run
 func (c *Client) PreviewValuesChange(namespace, name string, newValues map[string]any) (*ValuesPreviewResponse, error) {
 	actionConfig, err := c.getActionConfig(namespace)
@@ -1080,7 +1194,7 @@ func (c *Client) PreviewValuesChange(namespace, name string, newValues map[strin
 }
 
 // ApplyValues upgrades a release with new values (same chart version)
-func (c *Client) ApplyValues(namespace, name string, newValues map[string]any) error {
+func (c *Client) ApplyValues(ctx context.Context, namespace, name string, newValues map[string]any) error {
 	actionConfig, err := c.getActionConfig(namespace)
 	if err != nil {
 		return err
@@ -1097,11 +1211,11 @@ func (c *Client) ApplyValues(namespace, name string, newValues map[string]any) e
 	upgradeAction := action.NewUpgrade(actionConfig)
 	upgradeAction.Namespace = namespace
 	upgradeAction.Wait = true
-	upgradeAction.Timeout = 300 * time.Second
+	upgradeAction.Timeout = actionTimeout(ctx)
 	upgradeAction.ResetValues = true // Use only the provided values, don't merge
 
 	// Run the upgrade with the existing chart and new values
-	_, err = upgradeAction.Run(name, rel.Chart, newValues)
+	_, err = upgradeAction.RunWithContext(ctx, name, rel.Chart, newValues)
 	if err != nil {
 		return fmt.Errorf("failed to apply values: %w", err)
 	}
@@ -1178,6 +1292,7 @@ func (c *Client) UpdateRepository(repoName string) error {
 		return fmt.Errorf("failed to download index: %w", err)
 	}
 
+	invalidateUpgradeChecks()
 	return nil
 }
 
@@ -1200,7 +1315,7 @@ func (c *Client) SearchCharts(query string, allVersions bool) (*ChartSearchResul
 
 	for _, r := range f.Repositories {
 		indexPath := filepath.Join(cacheDir, r.Name+"-index.yaml")
-		indexFile, err := repo.LoadIndexFile(indexPath)
+		indexFile, err := loadRepoIndex(indexPath)
 		if err != nil {
 			continue
 		}
@@ -1256,6 +1371,93 @@ func (c *Client) SearchCharts(query string, allVersions bool) (*ChartSearchResul
 	}, nil
 }
 
+// SearchRepoCharts searches the merged repository indexes for charts matching query.
+// Matches are ranked by chart name (exact, prefix, substring) before keyword and
+// description matches, and only the latest version of each chart is returned.
+func (c *Client) SearchRepoCharts(query string, limit int) (*ChartSearchResult, error) {
+	f, err := repo.LoadFile(c.settings.RepositoryConfig)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ChartSearchResult{Charts: []ChartInfo{}}, nil
+		}
+		return nil, fmt.Errorf("failed to load repo file: %w", err)
+	}
+
+	queryLower := strings.ToLower(strings.TrimSpace(query))
+
+	type match struct {
+		info ChartInfo
+		rank int
+	}
+	var matches []match
+
+	for _, r := range f.Repositories {
+		indexPath := filepath.Join(c.settings.RepositoryCache, r.Name+"-index.yaml")
+		indexFile, err := loadRepoIndex(indexPath)
+		if err != nil {
+			continue
+		}
+
+		for chartName, versions := range indexFile.Entries {
+			if len(versions) == 0 {
+				continue
+			}
+			rank := chartMatchRank(chartName, versions[0], queryLower)
+			if rank < 0 {
+				continue
+			}
+			matches = append(matches, match{info: chartVersionToInfo(versions[0], r.Name), rank: rank})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		if matches[i].info.Name != matches[j].info.Name {
+			return matches[i].info.Name < matches[j].info.Name
+		}
+		return matches[i].info.Repository < matches[j].info.Repository
+	})
+
+	total := len(matches)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	charts := make([]ChartInfo, 0, len(matches))
+	for _, m := range matches {
+		charts = append(charts, m.info)
+	}
+
+	return &ChartSearchResult{
+		Charts: charts,
+		Total:  total,
+	}, nil
+}
+
+// chartMatchRank scores how well a chart matches a lowercase query (lower is better, -1 = no match)
+func chartMatchRank(chartName string, latest *repo.ChartVersion, query string) int {
+	nameLower := strings.ToLower(chartName)
+	switch {
+	case nameLower == query:
+		return 0
+	case strings.HasPrefix(nameLower, query):
+		return 1
+	case strings.Contains(nameLower, query):
+		return 2
+	}
+	for _, kw := range latest.Keywords {
+		if strings.ToLower(kw) == query {
+			return 3
+		}
+	}
+	if strings.Contains(strings.ToLower(latest.Description), query) {
+		return 4
+	}
+	return -1
+}
+
 // GetChartDetail returns detailed information about a specific chart version
 func (c *Client) GetChartDetail(repoName, chartName, version string) (*ChartDetail, error) {
 	repoFile := c.settings.RepositoryConfig
@@ -1280,7 +1482,7 @@ func (c *Client) GetChartDetail(repoName, chartName, version string) (*ChartDeta
 	// Load index
 	cacheDir := c.settings.RepositoryCache
 	indexPath := filepath.Join(cacheDir, repoName+"-index.yaml")
-	indexFile, err := repo.LoadIndexFile(indexPath)
+	indexFile, err := loadRepoIndex(indexPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load index file: %w", err)
 	}
@@ -1379,38 +1581,34 @@ func (c *Client) GetChartDetail(repoName, chartName, version string) (*ChartDeta
 	return detail, nil
 }
 
-// Install installs a new Helm release
-func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
-	actionConfig, err := c.getActionConfig(req.Namespace)
-	if err != nil {
-		return nil, err
-	}
-
+// resolveChartURL finds the download URL for a chart version in a local
+// repository (by name) or a remote repository (by URL)
+func (c *Client) resolveChartURL(repository, chartName, version string) (string, error) {
 	var chartURL string
 
 	// Check if the repository is a URL (for ArtifactHub installs) or a local repo name
-	isRepoURL := strings.HasPrefix(req.Repository, "http://") || strings.HasPrefix(req.Repository, "https://")
+	isRepoURL := strings.HasPrefix(repository, "http://") || strings.HasPrefix(repository, "https://")
 
 	if isRepoURL {
 		// Direct URL - fetch the repository index to find the chart
-		repoURL := strings.TrimSuffix(req.Repository, "/")
+		repoURL := strings.TrimSuffix(repository, "/")
 
 		// Try to fetch the index.yaml from the repo to find the chart URL
 		indexURL := repoURL + "/index.yaml"
 		resp, err := httpClient.Get(indexURL)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch repository index: %w", err)
+			return "", fmt.Errorf("failed to fetch repository index: %w", err)
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
-			return nil, fmt.Errorf("repository %s returned status %d", req.Repository, resp.StatusCode)
+			return "", fmt.Errorf("repository %s returned status %d", repository, resp.StatusCode)
 		}
 
 		// Save to temp file and load (repo package doesn't have LoadIndexFromBytes)
 		tmpFile, err := os.CreateTemp("", "helm-index-*.yaml")
 		if err != nil {
-			return nil, fmt.Errorf("failed to create temp file: %w", err)
+			return "", fmt.Errorf("failed to create temp file: %w", err)
 		}
 		defer os.Remove(tmpFile.Name())
 		defer tmpFile.Close()
@@ -1418,27 +1616,27 @@ func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
 		indexBytes := new(bytes.Buffer)
 		indexBytes.ReadFrom(resp.Body)
 		if _, err := tmpFile.Write(indexBytes.Bytes()); err != nil {
-			return nil, fmt.Errorf("failed to write temp index: %w", err)
+			return "", fmt.Errorf("failed to write temp index: %w", err)
 		}
 		tmpFile.Close()
 
 		indexFile, err := repo.LoadIndexFile(tmpFile.Name())
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse repository index: %w", err)
+			return "", fmt.Errorf("failed to parse repository index: %w", err)
 		}
 
 		// Find the chart version
-		versions, ok := indexFile.Entries[req.ChartName]
+		versions, ok := indexFile.Entries[chartName]
 		if !ok || len(versions) == 0 {
-			return nil, fmt.Errorf("chart %s not found in repository", req.ChartName)
+			return "", fmt.Errorf("chart %s not found in repository", chartName)
 		}
 
 		var chartVersion *repo.ChartVersion
-		if req.Version == "" || req.Version == "latest" {
+		if version == "" || version == "latest" {
 			chartVersion = versions[0]
 		} else {
 			for _, v := range versions {
-				if v.Version == req.Version {
+				if v.Version == version {
 					chartVersion = v
 					break
 				}
@@ -1446,7 +1644,7 @@ func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
 		}
 
 		if chartVersion == nil {
-			return nil, fmt.Errorf("version %s not found for chart %s", req.Version, req.ChartName)
+			return "", fmt.Errorf("version %s not found for chart %s", version, chartName)
 		}
 
 		// Build chart URL
@@ -1459,41 +1657,41 @@ func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
 		repoFile := c.settings.RepositoryConfig
 		f, err := repo.LoadFile(repoFile)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load repo file: %w", err)
+			return "", fmt.Errorf("failed to load repo file: %w", err)
 		}
 
 		// Find repository
 		var repoEntry *repo.Entry
 		for _, r := range f.Repositories {
-			if r.Name == req.Repository {
+			if r.Name == repository {
 				repoEntry = r
 				break
 			}
 		}
 
 		if repoEntry == nil {
-			return nil, fmt.Errorf("repository %s not found", req.Repository)
+			return "", fmt.Errorf("repository %s not found", repository)
 		}
 
 		// Load index and find chart
 		cacheDir := c.settings.RepositoryCache
-		indexPath := filepath.Join(cacheDir, req.Repository+"-index.yaml")
-		indexFile, err := repo.LoadIndexFile(indexPath)
+		indexPath := filepath.Join(cacheDir, repository+"-index.yaml")
+		indexFile, err := loadRepoIndex(indexPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load index file: %w", err)
+			return "", fmt.Errorf("failed to load index file: %w", err)
 		}
 
-		versions, ok := indexFile.Entries[req.ChartName]
+		versions, ok := indexFile.Entries[chartName]
 		if !ok || len(versions) == 0 {
-			return nil, fmt.Errorf("chart %s not found", req.ChartName)
+			return "", fmt.Errorf("chart %s not found", chartName)
 		}
 
 		var chartVersion *repo.ChartVersion
-		if req.Version == "" || req.Version == "latest" {
+		if version == "" || version == "latest" {
 			chartVersion = versions[0]
 		} else {
 			for _, v := range versions {
-				if v.Version == req.Version {
+				if v.Version == version {
 					chartVersion = v
 					break
 				}
@@ -1501,7 +1699,7 @@ func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
 		}
 
 		if chartVersion == nil {
-			return nil, fmt.Errorf("version %s not found for chart %s", req.Version, req.ChartName)
+			return "", fmt.Errorf("version %s not found for chart %s", version, chartName)
 		}
 
 		// Build chart URL
@@ -1511,13 +1709,28 @@ func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
 		}
 	}
 
+	return chartURL, nil
+}
+
+// Install installs a new Helm release
+func (c *Client) Install(ctx context.Context, req *InstallRequest) (*HelmRelease, error) {
+	actionConfig, err := c.getActionConfig(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	chartURL, err := c.resolveChartURL(req.Repository, req.ChartName, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create install action
 	installAction := action.NewInstall(actionConfig)
 	installAction.ReleaseName = req.ReleaseName
 	installAction.Namespace = req.Namespace
 	installAction.CreateNamespace = req.CreateNamespace
 	installAction.Wait = true
-	installAction.Timeout = 300 * time.Second
+	installAction.Timeout = actionTimeout(ctx)
 	installAction.Version = req.Version
 
 	// Locate/download chart
@@ -1533,7 +1746,7 @@ func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
 	}
 
 	// Run install
-	rel, err := installAction.Run(chart, req.Values)
+	rel, err := installAction.RunWithContext(ctx, chart, req.Values)
 	if err != nil {
 		return nil, fmt.Errorf("install failed: %w", err)
 	}
@@ -1551,7 +1764,7 @@ func (c *Client) Install(req *InstallRequest) (*HelmRelease, error) {
 }
 
 // InstallWithProgress installs a new Helm release and streams progress updates
-func (c *Client) InstallWithProgress(req *InstallRequest, progressCh chan<- InstallProgress) (*HelmRelease, error) {
+func (c *Client) InstallWithProgress(ctx context.Context, req *InstallRequest, progressCh chan<- InstallProgress) (*HelmRelease, error) {
 	sendProgress := func(phase, message, detail string) {
 		select {
 		case progressCh <- InstallProgress{Phase: phase, Message: message, Detail: detail}:
@@ -1575,7 +1788,11 @@ func (c *Client) InstallWithProgress(req *InstallRequest, progressCh chan<- Inst
 
 		repoURL := strings.TrimSuffix(req.Repository, "/")
 		indexURL := repoURL + "/index.yaml"
-		resp, err := httpClient.Get(indexURL)
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch repository index: %w", err)
+		}
+		resp, err := httpClient.Do(httpReq)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch repository index: %w", err)
 		}
@@ -1654,7 +1871,7 @@ func (c *Client) InstallWithProgress(req *InstallRequest, progressCh chan<- Inst
 
 		cacheDir := c.settings.RepositoryCache
 		indexPath := filepath.Join(cacheDir, req.Repository+"-index.yaml")
-		indexFile, err := repo.LoadIndexFile(indexPath)
+		indexFile, err := loadRepoIndex(indexPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load index file: %w", err)
 		}
@@ -1693,7 +1910,7 @@ func (c *Client) InstallWithProgress(req *InstallRequest, progressCh chan<- Inst
 	installAction.Namespace = req.Namespace
 	installAction.CreateNamespace = req.CreateNamespace
 	installAction.Wait = true
-	installAction.Timeout = 300 * time.Second
+	installAction.Timeout = actionTimeout(ctx)
 	installAction.Version = req.Version
 
 	cp, err := installAction.ChartPathOptions.LocateChart(chartURL, c.settings)
@@ -1714,7 +1931,7 @@ func (c *Client) InstallWithProgress(req *InstallRequest, progressCh chan<- Inst
 		sendProgress("installing", fmt.Sprintf("Creating namespace %s if needed...", req.Namespace), "")
 	}
 
-	rel, err := installAction.Run(chart, req.Values)
+	rel, err := installAction.RunWithContext(ctx, chart, req.Values)
 	if err != nil {
 		return nil, fmt.Errorf("install failed: %w", err)
 	}
@@ -0,0 +1,220 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// CRD status values
+const (
+	CRDEstablished    = "established"
+	CRDNotEstablished = "not-established"
+	CRDMissing        = "missing"
+	CRDUnknown        = "unknown"
+)
+
+// CRD sources: Helm installs the chart's crds/ directory before anything else
+// and never upgrades or deletes it, while templated CRDs are regular resources
+const (
+	CRDSourceChart    = "crds"
+	CRDSourceManifest = "manifest"
+)
+
+// ReleaseCRDs lists the CustomResourceDefinitions a release ships
+type ReleaseCRDs struct {
+	Name             string       `json:"name"`
+	Namespace        string       `json:"namespace"`
+	Revision         int          `json:"revision"`
+	CRDs             []ReleaseCRD `json:"crds"`
+	EstablishedCount int          `json:"establishedCount"`
+	ProblemCount     int          `json:"problemCount"` // CRDs missing or not established
+}
+
+// ReleaseCRD is one CRD of a release and its state in the cluster
+type ReleaseCRD struct {
+	Name            string   `json:"name"`
+	Group           string   `json:"group"`
+	Kind            string   `json:"kind"`
+	Versions        []string `json:"versions"`       // Versions the chart defines
+	Source          string   `json:"source"`         // crds or manifest
+	File            string   `json:"file,omitempty"` // File under a crds/ directory, including subcharts'
+	Status          string   `json:"status"`
+	Message         string   `json:"message,omitempty"`         // Why the CRD isn't established, or the lookup error
+	MissingVersions []string `json:"missingVersions,omitempty"` // Chart versions the cluster's CRD doesn't define
+}
+
+// GetCRDs lists the CRDs of a release, from its chart's crds/ directories
+// and its rendered manifest, with whether each is established in the cluster
+func (c *Client) GetCRDs(ctx context.Context, namespace, name string) (*ReleaseCRDs, error) {
+	rel, err := c.getRelease(namespace, name, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get helm release %s/%s: %w", namespace, name, err)
+	}
+
+	discovery := k8s.GetResourceDiscovery()
+	dynamicCache := k8s.GetDynamicResourceCache()
+	if discovery == nil || dynamicCache == nil {
+		return nil, fmt.Errorf("resource discovery not initialized")
+	}
+
+	var chartCRDs []chart.CRD
+	if rel.Chart != nil {
+		chartCRDs = rel.Chart.CRDObjects()
+	}
+	crds := releaseCRDList(chartCRDs, rel.Manifest)
+
+	result := &ReleaseCRDs{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		CRDs:      []ReleaseCRD{},
+	}
+
+	gvr, known := discovery.GetGVRWithGroup("CustomResourceDefinition", "apiextensions.k8s.io")
+	for _, crd := range crds {
+		if !known {
+			crd.Status = CRDUnknown
+			crd.Message = "CustomResourceDefinitions are not readable"
+		} else if live, err := dynamicCache.GetDirect(ctx, gvr, "", crd.Name); err != nil {
+			if apierrors.IsNotFound(err) {
+				crd.Status = CRDMissing
+				crd.Message = "CRD not found in cluster"
+			} else {
+				crd.Status = CRDUnknown
+				crd.Message = err.Error()
+			}
+		} else {
+			crd.Status, crd.Message = crdEstablished(live)
+			liveVersions := crdVersions(live.Object)
+			for _, v := range crd.Versions {
+				if !slices.Contains(liveVersions, v) {
+					crd.MissingVersions = append(crd.MissingVersions, v)
+				}
+			}
+		}
+
+		switch crd.Status {
+		case CRDEstablished:
+			result.EstablishedCount++
+		case CRDMissing, CRDNotEstablished:
+			result.ProblemCount++
+		}
+		result.CRDs = append(result.CRDs, crd)
+	}
+
+	return result, nil
+}
+
+// releaseCRDList collects the CRDs of a chart's crds/ directories and of a
+// rendered manifest, sorted by name. A CRD in both is listed once, as the
+// chart's, since Helm installs those first.
+func releaseCRDList(chartCRDs []chart.CRD, manifest string) []ReleaseCRD {
+	var crds []ReleaseCRD
+	seen := make(map[string]bool)
+	add := func(doc, source, file string) {
+		crd, ok := parseCRD(doc)
+		if !ok || seen[crd.Name] {
+			return
+		}
+		seen[crd.Name] = true
+		crd.Source = source
+		crd.File = file
+		crds = append(crds, crd)
+	}
+	for _, obj := range chartCRDs {
+		if obj.File == nil {
+			continue
+		}
+		for _, doc := range releaseutil.SplitManifests(string(obj.File.Data)) {
+			add(doc, CRDSourceChart, obj.Filename)
+		}
+	}
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		add(doc, CRDSourceManifest, "")
+	}
+	sort.Slice(crds, func(i, j int) bool { return crds[i].Name < crds[j].Name })
+	return crds
+}
+
+// parseCRD reads the identity of a CRD from a manifest document, reporting
+// false for documents of other kinds
+func parseCRD(doc string) (ReleaseCRD, bool) {
+	var obj map[string]any
+	if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || len(obj) == 0 {
+		return ReleaseCRD{}, false
+	}
+	u := unstructured.Unstructured{Object: obj}
+	if u.GetKind() != "CustomResourceDefinition" || u.GetName() == "" {
+		return ReleaseCRD{}, false
+	}
+	group, _, _ := unstructured.NestedString(obj, "spec", "group")
+	kind, _, _ := unstructured.NestedString(obj, "spec", "names", "kind")
+	return ReleaseCRD{
+		Name:     u.GetName(),
+		Group:    group,
+		Kind:     kind,
+		Versions: crdVersions(obj),
+	}, true
+}
+
+// crdVersions returns the version names a CRD defines, in spec order
+func crdVersions(obj map[string]any) []string {
+	items, _, _ := unstructured.NestedSlice(obj, "spec", "versions")
+	versions := make([]string, 0, len(items))
+	for _, item := range items {
+		if v, ok := item.(map[string]any); ok {
+			if name, _ := v["name"].(string); name != "" {
+				versions = append(versions, name)
+			}
+		}
+	}
+	return versions
+}
+
+// crdEstablished returns the status of a live CRD from its conditions. A CRD
+// whose names conflict with another is never established, so a rejected
+// NamesAccepted explains the failure better than Established does.
+func crdEstablished(live *unstructured.Unstructured) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(live.Object, "status", "conditions")
+	var established, namesRejected map[string]any
+	for _, item := range conditions {
+		cond, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch cond["type"] {
+		case "Established":
+			established = cond
+		case "NamesAccepted":
+			if cond["status"] == "False" {
+				namesRejected = cond
+			}
+		}
+	}
+
+	if established != nil && established["status"] == "True" {
+		return CRDEstablished, ""
+	}
+	for _, cond := range []map[string]any{namesRejected, established} {
+		if cond == nil {
+			continue
+		}
+		if msg, _ := cond["message"].(string); msg != "" {
+			return CRDNotEstablished, msg
+		}
+		if reason, _ := cond["reason"].(string); reason != "" {
+			return CRDNotEstablished, reason
+		}
+	}
+	return CRDNotEstablished, "CRD has not been established yet"
+}
@@ -0,0 +1,147 @@
+package helm
+
+import (
+	"slices"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  versions:
+    - name: v1beta1
+    - name: v1
+`
+
+const gadgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Gadget
+  versions:
+    - name: v1
+`
+
+func TestParseCRD(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want *ReleaseCRD
+	}{
+		{
+			name: "crd",
+			doc:  widgetCRD,
+			want: &ReleaseCRD{Name: "widgets.example.com", Group: "example.com", Kind: "Widget", Versions: []string{"v1beta1", "v1"}},
+		},
+		{name: "other kind", doc: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: widgets\n"},
+		{name: "unnamed", doc: "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n"},
+		{name: "empty", doc: "# just a comment\n"},
+		{name: "not yaml", doc: "kind: [unclosed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseCRD(tt.doc)
+			if tt.want == nil {
+				if ok {
+					t.Errorf("expected the document to be skipped, got %+v", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("expected a CRD")
+			}
+			if got.Name != tt.want.Name || got.Group != tt.want.Group || got.Kind != tt.want.Kind || !slices.Equal(got.Versions, tt.want.Versions) {
+				t.Errorf("expected %+v, got %+v", *tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReleaseCRDList(t *testing.T) {
+	chartCRDs := []chart.CRD{
+		{Name: "crds/widgets.yaml", Filename: "app/crds/widgets.yaml", File: &chart.File{Name: "crds/widgets.yaml", Data: []byte(widgetCRD)}},
+		{Name: "crds/missing.yaml", Filename: "app/crds/missing.yaml"},
+	}
+	// The manifest repeats the chart's CRD and adds a templated one
+	manifest := "---\n# Source: app/templates/crds.yaml\n" + widgetCRD +
+		"---\n# Source: app/templates/gadgets.yaml\n" + gadgetCRD +
+		"---\napiVersion: v1\nkind: Service\nmetadata:\n  name: app\n"
+
+	crds := releaseCRDList(chartCRDs, manifest)
+	if len(crds) != 2 {
+		t.Fatalf("expected 2 CRDs, got %+v", crds)
+	}
+	if c := crds[0]; c.Name != "gadgets.example.com" || c.Source != CRDSourceManifest || c.File != "" {
+		t.Errorf("expected the templated CRD first, got %+v", c)
+	}
+	if c := crds[1]; c.Name != "widgets.example.com" || c.Source != CRDSourceChart || c.File != "app/crds/widgets.yaml" {
+		t.Errorf("expected the CRD in both listed once as the chart's, got %+v", c)
+	}
+}
+
+func TestCRDEstablished(t *testing.T) {
+	condition := func(typ, status, reason, message string) any {
+		return map[string]any{"type": typ, "status": status, "reason": reason, "message": message}
+	}
+	tests := []struct {
+		name        string
+		conditions  []any
+		wantStatus  string
+		wantMessage string
+	}{
+		{
+			name:       "established",
+			conditions: []any{condition("NamesAccepted", "True", "NoConflicts", ""), condition("Established", "True", "InitialNamesAccepted", "")},
+			wantStatus: CRDEstablished,
+		},
+		{
+			name: "names rejected",
+			conditions: []any{
+				condition("Established", "False", "NotAccepted", "not all names are accepted"),
+				condition("NamesAccepted", "False", "KindConflict", `"Widget" is already in use`),
+			},
+			wantStatus:  CRDNotEstablished,
+			wantMessage: `"Widget" is already in use`,
+		},
+		{
+			name:        "names accepted, not established",
+			conditions:  []any{condition("NamesAccepted", "True", "NoConflicts", "no conflicts found"), condition("Established", "False", "Installing", "the initial names have not been accepted")},
+			wantStatus:  CRDNotEstablished,
+			wantMessage: "the initial names have not been accepted",
+		},
+		{
+			name:        "reason without message",
+			conditions:  []any{condition("Established", "False", "Installing", "")},
+			wantStatus:  CRDNotEstablished,
+			wantMessage: "Installing",
+		},
+		{
+			name:        "no conditions",
+			wantStatus:  CRDNotEstablished,
+			wantMessage: "CRD has not been established yet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live := &unstructured.Unstructured{Object: map[string]any{}}
+			if tt.conditions != nil {
+				live.Object["status"] = map[string]any{"conditions": tt.conditions}
+			}
+			status, message := crdEstablished(live)
+			if status != tt.wantStatus || message != tt.wantMessage {
+				t.Errorf("expected %s %q, got %s %q", tt.wantStatus, tt.wantMessage, status, message)
+			}
+		})
+	}
+}
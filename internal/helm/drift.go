@@ -0,0 +1,283 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// Drift status values for a single resource
+const (
+	DriftInSync  = "in-sync"
+	DriftDrifted = "drifted"
+	DriftMissing = "missing"
+	DriftUnknown = "unknown"
+)
+
+const (
+	maxDriftChanges = 50           // Cap on field changes reported per resource
+	redactedValue   = "<redacted>" // Replaces Secret values in drift output
+)
+
+// GetDrift compares the current release manifest with the live cluster state.
+// Only fields set in the manifest are compared, so defaults and status added
+// by the API server are not reported as drift.
+func (c *Client) GetDrift(ctx context.Context, namespace, name string) (*ReleaseDrift, error) {
+	actionConfig, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := action.NewGet(actionConfig).Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get helm release %s/%s: %w", namespace, name, err)
+	}
+
+	discovery := k8s.GetResourceDiscovery()
+	dynamicCache := k8s.GetDynamicResourceCache()
+	if discovery == nil || dynamicCache == nil {
+		return nil, fmt.Errorf("resource discovery not initialized")
+	}
+
+	result := &ReleaseDrift{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		Resources: []ResourceDrift{},
+	}
+
+	manifests := releaseutil.SplitManifests(rel.Manifest)
+	keys := make([]string, 0, len(manifests))
+	for k := range manifests {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var desired map[string]any
+		if err := yaml.Unmarshal([]byte(manifests[key]), &desired); err != nil || len(desired) == 0 {
+			continue
+		}
+
+		kind, _ := desired["kind"].(string)
+		apiVersion, _ := desired["apiVersion"].(string)
+		metadata, _ := desired["metadata"].(map[string]any)
+		resName, _ := metadata["name"].(string)
+		resNamespace, _ := metadata["namespace"].(string)
+		if kind == "" || resName == "" {
+			continue
+		}
+
+		gv, _ := schema.ParseGroupVersion(apiVersion)
+		apiResource, known := discovery.GetResource(kind)
+		if known && apiResource.Namespaced && resNamespace == "" {
+			resNamespace = rel.Namespace
+		}
+		if known && !apiResource.Namespaced {
+			resNamespace = ""
+		}
+
+		rd := ResourceDrift{
+			Kind:      kind,
+			Name:      resName,
+			Namespace: resNamespace,
+		}
+
+		gvr, ok := discovery.GetGVRWithGroup(kind, gv.Group)
+		if !ok {
+			rd.Status = DriftUnknown
+			rd.Message = "unknown resource kind"
+			result.Resources = append(result.Resources, rd)
+			continue
+		}
+
+		// Read straight from the API so out-of-band edits show up immediately
+		live, err := dynamicCache.GetDirect(ctx, gvr, resNamespace, resName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				rd.Status = DriftMissing
+				rd.Message = "resource not found in cluster"
+			} else {
+				rd.Status = DriftUnknown
+				rd.Message = err.Error()
+			}
+			result.Resources = append(result.Resources, rd)
+			continue
+		}
+
+		// Status never comes from the chart, and the API server folds a
+		// Secret's stringData into data, so neither can be compared directly
+		delete(desired, "status")
+		if kind == "Secret" {
+			delete(desired, "stringData")
+		}
+		var changes []DriftChange
+		compareDesired("", desired, live.Object, &changes)
+		if kind == "Secret" {
+			for idx := range changes {
+				changes[idx].Desired = redactedValue
+				changes[idx].Live = redactedValue
+			}
+		}
+		if len(changes) > maxDriftChanges {
+			rd.Message = fmt.Sprintf("showing %d of %d changes", maxDriftChanges, len(changes))
+			changes = changes[:maxDriftChanges]
+		}
+
+		rd.Changes = changes
+		if len(changes) > 0 {
+			rd.Status = DriftDrifted
+		} else {
+			rd.Status = DriftInSync
+		}
+		result.Resources = append(result.Resources, rd)
+	}
+
+	for _, rd := range result.Resources {
+		switch rd.Status {
+		case DriftDrifted, DriftMissing:
+			result.DriftedCount++
+		case DriftInSync:
+			result.InSyncCount++
+		}
+	}
+	result.Drifted = result.DriftedCount > 0
+
+	return result, nil
+}
+
+// compareDesired records every field set in desired whose live value differs
+func compareDesired(path string, desired, live any, changes *[]DriftChange) {
+	switch d := desired.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			*changes = append(*changes, DriftChange{Path: displayPath(path), Desired: desired, Live: live})
+			return
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lv, exists := l[k]
+			if !exists {
+				// Empty values in the manifest are commonly dropped by the API server
+				if isEmptyValue(d[k]) {
+					continue
+				}
+				*changes = append(*changes, DriftChange{Path: joinPath(path, k), Desired: d[k]})
+				continue
+			}
+			compareDesired(joinPath(path, k), d[k], lv, changes)
+		}
+
+	case []any:
+		l, ok := live.([]any)
+		if !ok || len(l) != len(d) {
+			*changes = append(*changes, DriftChange{Path: displayPath(path), Desired: desired, Live: live})
+			return
+		}
+		for idx := range d {
+			compareDesired(fmt.Sprintf("%s[%d]", path, idx), d[idx], l[idx], changes)
+		}
+
+	default:
+		if !scalarsEqual(desired, live, quantityPath(path)) {
+			*changes = append(*changes, DriftChange{Path: displayPath(path), Desired: desired, Live: live})
+		}
+	}
+}
+
+// scalarsEqual compares leaf values, tolerating numeric type differences
+// (YAML decodes to float64, the API returns int64). When quantities is set,
+// values that are the same quantity are equal too: the API server
+// normalizes a CPU request of "0.5" or 0.5 to "500m".
+func scalarsEqual(a, b any, quantities bool) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	if quantities {
+		aq, errA := resource.ParseQuantity(quantityString(a))
+		bq, errB := resource.ParseQuantity(quantityString(b))
+		if errA == nil && errB == nil {
+			return aq.Cmp(bq) == 0
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// quantityPath reports whether path is a resource request or limit, the
+// fields whose values are quantities
+func quantityPath(path string) bool {
+	parent := path[:strings.LastIndex(path, ".")+1]
+	return strings.HasSuffix(parent, "resources.requests.") || strings.HasSuffix(parent, "resources.limits.")
+}
+
+// quantityString returns a leaf value as a quantity string, "" if it can't be one
+func quantityString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if f, ok := toFloat(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return ""
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func isEmptyValue(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		return len(val) == 0
+	case []any:
+		return len(val) == 0
+	case string:
+		return val == ""
+	}
+	return false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return strings.TrimPrefix(path, ".")
+}
@@ -1,47 +1,75 @@
 package helm
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"helm.sh/helm/v3/pkg/release"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+	"github.com/skyhook-io/radar/internal/k8s"
 )
 
 // Handlers provides HTTP handlers for Helm endpoints
-type Handlers struct{}
+type Handlers struct {
+	readOnly bool // Refuse install, upgrade, rollback, values changes and uninstall
+}
 
 // NewHandlers creates a new Handlers instance
-func NewHandlers() *Handlers {
-	return &Handlers{}
+func NewHandlers(readOnly bool) *Handlers {
+	return &Handlers{readOnly: readOnly}
 }
 
 // RegisterRoutes registers Helm routes on the given router
 func (h *Handlers) RegisterRoutes(r chi.Router) {
 	r.Route("/helm", func(r chi.Router) {
+		writes := r.With(h.denyInReadOnly)
+
+		// Routes naming a namespace check it exists and is accessible first
+		ns := r.With(requireNamespace)
+		nsWrites := writes.With(requireNamespace)
+
 		// Release management
 		r.Get("/releases", h.handleListReleases)
-		r.Post("/releases", h.handleInstall)
-		r.Post("/releases/install-stream", h.handleInstallStream)
-		r.Get("/releases/{namespace}/{name}", h.handleGetRelease)
-		r.Get("/releases/{namespace}/{name}/manifest", h.handleGetManifest)
-		r.Get("/releases/{namespace}/{name}/values", h.handleGetValues)
-		r.Get("/releases/{namespace}/{name}/diff", h.handleGetDiff)
-		r.Get("/releases/{namespace}/{name}/upgrade-info", h.handleCheckUpgrade)
+		writes.Post("/releases", h.handleInstall)
+		writes.Post("/releases/install-stream", h.handleInstallStream)
+		ns.Get("/releases/{namespace}/{name}", h.handleGetRelease)
+		ns.Get("/releases/{namespace}/{name}/manifest", h.handleGetManifest)
+		ns.Get("/releases/{namespace}/{name}/notes", h.handleGetNotes)
+		ns.Get("/releases/{namespace}/{name}/readme", h.handleGetReadme)
+		ns.Get("/releases/{namespace}/{name}/values", h.handleGetValues)
+		ns.Get("/releases/{namespace}/{name}/diff", h.handleGetDiff)
+		ns.Get("/releases/{namespace}/{name}/drift", h.handleGetDrift)
+		ns.Get("/releases/{namespace}/{name}/crds", h.handleGetCRDs)
+		ns.Get("/releases/{namespace}/{name}/hooks/watch", h.handleWatchHooks)
+		ns.Get("/releases/{namespace}/{name}/upgrade-info", h.handleCheckUpgrade)
+		ns.Get("/releases/{namespace}/{name}/provenance", h.handleGetProvenance)
 		r.Get("/upgrade-check", h.handleBatchUpgradeCheck)
 		// Actions (write operations)
-		r.Post("/releases/{namespace}/{name}/rollback", h.handleRollback)
-		r.Post("/releases/{namespace}/{name}/upgrade", h.handleUpgrade)
-		r.Post("/releases/{namespace}/{name}/values/preview", h.handlePreviewValues)
-		r.Put("/releases/{namespace}/{name}/values", h.handleApplyValues)
-		r.Delete("/releases/{namespace}/{name}", h.handleUninstall)
+		// Rollback checks read-only mode itself so ?dryRun=true previews still work
+		ns.Post("/releases/{namespace}/{name}/rollback", h.handleRollback)
+		nsWrites.Post("/releases/{namespace}/{name}/upgrade", h.handleUpgrade)
+		ns.Post("/releases/{namespace}/{name}/values/preview", h.handlePreviewValues)
+		nsWrites.Put("/releases/{namespace}/{name}/values", h.handleApplyValues)
+		nsWrites.Delete("/releases/{namespace}/{name}", h.handleUninstall)
 
 		// Chart browser (local repositories)
 		r.Get("/repositories", h.handleListRepositories)
 		r.Post("/repositories/{name}/update", h.handleUpdateRepository)
 		r.Get("/charts", h.handleSearchCharts)
+		r.Get("/charts/search", h.handleSearchRepoCharts)
 		r.Get("/charts/{repo}/{chart}", h.handleGetChartDetail)
 		r.Get("/charts/{repo}/{chart}/{version}", h.handleGetChartDetailVersion)
+		r.Post("/validate-values", h.handleValidateValues)
+		r.Post("/template", h.handleTemplate)
 
 		// ArtifactHub integration
 		r.Get("/artifacthub/search", h.handleArtifactHubSearch)
@@ -50,30 +78,248 @@ func (h *Handlers) RegisterRoutes(r chi.Router) {
 	})
 }
 
-// handleListReleases returns all Helm releases
+// actionContext returns the context for a Helm write action. It is cancelled
+// when the client disconnects but not by the router's request timeout, which
+// is shorter than a Helm wait; the action is bounded by defaultActionTimeout.
+func actionContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), defaultActionTimeout)
+	stop := context.AfterFunc(r.Context(), func() {
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			cancel()
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// denyInReadOnly refuses write operations with 403 when running with
+// --read-only
+func (h *Handlers) denyInReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.readOnly {
+			writeReadOnly(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeReadOnly refuses a write operation on a read-only server
+func writeReadOnly(w http.ResponseWriter) {
+	writeErrorCode(w, http.StatusForbidden, httperr.CodeReadOnly, "Write operations are disabled: server is running in read-only mode")
+}
+
+// requireNamespace refuses requests whose {namespace} URL parameter names a
+// namespace that doesn't exist (404) or that the user has no access to (403)
+func requireNamespace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace := chi.URLParam(r, "namespace")
+		if err := k8s.CheckNamespace(r.Context(), namespace); err != nil {
+			if errors.Is(err, k8s.ErrNamespaceNotFound) {
+				writeErrorCode(w, http.StatusNotFound, httperr.CodeNamespaceNotFound, fmt.Sprintf("Namespace %q not found", namespace))
+			} else {
+				writeErrorCode(w, http.StatusForbidden, httperr.CodeNamespaceForbidden, fmt.Sprintf("Namespace %q is not accessible with the current credentials", namespace))
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// releaseStatusFilters are the values accepted by the status parameter of
+// the release list: Helm's release statuses, plus "pending" for any of the
+// pending-install, pending-upgrade and pending-rollback statuses
+var releaseStatusFilters = []string{
+	release.StatusDeployed.String(),
+	release.StatusFailed.String(),
+	"pending",
+	release.StatusPendingInstall.String(),
+	release.StatusPendingUpgrade.String(),
+	release.StatusPendingRollback.String(),
+	release.StatusSuperseded.String(),
+	release.StatusUninstalling.String(),
+	release.StatusUninstalled.String(),
+	release.StatusUnknown.String(),
+}
+
+// releaseHealthFields are the HelmRelease fields computed from owned resources,
+// the expensive part of listing releases
+var releaseHealthFields = []string{"resourceHealth", "healthIssue", "healthSummary", "healthMessage"}
+
+// releaseListFields are the HelmRelease JSON fields accepted by the fields
+// parameter of the release list
+var releaseListFields = append([]string{
+	"name", "namespace", "chart", "chartVersion", "appVersion", "status", "revision", "updated",
+}, releaseHealthFields...)
+
+// handleListReleases returns all Helm releases, optionally filtered by
+// namespace, status (comma-separated, see releaseStatusFilters) and chart name.
+// sort is updated (default), name or revision, and order is asc or desc
+// (default: desc for updated and revision, asc for name). fields limits each
+// release to a comma-separated list of its JSON fields; health is only
+// computed when a health field is requested.
 func (h *Handlers) handleListReleases(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
 	namespace := r.URL.Query().Get("namespace")
+	chart := r.URL.Query().Get("chart")
+
+	var statuses []string
+	if value := r.URL.Query().Get("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.ToLower(strings.TrimSpace(status))
+			if !slices.Contains(releaseStatusFilters, status) {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid status %q, must be one of: %s", status, strings.Join(releaseStatusFilters, ", ")))
+				return
+			}
+			statuses = append(statuses, status)
+		}
+	}
 
-	releases, err := client.ListReleases(namespace)
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = "updated"
+	}
+	if sortBy != "updated" && sortBy != "name" && sortBy != "revision" {
+		writeError(w, http.StatusBadRequest, "sort must be one of: updated, name, revision")
+		return
+	}
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = "desc"
+		if sortBy == "name" {
+			order = "asc"
+		}
+	}
+	if order != "asc" && order != "desc" {
+		writeError(w, http.StatusBadRequest, "order must be asc or desc")
+		return
+	}
+
+	var fields []string
+	withHealth := true
+	if value := r.URL.Query().Get("fields"); value != "" {
+		withHealth = false
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if !slices.Contains(releaseListFields, field) {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid field %q, must be one of: %s", field, strings.Join(releaseListFields, ", ")))
+				return
+			}
+			fields = append(fields, field)
+			withHealth = withHealth || slices.Contains(releaseHealthFields, field)
+		}
+	}
+
+	var releases []HelmRelease
+	var err error
+	if withHealth {
+		releases, err = client.ListReleases(namespace)
+	} else {
+		releases, err = client.ListReleasesWithoutHealth(namespace)
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	writeJSON(w, releases)
+	if len(statuses) > 0 || chart != "" {
+		filtered := releases[:0]
+		for _, rel := range releases {
+			if chart != "" && !strings.EqualFold(rel.Chart, chart) {
+				continue
+			}
+			if len(statuses) > 0 && !matchesReleaseStatus(rel.Status, statuses) {
+				continue
+			}
+			filtered = append(filtered, rel)
+		}
+		releases = filtered
+	}
+
+	sortReleases(releases, sortBy, order == "desc")
+
+	if len(fields) == 0 {
+		writeJSON(w, releases)
+		return
+	}
+	projected, err := projectFields(releases, fields)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, projected)
+}
+
+// sortReleases sorts releases by updated time, name or revision. Ties are
+// broken by namespace and name so the order is stable across requests.
+func sortReleases(releases []HelmRelease, sortBy string, desc bool) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		a, b := releases[i], releases[j]
+		if desc {
+			a, b = b, a
+		}
+		switch sortBy {
+		case "updated":
+			if !a.Updated.Equal(b.Updated) {
+				return a.Updated.Before(b.Updated)
+			}
+		case "revision":
+			if a.Revision != b.Revision {
+				return a.Revision < b.Revision
+			}
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Namespace < b.Namespace
+	})
+}
+
+// projectFields reduces each release to the given JSON fields
+func projectFields(releases []HelmRelease, fields []string) ([]map[string]any, error) {
+	result := make([]map[string]any, 0, len(releases))
+	for _, rel := range releases {
+		data, err := json.Marshal(rel)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]any
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		item := make(map[string]any, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				item[field] = value
+			}
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// matchesReleaseStatus reports whether a release status is one of the filters
+func matchesReleaseStatus(status string, filters []string) bool {
+	for _, filter := range filters {
+		if status == filter || filter == "pending" && strings.HasPrefix(status, "pending-") {
+			return true
+		}
+	}
+	return false
 }
 
 // handleGetRelease returns details for a specific release
 func (h *Handlers) handleGetRelease(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -93,7 +339,7 @@ func (h *Handlers) handleGetRelease(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) handleGetManifest(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -119,11 +365,69 @@ func (h *Handlers) handleGetManifest(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(manifest))
 }
 
+// handleGetNotes returns the rendered NOTES.txt for a release, without the
+// rest of the release detail
+func (h *Handlers) handleGetNotes(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	revision := 0
+	if revStr := r.URL.Query().Get("revision"); revStr != "" {
+		if rev, err := strconv.Atoi(revStr); err == nil {
+			revision = rev
+		}
+	}
+
+	notes, err := client.GetNotes(namespace, name, revision)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(notes))
+}
+
+// handleGetReadme returns the chart README for a release, without the rest
+// of the release detail
+func (h *Handlers) handleGetReadme(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	revision := 0
+	if revStr := r.URL.Query().Get("revision"); revStr != "" {
+		if rev, err := strconv.Atoi(revStr); err == nil {
+			revision = rev
+		}
+	}
+
+	readme, err := client.GetReadme(namespace, name, revision)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(readme))
+}
+
 // handleGetValues returns the values for a release
 func (h *Handlers) handleGetValues(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -144,7 +448,7 @@ func (h *Handlers) handleGetValues(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) handleGetDiff(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -180,11 +484,51 @@ func (h *Handlers) handleGetDiff(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, diff)
 }
 
+// handleGetDrift compares the current release manifest with live cluster resources
+func (h *Handlers) handleGetDrift(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	drift, err := client.GetDrift(r.Context(), namespace, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, drift)
+}
+
+// handleGetCRDs lists the CRDs a release ships and whether each is established
+func (h *Handlers) handleGetCRDs(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	crds, err := client.GetCRDs(r.Context(), namespace, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, crds)
+}
+
 // handleCheckUpgrade checks if a newer version is available
 func (h *Handlers) handleCheckUpgrade(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -200,11 +544,32 @@ func (h *Handlers) handleCheckUpgrade(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, info)
 }
 
+// handleGetProvenance resolves where a release's chart came from and whether
+// that source can still be fetched
+func (h *Handlers) handleGetProvenance(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	prov, err := client.GetChartProvenance(r.Context(), namespace, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, prov)
+}
+
 // handleBatchUpgradeCheck checks all releases for upgrades at once
 func (h *Handlers) handleBatchUpgradeCheck(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -223,7 +588,7 @@ func (h *Handlers) handleBatchUpgradeCheck(w http.ResponseWriter, r *http.Reques
 func (h *Handlers) handleRollback(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -242,7 +607,25 @@ func (h *Handlers) handleRollback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := client.Rollback(namespace, name, revision); err != nil {
+	// Dry run: report what the rollback would change without applying it
+	if r.URL.Query().Get("dryRun") == "true" {
+		preview, err := client.PreviewRollback(namespace, name, revision)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, preview)
+		return
+	}
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	if err := client.Rollback(ctx, namespace, name, revision); err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -254,14 +637,17 @@ func (h *Handlers) handleRollback(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) handleUninstall(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
 	namespace := chi.URLParam(r, "namespace")
 	name := chi.URLParam(r, "name")
 
-	if err := client.Uninstall(namespace, name); err != nil {
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	if err := client.Uninstall(ctx, namespace, name); err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -273,7 +659,7 @@ func (h *Handlers) handleUninstall(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) handleUpgrade(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -286,7 +672,10 @@ func (h *Handlers) handleUpgrade(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := client.Upgrade(namespace, name, version); err != nil {
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	if err := client.Upgrade(ctx, namespace, name, version); err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -298,7 +687,7 @@ func (h *Handlers) handleUpgrade(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) handlePreviewValues(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -320,11 +709,77 @@ func (h *Handlers) handlePreviewValues(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, preview)
 }
 
+// handleValidateValues validates values against a chart's values schema
+func (h *Handlers) handleValidateValues(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	var req ValidateValuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if req.ReleaseName == "" && (req.Repository == "" || req.ChartName == "") {
+		writeError(w, http.StatusBadRequest, "either releaseName or repository and chartName are required")
+		return
+	}
+	if req.ReleaseName != "" && req.Namespace == "" {
+		writeError(w, http.StatusBadRequest, "namespace is required with releaseName")
+		return
+	}
+
+	result, err := client.ValidateValues(&req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleTemplate renders a chart with values locally, without installing it
+func (h *Handlers) handleTemplate(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if req.Repository == "" || req.ChartName == "" {
+		writeError(w, http.StatusBadRequest, "repository and chartName are required")
+		return
+	}
+	if req.ReleaseName == "" {
+		req.ReleaseName = "release-name" // Same placeholder as helm template
+	}
+	if req.Namespace == "" {
+		req.Namespace = "default"
+	}
+
+	result, err := client.TemplateChart(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, result)
+}
+
 // handleApplyValues applies new values to a release
 func (h *Handlers) handleApplyValues(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -337,7 +792,10 @@ func (h *Handlers) handleApplyValues(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := client.ApplyValues(namespace, name, req.Values); err != nil {
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	if err := client.ApplyValues(ctx, namespace, name, req.Values); err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -353,7 +811,7 @@ func (h *Handlers) handleApplyValues(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) handleListRepositories(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -370,7 +828,7 @@ func (h *Handlers) handleListRepositories(w http.ResponseWriter, r *http.Request
 func (h *Handlers) handleUpdateRepository(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -392,7 +850,7 @@ func (h *Handlers) handleUpdateRepository(w http.ResponseWriter, r *http.Request
 func (h *Handlers) handleSearchCharts(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -408,11 +866,44 @@ func (h *Handlers) handleSearchCharts(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, result)
 }
 
+// handleSearchRepoCharts searches configured repositories for charts matching q
+func (h *Handlers) handleSearchRepoCharts(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "q parameter is required")
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = l
+	}
+
+	result, err := client.SearchRepoCharts(query, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, result)
+}
+
 // handleGetChartDetail returns detailed info about a chart (latest version)
 func (h *Handlers) handleGetChartDetail(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -432,7 +923,7 @@ func (h *Handlers) handleGetChartDetail(w http.ResponseWriter, r *http.Request)
 func (h *Handlers) handleGetChartDetailVersion(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -453,7 +944,7 @@ func (h *Handlers) handleGetChartDetailVersion(w http.ResponseWriter, r *http.Re
 func (h *Handlers) handleInstall(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -481,7 +972,10 @@ func (h *Handlers) handleInstall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	release, err := client.Install(&req)
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
+	release, err := client.Install(ctx, &req)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -494,7 +988,7 @@ func (h *Handlers) handleInstall(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) handleInstallStream(w http.ResponseWriter, r *http.Request) {
 	client := GetClient()
 	if client == nil {
-		writeError(w, http.StatusServiceUnavailable, "Helm client not initialized")
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
 		return
 	}
 
@@ -538,10 +1032,13 @@ func (h *Handlers) handleInstallStream(w http.ResponseWriter, r *http.Request) {
 	progressCh := make(chan InstallProgress, 10)
 	defer close(progressCh)
 
+	ctx, cancel := actionContext(r)
+	defer cancel()
+
 	// Start install in goroutine
 	resultCh := make(chan installResult, 1)
 	go func() {
-		release, err := client.InstallWithProgress(&req, progressCh)
+		release, err := client.InstallWithProgress(ctx, &req, progressCh)
 		resultCh <- installResult{release: release, err: err}
 	}()
 
@@ -602,9 +1099,11 @@ func writeJSON(w http.ResponseWriter, data any) {
 }
 
 func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	httperr.Write(w, status, "", message)
+}
+
+func writeErrorCode(w http.ResponseWriter, status int, code, message string) {
+	httperr.Write(w, status, code, message)
 }
 
 // ============================================================================
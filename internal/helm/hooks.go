@@ -0,0 +1,397 @@
+package helm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+const (
+	// hookPollInterval is how often a hook watch re-reads the release record,
+	// which Helm updates as each hook starts and finishes
+	hookPollInterval = 2 * time.Second
+
+	// hookFailureLogLines is how many log lines per container a failed event carries
+	hookFailureLogLines = 200
+
+	// hookLogDrainTimeout is how long a watch keeps forwarding hook logs after
+	// the release leaves its pending state
+	hookLogDrainTimeout = 5 * time.Second
+)
+
+// HookStatus is the state of one hook of a release revision
+type HookStatus struct {
+	HelmHook
+	Namespace   string     `json:"namespace"`
+	Revision    int        `json:"revision"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// HookPod is the state of a pod run by a Job or Pod hook
+type HookPod struct {
+	Hook      string `json:"hook"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Phase     string `json:"phase"`
+	Message   string `json:"message,omitempty"`
+}
+
+// HookLogLine is one log line of a hook pod
+type HookLogLine struct {
+	Hook      string `json:"hook"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Content   string `json:"content"`
+}
+
+// HookFailure carries the recent logs of a hook pod that failed, by container
+type HookFailure struct {
+	Hook string            `json:"hook"`
+	Pod  string            `json:"pod"`
+	Logs map[string]string `json:"logs"`
+}
+
+// LatestRelease returns the newest revision of a release, including one that
+// is still pending
+func (c *Client) LatestRelease(namespace, name string) (*release.Release, error) {
+	actionConfig, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return action.NewGet(actionConfig).Run(name)
+}
+
+// hookNamespace returns the namespace a hook's manifest sets, or def
+func hookNamespace(hook *release.Hook, def string) string {
+	var manifest struct {
+		Metadata struct {
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(hook.Manifest), &manifest); err == nil && manifest.Metadata.Namespace != "" {
+		return manifest.Metadata.Namespace
+	}
+	return def
+}
+
+func toHookStatus(rel *release.Release, hook *release.Hook) HookStatus {
+	status := HookStatus{
+		HelmHook:  toHelmHook(hook),
+		Namespace: hookNamespace(hook, rel.Namespace),
+		Revision:  rel.Version,
+	}
+	if !hook.LastRun.StartedAt.IsZero() {
+		t := hook.LastRun.StartedAt.Time
+		status.StartedAt = &t
+	}
+	if !hook.LastRun.CompletedAt.IsZero() {
+		t := hook.LastRun.CompletedAt.Time
+		status.CompletedAt = &t
+	}
+	return status
+}
+
+// hookPods returns the pods run by a Job or Pod hook from the resource cache
+func hookPods(hook HookStatus) []*corev1.Pod {
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		return nil
+	}
+	switch hook.Kind {
+	case "Job":
+		pods, err := cache.Pods().Pods(hook.Namespace).List(labels.SelectorFromSet(labels.Set{"job-name": hook.Name}))
+		if err != nil {
+			return nil
+		}
+		return pods
+	case "Pod":
+		pod, err := cache.Pods().Pods(hook.Namespace).Get(hook.Name)
+		if err != nil {
+			return nil
+		}
+		return []*corev1.Pod{pod}
+	}
+	return nil
+}
+
+// podStarted reports whether a pod's containers have logs to read yet
+func podStarted(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodRunning || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// podMessage explains why a hook pod is waiting or failed, from its container states
+func podMessage(pod *corev1.Pod) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		switch {
+		case cs.State.Waiting != nil && cs.State.Waiting.Reason != "":
+			return cs.Name + ": " + cs.State.Waiting.Reason
+		case cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0:
+			return fmt.Sprintf("%s: %s (exit code %d)", cs.Name, cs.State.Terminated.Reason, cs.State.Terminated.ExitCode)
+		}
+	}
+	return pod.Status.Message
+}
+
+// followHookLogs sends a hook container's log lines to out until the
+// container exits or ctx is done
+func followHookLogs(ctx context.Context, hook string, pod *corev1.Pod, container string, out chan<- HookLogLine) {
+	client := k8s.GetClient()
+	if client == nil {
+		return
+	}
+	stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Follow:     true,
+		Timestamps: true,
+	}).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[helm] Failed to follow logs of hook pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		return
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		timestamp, content, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			timestamp, content = "", timestamp
+		}
+		select {
+		case out <- HookLogLine{Hook: hook, Pod: pod.Name, Container: container, Timestamp: timestamp, Content: content}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// hookFailureLogs returns the last lines of each container of a failed hook pod
+func hookFailureLogs(ctx context.Context, pod *corev1.Pod) map[string]string {
+	logs := make(map[string]string)
+	client := k8s.GetClient()
+	if client == nil {
+		return logs
+	}
+	tail := int64(hookFailureLogLines)
+	for _, container := range pod.Spec.Containers {
+		stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: container.Name,
+			TailLines: &tail,
+		}).Stream(ctx)
+		if err != nil {
+			logs[container.Name] = fmt.Sprintf("failed to get logs: %v", err)
+			continue
+		}
+		content, err := io.ReadAll(stream)
+		stream.Close()
+		if err != nil {
+			logs[container.Name] = fmt.Sprintf("failed to read logs: %v", err)
+			continue
+		}
+		logs[container.Name] = string(content)
+	}
+	return logs
+}
+
+// handleWatchHooks streams the hooks of a release's newest revision via SSE,
+// for following an upgrade (or install or rollback) from the UI or the helm
+// CLI. Events: release when the revision or status changes, hook when a
+// hook's phase changes, pod when a Job or Pod hook's pod changes phase, log
+// for each line its containers write, and failed with a failed pod's recent
+// logs. Once a release seen pending settles, done is sent and the stream ends.
+// GET /api/helm/releases/{namespace}/{name}/hooks/watch
+func (h *Handlers) handleWatchHooks(w http.ResponseWriter, r *http.Request) {
+	client := GetClient()
+	if client == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeHelmNotInitialized, "Helm client not initialized")
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	rel, err := client.LatestRelease(namespace, name)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	send := func(event string, v any) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	logs := make(chan HookLogLine, 256)
+	var followers sync.WaitGroup
+	defer followers.Wait()
+	defer cancel()
+
+	var (
+		lastRevision   int
+		lastStatus     release.Status
+		sawPending     bool
+		hookPhases     = make(map[string]string)
+		podPhases      = make(map[types.UID]string)
+		followed       = make(map[string]bool)
+		failedReported = make(map[types.UID]bool)
+	)
+
+	// update reports changes since the last poll; false means the client went away
+	update := func(rel *release.Release) bool {
+		if rel.Version != lastRevision || rel.Info.Status != lastStatus {
+			lastRevision, lastStatus = rel.Version, rel.Info.Status
+			if !send("release", map[string]any{"revision": rel.Version, "status": rel.Info.Status}) {
+				return false
+			}
+		}
+		if rel.Info.Status.IsPending() {
+			sawPending = true
+		}
+
+		for _, hook := range rel.Hooks {
+			status := toHookStatus(rel, hook)
+			key := fmt.Sprintf("%d/%s/%s", rel.Version, hook.Kind, hook.Name)
+			if phase, seen := hookPhases[key]; !seen || phase != status.Status {
+				hookPhases[key] = status.Status
+				if !send("hook", status) {
+					return false
+				}
+			}
+
+			for _, pod := range hookPods(status) {
+				phase := string(pod.Status.Phase)
+				if podPhases[pod.UID] != phase {
+					podPhases[pod.UID] = phase
+					if !send("pod", HookPod{Hook: hook.Name, Namespace: pod.Namespace, Name: pod.Name, Phase: phase, Message: podMessage(pod)}) {
+						return false
+					}
+				}
+
+				if podStarted(pod) {
+					for _, container := range pod.Spec.Containers {
+						key := string(pod.UID) + "/" + container.Name
+						if followed[key] {
+							continue
+						}
+						followed[key] = true
+						followers.Add(1)
+						go func() {
+							defer followers.Done()
+							followHookLogs(ctx, hook.Name, pod, container.Name, logs)
+						}()
+					}
+				}
+
+				if pod.Status.Phase == corev1.PodFailed && !failedReported[pod.UID] {
+					failedReported[pod.UID] = true
+					if !send("failed", HookFailure{Hook: hook.Name, Pod: pod.Name, Logs: hookFailureLogs(ctx, pod)}) {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	if !send("connected", map[string]any{"release": name, "namespace": namespace}) || !update(rel) {
+		return
+	}
+
+	poll := time.NewTicker(hookPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	// drain is set once the release has settled, bounding how long the
+	// remaining hook logs are forwarded
+	var drain <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case line := <-logs:
+			if !send("log", line) {
+				return
+			}
+
+		case <-poll.C:
+			if drain != nil {
+				continue
+			}
+			rel, err := client.LatestRelease(namespace, name)
+			if err != nil {
+				if errors.Is(err, driver.ErrReleaseNotFound) {
+					send("done", map[string]any{"revision": lastRevision, "status": "uninstalled"})
+					return
+				}
+				log.Printf("[helm] Hook watch failed to read release %s/%s: %v", namespace, name, err)
+				continue
+			}
+			if !update(rel) {
+				return
+			}
+			if sawPending && !rel.Info.Status.IsPending() {
+				drain = time.After(hookLogDrainTimeout)
+			}
+
+		case <-drain:
+			send("done", map[string]any{"revision": lastRevision, "status": lastStatus})
+			return
+
+		case <-heartbeat.C:
+			if !send("heartbeat", struct{}{}) {
+				return
+			}
+		}
+	}
+}
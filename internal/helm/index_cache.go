@@ -0,0 +1,49 @@
+package helm
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// cachedIndex is a parsed repository index along with the file state it was read from
+type cachedIndex struct {
+	index   *repo.IndexFile
+	modTime time.Time
+	size    int64
+}
+
+var (
+	indexCache   = make(map[string]*cachedIndex)
+	indexCacheMu sync.Mutex
+)
+
+// loadRepoIndex returns the parsed index at path, re-parsing only when the file
+// changes (e.g. after a repository update). The returned index is shared and
+// must not be modified.
+func loadRepoIndex(path string) (*repo.IndexFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	indexCacheMu.Lock()
+	cached, ok := indexCache[path]
+	indexCacheMu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.index, nil
+	}
+
+	index, err := repo.LoadIndexFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	indexCacheMu.Lock()
+	indexCache[path] = &cachedIndex{index: index, modTime: info.ModTime(), size: info.Size()}
+	indexCacheMu.Unlock()
+
+	return index, nil
+}
@@ -0,0 +1,269 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// provenanceProbeTimeout bounds the reachability check of a chart source
+const provenanceProbeTimeout = 10 * time.Second
+
+// Chart source types
+const (
+	ChartSourceRepository = "repository" // A repository in the local Helm repositories file
+	ChartSourceFlux       = "flux"       // Installed by a Flux HelmRelease from its own source
+	ChartSourceUnknown    = "unknown"    // Not in any configured repository: OCI, a local path or a removed repository
+)
+
+// Release labels Flux's helm-controller sets on the releases it manages
+const (
+	fluxHelmReleaseNameLabel      = "helm.toolkit.fluxcd.io/name"
+	fluxHelmReleaseNamespaceLabel = "helm.toolkit.fluxcd.io/namespace"
+)
+
+// ChartSourceCandidate is a configured repository that has the release's chart
+type ChartSourceCandidate struct {
+	Repository       string `json:"repository"`
+	URL              string `json:"url"`
+	HasVersion       bool   `json:"hasVersion"`         // The installed version is in the index
+	ChartURL         string `json:"chartUrl,omitempty"` // Archive of the installed version
+	Digest           string `json:"digest,omitempty"`   // Archive digest from the index
+	LatestVersion    string `json:"latestVersion,omitempty"`
+	MetadataMismatch string `json:"metadataMismatch,omitempty"` // Why the indexed version may not be the chart installed
+}
+
+// SourceReachability is the result of requesting a chart archive
+type SourceReachability struct {
+	Reachable  bool   `json:"reachable"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ChartProvenance is where a release's chart most likely came from. Helm
+// doesn't record the source of a chart in the release, so it is inferred
+// from the configured repositories holding a chart of the same name and
+// version, and from the labels of the tool that installed it.
+type ChartProvenance struct {
+	Chart      string                 `json:"chart"`
+	Version    string                 `json:"version"`
+	AppVersion string                 `json:"appVersion,omitempty"`
+	Home       string                 `json:"home,omitempty"`
+	Sources    []string               `json:"sources,omitempty"` // Chart.yaml sources, usually the chart's git repository
+	SourceType string                 `json:"sourceType"`
+	Resolved   *ChartSourceCandidate  `json:"resolved,omitempty"`
+	Candidates []ChartSourceCandidate `json:"candidates"`
+	Reachable  *SourceReachability    `json:"reachability,omitempty"`
+	ManagedBy  string                 `json:"managedBy,omitempty"` // e.g. HelmRelease flux-system/podinfo
+	CanUpgrade bool                   `json:"canUpgrade"`          // Upgrade can find the chart in a reachable repository
+	Message    string                 `json:"message"`
+}
+
+// GetChartProvenance resolves the source of a release's chart and checks
+// that the chart archive can still be fetched from it
+func (c *Client) GetChartProvenance(ctx context.Context, namespace, name string) (*ChartProvenance, error) {
+	actionConfig, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	getAction := action.NewGet(actionConfig)
+	rel, err := getAction.Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+	if rel.Chart == nil || rel.Chart.Metadata == nil {
+		return nil, fmt.Errorf("release %s/%s has no chart metadata", namespace, name)
+	}
+
+	meta := rel.Chart.Metadata
+	prov := &ChartProvenance{
+		Chart:      meta.Name,
+		Version:    meta.Version,
+		AppVersion: meta.AppVersion,
+		Home:       meta.Home,
+		Sources:    meta.Sources,
+		SourceType: ChartSourceUnknown,
+		Candidates: []ChartSourceCandidate{},
+	}
+	if fluxName := rel.Labels[fluxHelmReleaseNameLabel]; fluxName != "" {
+		prov.ManagedBy = "HelmRelease " + rel.Labels[fluxHelmReleaseNamespaceLabel] + "/" + fluxName
+	}
+
+	if f, err := repo.LoadFile(c.settings.RepositoryConfig); err == nil {
+		prov.Candidates = findChartCandidates(f.Repositories, c.settings.RepositoryCache, meta)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load repo file: %w", err)
+	}
+
+	for idx := range prov.Candidates {
+		if prov.Candidates[idx].HasVersion {
+			prov.Resolved = &prov.Candidates[idx]
+			prov.SourceType = ChartSourceRepository
+			break
+		}
+	}
+
+	if prov.Resolved != nil && prov.Resolved.ChartURL != "" {
+		entry := repoEntry(c.settings.RepositoryConfig, prov.Resolved.Repository)
+		prov.Reachable = probeChartURL(ctx, prov.Resolved.ChartURL, entry)
+		prov.CanUpgrade = prov.Reachable.Reachable
+	}
+	if prov.ManagedBy != "" {
+		// Flux would roll back an upgrade made outside the HelmRelease
+		prov.SourceType = ChartSourceFlux
+		prov.CanUpgrade = false
+	}
+	prov.Message = describeProvenance(prov)
+	return prov, nil
+}
+
+// findChartCandidates lists the repositories whose cached index has a chart
+// named like the release's, those with its version first
+func findChartCandidates(repos []*repo.Entry, cacheDir string, meta *chart.Metadata) []ChartSourceCandidate {
+	candidates := []ChartSourceCandidate{}
+	for _, r := range repos {
+		indexFile, err := loadRepoIndex(filepath.Join(cacheDir, fmt.Sprintf("%s-index.yaml", r.Name)))
+		if err != nil {
+			continue
+		}
+		versions := indexFile.Entries[meta.Name]
+		if len(versions) == 0 {
+			continue
+		}
+
+		cand := ChartSourceCandidate{Repository: r.Name, URL: r.URL, LatestVersion: versions[0].Version}
+		for _, v := range versions {
+			if compareVersions(v.Version, cand.LatestVersion) > 0 {
+				cand.LatestVersion = v.Version
+			}
+			if v.Version != meta.Version {
+				continue
+			}
+			cand.HasVersion = true
+			cand.Digest = v.Digest
+			if len(v.URLs) > 0 {
+				cand.ChartURL = v.URLs[0]
+				if !strings.HasPrefix(cand.ChartURL, "http://") && !strings.HasPrefix(cand.ChartURL, "https://") {
+					cand.ChartURL = strings.TrimSuffix(r.URL, "/") + "/" + cand.ChartURL
+				}
+			}
+			// A fork republished under the same name and version usually
+			// differs in one of these
+			switch {
+			case v.AppVersion != meta.AppVersion:
+				cand.MetadataMismatch = fmt.Sprintf("indexed appVersion %q, installed %q", v.AppVersion, meta.AppVersion)
+			case v.Home != meta.Home:
+				cand.MetadataMismatch = fmt.Sprintf("indexed home %q, installed %q", v.Home, meta.Home)
+			}
+		}
+		candidates = append(candidates, cand)
+	}
+
+	// Exact version matches without metadata differences first, then by name
+	rank := func(c ChartSourceCandidate) int {
+		switch {
+		case c.HasVersion && c.MetadataMismatch == "":
+			return 0
+		case c.HasVersion:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if rank(candidates[i]) != rank(candidates[j]) {
+			return rank(candidates[i]) < rank(candidates[j])
+		}
+		return candidates[i].Repository < candidates[j].Repository
+	})
+	return candidates
+}
+
+// repoEntry returns the configured repository called name, for its credentials
+func repoEntry(repoFile, name string) *repo.Entry {
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		return nil
+	}
+	for _, r := range f.Repositories {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// probeChartURL checks that a chart archive can be fetched, with the
+// repository's credentials if it has any. HEAD is tried first; servers that
+// don't allow it get a one-byte ranged GET.
+func probeChartURL(ctx context.Context, chartURL string, entry *repo.Entry) *SourceReachability {
+	result := &SourceReachability{URL: chartURL}
+	ctx, cancel := context.WithTimeout(ctx, provenanceProbeTimeout)
+	defer cancel()
+
+	do := func(method string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, chartURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if method == http.MethodGet {
+			req.Header.Set("Range", "bytes=0-0")
+		}
+		if entry != nil && entry.Username != "" {
+			req.SetBasicAuth(entry.Username, entry.Password)
+		}
+		return httpClient.Do(req)
+	}
+
+	resp, err := do(http.MethodHead)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusForbidden) {
+		// S3 presigned and some CDN URLs refuse HEAD
+		resp.Body.Close()
+		resp, err = do(http.MethodGet)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Reachable = resp.StatusCode < 300
+	if !result.Reachable {
+		result.Error = fmt.Sprintf("%s returned %s", chartURL, resp.Status)
+	}
+	return result
+}
+
+// describeProvenance sums up where the chart came from and whether it can be upgraded from there
+func describeProvenance(prov *ChartProvenance) string {
+	chartRef := prov.Chart + " " + prov.Version
+	var msg string
+	switch {
+	case prov.Resolved == nil && len(prov.Candidates) > 0:
+		msg = fmt.Sprintf("%s isn't in any configured repository; %s has versions up to %s", chartRef, prov.Candidates[0].Repository, prov.Candidates[0].LatestVersion)
+	case prov.Resolved == nil:
+		msg = fmt.Sprintf("%s isn't in any configured repository; it was likely installed from an OCI registry, a local path or a repository that has since been removed", chartRef)
+	case prov.Reachable != nil && !prov.Reachable.Reachable:
+		msg = fmt.Sprintf("%s is indexed in %s, but its archive can't be fetched: %s", chartRef, prov.Resolved.Repository, prov.Reachable.Error)
+	default:
+		msg = fmt.Sprintf("%s comes from %s (%s)", chartRef, prov.Resolved.Repository, prov.Resolved.URL)
+		if prov.Resolved.MetadataMismatch != "" {
+			msg += ", though the indexed chart differs: " + prov.Resolved.MetadataMismatch
+		}
+	}
+	if prov.ManagedBy != "" {
+		msg += fmt.Sprintf(". It is managed by Flux %s, which upgrades it from its own source; change the HelmRelease rather than upgrading here", prov.ManagedBy)
+	}
+	return msg
+}
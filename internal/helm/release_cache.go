@@ -0,0 +1,130 @@
+package helm
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+const (
+	releaseCacheTTL        = 2 * time.Minute
+	releaseCacheMaxEntries = 32 // Releases carry their chart, so keep only a few
+)
+
+// releaseCacheKey identifies a release revision as stored. Helm rewrites a
+// revision's secret when its status changes (e.g. deployed to superseded),
+// which changes the resource version and so the key.
+type releaseCacheKey struct {
+	namespace       string
+	name            string
+	revision        int
+	resourceVersion string
+}
+
+type cachedRelease struct {
+	rel     *release.Release
+	fetched time.Time
+}
+
+var (
+	releaseCache   = make(map[releaseCacheKey]*cachedRelease)
+	releaseCacheMu sync.Mutex
+)
+
+// getRelease returns a release at a revision (0 = latest), from the cache
+// when its storage secret hasn't changed since it was read. The returned
+// release is shared and must not be modified.
+func (c *Client) getRelease(namespace, name string, revision int) (*release.Release, error) {
+	key, cacheable := releaseStorageKey(namespace, name, revision)
+	if cacheable {
+		releaseCacheMu.Lock()
+		cached, ok := releaseCache[key]
+		releaseCacheMu.Unlock()
+		if ok && time.Since(cached.fetched) < releaseCacheTTL {
+			return cached.rel, nil
+		}
+	}
+
+	actionConfig, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	getAction := action.NewGet(actionConfig)
+	if revision > 0 {
+		getAction.Version = revision
+	}
+	rel, err := getAction.Run(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The latest revision may have moved on between the lookup and the read
+	if cacheable && rel.Version == key.revision {
+		storeRelease(key, rel)
+	}
+	return rel, nil
+}
+
+// releaseStorageKey looks up the storage secret of a release revision in the
+// resource cache, resolving revision 0 to the latest. It reports false when
+// secrets aren't cached (e.g. RBAC doesn't allow listing them) or the secret
+// isn't there yet, in which case the release can't be cached safely.
+func releaseStorageKey(namespace, name string, revision int) (releaseCacheKey, bool) {
+	lister := k8s.GetResourceCache().Secrets()
+	if lister == nil {
+		return releaseCacheKey{}, false
+	}
+	secrets, err := lister.Secrets(namespace).List(labels.SelectorFromSet(labels.Set{"owner": "helm", "name": name}))
+	if err != nil {
+		return releaseCacheKey{}, false
+	}
+
+	key := releaseCacheKey{namespace: namespace, name: name}
+	for _, secret := range secrets {
+		version, err := strconv.Atoi(secret.Labels["version"])
+		if err != nil {
+			continue
+		}
+		if (revision > 0 && version == revision) || (revision == 0 && version > key.revision) {
+			key.revision = version
+			key.resourceVersion = secret.ResourceVersion
+		}
+	}
+	return key, key.resourceVersion != ""
+}
+
+func storeRelease(key releaseCacheKey, rel *release.Release) {
+	releaseCacheMu.Lock()
+	defer releaseCacheMu.Unlock()
+
+	now := time.Now()
+	for k, cached := range releaseCache {
+		// Older resource versions of the same revision are stale for good
+		if now.Sub(cached.fetched) >= releaseCacheTTL || (k.namespace == key.namespace && k.name == key.name && k.revision == key.revision) {
+			delete(releaseCache, k)
+		}
+	}
+	if len(releaseCache) >= releaseCacheMaxEntries {
+		var oldest releaseCacheKey
+		for k, cached := range releaseCache {
+			if oldest == (releaseCacheKey{}) || cached.fetched.Before(releaseCache[oldest].fetched) {
+				oldest = k
+			}
+		}
+		delete(releaseCache, oldest)
+	}
+	releaseCache[key] = &cachedRelease{rel: rel, fetched: now}
+}
+
+// clearReleaseCache drops all cached releases, e.g. on a context switch
+func clearReleaseCache() {
+	releaseCacheMu.Lock()
+	defer releaseCacheMu.Unlock()
+	releaseCache = make(map[releaseCacheKey]*cachedRelease)
+}
@@ -0,0 +1,95 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// TemplateChart renders a chart version with the given values locally, like
+// `helm template`. It runs client-only: no cluster access, default
+// Kubernetes capabilities, and nothing is installed.
+func (c *Client) TemplateChart(ctx context.Context, req *TemplateRequest) (*TemplateResponse, error) {
+	chartURL, err := c.resolveChartURL(req.Repository, req.ChartName, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	actionConfig, err := c.getActionConfig(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	installAction := action.NewInstall(actionConfig)
+	installAction.ReleaseName = req.ReleaseName
+	installAction.Namespace = req.Namespace
+	installAction.DryRun = true
+	installAction.ClientOnly = true
+	installAction.Replace = true // Skip the name uniqueness check, it needs the cluster
+	installAction.IncludeCRDs = req.IncludeCRDs
+	installAction.Version = req.Version
+	if installAction.Version == "latest" {
+		installAction.Version = ""
+	}
+
+	cp, err := installAction.ChartPathOptions.LocateChart(chartURL, c.settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate chart: %w", err)
+	}
+
+	chrt, err := loader.Load(cp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	rel, err := installAction.RunWithContext(ctx, chrt, req.Values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	resp := &TemplateResponse{
+		Manifest:     rel.Manifest,
+		ChartVersion: rel.Chart.Metadata.Version,
+		Resources:    []RenderedResource{},
+	}
+	if rel.Info != nil {
+		resp.Notes = rel.Info.Notes
+	}
+	// Keep resources in the order they were rendered
+	docs := releaseutil.SplitManifests(rel.Manifest)
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+	for _, key := range keys {
+		doc := docs[key]
+		for _, res := range parseManifestResources(doc, req.Namespace) {
+			resp.Resources = append(resp.Resources, RenderedResource{
+				Kind:      res.Kind,
+				Name:      res.Name,
+				Namespace: res.Namespace,
+				Source:    manifestSource(doc),
+				Manifest:  strings.TrimSpace(doc),
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// manifestSource returns the template path from a rendered document's
+// "# Source:" comment
+func manifestSource(doc string) string {
+	for _, line := range strings.Split(doc, "\n") {
+		if src, ok := strings.CutPrefix(strings.TrimSpace(line), "# Source:"); ok {
+			return strings.TrimSpace(src)
+		}
+	}
+	return ""
+}
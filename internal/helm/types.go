@@ -18,6 +18,7 @@ type HelmRelease struct {
 	ResourceHealth string `json:"resourceHealth,omitempty"` // healthy, degraded, unhealthy, unknown
 	HealthIssue    string `json:"healthIssue,omitempty"`    // Primary issue if unhealthy (e.g., "OOMKilled")
 	HealthSummary  string `json:"healthSummary,omitempty"`  // Brief summary like "2/3 pods ready"
+	HealthMessage  string `json:"healthMessage,omitempty"`  // Rollup like "Degraded: 1 deployment not ready"
 }
 
 // HelmRevision represents a single revision in the release history
@@ -47,6 +48,11 @@ type HelmReleaseDetail struct {
 	Hooks        []HelmHook        `json:"hooks,omitempty"`
 	Readme       string            `json:"readme,omitempty"`
 	Dependencies []ChartDependency `json:"dependencies,omitempty"`
+	// Health summary from owned resources, same as the list view
+	ResourceHealth string `json:"resourceHealth,omitempty"` // healthy, degraded, unhealthy, unknown
+	HealthIssue    string `json:"healthIssue,omitempty"`
+	HealthSummary  string `json:"healthSummary,omitempty"`
+	HealthMessage  string `json:"healthMessage,omitempty"`
 }
 
 // HelmHook represents a Helm hook (pre/post install, upgrade, etc.)
@@ -72,9 +78,9 @@ type OwnedResource struct {
 	Kind      string `json:"kind"`
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
-	Status    string `json:"status,omitempty"`  // Running, Pending, Failed, etc.
+	Status    string `json:"status,omitempty"`  // Running, Pending, Failed, etc.; Unknown if the lookup failed
 	Ready     string `json:"ready,omitempty"`   // e.g., "3/3" for deployments
-	Message   string `json:"message,omitempty"` // Status message or reason
+	Message   string `json:"message,omitempty"` // Status message or reason, or the lookup error
 	Summary   string `json:"summary,omitempty"` // Brief status like "0/3 OOMKilled"
 	Issue     string `json:"issue,omitempty"`   // Primary issue if unhealthy
 }
@@ -92,6 +98,34 @@ type ManifestDiff struct {
 	Diff      string `json:"diff"`
 }
 
+// ReleaseDrift compares a release's current manifest with the live cluster state
+type ReleaseDrift struct {
+	Name         string          `json:"name"`
+	Namespace    string          `json:"namespace"`
+	Revision     int             `json:"revision"`
+	Drifted      bool            `json:"drifted"`
+	DriftedCount int             `json:"driftedCount"` // Resources that are drifted or missing
+	InSyncCount  int             `json:"inSyncCount"`
+	Resources    []ResourceDrift `json:"resources"`
+}
+
+// ResourceDrift describes how a single manifest resource differs from the cluster
+type ResourceDrift struct {
+	Kind      string        `json:"kind"`
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace,omitempty"`
+	Status    string        `json:"status"` // in-sync, drifted, missing, unknown
+	Message   string        `json:"message,omitempty"`
+	Changes   []DriftChange `json:"changes,omitempty"`
+}
+
+// DriftChange is a single field whose live value differs from the manifest
+type DriftChange struct {
+	Path    string `json:"path"` // e.g. "spec.replicas" or "spec.template.spec.containers[0].image"
+	Desired any    `json:"desired"`
+	Live    any    `json:"live"` // nil if the field is absent in the cluster
+}
+
 // UpgradeInfo contains information about available upgrades
 type UpgradeInfo struct {
 	CurrentVersion  string `json:"currentVersion"`
@@ -119,6 +153,44 @@ type ValuesPreviewResponse struct {
 	ManifestDiff  string         `json:"manifestDiff"`
 }
 
+// RollbackPreview describes what rolling back to a revision would change
+type RollbackPreview struct {
+	CurrentRevision     int             `json:"currentRevision"`
+	TargetRevision      int             `json:"targetRevision"`
+	CurrentChartVersion string          `json:"currentChartVersion"`
+	TargetChartVersion  string          `json:"targetChartVersion"`
+	ManifestDiff        string          `json:"manifestDiff"`
+	Added               []OwnedResource `json:"added,omitempty"`   // Resources the rollback would create
+	Removed             []OwnedResource `json:"removed,omitempty"` // Resources the rollback would delete
+	Changed             []OwnedResource `json:"changed,omitempty"` // Resources the rollback would modify
+}
+
+// ValidateValuesRequest is the request body for validating values against a
+// chart's values schema. The chart is identified by repository/chart/version,
+// or by an installed release (namespace + releaseName).
+type ValidateValuesRequest struct {
+	Repository  string         `json:"repository,omitempty"`
+	ChartName   string         `json:"chartName,omitempty"`
+	Version     string         `json:"version,omitempty"`
+	Namespace   string         `json:"namespace,omitempty"`
+	ReleaseName string         `json:"releaseName,omitempty"`
+	Values      map[string]any `json:"values"`
+}
+
+// ValuesValidationResult contains the result of validating values against a chart schema
+type ValuesValidationResult struct {
+	Valid   bool                    `json:"valid"`
+	Skipped bool                    `json:"skipped,omitempty"` // True if the chart has no values.schema.json
+	Errors  []ValuesValidationError `json:"errors,omitempty"`
+}
+
+// ValuesValidationError is a single schema violation
+type ValuesValidationError struct {
+	Path    string `json:"path"`            // e.g. "image.tag" or "redis.auth.password"
+	Chart   string `json:"chart,omitempty"` // Chart (or subchart) whose schema was violated
+	Message string `json:"message"`
+}
+
 // HelmRepository represents a configured Helm repository
 type HelmRepository struct {
 	Name        string    `json:"name"`
@@ -167,6 +239,34 @@ type InstallRequest struct {
 	CreateNamespace bool           `json:"createNamespace,omitempty"`
 }
 
+// TemplateRequest is the request body for rendering a chart without installing it
+type TemplateRequest struct {
+	Repository  string         `json:"repository"`
+	ChartName   string         `json:"chartName"`
+	Version     string         `json:"version"`
+	ReleaseName string         `json:"releaseName"`
+	Namespace   string         `json:"namespace"`
+	Values      map[string]any `json:"values,omitempty"`
+	IncludeCRDs bool           `json:"includeCrds,omitempty"`
+}
+
+// TemplateResponse contains a locally rendered chart
+type TemplateResponse struct {
+	Manifest     string             `json:"manifest"`
+	ChartVersion string             `json:"chartVersion"`
+	Resources    []RenderedResource `json:"resources"`
+	Notes        string             `json:"notes,omitempty"`
+}
+
+// RenderedResource is a single resource from a rendered chart
+type RenderedResource struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Source    string `json:"source,omitempty"` // Template the resource was rendered from
+	Manifest  string `json:"manifest"`
+}
+
 // ChartSearchResult contains search results for charts
 type ChartSearchResult struct {
 	Charts []ChartInfo `json:"charts"`
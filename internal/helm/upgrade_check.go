@@ -0,0 +1,210 @@
+package helm
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	upgradeCheckTTL         = 5 * time.Minute
+	upgradeCheckConcurrency = 8 // Repository indexes parsed at once
+)
+
+// upgradeCheck is a cached upgrade check result for one chart+version
+type upgradeCheck struct {
+	info    UpgradeInfo
+	expires time.Time
+}
+
+var (
+	upgradeChecks   = make(map[string]upgradeCheck)
+	upgradeChecksMu sync.Mutex
+)
+
+// invalidateUpgradeChecks drops cached upgrade checks, e.g. after a repository update
+func invalidateUpgradeChecks() {
+	upgradeChecksMu.Lock()
+	defer upgradeChecksMu.Unlock()
+	upgradeChecks = make(map[string]upgradeCheck)
+}
+
+// latestChartVersion is the newest version of a chart across repositories
+type latestChartVersion struct {
+	version  string
+	repoName string
+}
+
+// BatchCheckUpgrades checks for upgrades for all releases at once. Results are
+// cached per chart+version, so releases sharing a chart are checked once and
+// repeated calls only read repository indexes for charts not seen recently.
+// A repository whose index can't be read only affects the releases whose
+// chart wasn't found elsewhere; they get a per-release error.
+func (c *Client) BatchCheckUpgrades(namespace string) (*BatchUpgradeInfo, error) {
+	actionConfig, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// List releases directly: the health enrichment done by ListReleases
+	// isn't needed here and is the slow part with many releases
+	listAction := action.NewList(actionConfig)
+	listAction.All = true
+	listAction.AllNamespaces = namespace == ""
+	listAction.StateMask = action.ListAll
+
+	releases, err := listAction.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	result := &BatchUpgradeInfo{
+		Releases: make(map[string]*UpgradeInfo),
+	}
+
+	type releaseChart struct {
+		key      string // namespace/name
+		chart    string
+		version  string
+		cacheKey string // chart@version
+	}
+
+	now := time.Now()
+	var pending []releaseChart
+	needed := make(map[string]bool)
+
+	upgradeChecksMu.Lock()
+	for _, rel := range releases {
+		if rel.Chart == nil || rel.Chart.Metadata == nil {
+			continue
+		}
+		rc := releaseChart{
+			key:     rel.Namespace + "/" + rel.Name,
+			chart:   rel.Chart.Metadata.Name,
+			version: rel.Chart.Metadata.Version,
+		}
+		rc.cacheKey = rc.chart + "@" + rc.version
+		if cached, ok := upgradeChecks[rc.cacheKey]; ok && now.Before(cached.expires) {
+			info := cached.info
+			result.Releases[rc.key] = &info
+			continue
+		}
+		pending = append(pending, rc)
+		needed[rc.chart] = true
+	}
+	upgradeChecksMu.Unlock()
+
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	f, err := repo.LoadFile(c.settings.RepositoryConfig)
+	if err != nil {
+		// No repos configured - return results with per-release errors
+		for _, rc := range pending {
+			result.Releases[rc.key] = &UpgradeInfo{
+				CurrentVersion: rc.version,
+				Error:          "no helm repositories configured",
+			}
+		}
+		return result, nil
+	}
+
+	latest, failedRepos := findLatestChartVersions(f.Repositories, c.settings.RepositoryCache, needed)
+
+	var notFound string
+	if len(failedRepos) > 0 {
+		notFound = fmt.Sprintf("chart not found in configured repositories (could not read index for: %s)", strings.Join(failedRepos, ", "))
+	} else {
+		notFound = "chart not found in configured repositories"
+	}
+
+	expires := time.Now().Add(upgradeCheckTTL)
+	upgradeChecksMu.Lock()
+	defer upgradeChecksMu.Unlock()
+	for _, rc := range pending {
+		info := UpgradeInfo{CurrentVersion: rc.version}
+		if l, ok := latest[rc.chart]; ok {
+			info.LatestVersion = l.version
+			info.RepositoryName = l.repoName
+			info.UpdateAvailable = compareVersions(l.version, rc.version) > 0
+		} else {
+			info.Error = notFound
+		}
+
+		// An unreadable repository may hold a newer version, so only
+		// cache results that saw every index
+		if len(failedRepos) == 0 {
+			upgradeChecks[rc.cacheKey] = upgradeCheck{info: info, expires: expires}
+		}
+		result.Releases[rc.key] = &info
+	}
+
+	return result, nil
+}
+
+// findLatestChartVersions reads the repository indexes in parallel and returns
+// the newest version of each requested chart, along with the names of
+// repositories whose index could not be read
+func findLatestChartVersions(repos []*repo.Entry, cacheDir string, charts map[string]bool) (map[string]latestChartVersion, []string) {
+	latest := make(map[string]latestChartVersion)
+	var failed []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, upgradeCheckConcurrency)
+
+	for _, r := range repos {
+		wg.Add(1)
+		go func(r *repo.Entry) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			indexPath := filepath.Join(cacheDir, fmt.Sprintf("%s-index.yaml", r.Name))
+			indexFile, err := loadRepoIndex(indexPath)
+			if err != nil {
+				mu.Lock()
+				failed = append(failed, r.Name)
+				mu.Unlock()
+				return
+			}
+
+			found := make(map[string]string)
+			for chartName := range charts {
+				versions := indexFile.Entries[chartName]
+				if len(versions) == 0 {
+					continue
+				}
+				// versions[0] is typically the latest
+				latestInRepo := versions[0].Version
+				for _, v := range versions {
+					if compareVersions(v.Version, latestInRepo) > 0 {
+						latestInRepo = v.Version
+					}
+				}
+				found[chartName] = latestInRepo
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for chartName, version := range found {
+				existing, exists := latest[chartName]
+				// Tie-break on repo name so the result doesn't depend on goroutine order
+				if !exists || compareVersions(version, existing.version) > 0 ||
+					(compareVersions(version, existing.version) == 0 && r.Name < existing.repoName) {
+					latest[chartName] = latestChartVersion{version: version, repoName: r.Name}
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	sort.Strings(failed)
+	return latest, failed
+}
@@ -0,0 +1,208 @@
+package helm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const valuesSchemaURL = "file:///values.schema.json"
+
+var schemaErrorPrinter = message.NewPrinter(language.English)
+
+// ValidateValues validates values against the chart's values.schema.json (and
+// those of its subcharts) the same way Helm does before install or upgrade,
+// but reports each violation separately so the UI can point at the field.
+func (c *Client) ValidateValues(req *ValidateValuesRequest) (*ValuesValidationResult, error) {
+	chrt, err := c.loadChartForValidation(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !chartHasSchema(chrt) {
+		return &ValuesValidationResult{Valid: true, Skipped: true}, nil
+	}
+
+	// Helm validates the user's values merged over the chart defaults
+	values, err := chartutil.CoalesceValues(chrt, req.Values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge values: %w", err)
+	}
+
+	result := &ValuesValidationResult{Errors: []ValuesValidationError{}}
+	if err := validateChartValues(chrt, values.AsMap(), "", &result.Errors); err != nil {
+		return nil, err
+	}
+	result.Valid = len(result.Errors) == 0
+
+	return result, nil
+}
+
+// loadChartForValidation loads the chart referenced by the request: either an
+// installed release's chart or a chart version from a repository
+func (c *Client) loadChartForValidation(req *ValidateValuesRequest) (*chart.Chart, error) {
+	if req.ReleaseName != "" {
+		actionConfig, err := c.getActionConfig(req.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := action.NewGet(actionConfig).Run(req.ReleaseName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get release: %w", err)
+		}
+		if rel.Chart == nil {
+			return nil, fmt.Errorf("release %s has no chart", req.ReleaseName)
+		}
+		return rel.Chart, nil
+	}
+
+	chartURL, err := c.resolveChartURL(req.Repository, req.ChartName, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	actionConfig, err := c.getActionConfig("")
+	if err != nil {
+		return nil, err
+	}
+
+	client := action.NewInstall(actionConfig)
+	client.Version = req.Version
+	if client.Version == "latest" {
+		client.Version = ""
+	}
+
+	cp, err := client.ChartPathOptions.LocateChart(chartURL, c.settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate chart: %w", err)
+	}
+
+	chrt, err := loader.Load(cp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+	return chrt, nil
+}
+
+// chartHasSchema reports whether the chart or any of its subcharts ships a values schema
+func chartHasSchema(chrt *chart.Chart) bool {
+	if len(chrt.Schema) > 0 {
+		return true
+	}
+	for _, dep := range chrt.Dependencies() {
+		if chartHasSchema(dep) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateChartValues validates values against the chart's schema and recurses
+// into subcharts with the values scoped under each subchart's name
+func validateChartValues(chrt *chart.Chart, values map[string]any, prefix string, errs *[]ValuesValidationError) error {
+	if len(chrt.Schema) > 0 {
+		if err := validateAgainstSchema(chrt.Name(), chrt.Schema, values, prefix, errs); err != nil {
+			return err
+		}
+	}
+
+	for _, dep := range chrt.Dependencies() {
+		raw, exists := values[dep.Name()]
+		if !exists || raw == nil {
+			continue
+		}
+		depValues, ok := raw.(map[string]any)
+		if !ok {
+			*errs = append(*errs, ValuesValidationError{
+				Path:    joinPath(prefix, dep.Name()),
+				Chart:   dep.Name(),
+				Message: fmt.Sprintf("invalid type for subchart values: expected object, got %T", raw),
+			})
+			continue
+		}
+		if err := validateChartValues(dep, depValues, joinPath(prefix, dep.Name()), errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAgainstSchema appends one error per failing leaf of the schema
+// validation. External $refs are not loaded, so a chart schema cannot make the
+// server read local files or fetch URLs.
+func validateAgainstSchema(chartName string, schemaJSON []byte, values map[string]any, prefix string, errs *[]ValuesValidationError) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaJSON))
+	if err != nil {
+		return fmt.Errorf("invalid values schema in chart %s: %w", chartName, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := compiler.AddResource(valuesSchemaURL, doc); err != nil {
+		return fmt.Errorf("invalid values schema in chart %s: %w", chartName, err)
+	}
+	schema, err := compiler.Compile(valuesSchemaURL)
+	if err != nil {
+		return fmt.Errorf("invalid values schema in chart %s: %w", chartName, err)
+	}
+
+	err = schema.Validate(values)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return fmt.Errorf("failed to validate values for chart %s: %w", chartName, err)
+	}
+
+	var found []ValuesValidationError
+	collectSchemaErrors(validationErr, chartName, prefix, &found)
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	*errs = append(*errs, found...)
+	return nil
+}
+
+// collectSchemaErrors flattens a validation error tree into its leaf causes,
+// which carry the specific field and reason (wrong type, missing property, ...)
+func collectSchemaErrors(e *jsonschema.ValidationError, chartName, prefix string, errs *[]ValuesValidationError) {
+	if len(e.Causes) > 0 {
+		for _, cause := range e.Causes {
+			collectSchemaErrors(cause, chartName, prefix, errs)
+		}
+		return
+	}
+
+	path := prefix
+	for _, token := range e.InstanceLocation {
+		path = joinPath(path, token)
+	}
+
+	// Report missing required properties at the field itself rather than its parent
+	if required, ok := e.ErrorKind.(*kind.Required); ok {
+		for _, missing := range required.Missing {
+			*errs = append(*errs, ValuesValidationError{
+				Path:    joinPath(path, missing),
+				Chart:   chartName,
+				Message: "required property is missing",
+			})
+		}
+		return
+	}
+
+	*errs = append(*errs, ValuesValidationError{
+		Path:    displayPath(path),
+		Chart:   chartName,
+		Message: strings.TrimSpace(e.ErrorKind.LocalizedString(schemaErrorPrinter)),
+	})
+}
@@ -0,0 +1,90 @@
+// Package httperr defines the JSON error body shared by the API handlers.
+//
+// Every error response has the form {"error": "<message>", "code": "<CODE>"}.
+// The message is for humans; the code is stable and lets the UI branch on the
+// cause (e.g. prompt for registry credentials) without parsing messages.
+package httperr
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Generic codes, used when a handler has nothing more specific than the status
+const (
+	CodeBadRequest   = "BAD_REQUEST"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeNotFound     = "NOT_FOUND"
+	CodeConflict     = "CONFLICT"
+	CodeRateLimited  = "RATE_LIMITED"
+	CodeUnavailable  = "UNAVAILABLE"
+	CodeInternal     = "INTERNAL"
+)
+
+// Specific codes
+const (
+	CodeCacheUnavailable = "CACHE_UNAVAILABLE" // Resource cache failed to start
+	CodeCacheSyncing     = "CACHE_SYNCING"     // Resource cache is still loading; retry shortly
+
+	CodeHelmNotInitialized = "HELM_NOT_INITIALIZED"
+
+	CodeReadOnly = "READ_ONLY" // Write operation refused because the server runs with --read-only
+
+	CodeNamespaceNotFound  = "NAMESPACE_NOT_FOUND"
+	CodeNamespaceForbidden = "NAMESPACE_FORBIDDEN" // Current user has no permissions in the namespace
+
+	CodeImageInvalidReference = "IMAGE_INVALID_REFERENCE"
+	CodeImageUnauthorized     = "IMAGE_UNAUTHORIZED"       // Registry requires (different) credentials
+	CodeImageNotFound         = "IMAGE_NOT_FOUND"          // Repository or tag does not exist
+	CodeImageRegistryBlocked  = "IMAGE_REGISTRY_BLOCKED"   // Registry excluded by allow/deny list
+	CodeImageRegistryThrottle = "IMAGE_REGISTRY_THROTTLED" // Registry returned 429
+	CodeImagePathNotFound     = "IMAGE_PATH_NOT_FOUND"
+	CodeImageNotDirectory     = "IMAGE_NOT_DIRECTORY"
+	CodeImageTooLarge         = "IMAGE_TOO_LARGE"       // Layers don't fit in the layer cache
+	CodeImagePullTimeout      = "IMAGE_PULL_TIMEOUT"    // Registry fetch or layer download exceeded --image-pull-timeout
+	CodeImageInspectTimeout   = "IMAGE_INSPECT_TIMEOUT" // Request exceeded --image-inspect-timeout
+	CodeImageFileTooLarge     = "IMAGE_FILE_TOO_LARGE"  // File exceeds the inline view size cap; download it instead
+	CodeImageBinaryFile       = "IMAGE_BINARY_FILE"     // File isn't text, so it can't be viewed inline
+	CodeImageNotContainer     = "IMAGE_NOT_CONTAINER"   // OCI artifact (e.g. a Helm chart), not a container filesystem
+)
+
+// Body is the JSON error response
+type Body struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// Write writes a JSON error response with the given status, code and message
+func Write(w http.ResponseWriter, status int, code, message string) error {
+	if code == "" {
+		code = CodeForStatus(status)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(Body{Error: message, Code: code})
+}
+
+// CodeForStatus returns the generic code for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 400 && status < 500 {
+		return CodeBadRequest
+	}
+	return CodeInternal
+}
@@ -0,0 +1,36 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// ErrNotContainerImage is returned for registry artifacts that aren't
+// container images, such as Helm charts or signatures stored as OCI
+// artifacts, whose layers aren't filesystem tarballs
+var ErrNotContainerImage = errors.New("not an inspectable container image")
+
+// checkContainerImage returns ErrNotContainerImage if img's manifest
+// describes an OCI artifact rather than a container image: a config or
+// layer media type that isn't one of the image types. Artifacts with an
+// artifactType carry the OCI empty config, so they're caught the same way.
+// An image with no layers at all (built FROM scratch with only metadata) is
+// a valid image with an empty filesystem.
+func checkContainerImage(img v1.Image) error {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("failed to read image manifest: %w", err)
+	}
+
+	if mt := manifest.Config.MediaType; mt != "" && !mt.IsConfig() {
+		return fmt.Errorf("%w: artifact with config type %s", ErrNotContainerImage, mt)
+	}
+	for _, layer := range manifest.Layers {
+		if !layer.MediaType.IsLayer() {
+			return fmt.Errorf("%w: layer of type %s", ErrNotContainerImage, layer.MediaType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func TestCheckContainerImage(t *testing.T) {
+	img, err := random.Image(64, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkContainerImage(img); err != nil {
+		t.Errorf("container image rejected: %v", err)
+	}
+
+	// FROM scratch with no files: no layers, still an image
+	if err := checkContainerImage(empty.Image); err != nil {
+		t.Errorf("image without layers rejected: %v", err)
+	}
+
+	// Helm charts pushed with helm push
+	chart, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer([]byte("chart"), "application/vnd.cncf.helm.chart.content.v1.tar+gzip"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chart = mutate.ConfigMediaType(mutate.MediaType(chart, types.OCIManifestSchema1), "application/vnd.cncf.helm.config.v1+json")
+	if err := checkContainerImage(chart); !errors.Is(err, ErrNotContainerImage) {
+		t.Errorf("expected ErrNotContainerImage for a Helm chart, got %v", err)
+	}
+
+	// Image config with a non-filesystem layer
+	artifact, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer([]byte("{}"), "application/vnd.dev.sigstore.bundle.v0.3+json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkContainerImage(artifact); !errors.Is(err, ErrNotContainerImage) {
+		t.Errorf("expected ErrNotContainerImage for an artifact layer, got %v", err)
+	}
+}
+
+func TestBuildFilesystemTreeFromFiles_Empty(t *testing.T) {
+	root, totalFiles, totalSize, err := buildFilesystemTreeFromFiles(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("empty image failed: %v", err)
+	}
+	if root == nil || root.Path != "/" || len(root.Children) != 0 || totalFiles != 0 || totalSize != 0 {
+		t.Errorf("expected an empty root, got %+v (%d files, %d bytes)", root, totalFiles, totalSize)
+	}
+
+	// Empty and non-tar layers end the layer instead of erroring or spinning
+	dir := t.TempDir()
+	emptyLayer := filepath.Join(dir, "empty")
+	garbageLayer := filepath.Join(dir, "garbage")
+	if err := os.WriteFile(emptyLayer, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(garbageLayer, []byte("this is not a tar archive, just some bytes that go on for a while"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	root, totalFiles, _, err = buildFilesystemTreeFromFiles(context.Background(), []string{emptyLayer, garbageLayer})
+	if err != nil {
+		t.Fatalf("unreadable layers failed: %v", err)
+	}
+	if len(root.Children) != 0 || totalFiles != 0 {
+		t.Errorf("expected an empty tree, got %d files", totalFiles)
+	}
+}
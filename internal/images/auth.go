@@ -28,7 +28,8 @@ type DockerConfigEntry struct {
 }
 
 // GetPullSecretsFromPod discovers ImagePullSecrets from a pod's spec
-// Returns a list of secret names that can be used for authentication
+// Returns a list of secret names that can be used for authentication.
+// Pull secrets are pod-level, so they cover init and ephemeral containers too.
 func GetPullSecretsFromPod(namespace, podName string) []string {
 	if namespace == "" || podName == "" {
 		return nil
@@ -175,35 +176,48 @@ func GetAnonymousKeychain() authn.Keychain {
 }
 
 // GetAuthenticatedKeychain creates a keychain with all available credentials
-// for the given image. This includes:
+// for the given image, in the order set by SetKeychainOrder. By default:
 // 1. ImagePullSecrets from the cluster
 // 2. Registry-specific authentication (Google ADC, etc.)
 // 3. Default keychain (docker config.json)
 func GetAuthenticatedKeychain(imageRef string, namespace string, secretNames []string) authn.Keychain {
 	var keychains []authn.Keychain
-	registryType := DetectRegistryType(imageRef)
-
-	// 1. Try ImagePullSecrets from cluster
-	if len(secretNames) > 0 {
-		psKeychain := getKeychainFromSecrets(namespace, secretNames)
-		if psKeychain != nil {
-			keychains = append(keychains, psKeychain)
+	for _, source := range KeychainOrder() {
+		if keychain := sourceKeychain(source, imageRef, namespace, secretNames); keychain != nil {
+			keychains = append(keychains, keychain)
 		}
 	}
 
-	// 2. Add registry-specific keychains
-	switch registryType {
-	case RegistryGoogle:
-		log.Printf("Adding Google keychain for registry: %s", imageRef)
-		keychains = append(keychains, google.Keychain)
-	// AWS, Azure, GitHub, Quay, GitLab all use docker config.json credentials
-	// which are handled by the default keychain
-	}
+	return authn.NewMultiKeychain(keychains...)
+}
 
-	// 3. Add default keychain as fallback (reads ~/.docker/config.json)
-	keychains = append(keychains, authn.DefaultKeychain)
+// sourceKeychain returns the keychain for one credential source, or nil if
+// the source has nothing to offer for the image
+func sourceKeychain(source, imageRef, namespace string, secretNames []string) authn.Keychain {
+	switch source {
+	case KeychainPullSecrets:
+		// ImagePullSecrets from cluster
+		if len(secretNames) > 0 {
+			if psKeychain := getKeychainFromSecrets(namespace, secretNames); psKeychain != nil {
+				return psKeychain
+			}
+		}
 
-	return authn.NewMultiKeychain(keychains...)
+	case KeychainCloud:
+		// Registry-specific keychains
+		switch DetectRegistryType(imageRef) {
+		case RegistryGoogle:
+			log.Printf("Adding Google keychain for registry: %s", imageRef)
+			return google.Keychain
+			// AWS, Azure, GitHub, Quay, GitLab all use docker config.json credentials
+			// which are handled by the default keychain
+		}
+
+	case KeychainDockerConfig:
+		// Default keychain (reads ~/.docker/config.json)
+		return authn.DefaultKeychain
+	}
+	return nil
 }
 
 // GetKeychainForImage creates an authn.Keychain for fetching an image
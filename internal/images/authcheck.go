@@ -0,0 +1,270 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// AuthAttempt is the outcome of fetching the image manifest with one set of credentials
+type AuthAttempt struct {
+	OK         bool   `json:"ok"`
+	StatusCode int    `json:"statusCode,omitempty"` // HTTP status from the registry
+	Code       string `json:"code,omitempty"`       // Error code as the inspect endpoints would return it
+	Error      string `json:"error,omitempty"`
+}
+
+// PullSecretCheck is what was found in one of the pull secrets considered
+type PullSecretCheck struct {
+	Name       string   `json:"name"`
+	Source     string   `json:"source"` // pod, serviceAccount or request
+	Found      bool     `json:"found"`
+	Type       string   `json:"type,omitempty"`
+	Registries []string `json:"registries,omitempty"` // Registries the secret has credentials for
+	Matches    bool     `json:"matches"`              // Has credentials for the image's registry
+	Problem    string   `json:"problem,omitempty"`
+}
+
+// CredentialSourceCheck is one credential source and whether it had
+// credentials for the image's registry
+type CredentialSourceCheck struct {
+	Source         string `json:"source"` // As named in --image-auth-order
+	Enabled        bool   `json:"enabled"`
+	HasCredentials bool   `json:"hasCredentials"`
+	Used           bool   `json:"used"` // First source with credentials; the one the registry saw
+}
+
+// AuthCheck explains how fetchImageBruteForce authenticates for an image:
+// whether anonymous access works, which pull secrets and credential sources
+// were considered, and what the registry said to the credentials used.
+type AuthCheck struct {
+	Image         string                  `json:"image"`
+	Registry      string                  `json:"registry"`
+	RegistryType  string                  `json:"registryType"`
+	Namespace     string                  `json:"namespace,omitempty"`
+	Pod           string                  `json:"pod,omitempty"`
+	Anonymous     AuthAttempt             `json:"anonymous"`
+	PullSecrets   []PullSecretCheck       `json:"pullSecrets"`
+	Sources       []CredentialSourceCheck `json:"sources"`
+	Authenticated *AuthAttempt            `json:"authenticated,omitempty"` // Only tried when anonymous access fails
+	AuthMethod    string                  `json:"authMethod,omitempty"`    // anonymous or the registry type, as in metadata responses
+	Summary       string                  `json:"summary"`
+}
+
+// CheckAuth fetches the image manifest the way inspection does, anonymous
+// first and then with credentials, and records every decision along the way.
+// Registry failures are part of the result, not an error; an error means the
+// check itself couldn't run, e.g. for an invalid or blocked reference.
+func (i *Inspector) CheckAuth(ctx context.Context, req InspectRequest) (*AuthCheck, error) {
+	ref, err := name.ParseReference(req.Image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference: %w", err)
+	}
+	if err := checkRegistryAllowed(ref); err != nil {
+		return nil, err
+	}
+
+	registry := ref.Context().RegistryStr()
+	check := &AuthCheck{
+		Image:        req.Image,
+		Registry:     registry,
+		RegistryType: string(DetectRegistryType(req.Image)),
+		Namespace:    req.Namespace,
+		Pod:          req.PodName,
+		PullSecrets:  checkPullSecrets(req, ref.Context()),
+	}
+
+	order := KeychainOrder()
+	var used string
+	for _, source := range append(slices.Clone(order), disabledSources(order)...) {
+		sc := CredentialSourceCheck{Source: source, Enabled: slices.Contains(order, source)}
+		if sc.Enabled {
+			if keychain := sourceKeychain(source, req.Image, req.Namespace, req.PullSecretNames); keychain != nil {
+				if auth, err := keychain.Resolve(ref.Context()); err == nil && auth != authn.Anonymous {
+					sc.HasCredentials = true
+				}
+			}
+			if sc.HasCredentials && used == "" {
+				sc.Used = true
+				used = source
+			}
+		}
+		check.Sources = append(check.Sources, sc)
+	}
+
+	regCtx := registryContext(ctx)
+	check.Anonymous = tryManifest(ref, registryOptions(regCtx, remote.WithAuth(authn.Anonymous)))
+	if check.Anonymous.OK {
+		check.AuthMethod = "anonymous"
+		check.Summary = fmt.Sprintf("%s is readable without credentials.", registry)
+		return check, nil
+	}
+
+	keychain := GetAuthenticatedKeychain(req.Image, req.Namespace, req.PullSecretNames)
+	authenticated := tryManifest(ref, registryOptions(regCtx, remote.WithAuthFromKeychain(keychain)))
+	check.Authenticated = &authenticated
+	if authenticated.OK {
+		check.AuthMethod = check.RegistryType
+		if used == "" {
+			check.AuthMethod = "anonymous"
+		}
+	}
+	check.Summary = summarizeAuthCheck(check, used)
+	return check, nil
+}
+
+// tryManifest fetches the image's manifest, leaving its layers alone
+func tryManifest(ref name.Reference, opts []remote.Option) AuthAttempt {
+	_, err := remote.Get(ref, opts...)
+	if err == nil {
+		return AuthAttempt{OK: true}
+	}
+	attempt := AuthAttempt{Error: err.Error()}
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		attempt.StatusCode = terr.StatusCode
+	}
+	_, attempt.Code = classifyError(err)
+	return attempt
+}
+
+// checkPullSecrets looks at each pull secret of the request: whether it
+// exists, is a docker config secret, and has credentials for the registry
+func checkPullSecrets(req InspectRequest, repo name.Repository) []PullSecretCheck {
+	checks := []PullSecretCheck{}
+	if len(req.PullSecretNames) == 0 {
+		return checks
+	}
+	sources := pullSecretSources(req.Namespace, req.PodName)
+
+	var lister func(string) (*corev1.Secret, error)
+	if cache := k8s.GetResourceCache(); cache != nil && cache.Secrets() != nil {
+		lister = cache.Secrets().Secrets(req.Namespace).Get
+	}
+
+	for _, secretName := range req.PullSecretNames {
+		sc := PullSecretCheck{Name: secretName, Source: sources[secretName]}
+		if sc.Source == "" {
+			sc.Source = "request"
+		}
+		checks = append(checks, sc)
+		current := &checks[len(checks)-1]
+
+		if lister == nil {
+			current.Problem = "can't be read: the resource cache has no access to secrets"
+			continue
+		}
+		secret, err := lister(secretName)
+		if err != nil {
+			current.Problem = fmt.Sprintf("not found in namespace %s", req.Namespace)
+			continue
+		}
+		current.Found = true
+		current.Type = string(secret.Type)
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			current.Problem = fmt.Sprintf("type is %s, only %s secrets are used", secret.Type, corev1.SecretTypeDockerConfigJson)
+			continue
+		}
+		var config DockerConfigJSON
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+			current.Problem = fmt.Sprintf("%s is not valid JSON: %v", corev1.DockerConfigJsonKey, err)
+			continue
+		}
+		for registry := range config.Auths {
+			current.Registries = append(current.Registries, registry)
+		}
+		slices.Sort(current.Registries)
+
+		keychain := &pullSecretKeychain{auths: config.Auths}
+		if auth, err := keychain.Resolve(repo); err == nil && auth != authn.Anonymous {
+			current.Matches = true
+		} else {
+			current.Problem = fmt.Sprintf("has no credentials for %s", repo.RegistryStr())
+		}
+	}
+	return checks
+}
+
+// pullSecretSources says for each pull secret of a pod whether it comes from
+// the pod spec or the pod's service account
+func pullSecretSources(namespace, podName string) map[string]string {
+	sources := make(map[string]string)
+	pod, err := getCachedPod(namespace, podName)
+	if err != nil {
+		return sources
+	}
+	saName := pod.Spec.ServiceAccountName
+	if saName == "" {
+		saName = "default"
+	}
+	for _, secretName := range getServiceAccountPullSecrets(namespace, saName) {
+		sources[secretName] = "serviceAccount"
+	}
+	for _, ref := range pod.Spec.ImagePullSecrets {
+		sources[ref.Name] = "pod"
+	}
+	return sources
+}
+
+// disabledSources returns the credential sources left out of order
+func disabledSources(order []string) []string {
+	var disabled []string
+	for _, source := range DefaultKeychainOrder {
+		if !slices.Contains(order, source) {
+			disabled = append(disabled, source)
+		}
+	}
+	return disabled
+}
+
+// summarizeAuthCheck says in one or two sentences why authenticated access
+// worked or didn't, and what to fix
+func summarizeAuthCheck(check *AuthCheck, used string) string {
+	auth := check.Authenticated
+	anonymous := fmt.Sprintf("Anonymous access to %s failed", check.Registry)
+	if check.Anonymous.StatusCode != 0 {
+		anonymous += fmt.Sprintf(" (HTTP %d)", check.Anonymous.StatusCode)
+	}
+
+	if auth.OK {
+		if used == "" {
+			// No source had credentials, so the second fetch was anonymous too
+			return fmt.Sprintf("%s, but a retry without credentials succeeded. The failure was likely transient, such as throttling.", anonymous)
+		}
+		return fmt.Sprintf("%s; credentials from %s were accepted.", anonymous, used)
+	}
+	if auth.Code != httperr.CodeImageUnauthorized {
+		// Not found, throttled, unreachable: credentials aren't the problem, or not the only one
+		return fmt.Sprintf("%s; with credentials the fetch failed too: %s", anonymous, auth.Error)
+	}
+	if used != "" {
+		return fmt.Sprintf("%s; credentials from %s were rejected. They may be expired or lack pull access to this repository.", anonymous, used)
+	}
+
+	var problems []string
+	for _, ps := range check.PullSecrets {
+		if ps.Problem == "" {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("pull secret %s: %s", ps.Name, ps.Problem))
+	}
+	switch {
+	case len(check.PullSecrets) == 0 && check.Pod != "":
+		problems = append(problems, fmt.Sprintf("pod %s and its service account have no imagePullSecrets", check.Pod))
+	case len(check.PullSecrets) == 0:
+		problems = append(problems, "no pull secrets were given")
+	}
+	return fmt.Sprintf("%s and no credential source has credentials for it: %s.", anonymous, strings.Join(problems, "; "))
+}
@@ -0,0 +1,143 @@
+package images
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+)
+
+// testRegistry serves an in-memory registry holding one random image and
+// returns its reference. With private set, every request without
+// credentials gets a 401.
+func testRegistry(t *testing.T, private bool) string {
+	t.Helper()
+	reg := registry.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if private && r.Header.Get("Authorization") == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	image := strings.TrimPrefix(srv.URL, "http://") + "/team/app:v1"
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img, remote.WithAuth(&authn.Basic{Username: "ci", Password: "secret"})); err != nil {
+		t.Fatal(err)
+	}
+	return image
+}
+
+func TestCheckAuth_Anonymous(t *testing.T) {
+	image := testRegistry(t, false)
+
+	check, err := (&Inspector{}).CheckAuth(context.Background(), InspectRequest{Image: image})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !check.Anonymous.OK || check.Authenticated != nil || check.AuthMethod != "anonymous" {
+		t.Errorf("expected anonymous access only, got %+v", check)
+	}
+}
+
+func TestCheckAuth_NoCredentials(t *testing.T) {
+	image := testRegistry(t, true)
+	defer SetKeychainOrder(KeychainOrder())
+	SetKeychainOrder([]string{KeychainPullSecrets})
+
+	check, err := (&Inspector{}).CheckAuth(context.Background(), InspectRequest{Image: image, Namespace: "apps"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Anonymous.OK || check.Anonymous.StatusCode != http.StatusUnauthorized || check.Anonymous.Code != httperr.CodeImageUnauthorized {
+		t.Errorf("expected anonymous 401, got %+v", check.Anonymous)
+	}
+	if check.Authenticated == nil || check.Authenticated.OK {
+		t.Errorf("expected authenticated attempt to fail, got %+v", check.Authenticated)
+	}
+	if len(check.Sources) != len(DefaultKeychainOrder) {
+		t.Fatalf("expected every credential source listed, got %+v", check.Sources)
+	}
+	for _, source := range check.Sources {
+		if source.Enabled != (source.Source == KeychainPullSecrets) || source.HasCredentials || source.Used {
+			t.Errorf("unexpected source state %+v", source)
+		}
+	}
+	if !strings.Contains(check.Summary, "no pull secrets were given") {
+		t.Errorf("summary doesn't name the missing pull secrets: %q", check.Summary)
+	}
+}
+
+func TestSummarizeAuthCheck(t *testing.T) {
+	unauthorized := AuthAttempt{StatusCode: 401, Code: httperr.CodeImageUnauthorized, Error: "UNAUTHORIZED"}
+	tests := []struct {
+		name  string
+		check AuthCheck
+		used  string
+		want  string
+	}{
+		{
+			name:  "accepted",
+			check: AuthCheck{Registry: "ghcr.io", Anonymous: unauthorized, Authenticated: &AuthAttempt{OK: true}},
+			used:  KeychainPullSecrets,
+			want:  "credentials from pull-secrets were accepted",
+		},
+		{
+			name:  "accepted without credentials",
+			check: AuthCheck{Registry: "ghcr.io", Anonymous: unauthorized, Authenticated: &AuthAttempt{OK: true}},
+			want:  "a retry without credentials succeeded",
+		},
+		{
+			name:  "rejected",
+			check: AuthCheck{Registry: "ghcr.io", Anonymous: unauthorized, Authenticated: &unauthorized},
+			used:  KeychainDockerConfig,
+			want:  "credentials from docker-config were rejected",
+		},
+		{
+			name: "secret for another registry",
+			check: AuthCheck{
+				Registry:      "ghcr.io",
+				Anonymous:     unauthorized,
+				Authenticated: &unauthorized,
+				PullSecrets:   []PullSecretCheck{{Name: "regcred", Found: true, Problem: "has no credentials for ghcr.io"}},
+			},
+			want: "pull secret regcred: has no credentials for ghcr.io",
+		},
+		{
+			name: "not found",
+			check: AuthCheck{
+				Registry:      "ghcr.io",
+				Anonymous:     unauthorized,
+				Authenticated: &AuthAttempt{StatusCode: 404, Code: httperr.CodeImageNotFound, Error: "MANIFEST_UNKNOWN"},
+			},
+			used: KeychainPullSecrets,
+			want: "fetch failed too: MANIFEST_UNKNOWN",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizeAuthCheck(&tt.check, tt.used); !strings.Contains(got, tt.want) {
+				t.Errorf("summary %q doesn't contain %q", got, tt.want)
+			}
+		})
+	}
+}
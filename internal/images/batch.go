@@ -0,0 +1,151 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"golang.org/x/time/rate"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+)
+
+const (
+	maxBatchImages   = 100 // Images in one batch metadata request
+	batchConcurrency = 4   // Lookups of one batch in flight at once
+)
+
+// BatchImageRef is one image of a batch metadata request, with the same
+// context as the query parameters of GET /api/images/metadata
+type BatchImageRef struct {
+	Image       string   `json:"image"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Pod         string   `json:"pod,omitempty"` // Pull secrets are discovered from the pod when none are given
+	PullSecrets []string `json:"pullSecrets,omitempty"`
+}
+
+// BatchMetadataResult is one item of a batch metadata response. Exactly one
+// of Metadata and Error is set.
+type BatchMetadataResult struct {
+	BatchImageRef
+	Metadata *ImageMetadata `json:"metadata,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Code     string         `json:"code,omitempty"` // Same codes as error responses, e.g. IMAGE_NOT_FOUND
+}
+
+// GetMetadataBatch looks up the metadata of several images, batchConcurrency
+// at a time, and returns the results in request order. A lookup that fails
+// is reported on its item. limiter, when set, is the caller's rate limit:
+// every image after the first waits for a token, so a batch costs as much as
+// the separate requests it replaces. Once a registry throttles, its
+// remaining images fail without being requested. Metadata omits the
+// filesystem even for cached images; fetch it per image.
+func (i *Inspector) GetMetadataBatch(ctx context.Context, refs []BatchImageRef, limiter *rate.Limiter) []BatchMetadataResult {
+	results := make([]BatchMetadataResult, len(refs))
+
+	// Identical refs are looked up once
+	first := make(map[string]int)
+	duplicates := make(map[int]int)
+	for idx, ref := range refs {
+		results[idx].BatchImageRef = ref
+		key := fmt.Sprintf("%s|%s|%s|%v", ref.Image, ref.Namespace, ref.Pod, ref.PullSecrets)
+		if orig, ok := first[key]; ok {
+			duplicates[idx] = orig
+			continue
+		}
+		first[key] = idx
+	}
+
+	var mu sync.Mutex
+	throttled := make(map[string]bool)
+	registryThrottled := func(image string) bool {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			return false
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return throttled[ref.Context().RegistryStr()]
+	}
+	markThrottled := func(image string) {
+		if ref, err := name.ParseReference(image); err == nil {
+			mu.Lock()
+			throttled[ref.Context().RegistryStr()] = true
+			mu.Unlock()
+		}
+	}
+
+	lookup := func(idx int, wait bool) {
+		result := &results[idx]
+		if result.Image == "" {
+			result.Error = "image is required"
+			result.Code = httperr.CodeBadRequest
+			return
+		}
+		if registryThrottled(result.Image) {
+			result.Error = "Registry is throttling requests, skipped"
+			result.Code = httperr.CodeImageRegistryThrottle
+			return
+		}
+		if limiter != nil && wait {
+			if err := limiter.Wait(ctx); err != nil {
+				result.Error = "Rate limit reached before this image was looked up"
+				result.Code = httperr.CodeRateLimited
+				return
+			}
+		}
+
+		req := InspectRequest{
+			Image:           result.Image,
+			Namespace:       result.Namespace,
+			PodName:         result.Pod,
+			PullSecretNames: result.PullSecrets,
+		}
+		if req.PodName != "" && req.Namespace != "" && len(req.PullSecretNames) == 0 {
+			req.PullSecretNames = GetPullSecretsFromPod(req.Namespace, req.PodName)
+		}
+
+		// Each image gets the full pull timeout rather than sharing the request's
+		lookupCtx, cancel := withPullTimeout(ctx)
+		defer cancel()
+		metadata, err := i.GetMetadata(lookupCtx, req)
+		if err != nil {
+			_, code := classifyError(err)
+			if code == httperr.CodeImageRegistryThrottle {
+				markThrottled(result.Image)
+			}
+			result.Error = imageErrorMessage(err, code, result.Image)
+			result.Code = code
+			return
+		}
+		metadata.Filesystem = nil
+		result.Metadata = metadata
+	}
+
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	started := 0
+	for idx := range refs {
+		if _, ok := duplicates[idx]; ok {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		// The first lookup was paid for by the request itself
+		go func(idx int, wait bool) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			lookup(idx, wait)
+		}(idx, started > 0)
+		started++
+	}
+	wg.Wait()
+
+	for idx, orig := range duplicates {
+		ref := results[idx].BatchImageRef
+		results[idx] = results[orig]
+		results[idx].BatchImageRef = ref
+	}
+	return results
+}
@@ -0,0 +1,69 @@
+package images
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+)
+
+func TestGetMetadataBatch(t *testing.T) {
+	image := testRegistry(t, false)
+	missing := strings.TrimSuffix(image, ":v1") + ":missing"
+
+	i := NewInspector(t.TempDir())
+	results := i.GetMetadataBatch(context.Background(), []BatchImageRef{
+		{Image: image},
+		{Image: missing},
+		{Image: ""},
+		{Image: image},
+	}, nil)
+
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	if results[0].Metadata == nil || results[0].Metadata.Digest == "" || results[0].Error != "" {
+		t.Errorf("expected metadata for %s, got %+v", image, results[0])
+	}
+	if results[1].Metadata != nil || results[1].Code != httperr.CodeImageNotFound {
+		t.Errorf("expected not found for %s, got %+v", missing, results[1])
+	}
+	if results[2].Code != httperr.CodeBadRequest {
+		t.Errorf("expected bad request for an empty image, got %+v", results[2])
+	}
+	if results[3].Metadata == nil || results[3].Metadata.Digest != results[0].Metadata.Digest {
+		t.Errorf("expected the duplicate to share the first result, got %+v", results[3])
+	}
+}
+
+func TestGetMetadataBatch_RegistryThrottled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	refs := make([]BatchImageRef, 12)
+	for idx := range refs {
+		refs[idx].Image = host + "/team/app:v" + string(rune('a'+idx))
+	}
+	results := (&Inspector{}).GetMetadataBatch(context.Background(), refs, nil)
+
+	skipped := 0
+	for _, result := range results {
+		if result.Code != httperr.CodeImageRegistryThrottle {
+			t.Errorf("expected %s throttled, got %+v", result.Image, result)
+		}
+		if strings.Contains(result.Error, "skipped") {
+			skipped++
+		}
+	}
+	// Lookups already in flight still reach the registry, later ones don't
+	if want := len(refs) - batchConcurrency; skipped != want {
+		t.Errorf("expected %d lookups skipped after throttling, got %d", want, skipped)
+	}
+}
@@ -0,0 +1,367 @@
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// ClusterImage is a distinct image running in the cluster
+type ClusterImage struct {
+	Image          string   `json:"image"`
+	PodCount       int      `json:"podCount"`
+	Namespaces     []string `json:"namespaces"`
+	Digests        []string `json:"digests,omitempty"`        // Running digests reported by container statuses
+	Cached         bool     `json:"cached"`                   // Whether the filesystem is in the layer cache
+	Digest         string   `json:"digest,omitempty"`         // What the reference resolves to, once known
+	Platform       string   `json:"platform,omitempty"`       // e.g. linux/arm64, once known
+	TotalSize      int64    `json:"totalSize,omitempty"`      // Uncompressed size of the cached layers
+	CompressedSize int64    `json:"compressedSize,omitempty"` // Compressed size of all layers per the registry
+	Pending        bool     `json:"pending,omitempty"`        // A background registry lookup hasn't finished yet
+	LookupError    string   `json:"lookupError,omitempty"`    // Why the registry lookup failed
+
+	// The workloads running the image, most pods first (only when asked for)
+	Workloads []ImageWorkload `json:"workloads,omitempty"`
+}
+
+// ImageWorkload is a workload whose pods run an image
+type ImageWorkload struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"` // Deployment, StatefulSet, DaemonSet, CronJob, Job, ... or Pod for bare pods
+	Name      string `json:"name"`
+	Pods      int    `json:"pods"`
+	Instance  string `json:"instance,omitempty"`  // app.kubernetes.io/instance label, e.g. a Helm release
+	ManagedBy string `json:"managedBy,omitempty"` // app.kubernetes.io/managed-by label, e.g. Helm
+}
+
+// ListClusterImages returns every distinct container and init container image
+// in the pod cache (optionally limited to one namespace), most used first.
+// Images in the layer cache are enriched with their digest, platform and size.
+// Other images get their digest, platform and compressed size from a
+// registry lookup that runs in the background: the first listing returns
+// them as pending, and later ones fill them in from a cache with a TTL.
+// With withWorkloads, each image also lists the workloads running it, so
+// the pods of one Deployment show as one entry.
+func (i *Inspector) ListClusterImages(namespace string, withWorkloads bool) ([]ClusterImage, error) {
+	cache := k8s.GetResourceCache()
+	if cache == nil || cache.Pods() == nil {
+		return nil, errResourceCacheUnavailable
+	}
+
+	var pods []*corev1.Pod
+	var err error
+	if namespace != "" {
+		pods, err = cache.Pods().Pods(namespace).List(labels.Everything())
+	} else {
+		pods, err = cache.Pods().List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byImage := make(map[string]*ClusterImage)
+	lookupReqs := make(map[string]InspectRequest) // A pod running each image, for pull secrets
+	workloads := make(map[string]map[imageWorkloadKey]*ImageWorkload)
+	for _, pod := range pods {
+		seen := make(map[string]bool)
+		var workload *ImageWorkload
+		if withWorkloads {
+			workload = podImageWorkload(cache, pod)
+		}
+		add := func(image string, status *corev1.ContainerStatus) {
+			if image == "" {
+				return
+			}
+			img, ok := byImage[image]
+			if !ok {
+				img = &ClusterImage{Image: image}
+				byImage[image] = img
+				lookupReqs[image] = InspectRequest{Image: image, Namespace: pod.Namespace, PodName: pod.Name}
+			}
+			if !seen[image] {
+				seen[image] = true
+				img.PodCount++
+				if !slices.Contains(img.Namespaces, pod.Namespace) {
+					img.Namespaces = append(img.Namespaces, pod.Namespace)
+				}
+				if workload != nil {
+					addImageWorkload(workloads, image, workload)
+				}
+			}
+			if digest := runningDigest(status); digest != "" && !slices.Contains(img.Digests, digest) {
+				img.Digests = append(img.Digests, digest)
+			}
+		}
+		for _, c := range pod.Spec.InitContainers {
+			add(c.Image, findContainerStatus(pod.Status.InitContainerStatuses, c.Name))
+		}
+		for _, c := range pod.Spec.Containers {
+			add(c.Image, findContainerStatus(pod.Status.ContainerStatuses, c.Name))
+		}
+		for _, c := range pod.Spec.EphemeralContainers {
+			add(c.Image, findContainerStatus(pod.Status.EphemeralContainerStatuses, c.Name))
+		}
+	}
+
+	cached := i.cachedImages()
+	result := make([]ClusterImage, 0, len(byImage))
+	for _, img := range byImage {
+		sort.Strings(img.Namespaces)
+		sort.Strings(img.Digests)
+		img.Workloads = sortedImageWorkloads(workloads[img.Image])
+		if entry, ok := cached[img.Image]; ok {
+			img.Cached = true
+			img.Digest = entry.meta.Digest
+			img.Platform = entry.meta.Platform
+			img.TotalSize = entry.size
+		} else {
+			if entry, ok := i.lookupEnrichment(lookupReqs[img.Image]); ok {
+				img.Digest = entry.digest
+				img.Platform = entry.platform
+				img.CompressedSize = entry.size
+				img.LookupError = entry.err
+			} else {
+				img.Pending = true
+			}
+		}
+		result = append(result, *img)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].PodCount != result[b].PodCount {
+			return result[a].PodCount > result[b].PodCount
+		}
+		return result[a].Image < result[b].Image
+	})
+	return result, nil
+}
+
+type imageWorkloadKey struct {
+	namespace, kind, name string
+}
+
+// podImageWorkload returns the workload a pod belongs to, following Jobs up
+// to their CronJob so each run doesn't show as its own workload
+func podImageWorkload(cache *k8s.ResourceCache, pod *corev1.Pod) *ImageWorkload {
+	kind, name := podWorkload(cache, pod)
+	if kind == "Job" {
+		if job, err := cache.Jobs().Jobs(pod.Namespace).Get(name); err == nil {
+			if owner := metav1.GetControllerOf(job); owner != nil && owner.Kind == "CronJob" {
+				kind, name = owner.Kind, owner.Name
+			}
+		}
+	}
+	return &ImageWorkload{
+		Namespace: pod.Namespace,
+		Kind:      kind,
+		Name:      name,
+		Instance:  pod.Labels["app.kubernetes.io/instance"],
+		ManagedBy: pod.Labels["app.kubernetes.io/managed-by"],
+	}
+}
+
+// addImageWorkload counts one pod of workload as running image
+func addImageWorkload(workloads map[string]map[imageWorkloadKey]*ImageWorkload, image string, workload *ImageWorkload) {
+	byKey, ok := workloads[image]
+	if !ok {
+		byKey = make(map[imageWorkloadKey]*ImageWorkload)
+		workloads[image] = byKey
+	}
+	key := imageWorkloadKey{workload.Namespace, workload.Kind, workload.Name}
+	entry, ok := byKey[key]
+	if !ok {
+		entry = &ImageWorkload{Namespace: workload.Namespace, Kind: workload.Kind, Name: workload.Name, Instance: workload.Instance, ManagedBy: workload.ManagedBy}
+		byKey[key] = entry
+	}
+	entry.Pods++
+}
+
+// sortedImageWorkloads returns workloads by pod count, then namespace, kind and name
+func sortedImageWorkloads(byKey map[imageWorkloadKey]*ImageWorkload) []ImageWorkload {
+	if len(byKey) == 0 {
+		return nil
+	}
+	result := make([]ImageWorkload, 0, len(byKey))
+	for _, w := range byKey {
+		result = append(result, *w)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		wa, wb := result[a], result[b]
+		if wa.Pods != wb.Pods {
+			return wa.Pods > wb.Pods
+		}
+		if wa.Namespace != wb.Namespace {
+			return wa.Namespace < wb.Namespace
+		}
+		if wa.Kind != wb.Kind {
+			return wa.Kind < wb.Kind
+		}
+		return wa.Name < wb.Name
+	})
+	return result
+}
+
+// findContainerStatus returns the status for the named container, or nil
+func findContainerStatus(statuses []corev1.ContainerStatus, name string) *corev1.ContainerStatus {
+	for idx := range statuses {
+		if statuses[idx].Name == name {
+			return &statuses[idx]
+		}
+	}
+	return nil
+}
+
+// cachedImage is a layer cache entry along with the size of its layers on disk
+type cachedImage struct {
+	meta layerCacheMetadata
+	size int64
+}
+
+// cachedImages returns the unexpired layer cache entries keyed by the image
+// reference they were inspected as
+func (i *Inspector) cachedImages() map[string]cachedImage {
+	i.cacheMu.RLock()
+	defer i.cacheMu.RUnlock()
+
+	result := make(map[string]cachedImage)
+	entries, err := os.ReadDir(i.cacheDir)
+	if err != nil {
+		return result
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == layerStoreDir {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(i.cacheDir, entry.Name(), "metadata.json"))
+		if err != nil {
+			continue
+		}
+		var meta layerCacheMetadata
+		if err := json.Unmarshal(data, &meta); err != nil || time.Since(meta.CachedAt) >= cacheTTL() {
+			continue
+		}
+		var size int64
+		for _, layerDigest := range meta.Layers {
+			if info, err := os.Stat(i.layerBlobPath(layerDigest)); err == nil {
+				size += info.Size()
+			}
+		}
+		result[meta.ImageRef] = cachedImage{meta: meta, size: size}
+	}
+	return result
+}
+
+// PodContainerImage is the image of one container in a pod
+type PodContainerImage struct {
+	Container     string `json:"container"`
+	Type          string `json:"type"` // "container", "init" or "ephemeral"
+	Image         string `json:"image"`
+	RunningDigest string `json:"runningDigest,omitempty"`
+}
+
+// PodImages lists the images of all of a pod's containers along with the
+// pull secrets to inspect them with
+type PodImages struct {
+	Namespace   string              `json:"namespace"`
+	Pod         string              `json:"pod"`
+	Containers  []PodContainerImage `json:"containers"`
+	PullSecrets []string            `json:"pullSecrets,omitempty"`
+}
+
+// getCachedPod returns a pod from the resource cache
+func getCachedPod(namespace, podName string) (*corev1.Pod, error) {
+	cache := k8s.GetResourceCache()
+	if cache == nil || cache.Pods() == nil {
+		return nil, errResourceCacheUnavailable
+	}
+	pod, err := cache.Pods().Pods(namespace).Get(podName)
+	if err != nil {
+		return nil, ErrPodNotFound
+	}
+	return pod, nil
+}
+
+// GetPodImages returns the images of a pod's init, regular and ephemeral containers
+func GetPodImages(namespace, podName string) (*PodImages, error) {
+	pod, err := getCachedPod(namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PodImages{
+		Namespace:  namespace,
+		Pod:        podName,
+		Containers: podContainerImages(pod),
+		// Pull secrets are pod-level, so they apply to every container type
+		PullSecrets: GetPullSecretsFromPod(namespace, podName),
+	}, nil
+}
+
+// podContainerImages lists the images of a pod's init, regular and ephemeral containers
+func podContainerImages(pod *corev1.Pod) []PodContainerImage {
+	var result []PodContainerImage
+	for _, c := range pod.Spec.InitContainers {
+		result = append(result, PodContainerImage{
+			Container:     c.Name,
+			Type:          "init",
+			Image:         c.Image,
+			RunningDigest: runningDigest(findContainerStatus(pod.Status.InitContainerStatuses, c.Name)),
+		})
+	}
+	for _, c := range pod.Spec.Containers {
+		result = append(result, PodContainerImage{
+			Container:     c.Name,
+			Type:          "container",
+			Image:         c.Image,
+			RunningDigest: runningDigest(findContainerStatus(pod.Status.ContainerStatuses, c.Name)),
+		})
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		result = append(result, PodContainerImage{
+			Container:     c.Name,
+			Type:          "ephemeral",
+			Image:         c.Image,
+			RunningDigest: runningDigest(findContainerStatus(pod.Status.EphemeralContainerStatuses, c.Name)),
+		})
+	}
+	return result
+}
+
+// runningImageRef returns a digest reference to the image a pod's container
+// is actually running, built from the spec image's repository and the digest
+// in the container status. The container is picked by name, or else by its
+// spec image.
+func runningImageRef(namespace, podName, container, image string) (string, error) {
+	pod, err := getCachedPod(namespace, podName)
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range podContainerImages(pod) {
+		if container != "" && c.Container != container || container == "" && c.Image != image {
+			continue
+		}
+		if c.RunningDigest == "" {
+			return "", fmt.Errorf("container %s has no running image digest yet", c.Container)
+		}
+		ref, err := name.ParseReference(c.Image)
+		if err != nil {
+			return "", fmt.Errorf("invalid image reference: %w", err)
+		}
+		return ref.Context().Digest(c.RunningDigest).String(), nil
+	}
+	if container != "" {
+		return "", fmt.Errorf("container %s not found in pod %s/%s", container, namespace, podName)
+	}
+	return "", fmt.Errorf("no container in pod %s/%s runs image %s", namespace, podName, image)
+}
@@ -0,0 +1,27 @@
+package images
+
+import "testing"
+
+func TestImageWorkloads(t *testing.T) {
+	workloads := make(map[string]map[imageWorkloadKey]*ImageWorkload)
+	web := &ImageWorkload{Namespace: "shop", Kind: "Deployment", Name: "web", Instance: "shop", ManagedBy: "Helm"}
+	for range 3 {
+		addImageWorkload(workloads, "nginx:1.27", web)
+	}
+	addImageWorkload(workloads, "nginx:1.27", &ImageWorkload{Namespace: "infra", Kind: "DaemonSet", Name: "proxy"})
+	addImageWorkload(workloads, "nginx:1.27", &ImageWorkload{Namespace: "infra", Kind: "Pod", Name: "debug"})
+
+	got := sortedImageWorkloads(workloads["nginx:1.27"])
+	if len(got) != 3 {
+		t.Fatalf("expected 3 workloads, got %+v", got)
+	}
+	if got[0].Name != "web" || got[0].Pods != 3 || got[0].Instance != "shop" || got[0].ManagedBy != "Helm" {
+		t.Errorf("expected the Deployment's pods counted together first, got %+v", got[0])
+	}
+	if got[1].Kind != "DaemonSet" || got[2].Kind != "Pod" {
+		t.Errorf("expected ties ordered by kind, got %+v", got[1:])
+	}
+	if sortedImageWorkloads(workloads["other"]) != nil {
+		t.Error("expected no workloads for an unknown image")
+	}
+}
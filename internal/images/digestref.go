@@ -0,0 +1,135 @@
+package images
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// A digest-pinned reference (repo@sha256:...) names immutable content, so an
+// image cached for that digest can be served without asking the registry
+// what the reference points to. The digest may be the image's own manifest
+// digest or that of a multi-platform index; the latter is recorded in the
+// cache metadata as a reference digest so either finds the entry.
+//
+// Cache entries are keyed by the digest of the image resolved for one
+// platform, never the index, so each platform of a multi-platform tag gets
+// its own entry. An index digest is recorded on the entry of every platform
+// pulled through it, so aliases only match entries of the platform being
+// asked for.
+
+// resolvedPlatform is the platform remote.Image picks from an index when
+// none is given, the only one inspection resolves indexes to
+const resolvedPlatform = "linux/amd64"
+
+// pinnedDigest returns the digest a reference is pinned to, or "" for a tag
+// reference or one that doesn't parse
+func pinnedDigest(image string) string {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return ""
+	}
+	if d, ok := ref.(name.Digest); ok {
+		return d.DigestStr()
+	}
+	return ""
+}
+
+// cachedPinnedLayers returns the cached layers of a digest-pinned reference
+// without contacting the registry. It reports false for tag references and
+// uncached digests, which have to be fetched. The registry policy still
+// applies to cached images.
+func (i *Inspector) cachedPinnedLayers(image string) ([]string, *layerCacheMetadata, bool, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("invalid image reference: %w", err)
+	}
+	digest, ok := ref.(name.Digest)
+	if !ok {
+		return nil, nil, false, nil
+	}
+	if err := checkRegistryAllowed(ref); err != nil {
+		return nil, nil, false, err
+	}
+	layerPaths, meta, cached := i.getCachedLayers(digest.DigestStr())
+	return layerPaths, meta, cached, nil
+}
+
+// cachedPinnedDigest returns the image digest of a cached digest-pinned
+// reference, or "" if the reference isn't pinned, isn't cached or is refused
+// by the registry policy
+func (i *Inspector) cachedPinnedDigest(image string) string {
+	if _, meta, cached, err := i.cachedPinnedLayers(image); err == nil && cached {
+		return meta.Digest
+	}
+	return ""
+}
+
+// lookupCachedLayers is getCachedLayers for an image fetched from the
+// registry as image. When image is pinned to a different digest than the
+// fetched one (an index digest), that digest is recorded on the entry so the
+// next request for it is served from the cache.
+func (i *Inspector) lookupCachedLayers(image, digest string) ([]string, *layerCacheMetadata, bool) {
+	layerPaths, meta, cached := i.getCachedLayers(digest)
+	if cached {
+		if pinned := pinnedDigest(image); pinned != "" && pinned != digest && !slices.Contains(meta.RefDigests, pinned) {
+			i.recordRefDigest(digest, pinned)
+		}
+	}
+	return layerPaths, meta, cached
+}
+
+// recordRefDigest adds a reference digest to the cache entry of digest
+func (i *Inspector) recordRefDigest(digest, refDigest string) {
+	i.cacheMu.Lock()
+	defer i.cacheMu.Unlock()
+
+	metadataPath := filepath.Join(i.cacheDir, getCacheKey(digest), "metadata.json")
+	data, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return
+	}
+	var meta layerCacheMetadata
+	if err := json.Unmarshal(data, &meta); err != nil || slices.Contains(meta.RefDigests, refDigest) {
+		return
+	}
+	meta.RefDigests = append(meta.RefDigests, refDigest)
+	if data, err = json.Marshal(meta); err == nil {
+		os.WriteFile(metadataPath, data, 0644)
+	}
+}
+
+var errNoAliasedEntry = errors.New("no cache entry for reference digest")
+
+// readAliasedMetadata returns the metadata of the cache entry for platform
+// that records refDigest as a reference digest. The cache holds at most
+// maxCachedImages entries, so they are scanned. Must be called with cacheMu
+// held.
+func (i *Inspector) readAliasedMetadata(refDigest, platform string) ([]byte, error) {
+	entries, err := os.ReadDir(i.cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == layerStoreDir {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(i.cacheDir, entry.Name(), "metadata.json"))
+		if err != nil {
+			continue
+		}
+		var meta layerCacheMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			continue
+		}
+		if slices.Contains(meta.RefDigests, refDigest) && meta.Platform == platform {
+			return data, nil
+		}
+	}
+	return nil, errNoAliasedEntry
+}
@@ -0,0 +1,206 @@
+package images
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testImageDigest = "sha256:" + "1111111111111111111111111111111111111111111111111111111111111111"
+	testIndexDigest = "sha256:" + "2222222222222222222222222222222222222222222222222222222222222222"
+	testLayerDigest = "sha256:" + "3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+func TestPinnedDigest(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx:1.25", ""},
+		{"nginx", ""},
+		{"registry.example.com:5000/team/app@" + testImageDigest, testImageDigest},
+		{"ghcr.io/org/app:v1@" + testImageDigest, testImageDigest},
+		{"not a reference", ""},
+	}
+
+	for _, tt := range tests {
+		if got := pinnedDigest(tt.image); got != tt.want {
+			t.Errorf("pinnedDigest(%q) = %q, want %q", tt.image, got, tt.want)
+		}
+	}
+}
+
+// newTestCache returns an inspector with one cached image, recorded under
+// testImageDigest with testIndexDigest as a reference digest
+func newTestCache(t *testing.T) (*Inspector, string) {
+	t.Helper()
+	i := &Inspector{cacheDir: t.TempDir()}
+
+	layerPath := i.layerBlobPath(testLayerDigest)
+	if err := os.MkdirAll(filepath.Dir(layerPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(layerPath, []byte("layer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	imageDir := filepath.Join(i.cacheDir, getCacheKey(testImageDigest))
+	if err := os.MkdirAll(imageDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	meta := layerCacheMetadata{
+		ImageRef:   "registry.example.com/team/app@" + testIndexDigest,
+		Digest:     testImageDigest,
+		Platform:   "linux/amd64",
+		LayerCount: 1,
+		Layers:     []string{testLayerDigest},
+		CachedAt:   time.Now(),
+		RefDigests: []string{testIndexDigest},
+	}
+	data, _ := json.Marshal(meta)
+	if err := os.WriteFile(filepath.Join(imageDir, "metadata.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return i, layerPath
+}
+
+func TestCachedPinnedLayers_DigestReference(t *testing.T) {
+	i, layerPath := newTestCache(t)
+
+	// Pinned to the image's own digest: served from the cache, no registry call
+	layerPaths, meta, cached, err := i.cachedPinnedLayers("registry.example.com/team/app@" + testImageDigest)
+	if err != nil {
+		t.Fatalf("cachedPinnedLayers failed: %v", err)
+	}
+	if !cached {
+		t.Fatal("expected digest reference to be served from the cache")
+	}
+	if len(layerPaths) != 1 || layerPaths[0] != layerPath {
+		t.Errorf("unexpected layer paths: %v", layerPaths)
+	}
+	if meta.Digest != testImageDigest {
+		t.Errorf("expected digest %s, got %s", testImageDigest, meta.Digest)
+	}
+
+	// Pinned to the index it was pulled through: found by reference digest
+	_, meta, cached, err = i.cachedPinnedLayers("registry.example.com/team/app:v1@" + testIndexDigest)
+	if err != nil {
+		t.Fatalf("cachedPinnedLayers failed: %v", err)
+	}
+	if !cached || meta.Digest != testImageDigest {
+		t.Errorf("expected index digest to resolve to cached image %s, got cached=%v", testImageDigest, cached)
+	}
+	if got := i.cachedPinnedDigest("registry.example.com/team/app@" + testIndexDigest); got != testImageDigest {
+		t.Errorf("cachedPinnedDigest = %q, want %q", got, testImageDigest)
+	}
+}
+
+func TestCachedPinnedLayers_NeedsFetch(t *testing.T) {
+	i, _ := newTestCache(t)
+
+	for _, image := range []string{
+		"registry.example.com/team/app:v1",                 // Tags have to be resolved
+		"registry.example.com/team/app@" + testLayerDigest, // Digest not cached
+	} {
+		_, _, cached, err := i.cachedPinnedLayers(image)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", image, err)
+		}
+		if cached {
+			t.Errorf("%s: expected a cache miss", image)
+		}
+	}
+
+	if _, _, _, err := i.cachedPinnedLayers("Not A Reference"); err == nil {
+		t.Error("expected an error for an invalid reference")
+	}
+}
+
+func TestCachedPinnedLayers_RegistryPolicy(t *testing.T) {
+	i, _ := newTestCache(t)
+	SetRegistryPolicy(nil, []string{"registry.example.com"})
+	defer SetRegistryPolicy(nil, nil)
+
+	_, _, cached, err := i.cachedPinnedLayers("registry.example.com/team/app@" + testImageDigest)
+	if !errors.Is(err, ErrRegistryNotAllowed) {
+		t.Errorf("expected ErrRegistryNotAllowed for a denied registry, got %v", err)
+	}
+	if cached {
+		t.Error("denied registry served from the cache")
+	}
+}
+
+func TestRecordRefDigest(t *testing.T) {
+	i, _ := newTestCache(t)
+	const otherIndex = "sha256:" + "4444444444444444444444444444444444444444444444444444444444444444"
+
+	if _, _, cached := i.lookupCachedLayers("registry.example.com/team/app@"+otherIndex, testImageDigest); !cached {
+		t.Fatal("expected image to be cached")
+	}
+	_, meta, cached := i.getCachedLayers(otherIndex)
+	if !cached {
+		t.Fatal("expected the new index digest to be recorded for the cached image")
+	}
+	if strings.Join(meta.RefDigests, ",") != testIndexDigest+","+otherIndex {
+		t.Errorf("unexpected reference digests: %v", meta.RefDigests)
+	}
+}
+
+func TestCachedPinnedLayers_PlatformAlias(t *testing.T) {
+	i, _ := newTestCache(t)
+
+	// Another platform pulled through the same index, sorting ahead of the
+	// linux/amd64 entry so a platform-blind scan would find it first
+	const armDigest = "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+	imageDir := filepath.Join(i.cacheDir, getCacheKey(armDigest))
+	if err := os.MkdirAll(imageDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(layerCacheMetadata{
+		Digest:     armDigest,
+		Platform:   "linux/arm64",
+		LayerCount: 1,
+		Layers:     []string{testLayerDigest},
+		CachedAt:   time.Now(),
+		RefDigests: []string{testIndexDigest},
+	})
+	if err := os.WriteFile(filepath.Join(imageDir, "metadata.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, meta, cached, err := i.cachedPinnedLayers("registry.example.com/team/app@" + testIndexDigest)
+	if err != nil || !cached {
+		t.Fatalf("expected index digest to be cached, got cached=%v err=%v", cached, err)
+	}
+	if meta.Digest != testImageDigest || meta.Platform != resolvedPlatform {
+		t.Errorf("index digest resolved to %s (%s), want %s (%s)", meta.Digest, meta.Platform, testImageDigest, resolvedPlatform)
+	}
+
+	// Each platform's own digest still finds its entry
+	if _, meta, cached = i.getCachedLayers(armDigest); !cached || meta.Platform != "linux/arm64" {
+		t.Errorf("expected the linux/arm64 entry by its own digest, got cached=%v", cached)
+	}
+}
+
+func TestGetCachedLayers_RemovesExpired(t *testing.T) {
+	i, layerPath := newTestCache(t)
+	SetCacheLifetime(time.Nanosecond, 0)
+	defer SetCacheLifetime(0, 0)
+
+	// Looked up through the reference digest, the entry under the image's
+	// own digest is the one removed
+	if _, _, cached := i.getCachedLayers(testIndexDigest); cached {
+		t.Fatal("expected an expired entry to be a cache miss")
+	}
+	if _, err := os.Stat(filepath.Join(i.cacheDir, getCacheKey(testImageDigest))); !os.IsNotExist(err) {
+		t.Errorf("expected the expired entry to be removed, got %v", err)
+	}
+	if _, err := os.Stat(layerPath); !os.IsNotExist(err) {
+		t.Errorf("expected the unreferenced layer to be removed, got %v", err)
+	}
+}
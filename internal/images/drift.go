@@ -0,0 +1,118 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrPodNotFound is returned when a pod lookup targets a pod that isn't in the cache
+var ErrPodNotFound = errors.New("pod not found")
+
+var errResourceCacheUnavailable = errors.New("resource cache not available")
+
+// ContainerImageDrift compares a container's running image to what its tag
+// points to in the registry now
+type ContainerImageDrift struct {
+	Container     string `json:"container"`
+	Init          bool   `json:"init,omitempty"`
+	Ephemeral     bool   `json:"ephemeral,omitempty"` // Debug container added with kubectl debug
+	Image         string `json:"image"`
+	RunningDigest string `json:"runningDigest,omitempty"` // From status.containerStatuses[].imageID
+	CurrentDigest string `json:"currentDigest,omitempty"` // What the tag resolves to now
+	Drifted       bool   `json:"drifted"`
+	Error         string `json:"error,omitempty"` // Why the container couldn't be compared
+}
+
+// PodImageDrift is the drift check result for all containers of a pod
+type PodImageDrift struct {
+	Namespace  string                `json:"namespace"`
+	Pod        string                `json:"pod"`
+	Drifted    bool                  `json:"drifted"` // Any container drifted
+	Containers []ContainerImageDrift `json:"containers"`
+}
+
+// CheckPodDrift compares each container's running image digest against the
+// registry's current digest for the image's tag
+func (i *Inspector) CheckPodDrift(ctx context.Context, namespace, podName string) (*PodImageDrift, error) {
+	pod, err := getCachedPod(namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+
+	secretNames := GetPullSecretsFromPod(namespace, podName)
+	result := &PodImageDrift{Namespace: namespace, Pod: podName}
+
+	check := func(containers []corev1.Container, statuses []corev1.ContainerStatus, init, ephemeral bool) {
+		for _, c := range containers {
+			d := ContainerImageDrift{Container: c.Name, Init: init, Ephemeral: ephemeral, Image: c.Image}
+			d.RunningDigest = runningDigest(findContainerStatus(statuses, c.Name))
+
+			if d.RunningDigest == "" {
+				d.Error = "container has no running image digest"
+			} else {
+				i.compareDigest(ctx, &d, InspectRequest{
+					Image:           c.Image,
+					Namespace:       namespace,
+					PodName:         podName,
+					PullSecretNames: secretNames,
+				})
+			}
+			if d.Drifted {
+				result.Drifted = true
+			}
+			result.Containers = append(result.Containers, d)
+		}
+	}
+	check(pod.Spec.InitContainers, pod.Status.InitContainerStatuses, true, false)
+	check(pod.Spec.Containers, pod.Status.ContainerStatuses, false, false)
+	ephemeral := make([]corev1.Container, len(pod.Spec.EphemeralContainers))
+	for idx, c := range pod.Spec.EphemeralContainers {
+		ephemeral[idx] = corev1.Container(c.EphemeralContainerCommon)
+	}
+	check(ephemeral, pod.Status.EphemeralContainerStatuses, false, true)
+
+	return result, nil
+}
+
+// compareDigest resolves the container's image tag and compares it to the
+// running digest. The tag is resolved to its descriptor rather than through
+// fetchImageBruteForce, which picks one platform: runtimes record either the
+// index digest or the platform manifest digest, and both must count as a match.
+func (i *Inspector) compareDigest(ctx context.Context, d *ContainerImageDrift, req InspectRequest) {
+	ref, tag, pinned, err := parseImageReference(req.Image)
+	if err != nil {
+		d.Error = err.Error()
+		return
+	}
+	if tag == "" {
+		// Pinned by digest only: there is no tag that could have moved
+		d.CurrentDigest = pinned
+		return
+	}
+
+	desc, _, err := i.getDescriptorBruteForce(ctx, ref.Context().Tag(tag), req)
+	if err != nil {
+		d.Error = err.Error()
+		return
+	}
+	d.CurrentDigest = desc.Digest.String()
+	d.Drifted = !descriptorHasDigest(desc, d.RunningDigest)
+}
+
+// runningDigest extracts the repo digest from a container status imageID,
+// which runtimes report as e.g. "docker.io/library/nginx@sha256:..." or
+// "docker-pullable://nginx@sha256:...". A bare "sha256:..." is the local
+// image ID, not a registry digest, and can't be compared.
+func runningDigest(status *corev1.ContainerStatus) string {
+	if status == nil {
+		return ""
+	}
+	_, digest, ok := strings.Cut(status.ImageID, "@")
+	if !ok {
+		return ""
+	}
+	return digest
+}
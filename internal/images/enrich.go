@@ -0,0 +1,99 @@
+package images
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	enrichmentTTL         = 30 * time.Minute // How long a platform/size lookup is reused
+	enrichmentFailureTTL  = 5 * time.Minute  // How long before a failed lookup is retried
+	enrichmentTimeout     = 30 * time.Second // Max time spent on one lookup
+	enrichmentConcurrency = 4                // Lookups in flight at once, to go easy on registries
+)
+
+// imageEnrichment is the registry-derived detail of one image reference
+type imageEnrichment struct {
+	digest    string
+	platform  string
+	size      int64 // Compressed size of all layers
+	err       string
+	expiresAt time.Time
+}
+
+// imageEnricher looks up image platform and size in the background so the
+// cluster image list never waits on registry calls
+type imageEnricher struct {
+	mu      sync.Mutex
+	entries map[string]imageEnrichment
+	pending map[string]bool
+	sem     chan struct{}
+}
+
+func newImageEnricher() *imageEnricher {
+	return &imageEnricher{
+		entries: make(map[string]imageEnrichment),
+		pending: make(map[string]bool),
+		sem:     make(chan struct{}, enrichmentConcurrency),
+	}
+}
+
+// lookupEnrichment returns the enrichment for req.Image if one is cached and fresh.
+// Otherwise it queues a background lookup (once per image) and returns false.
+func (i *Inspector) lookupEnrichment(req InspectRequest) (imageEnrichment, bool) {
+	e := i.enrichment
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.entries[req.Image]
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry, true
+	}
+	if !e.pending[req.Image] {
+		e.pending[req.Image] = true
+		go i.enrich(req)
+	}
+	return imageEnrichment{}, false
+}
+
+// enrich fetches an image's manifest and config and records its platform and size
+func (i *Inspector) enrich(req InspectRequest) {
+	e := i.enrichment
+	e.sem <- struct{}{}
+	defer func() { <-e.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), enrichmentTimeout)
+	defer cancel()
+
+	req.PullSecretNames = GetPullSecretsFromPod(req.Namespace, req.PodName)
+
+	entry := imageEnrichment{expiresAt: time.Now().Add(enrichmentTTL)}
+	img, authMethod, err := i.fetchImageBruteForce(ctx, req)
+	if err == nil {
+		var metadata *ImageMetadata
+		if metadata, err = remoteMetadata(img, req.Image, authMethod); err == nil {
+			entry.digest = metadata.Digest
+			entry.platform = metadata.Platform
+			entry.size = metadata.TotalSize
+		}
+	}
+	if err != nil {
+		log.Printf("Warning: failed to look up platform and size of %s: %v", req.Image, err)
+		entry.err = err.Error()
+		entry.expiresAt = time.Now().Add(enrichmentFailureTTL)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Drop lookups for images that are no longer listed
+	now := time.Now()
+	for image, old := range e.entries {
+		if now.After(old.expiresAt) {
+			delete(e.entries, image)
+		}
+	}
+	e.entries[req.Image] = entry
+	delete(e.pending, req.Image)
+}
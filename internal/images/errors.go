@@ -0,0 +1,73 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+)
+
+// classifyError maps an image fetch error to an HTTP status and error code.
+// Registry errors are matched on their structured status and diagnostic codes;
+// message matching is kept as a fallback for errors that lost their type.
+func classifyError(err error) (status int, code string) {
+	switch {
+	case errors.Is(err, ErrRegistryNotAllowed):
+		return http.StatusForbidden, httperr.CodeImageRegistryBlocked
+	case errors.Is(err, ErrPathNotFound):
+		return http.StatusNotFound, httperr.CodeImagePathNotFound
+	case errors.Is(err, ErrLayerNotFound):
+		return http.StatusNotFound, httperr.CodeNotFound
+	case errors.Is(err, ErrNotDirectory):
+		return http.StatusBadRequest, httperr.CodeImageNotDirectory
+	case errors.Is(err, ErrNotContainerImage):
+		return http.StatusUnprocessableEntity, httperr.CodeImageNotContainer
+	case errors.Is(err, ErrImageTooLarge):
+		return http.StatusInsufficientStorage, httperr.CodeImageTooLarge
+	case errors.Is(err, ErrPullTimeout):
+		return http.StatusGatewayTimeout, httperr.CodeImagePullTimeout
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, httperr.CodeImageInspectTimeout
+	}
+
+	var badName *name.ErrBadName
+	if errors.As(err, &badName) {
+		return http.StatusBadRequest, httperr.CodeImageInvalidReference
+	}
+
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		switch terr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return http.StatusUnauthorized, httperr.CodeImageUnauthorized
+		case http.StatusNotFound:
+			return http.StatusNotFound, httperr.CodeImageNotFound
+		case http.StatusTooManyRequests:
+			return http.StatusTooManyRequests, httperr.CodeImageRegistryThrottle
+		}
+		for _, diag := range terr.Errors {
+			switch diag.Code {
+			case transport.UnauthorizedErrorCode, transport.DeniedErrorCode:
+				return http.StatusUnauthorized, httperr.CodeImageUnauthorized
+			case transport.ManifestUnknownErrorCode, transport.NameUnknownErrorCode:
+				return http.StatusNotFound, httperr.CodeImageNotFound
+			case transport.TooManyRequestsErrorCode:
+				return http.StatusTooManyRequests, httperr.CodeImageRegistryThrottle
+			}
+		}
+	}
+
+	errStr := err.Error()
+	if strings.Contains(errStr, "unauthorized") || strings.Contains(errStr, "denied") {
+		return http.StatusUnauthorized, httperr.CodeImageUnauthorized
+	}
+	if strings.Contains(errStr, "not found") || strings.Contains(errStr, "manifest unknown") {
+		return http.StatusNotFound, httperr.CodeImageNotFound
+	}
+	return http.StatusInternalServerError, httperr.CodeInternal
+}
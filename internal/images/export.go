@@ -0,0 +1,260 @@
+package images
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// exportEntry identifies a tar entry by its layer and position in the layer
+type exportEntry struct {
+	layer, index int
+}
+
+// before reports whether e is written to the export ahead of other
+func (e exportEntry) before(other exportEntry) bool {
+	return e.layer < other.layer || (e.layer == other.layer && e.index < other.index)
+}
+
+// FilesystemLayers returns the cached layer files of an image, bottom to top,
+// downloading them first if needed
+func (i *Inspector) FilesystemLayers(ctx context.Context, req InspectRequest) ([]string, error) {
+	// A cached digest-pinned image needs no registry round trip
+	if layerPaths, _, cached, err := i.cachedPinnedLayers(req.Image); err != nil {
+		return nil, err
+	} else if cached {
+		return layerPaths, nil
+	}
+
+	img, _, err := i.fetchImageBruteForce(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	layerPaths, _, cached := i.lookupCachedLayers(req.Image, digest.String())
+	if !cached {
+		layerPaths, _, err = i.cacheLayers(ctx, img, req.Image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cache layers: %w", err)
+		}
+	}
+	return layerPaths, nil
+}
+
+// writeMergedFilesystem writes the filesystem the layers add up to as a
+// single tar, the way a container sees it: whiteouts and opaque directories
+// are applied, and of entries at the same path only the topmost is kept.
+// A first pass over the layers decides which entries survive, a second
+// copies them, so nothing but the entry index is held in memory.
+//
+// Hardlinks whose target was removed or replaced by a later entry are
+// dropped, since the content they shared is not in the export.
+func writeMergedFilesystem(ctx context.Context, layerPaths []string, w io.Writer) error {
+	surviving, err := mergedFilesystemEntries(ctx, layerPaths)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for layer, layerPath := range layerPaths {
+		if err := copySurvivingEntries(ctx, tw, layer, layerPath, surviving); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// mergedFilesystemEntries returns, for every path in the merged filesystem,
+// the entry that provides it. Whiteouts are handled as in
+// buildFilesystemTreeFromFiles so the export matches the browsed tree.
+func mergedFilesystemEntries(ctx context.Context, layerPaths []string) (map[string]exportEntry, error) {
+	surviving := make(map[string]exportEntry)
+	// Paths that are or were directories in some layer, so only removing
+	// one of those has to look for entries below it
+	dirs := make(map[string]bool)
+	for layer, layerPath := range layerPaths {
+		file, err := os.Open(layerPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open layer %s: %w", filepath.Base(layerPath), err)
+		}
+
+		inLayer := make(map[string]bool)
+		tr := tar.NewReader(file)
+		for index := 0; ; index++ {
+			if err := ctx.Err(); err != nil {
+				file.Close()
+				return nil, err
+			}
+
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Printf("Stopped reading layer %s: %v", filepath.Base(layerPath), err)
+				break
+			}
+
+			path := tarEntryPath(header.Name)
+			entryName := filepath.Base(path)
+			if entryName == whiteoutOpaque {
+				clearLowerLayerEntries(surviving, filepath.Dir(path), inLayer)
+				continue
+			}
+			if strings.HasPrefix(entryName, whiteoutPrefix) {
+				deleteEntries(surviving, dirs, filepath.Join(filepath.Dir(path), strings.TrimPrefix(entryName, whiteoutPrefix)))
+				continue
+			}
+			if path == "/" {
+				continue
+			}
+
+			// A file replacing a directory hides what was below it
+			if header.Typeflag != tar.TypeDir {
+				deleteEntries(surviving, dirs, path)
+			} else {
+				dirs[path] = true
+			}
+			surviving[path] = exportEntry{layer: layer, index: index}
+			for p := path; p != "/" && p != "."; p = filepath.Dir(p) {
+				inLayer[p] = true
+				if p != path {
+					dirs[p] = true
+				}
+			}
+		}
+		file.Close()
+	}
+	return surviving, nil
+}
+
+// copySurvivingEntries copies the entries of one layer that made it into
+// the merged filesystem to tw, with names relative to the image root
+func copySurvivingEntries(ctx context.Context, tw *tar.Writer, layer int, layerPath string, surviving map[string]exportEntry) error {
+	file, err := os.Open(layerPath)
+	if err != nil {
+		return fmt.Errorf("failed to open layer %s: %w", filepath.Base(layerPath), err)
+	}
+	defer file.Close()
+
+	tr := tar.NewReader(file)
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Already logged by the first pass
+			return nil
+		}
+
+		path := tarEntryPath(header.Name)
+		entry := exportEntry{layer: layer, index: index}
+		if surviving[path] != entry {
+			continue
+		}
+
+		out := *header
+		out.Name = strings.TrimPrefix(path, "/")
+		if header.Typeflag == tar.TypeDir {
+			out.Name += "/"
+		}
+		if header.Typeflag == tar.TypeLink {
+			target := tarEntryPath(header.Linkname)
+			if owner, ok := surviving[target]; !ok || !owner.before(entry) {
+				continue
+			}
+			out.Linkname = strings.TrimPrefix(target, "/")
+		}
+		// Let the writer pick a format that fits the rewritten names
+		out.Format = tar.FormatUnknown
+		out.PAXRecords = nil
+		for key, value := range header.PAXRecords {
+			if key == "path" || key == "linkpath" {
+				continue
+			}
+			if out.PAXRecords == nil {
+				out.PAXRecords = make(map[string]string)
+			}
+			out.PAXRecords[key] = value
+		}
+
+		if err := tw.WriteHeader(&out); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if out.Typeflag == tar.TypeReg || out.Typeflag == tar.TypeRegA {
+			if _, err := io.Copy(tw, &ctxReader{ctx: ctx, r: tr}); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+	}
+}
+
+// deleteEntries removes a path and everything below it from the entry index.
+// Only paths in dirs can have entries below them, so removing a file is a
+// single delete rather than a scan of the index.
+func deleteEntries(surviving map[string]exportEntry, dirs map[string]bool, path string) {
+	delete(surviving, path)
+	if !dirs[path] {
+		return
+	}
+	prefix := path + "/"
+	for p := range surviving {
+		if strings.HasPrefix(p, prefix) {
+			delete(surviving, p)
+		}
+	}
+	for d := range dirs {
+		if d == path || strings.HasPrefix(d, prefix) {
+			delete(dirs, d)
+		}
+	}
+}
+
+// clearLowerLayerEntries removes everything under dir that the current layer didn't add
+func clearLowerLayerEntries(surviving map[string]exportEntry, dir string, inLayer map[string]bool) {
+	prefix := dir + "/"
+	if dir == "/" {
+		prefix = "/"
+	}
+	for p := range surviving {
+		if strings.HasPrefix(p, prefix) && !inLayer[p] {
+			delete(surviving, p)
+		}
+	}
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// exportFilename names the tar download after the image, e.g. nginx-1.27.tar
+// or app-sha256-0123456789ab.tar for a digest reference
+func exportFilename(image string) string {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "image.tar"
+	}
+	repo := ref.Context().RepositoryStr()
+	base := repo[strings.LastIndex(repo, "/")+1:]
+	version := ref.Identifier()
+	if algo, hex, ok := strings.Cut(version, ":"); ok {
+		version = algo + "-" + hex[:min(len(hex), 12)]
+	}
+	return unsafeFilenameChars.ReplaceAllString(base+"-"+version, "_") + ".tar"
+}
@@ -0,0 +1,163 @@
+package images
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type testTarEntry struct {
+	name, content, link string
+	typeflag            byte
+}
+
+func writeTestLayer(t *testing.T, path string, entries []testTarEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.link, Mode: 0644, Size: int64(len(e.content))}
+		if e.typeflag == 0 {
+			hdr.Typeflag = tar.TypeReg
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteMergedFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	lower := filepath.Join(dir, "lower")
+	upper := filepath.Join(dir, "upper")
+	writeTestLayer(t, lower, []testTarEntry{
+		{name: "./etc/", typeflag: tar.TypeDir},
+		{name: "./etc/config", content: "old"},
+		{name: "./etc/removed", content: "gone"},
+		{name: "./etc/linked", content: "shared"},
+		{name: "./var/cache/", typeflag: tar.TypeDir},
+		{name: "./var/cache/stale", content: "stale"},
+		{name: "./bin/sh", link: "busybox", typeflag: tar.TypeSymlink},
+		{name: "./bin/orphan", link: "etc/removed", typeflag: tar.TypeLink},
+	})
+	writeTestLayer(t, upper, []testTarEntry{
+		{name: "./etc/config", content: "new"},
+		{name: "./etc/.wh.removed"},
+		{name: "./etc/hardlink", link: "etc/linked", typeflag: tar.TypeLink},
+		{name: "./var/cache/fresh", content: "fresh"},
+		{name: "./var/cache/.wh..wh..opq"},
+	})
+
+	var out bytes.Buffer
+	if err := writeMergedFilesystem(context.Background(), []string{lower, upper}, &out); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	got := make(map[string]string)
+	tr := tar.NewReader(&out)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			got[hdr.Name] = "dir"
+		case tar.TypeSymlink, tar.TypeLink:
+			got[hdr.Name] = "-> " + hdr.Linkname
+		default:
+			content, _ := io.ReadAll(tr)
+			got[hdr.Name] = string(content)
+		}
+	}
+
+	want := map[string]string{
+		"etc/":            "dir",
+		"etc/config":      "new",
+		"etc/linked":      "shared",
+		"etc/hardlink":    "-> etc/linked",
+		"var/cache/":      "dir",
+		"var/cache/fresh": "fresh",
+		"bin/sh":          "-> busybox",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged filesystem:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestMergedFilesystemEntries_ReplacedDirectories(t *testing.T) {
+	dir := t.TempDir()
+	lower := filepath.Join(dir, "lower")
+	upper := filepath.Join(dir, "upper")
+	writeTestLayer(t, lower, []testTarEntry{
+		{name: "./opt/app/lib.so", content: "lib"}, // No entry for the directories
+		{name: "./srv/", typeflag: tar.TypeDir},
+		{name: "./srv/data", content: "data"},
+		{name: "./srv.bak", content: "backup"},
+	})
+	writeTestLayer(t, upper, []testTarEntry{
+		{name: "./opt/app", content: "binary"},
+		{name: "./.wh.srv"},
+	})
+
+	surviving, err := mergedFilesystemEntries(context.Background(), []string{lower, upper})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for p := range surviving {
+		got = append(got, p)
+	}
+	slices.Sort(got)
+	if want := []string{"/opt/app", "/srv.bak"}; !slices.Equal(got, want) {
+		t.Errorf("surviving entries = %v, want %v", got, want)
+	}
+}
+
+func TestWriteMergedFilesystem_Cancelled(t *testing.T) {
+	layer := filepath.Join(t.TempDir(), "layer")
+	writeTestLayer(t, layer, []testTarEntry{{name: "file", content: "x"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := writeMergedFilesystem(ctx, []string{layer}, io.Discard); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
+
+func TestExportFilename(t *testing.T) {
+	tests := map[string]string{
+		"nginx:1.27":           "nginx-1.27.tar",
+		"ghcr.io/org/team/app": "app-latest.tar",
+		"app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef": "app-sha256-0123456789ab.tar",
+		"not a reference": "image.tar",
+	}
+	for image, want := range tests {
+		if got := exportFilename(image); got != want {
+			t.Errorf("exportFilename(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// maxDiffFileSize caps the size of either side of a file diff; larger files
+// are compared for equality only
+const maxDiffFileSize = 1 << 20
+
+// defaultDiffContext is the number of unchanged lines around each hunk
+const defaultDiffContext = 3
+
+// FileDiff is the difference of one file's contents between two images
+type FileDiff struct {
+	Path       string `json:"path"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	FromExists bool   `json:"fromExists"`
+	ToExists   bool   `json:"toExists"`
+	Identical  bool   `json:"identical"`
+	Binary     bool   `json:"binary,omitempty"`   // Either side isn't text, so no diff is produced
+	TooLarge   bool   `json:"tooLarge,omitempty"` // Either side exceeds maxDiffFileSize, so no diff is produced
+	Diff       string `json:"diff,omitempty"`     // Unified diff from From to To
+}
+
+// DiffFile reads a file from two images and returns a unified diff of its
+// contents. A file that exists in only one image is diffed against empty
+// content; ErrPathNotFound is returned only if it exists in neither.
+func (i *Inspector) DiffFile(ctx context.Context, from, to InspectRequest, filePath string, contextLines int) (*FileDiff, error) {
+	result := &FileDiff{Path: filePath, From: from.Image, To: to.Image}
+
+	// Both images may need their layers pulled, so fetch them in parallel
+	var fromContent, toContent []byte
+	var fromErr, toErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		fromContent, fromErr = i.readFileForDiff(ctx, from, filePath)
+	}()
+	go func() {
+		defer wg.Done()
+		toContent, toErr = i.readFileForDiff(ctx, to, filePath)
+	}()
+	wg.Wait()
+	if fromErr != nil {
+		return nil, fromErr
+	}
+	if toErr != nil {
+		return nil, toErr
+	}
+	result.FromExists, result.ToExists = fromContent != nil, toContent != nil
+	if !result.FromExists && !result.ToExists {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, filePath)
+	}
+
+	result.Identical = result.FromExists == result.ToExists && bytes.Equal(fromContent, toContent)
+	if result.Identical {
+		return result, nil
+	}
+	if len(fromContent) > maxDiffFileSize || len(toContent) > maxDiffFileSize {
+		result.TooLarge = true
+		return result, nil
+	}
+	if !isText(fromContent) || !isText(toContent) {
+		result.Binary = true
+		return result, nil
+	}
+
+	fromName, toName := "/dev/null", "/dev/null"
+	if result.FromExists {
+		fromName = from.Image + ":" + filePath
+	}
+	if result.ToExists {
+		toName = to.Image + ":" + filePath
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(fromContent)),
+		B:        difflib.SplitLines(string(toContent)),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  contextLines,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s: %w", filePath, err)
+	}
+	result.Diff = diff
+	return result, nil
+}
+
+// readFileForDiff returns a file's content from an image, or nil if the image
+// doesn't have it
+func (i *Inspector) readFileForDiff(ctx context.Context, req InspectRequest, filePath string) ([]byte, error) {
+	content, _, err := i.GetFileContent(ctx, req, filePath)
+	if errors.Is(err, ErrPathNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", req.Image, err)
+	}
+	if content == nil {
+		content = []byte{}
+	}
+	return content, nil
+}
+
+// isText reports whether content looks like text: valid UTF-8 without NULs
+func isText(content []byte) bool {
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+}
@@ -0,0 +1,75 @@
+package images
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// maxViewFileSize caps the size of a file returned for inline viewing;
+// larger files have to be downloaded
+const maxViewFileSize = 1 << 20
+
+// maxControlRatio is the share of control characters above which content is
+// treated as binary even if it decodes
+const maxControlRatio = 0.1
+
+// Text encodings reported for viewable files, as HTTP charset names
+const (
+	encodingUTF8   = "utf-8"
+	encodingLatin1 = "iso-8859-1"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// detectTextEncoding returns the charset content is encoded in, or false if
+// it looks binary. Valid UTF-8 is reported as UTF-8; anything else without
+// NULs and with few control characters can still be shown as Latin-1, which
+// maps every byte to a character.
+func detectTextEncoding(content []byte) (string, bool) {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return "", false
+	}
+
+	if utf8.Valid(content) {
+		text := bytes.TrimPrefix(content, utf8BOM)
+		controls, chars := 0, 0
+		for len(text) > 0 {
+			r, size := utf8.DecodeRune(text)
+			text = text[size:]
+			chars++
+			if isControl(r) {
+				controls++
+			}
+		}
+		if tooManyControls(controls, chars) {
+			return "", false
+		}
+		return encodingUTF8, true
+	}
+
+	controls := 0
+	for _, b := range content {
+		// 0x80-0x9f are the C1 control characters in Latin-1
+		if isControl(rune(b)) || (b >= 0x80 && b <= 0x9f) {
+			controls++
+		}
+	}
+	if tooManyControls(controls, len(content)) {
+		return "", false
+	}
+	return encodingLatin1, true
+}
+
+// isControl reports whether r is a C0 control character other than common
+// whitespace, backspace and escape (terminal color codes in logs)
+func isControl(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r', '\f', '\v', '\b', 0x1b:
+		return false
+	}
+	return r < 0x20 || r == 0x7f
+}
+
+func tooManyControls(controls, total int) bool {
+	return total > 0 && float64(controls)/float64(total) > maxControlRatio
+}
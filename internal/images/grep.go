@@ -0,0 +1,246 @@
+package images
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxGrepPatternLength = 1024
+	maxGrepFileSize      = maxViewFileSize // Larger files are skipped, like files too large to view
+	maxGrepMatches       = 500             // Matches returned across all files
+	maxGrepFileMatches   = 20              // Matches returned per file
+	maxGrepSnippet       = 240             // Bytes of a matching line returned
+)
+
+// GrepMatch is one matching line of a file
+type GrepMatch struct {
+	Line int    `json:"line"` // 1-based
+	Text string `json:"text"` // The line, cut around the match when long
+}
+
+// GrepFile is a file with at least one match
+type GrepFile struct {
+	Path        string      `json:"path"`
+	Size        int64       `json:"size"`
+	Matches     []GrepMatch `json:"matches"`
+	MoreMatches bool        `json:"moreMatches,omitempty"` // The file has more than maxGrepFileMatches matching lines
+}
+
+// GrepResult lists the files of an image whose content matches a pattern
+type GrepResult struct {
+	Image         string     `json:"image"`
+	Pattern       string     `json:"pattern"`
+	Path          string     `json:"path"` // Directory searched
+	Files         []GrepFile `json:"files"`
+	TotalMatches  int        `json:"totalMatches"`
+	FilesSearched int        `json:"filesSearched"`
+	SkippedLarge  int        `json:"skippedLarge"`  // Files over the size limit
+	SkippedBinary int        `json:"skippedBinary"` // Files that don't look like text
+	Truncated     bool       `json:"truncated"`     // Search stopped at maxGrepMatches
+	MaxFileSize   int64      `json:"maxFileSize"`
+}
+
+// CompileGrepPattern compiles a content search pattern. Go regexps run in
+// linear time, so only the pattern length needs bounding.
+func CompileGrepPattern(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	if len(pattern) > maxGrepPatternLength {
+		return nil, fmt.Errorf("pattern is longer than %d characters", maxGrepPatternLength)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// GrepFilesystem searches the content of the text files under dir in the
+// image's merged filesystem for re
+func (i *Inspector) GrepFilesystem(ctx context.Context, req InspectRequest, re *regexp.Regexp, dir string) (*GrepResult, error) {
+	layerPaths, err := i.FilesystemLayers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := grepLayers(ctx, layerPaths, re, dir)
+	if err != nil {
+		return nil, err
+	}
+	result.Image = req.Image
+	return result, nil
+}
+
+// grepLayers searches the files the layers add up to, skipping those that
+// were deleted or replaced by a later layer, those over maxGrepFileSize and
+// those that look binary. Files are returned by path.
+func grepLayers(ctx context.Context, layerPaths []string, re *regexp.Regexp, dir string) (*GrepResult, error) {
+	dir = tarEntryPath(dir)
+	result := &GrepResult{
+		Pattern:     re.String(),
+		Path:        dir,
+		Files:       []GrepFile{},
+		MaxFileSize: maxGrepFileSize,
+	}
+
+	surviving, err := mergedFilesystemEntries(ctx, layerPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	for layer, layerPath := range layerPaths {
+		if result.Truncated {
+			break
+		}
+		if err := grepLayer(ctx, layer, layerPath, surviving, re, dir, result); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(result.Files, func(a, b int) bool { return result.Files[a].Path < result.Files[b].Path })
+	return result, nil
+}
+
+// grepLayer searches the regular files of one layer that made it into the
+// merged filesystem, adding to result
+func grepLayer(ctx context.Context, layer int, layerPath string, surviving map[string]exportEntry, re *regexp.Regexp, dir string, result *GrepResult) error {
+	file, err := os.Open(layerPath)
+	if err != nil {
+		return fmt.Errorf("failed to open layer %s: %w", filepath.Base(layerPath), err)
+	}
+	defer file.Close()
+
+	tr := tar.NewReader(file)
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header, err := tr.Next()
+		if err != nil {
+			// EOF, or a broken layer already logged by the first pass
+			return nil
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		path := tarEntryPath(header.Name)
+		if surviving[path] != (exportEntry{layer: layer, index: index}) || !underDir(path, dir) {
+			continue
+		}
+		if header.Size > maxGrepFileSize {
+			result.SkippedLarge++
+			continue
+		}
+
+		content, err := io.ReadAll(&ctxReader{ctx: ctx, r: tr})
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		charset, ok := detectTextEncoding(content)
+		if !ok {
+			result.SkippedBinary++
+			continue
+		}
+		if charset == encodingLatin1 {
+			content = latin1ToUTF8(content)
+		}
+
+		result.FilesSearched++
+		if match := grepContent(content, re, maxGrepMatches-result.TotalMatches); match != nil {
+			match.Path = path
+			match.Size = header.Size
+			result.Files = append(result.Files, *match)
+			result.TotalMatches += len(match.Matches)
+			if result.TotalMatches >= maxGrepMatches {
+				result.Truncated = true
+				return nil
+			}
+		}
+	}
+}
+
+// grepContent returns the lines of content matching re, at most limit and
+// at most maxGrepFileMatches, or nil if none match
+func grepContent(content []byte, re *regexp.Regexp, limit int) *GrepFile {
+	if !re.Match(content) {
+		return nil
+	}
+
+	file := &GrepFile{}
+	limit = min(limit, maxGrepFileMatches)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), maxGrepFileSize+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		loc := re.FindIndex(text)
+		if loc == nil {
+			continue
+		}
+		if len(file.Matches) == limit {
+			file.MoreMatches = true
+			break
+		}
+		file.Matches = append(file.Matches, GrepMatch{Line: line, Text: snippet(text, loc[0], loc[1])})
+	}
+	if len(file.Matches) == 0 {
+		// The pattern only matches across lines
+		return nil
+	}
+	return file
+}
+
+// snippet returns line, or for long lines the part around the match from
+// start to end, at most maxGrepSnippet bytes and cut at rune boundaries
+func snippet(line []byte, start, end int) string {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) <= maxGrepSnippet {
+		return string(line)
+	}
+	from := max(0, start-(maxGrepSnippet-(end-start))/2)
+	to := min(len(line), from+maxGrepSnippet)
+	from = max(0, to-maxGrepSnippet)
+	for from > 0 && !utf8.RuneStart(line[from]) {
+		from++
+	}
+	for to < len(line) && !utf8.RuneStart(line[to]) {
+		to--
+	}
+
+	text := string(line[from:to])
+	if from > 0 {
+		text = "…" + text
+	}
+	if to < len(line) {
+		text += "…"
+	}
+	return text
+}
+
+// underDir reports whether path is dir or inside it
+func underDir(path, dir string) bool {
+	return dir == "/" || path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// latin1ToUTF8 converts Latin-1 content, where every byte is a code point, to UTF-8
+func latin1ToUTF8(content []byte) []byte {
+	out := make([]byte, 0, len(content))
+	for _, b := range content {
+		out = utf8.AppendRune(out, rune(b))
+	}
+	return out
+}
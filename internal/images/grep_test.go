@@ -0,0 +1,105 @@
+package images
+
+import (
+	"archive/tar"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGrepLayers(t *testing.T) {
+	dir := t.TempDir()
+	lower := filepath.Join(dir, "lower")
+	upper := filepath.Join(dir, "upper")
+	writeTestLayer(t, lower, []testTarEntry{
+		{name: "./etc/", typeflag: tar.TypeDir},
+		{name: "./etc/app.conf", content: "url=http://old.example.com\n"},
+		{name: "./etc/removed.conf", content: "url=http://removed.example.com\n"},
+		{name: "./opt/app/config.yaml", content: "name: app\nendpoint: https://api.example.com\nretries: 3\n"},
+		{name: "./bin/app", content: "\x00\x01ELF https://binary.example.com"},
+	})
+	writeTestLayer(t, upper, []testTarEntry{
+		{name: "./etc/app.conf", content: "url=http://new.example.com\n"},
+		{name: "./etc/.wh.removed.conf"},
+		{name: "./etc/latin1.txt", content: "caf\xe9 at http://latin1.example.com\n"},
+	})
+
+	re, err := CompileGrepPattern(`https?://[a-z0-9]+\.example\.com`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := grepLayers(context.Background(), []string{lower, upper}, re, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range result.Files {
+		for _, m := range f.Matches {
+			got = append(got, f.Path+":"+m.Text)
+		}
+	}
+	want := []string{
+		"/etc/app.conf:url=http://new.example.com",
+		"/etc/latin1.txt:café at http://latin1.example.com",
+		"/opt/app/config.yaml:endpoint: https://api.example.com",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("matches:\n got %q\nwant %q", got, want)
+	}
+	if result.Files[2].Matches[0].Line != 2 {
+		t.Errorf("expected line 2, got %d", result.Files[2].Matches[0].Line)
+	}
+	if result.SkippedBinary != 1 || result.FilesSearched != 3 {
+		t.Errorf("expected 3 files searched and 1 binary skipped, got %d and %d", result.FilesSearched, result.SkippedBinary)
+	}
+
+	result, err = grepLayers(context.Background(), []string{lower, upper}, re, "/opt/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "/opt/app/config.yaml" {
+		t.Errorf("expected only /opt matches, got %+v", result.Files)
+	}
+}
+
+func TestGrepContent_Limits(t *testing.T) {
+	re, _ := CompileGrepPattern("match", false)
+	content := strings.Repeat("a match here\n", maxGrepFileMatches+5)
+
+	file := grepContent([]byte(content), re, maxGrepMatches)
+	if len(file.Matches) != maxGrepFileMatches || !file.MoreMatches {
+		t.Errorf("expected %d matches and more, got %d (more=%v)", maxGrepFileMatches, len(file.Matches), file.MoreMatches)
+	}
+	if file := grepContent([]byte(content), re, 3); len(file.Matches) != 3 {
+		t.Errorf("expected the remaining limit of 3 matches, got %d", len(file.Matches))
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	line := strings.Repeat("x", 500) + "SECRET" + strings.Repeat("y", 500)
+	got := snippet([]byte(line), 500, 506)
+	if !strings.Contains(got, "SECRET") || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("expected the match with ellipses on both sides, got %q", got)
+	}
+	if len(strings.Trim(got, "…")) > maxGrepSnippet {
+		t.Errorf("snippet is %d bytes, over %d", len(got), maxGrepSnippet)
+	}
+	if got := snippet([]byte("short line\r"), 0, 5); got != "short line" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCompileGrepPattern(t *testing.T) {
+	if _, err := CompileGrepPattern("", false); err == nil {
+		t.Error("expected an error for an empty pattern")
+	}
+	if _, err := CompileGrepPattern("(unclosed", false); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	re, err := CompileGrepPattern("token", true)
+	if err != nil || !re.MatchString("API_TOKEN") {
+		t.Errorf("expected a case-insensitive match, got %v", err)
+	}
+}
@@ -2,11 +2,17 @@ package images
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/skyhook-io/radar/internal/httperr"
 )
 
 // Handlers provides HTTP handlers for image inspection
@@ -14,31 +20,45 @@ type Handlers struct {
 	inspector *Inspector
 }
 
-// NewHandlers creates a new Handlers instance
-func NewHandlers() *Handlers {
+// NewHandlers creates a new Handlers instance caching image layers under cacheDir
+func NewHandlers(cacheDir string) *Handlers {
 	return &Handlers{
-		inspector: NewInspector(),
+		inspector: NewInspector(cacheDir),
 	}
 }
 
 // RegisterRoutes registers image inspection routes
 func (h *Handlers) RegisterRoutes(r chi.Router) {
 	r.Route("/images", func(r chi.Router) {
+		r.Use(rateLimit)
+		r.Use(requestTimeouts)
+		r.Get("/", h.handleListImages)
 		r.Get("/metadata", h.handleMetadata)
+		r.Post("/metadata/batch", h.handleMetadataBatch)
+		r.Get("/auth-check", h.handleAuthCheck)
+		r.Get("/layers", h.handleLayers)
+		r.Get("/layer", h.handleLayerTree)
+		r.Get("/resolve", h.handleResolve)
+		r.Get("/drift", h.handleDrift)
+		r.Get("/pod", h.handlePodImages)
+		r.Get("/startup", h.handleContainerStartup)
+		r.Get("/mismatches", h.handleImageMismatches)
 		r.Get("/inspect", h.handleInspect)
+		r.Get("/ls", h.handleListDirectory)
 		r.Get("/file", h.handleGetFile)
+		r.Get("/export", h.handleExportFilesystem)
+		r.Get("/grep", h.handleGrepFilesystem)
+		r.Get("/view", h.handleViewFile)
+		r.Get("/file/diff", h.handleFileDiff)
+		r.Get("/cache", h.handleCacheStatus)
+		r.Get("/rate-limits", h.handleRegistryRateLimits)
+		r.Delete("/cache", h.handleClearCache)
 	})
 }
 
-// handleMetadata returns lightweight metadata about an image
-// If the image is already cached, returns the full filesystem
-func (h *Handlers) handleMetadata(w http.ResponseWriter, r *http.Request) {
-	image := r.URL.Query().Get("image")
-	if image == "" {
-		writeError(w, http.StatusBadRequest, "image parameter is required")
-		return
-	}
-
+// inspectRequestFromQuery builds an InspectRequest from the image, namespace,
+// pod and pullSecrets query parameters
+func inspectRequestFromQuery(r *http.Request) InspectRequest {
 	namespace := r.URL.Query().Get("namespace")
 	podName := r.URL.Query().Get("pod")
 	pullSecrets := r.URL.Query().Get("pullSecrets")
@@ -53,83 +73,456 @@ func (h *Handlers) handleMetadata(w http.ResponseWriter, r *http.Request) {
 		secretNames = GetPullSecretsFromPod(namespace, podName)
 	}
 
-	req := InspectRequest{
-		Image:           image,
+	return InspectRequest{
+		Image:           r.URL.Query().Get("image"),
 		Namespace:       namespace,
 		PodName:         podName,
 		PullSecretNames: secretNames,
 	}
+}
+
+// pinRunningDigest points the request at the exact digest the pod is running
+// when running=true, so a tag that moved in the registry doesn't change what
+// is inspected. The container is picked by the container parameter, or else
+// by matching the image parameter against the pod's spec.
+func pinRunningDigest(r *http.Request, req *InspectRequest) error {
+	if r.URL.Query().Get("running") != "true" {
+		return nil
+	}
+	if req.Namespace == "" || req.PodName == "" {
+		return errors.New("namespace and pod parameters are required with running=true")
+	}
+	container := r.URL.Query().Get("container")
+	if container == "" && req.Image == "" {
+		return errors.New("image or container parameter is required")
+	}
+	ref, err := runningImageRef(req.Namespace, req.PodName, container, req.Image)
+	if err != nil {
+		return err
+	}
+	req.Image = ref
+	return nil
+}
+
+// writePinError writes an error from pinRunningDigest
+func writePinError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrPodNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errResourceCacheUnavailable):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+// parseDepth parses the optional depth query parameter (0 = unlimited)
+func parseDepth(r *http.Request, defaultDepth int) (int, error) {
+	value := r.URL.Query().Get("depth")
+	if value == "" {
+		return defaultDepth, nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 0 {
+		return 0, fmt.Errorf("depth must be a non-negative integer")
+	}
+	return depth, nil
+}
+
+// writeImageError writes an image fetch error with its status and error code
+func writeImageError(w http.ResponseWriter, err error, image string) {
+	status, code := classifyError(err)
+	httperr.Write(w, status, code, imageErrorMessage(err, code, image))
+}
+
+// imageErrorMessage is the message shown for an image fetch error classified as code
+func imageErrorMessage(err error, code, image string) string {
+	switch code {
+	case httperr.CodeImageUnauthorized:
+		return "Authentication required for this image"
+	case httperr.CodeImageNotFound:
+		return "Image not found: " + image
+	case httperr.CodeImageRegistryThrottle:
+		if limit, ok := registryRateLimitFor(image); ok {
+			return fmt.Sprintf("%s reports %s", limit.Registry, limit.summary(time.Now()))
+		}
+	}
+	return err.Error()
+}
+
+// handleMetadata returns lightweight metadata about an image
+// If the image is already cached, returns the full filesystem.
+// ?signature=true also looks for cosign signatures and attestations, which
+// takes several more registry requests the first time a digest is checked.
+func (h *Handlers) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
+	}
 
 	result, err := h.inspector.GetMetadata(r.Context(), req)
 	if err != nil {
-		errStr := err.Error()
-		if strings.Contains(errStr, "unauthorized") || strings.Contains(errStr, "denied") {
-			writeError(w, http.StatusUnauthorized, "Authentication required for this image")
+		writeImageError(w, err, req.Image)
+		return
+	}
+	if r.URL.Query().Get("signature") == "true" {
+		result.Signature = h.inspector.cachedCheckSignature(r.Context(), req, result.Digest)
+	}
+
+	writeJSON(w, result)
+}
+
+// handleMetadataBatch returns metadata for several images in one call, for
+// views that show many images at once. Items are returned in request order
+// and a lookup that fails is reported on its item rather than failing the batch.
+func (h *Handlers) handleMetadataBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Images []BatchImageRef `json:"images"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Images) > maxBatchImages {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("too many images: %d (max %d)", len(req.Images), maxBatchImages))
+		return
+	}
+
+	results := h.inspector.GetMetadataBatch(r.Context(), req.Images, limiterFor(clientKey(r)))
+	writeJSON(w, map[string]any{"images": results})
+}
+
+// handleAuthCheck explains which credentials were tried for an image and
+// what the registry made of them, for when inspection fails with a 401.
+// Registry failures are reported in the body with a 200.
+// GET /api/images/auth-check?image=...&namespace=...&pod=...
+func (h *Handlers) handleAuthCheck(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
+	}
+
+	result, err := h.inspector.CheckAuth(r.Context(), req)
+	if err != nil {
+		writeImageError(w, err, req.Image)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleLayers returns an image's layers ranked by size with the build step
+// that created each
+func (h *Handlers) handleLayers(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
+	}
+
+	result, err := h.inspector.GetLayers(r.Context(), req)
+	if err != nil {
+		writeImageError(w, err, req.Image)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleLayerTree returns the file tree of a single layer, i.e. what one
+// build step wrote or deleted. An optional depth limits directory levels as
+// for /images/inspect.
+// GET /api/images/layer?image=...&index=N[&depth=N]
+func (h *Handlers) handleLayerTree(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
+	}
+
+	index, err := strconv.Atoi(r.URL.Query().Get("index"))
+	if err != nil || index < 0 {
+		writeError(w, http.StatusBadRequest, "index must be a non-negative integer")
+		return
+	}
+
+	depth, err := parseDepth(r, 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.inspector.GetLayerTree(r.Context(), req, index)
+	if err != nil {
+		if errors.Is(err, ErrLayerNotFound) {
+			httperr.Write(w, http.StatusNotFound, httperr.CodeNotFound, fmt.Sprintf("Layer %d not found in %s", index, req.Image))
 			return
 		}
-		if strings.Contains(errStr, "not found") || strings.Contains(errStr, "manifest unknown") {
-			writeError(w, http.StatusNotFound, "Image not found: "+image)
+		writeImageError(w, err, req.Image)
+		return
+	}
+
+	if depth > 0 {
+		result.Truncated = truncateTree(result.Root, depth)
+	}
+
+	writeJSON(w, result)
+}
+
+// handleListImages lists the distinct images running in the cluster
+func (h *Handlers) handleListImages(w http.ResponseWriter, r *http.Request) {
+	withWorkloads := r.URL.Query().Get("workloads") == "true"
+	result, err := h.inspector.ListClusterImages(r.URL.Query().Get("namespace"), withWorkloads)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeJSON(w, result)
+}
+
+// handlePodImages lists the images of a pod's containers, including init and
+// ephemeral ones, so any of them can be picked for inspection
+func (h *Handlers) handlePodImages(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	podName := r.URL.Query().Get("pod")
+	if namespace == "" || podName == "" {
+		writeError(w, http.StatusBadRequest, "namespace and pod parameters are required")
+		return
+	}
+
+	result, err := GetPodImages(namespace, podName)
+	if err != nil {
+		if errors.Is(err, ErrPodNotFound) {
+			writeError(w, http.StatusNotFound, "Pod not found: "+namespace+"/"+podName)
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, http.StatusServiceUnavailable, err.Error())
 		return
 	}
 
 	writeJSON(w, result)
 }
 
-// handleInspect inspects an image and returns its filesystem tree
-func (h *Handlers) handleInspect(w http.ResponseWriter, r *http.Request) {
-	image := r.URL.Query().Get("image")
-	if image == "" {
+// handleContainerStartup returns a pod container's image config, the
+// command/args/env the pod spec overrides it with, and the resolved startup
+// GET /api/images/startup?namespace=X&pod=Y&container=Z
+func (h *Handlers) handleContainerStartup(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	podName := r.URL.Query().Get("pod")
+	if namespace == "" || podName == "" {
+		writeError(w, http.StatusBadRequest, "namespace and pod parameters are required")
+		return
+	}
+
+	result, err := h.inspector.GetContainerStartup(r.Context(), namespace, podName, r.URL.Query().Get("container"))
+	if err != nil {
+		writePinError(w, err)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleImageMismatches reports, per workload in a namespace, containers
+// whose pods run different image digests, e.g. a rollout stuck half way
+// GET /api/images/mismatches?namespace=X
+func (h *Handlers) handleImageMismatches(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		writeError(w, http.StatusBadRequest, "namespace parameter is required")
+		return
+	}
+
+	result, err := CompareNamespaceImages(namespace)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleResolve normalizes an image reference and resolves its tag to the
+// digest it currently points to
+func (h *Handlers) handleResolve(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if req.Image == "" {
 		writeError(w, http.StatusBadRequest, "image parameter is required")
 		return
 	}
 
+	result, err := h.inspector.ResolveReference(r.Context(), req)
+	if err != nil {
+		writeImageError(w, err, req.Image)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleDrift compares a pod's running image digests to the registry's
+// current digest for each container's tag
+func (h *Handlers) handleDrift(w http.ResponseWriter, r *http.Request) {
 	namespace := r.URL.Query().Get("namespace")
 	podName := r.URL.Query().Get("pod")
-	pullSecrets := r.URL.Query().Get("pullSecrets")
+	if namespace == "" || podName == "" {
+		writeError(w, http.StatusBadRequest, "namespace and pod parameters are required")
+		return
+	}
 
-	var secretNames []string
-	if pullSecrets != "" {
-		secretNames = strings.Split(pullSecrets, ",")
+	result, err := h.inspector.CheckPodDrift(r.Context(), namespace, podName)
+	if err != nil {
+		if errors.Is(err, ErrPodNotFound) {
+			writeError(w, http.StatusNotFound, "Pod not found: "+namespace+"/"+podName)
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
 	}
 
-	// If pod name is provided, auto-discover pull secrets from pod spec
-	if podName != "" && namespace != "" && len(secretNames) == 0 {
-		secretNames = GetPullSecretsFromPod(namespace, podName)
+	writeJSON(w, result)
+}
+
+// handleInspect inspects an image and returns its filesystem tree.
+// An optional depth limits how many directory levels are returned; deeper
+// directories are marked with hasChildren and can be expanded via /images/ls.
+func (h *Handlers) handleInspect(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
 	}
 
-	req := InspectRequest{
-		Image:           image,
-		Namespace:       namespace,
-		PodName:         podName,
-		PullSecretNames: secretNames,
+	depth, err := parseDepth(r, 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// A cached digest-pinned image is revalidated without building its tree
+	if digest := h.inspector.cachedPinnedDigest(req.Image); digest != "" {
+		etag := inspectETag(digest, depth)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "private, no-cache")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 	}
 
 	result, err := h.inspector.Inspect(r.Context(), req)
 	if err != nil {
-		// Check for common errors
-		errStr := err.Error()
-		if strings.Contains(errStr, "unauthorized") || strings.Contains(errStr, "denied") {
-			writeError(w, http.StatusUnauthorized, "Authentication required for this image")
+		writeImageError(w, err, req.Image)
+		return
+	}
+
+	// Image digests are immutable, so the tree for a digest can be revalidated
+	// with If-None-Match instead of being re-sent on every navigation
+	etag := inspectETag(result.Digest, depth)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if depth > 0 {
+		result.Truncated = truncateTree(result.Root, depth)
+	}
+
+	writeJSON(w, result)
+}
+
+// handleListDirectory returns a single directory of an image's filesystem
+// for lazy expansion of trees fetched with a depth limit
+func (h *Handlers) handleListDirectory(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
+	}
+
+	dirPath := r.URL.Query().Get("path")
+	if dirPath == "" {
+		dirPath = "/"
+	}
+
+	depth, err := parseDepth(r, 1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	node, err := h.inspector.ListDirectory(r.Context(), req, dirPath, depth)
+	if err != nil {
+		if errors.Is(err, ErrPathNotFound) {
+			httperr.Write(w, http.StatusNotFound, httperr.CodeImagePathNotFound, "Path not found: "+dirPath)
 			return
 		}
-		if strings.Contains(errStr, "not found") || strings.Contains(errStr, "manifest unknown") {
-			writeError(w, http.StatusNotFound, "Image not found: "+image)
+		if errors.Is(err, ErrNotDirectory) {
+			httperr.Write(w, http.StatusBadRequest, httperr.CodeImageNotDirectory, "Not a directory: "+dirPath)
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeImageError(w, err, req.Image)
 		return
 	}
 
-	writeJSON(w, result)
+	writeJSON(w, node)
+}
+
+// inspectETag returns a strong ETag for an image filesystem tree at a given depth
+func inspectETag(digest string, depth int) string {
+	return fmt.Sprintf("\"%s-v%d-d%d\"", getCacheKey(digest), treeFormatVersion, depth)
+}
+
+// etagMatches reports whether an If-None-Match header value matches the given ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
 }
 
 // handleGetFile returns the content of a specific file from an image
 func (h *Handlers) handleGetFile(w http.ResponseWriter, r *http.Request) {
-	image := r.URL.Query().Get("image")
-	if image == "" {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
 		writeError(w, http.StatusBadRequest, "image parameter is required")
 		return
 	}
@@ -140,52 +533,206 @@ func (h *Handlers) handleGetFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	namespace := r.URL.Query().Get("namespace")
-	podName := r.URL.Query().Get("pod")
-	pullSecrets := r.URL.Query().Get("pullSecrets")
+	content, filename, err := h.inspector.GetFileContent(r.Context(), req, filePath)
+	if err != nil {
+		if errors.Is(err, ErrPathNotFound) {
+			httperr.Write(w, http.StatusNotFound, httperr.CodeImagePathNotFound, "File not found: "+filePath)
+			return
+		}
+		writeImageError(w, err, req.Image)
+		return
+	}
 
-	var secretNames []string
-	if pullSecrets != "" {
-		secretNames = strings.Split(pullSecrets, ",")
+	// Set headers for file download
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+	w.Write(content)
+}
+
+// handleExportFilesystem streams the image's merged filesystem as a tar
+// download. Layers are fetched and cached before anything is written, so
+// registry errors still get a JSON error response; a failure while streaming
+// can only end the response early.
+// GET /api/images/export?image=...
+func (h *Handlers) handleExportFilesystem(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
 	}
 
-	// If pod name is provided, auto-discover pull secrets from pod spec
-	if podName != "" && namespace != "" && len(secretNames) == 0 {
-		secretNames = GetPullSecretsFromPod(namespace, podName)
+	layerPaths, err := h.inspector.FilesystemLayers(r.Context(), req)
+	if err != nil {
+		writeImageError(w, err, req.Image)
+		return
 	}
 
-	req := InspectRequest{
-		Image:           image,
-		Namespace:       namespace,
-		PodName:         podName,
-		PullSecretNames: secretNames,
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+exportFilename(req.Image)+"\"")
+	if err := writeMergedFilesystem(r.Context(), layerPaths, w); err != nil {
+		log.Printf("Export of %s ended early: %v", req.Image, err)
 	}
+}
 
-	content, filename, err := h.inspector.GetFileContent(r.Context(), req, filePath)
+// handleGrepFilesystem searches the content of an image's text files for a
+// regex, optionally under one directory, e.g. to find where a hardcoded value
+// is baked in. Large and binary files are skipped and the number of matches
+// returned is capped.
+// GET /api/images/grep?image=...&pattern=...&path=/etc&ignoreCase=true
+func (h *Handlers) handleGrepFilesystem(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
+	}
+
+	re, err := CompileGrepPattern(r.URL.Query().Get("pattern"), r.URL.Query().Get("ignoreCase") == "true")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.inspector.GrepFilesystem(r.Context(), req, re, r.URL.Query().Get("path"))
+	if err != nil {
+		writeImageError(w, err, req.Image)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleViewFile returns the content of a text file from an image for inline
+// display, e.g. peeking at a config file. The charset (UTF-8 or Latin-1) is
+// detected and sent in the Content-Type; files over maxViewFileSize or that
+// look binary are refused, so the client can offer a download instead.
+// GET /api/images/view?image=...&path=...
+func (h *Handlers) handleViewFile(w http.ResponseWriter, r *http.Request) {
+	req := inspectRequestFromQuery(r)
+	if err := pinRunningDigest(r, &req); err != nil {
+		writePinError(w, err)
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, "image parameter is required")
+		return
+	}
+
+	filePath := r.URL.Query().Get("path")
+	if filePath == "" {
+		writeError(w, http.StatusBadRequest, "path parameter is required")
+		return
+	}
+
+	content, _, err := h.inspector.GetFileContent(r.Context(), req, filePath)
 	if err != nil {
-		errStr := err.Error()
-		if strings.Contains(errStr, "not found") {
-			writeError(w, http.StatusNotFound, "File not found: "+filePath)
+		if errors.Is(err, ErrPathNotFound) {
+			httperr.Write(w, http.StatusNotFound, httperr.CodeImagePathNotFound, "File not found: "+filePath)
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeImageError(w, err, req.Image)
 		return
 	}
 
-	// Set headers for file download
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	if len(content) > maxViewFileSize {
+		httperr.Write(w, http.StatusRequestEntityTooLarge, httperr.CodeImageFileTooLarge,
+			fmt.Sprintf("File is %d bytes; only files up to %d bytes can be viewed, download it instead", len(content), maxViewFileSize))
+		return
+	}
+	charset, ok := detectTextEncoding(content)
+	if !ok {
+		httperr.Write(w, http.StatusUnsupportedMediaType, httperr.CodeImageBinaryFile, "File is binary and can't be viewed as text, download it instead")
+		return
+	}
+
+	// Always plain text: serving image content as HTML or script from this
+	// origin would let it run with the UI's privileges
+	w.Header().Set("Content-Type", "text/plain; charset="+charset)
+	w.Header().Set("Content-Disposition", "inline")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
 	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
 	w.Write(content)
 }
 
+// handleFileDiff returns a unified diff of one file's contents between two
+// images, e.g. a config file across two versions of an image. Both images
+// are read with the same namespace, pod and pullSecrets parameters.
+// GET /api/images/file/diff?from=...&to=...&path=...[&context=3]
+func (h *Handlers) handleFileDiff(w http.ResponseWriter, r *http.Request) {
+	from := inspectRequestFromQuery(r)
+	to := from
+	from.Image = r.URL.Query().Get("from")
+	to.Image = r.URL.Query().Get("to")
+	if from.Image == "" || to.Image == "" {
+		writeError(w, http.StatusBadRequest, "from and to parameters are required")
+		return
+	}
+
+	filePath := r.URL.Query().Get("path")
+	if filePath == "" {
+		writeError(w, http.StatusBadRequest, "path parameter is required")
+		return
+	}
+
+	contextLines := defaultDiffContext
+	if value := r.URL.Query().Get("context"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "context must be a non-negative integer")
+			return
+		}
+		contextLines = n
+	}
+
+	result, err := h.inspector.DiffFile(r.Context(), from, to, filePath, contextLines)
+	if err != nil {
+		if errors.Is(err, ErrPathNotFound) {
+			httperr.Write(w, http.StatusNotFound, httperr.CodeImagePathNotFound, "File not found in either image: "+filePath)
+			return
+		}
+		writeImageError(w, err, from.Image+" or "+to.Image)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// handleCacheStatus returns the size and persistence of the layer cache,
+// including the result of the startup validation when it persists
+// GET /api/images/cache
+func (h *Handlers) handleCacheStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, h.inspector.CacheStatus())
+}
+
+// handleRegistryRateLimits returns the pull budget each registry last
+// reported, to explain throttled or slow inspections
+// GET /api/images/rate-limits
+func (h *Handlers) handleRegistryRateLimits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"registries": RegistryRateLimits()})
+}
+
+// handleClearCache removes all cached images and layers, e.g. to reclaim a
+// persisted cache's disk space, and returns the now empty cache status
+// DELETE /api/images/cache
+func (h *Handlers) handleClearCache(w http.ResponseWriter, r *http.Request) {
+	h.inspector.ClearCache()
+	writeJSON(w, h.inspector.CacheStatus())
+}
+
 func writeJSON(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
 
 func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	httperr.Write(w, status, "", message)
 }
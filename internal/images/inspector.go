@@ -4,11 +4,13 @@ import (
 	"archive/tar"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -21,38 +23,87 @@ import (
 )
 
 const (
-	maxFileCount       = 50000             // Safety limit for file count
-	maxTotalSize       = 5 << 30           // 5GB safety limit
-	layerCacheTTL      = 5 * time.Minute   // TTL for cached layers on disk
-	maxCachedImages    = 5                 // Max number of images to cache on disk
-	cacheSubdir        = "radar-image-cache"
+	maxFileCount    = 50000           // Safety limit for file count
+	maxTotalSize    = 5 << 30         // 5GB safety limit for the layer store on disk
+	layerCacheTTL   = 5 * time.Minute // TTL for cached layers on disk
+	maxCachedImages = 5               // Max number of images to cache on disk
+	cacheSubdir     = "radar-image-cache"
+	layerStoreDir   = "blobs" // Shared, content-addressed layer files
+
+	layerDownloadConcurrency = 4 // Max layers downloaded in parallel
+
+	whiteoutPrefix = ".wh."         // OCI whiteout marker for a deleted entry
+	whiteoutOpaque = ".wh..wh..opq" // OCI marker hiding all lower-layer contents of a directory
+
+	// treeFormatVersion is part of the inspect ETag; bump it whenever the
+	// shape or contents of the returned FileNode tree change
+	treeFormatVersion = 5
+)
+
+// Errors returned when looking up paths inside an image filesystem
+var (
+	ErrPathNotFound  = errors.New("path not found")
+	ErrLayerNotFound = errors.New("layer not found")
+	ErrNotDirectory  = errors.New("not a directory")
 )
 
+// ErrImageTooLarge is returned when an image's layers alone exceed maxTotalSize
+var ErrImageTooLarge = errors.New("image too large to cache")
+
 // layerCacheMetadata stores metadata about cached image layers
 type layerCacheMetadata struct {
 	ImageRef   string    `json:"imageRef"`
 	Digest     string    `json:"digest"`
 	Platform   string    `json:"platform"`
 	LayerCount int       `json:"layerCount"`
+	Layers     []string  `json:"layers"` // Layer digests, bottom to top
 	CachedAt   time.Time `json:"cachedAt"`
+	// Sizes, media types and history per layer, bottom to top (absent in
+	// entries cached before they were recorded)
+	LayerDetails []LayerInfo `json:"layerDetails,omitempty"`
+	// Uncompressed layer digests, bottom to top, for verifying layer files
+	// kept across restarts
+	DiffIDs []string `json:"diffIds,omitempty"`
+	// Digests other than Digest the image was requested by, e.g. the index
+	// digest of a digest-pinned multi-platform reference
+	RefDigests []string `json:"refDigests,omitempty"`
 }
 
 // Inspector handles image filesystem inspection with disk-based layer caching
 type Inspector struct {
-	cacheDir string
-	cacheMu  sync.RWMutex
+	cacheDir   string
+	cacheMu    sync.RWMutex
+	persistent bool             // Cache directory is kept across restarts
+	validation *CacheValidation // Startup check of a persisted cache, once done
+
+	enrichment *imageEnricher // Background platform/size lookups for the cluster image list
 }
 
-// NewInspector creates a new image inspector
-func NewInspector() *Inspector {
-	cacheDir := filepath.Join(os.TempDir(), cacheSubdir)
+// NewInspector creates a new image inspector that caches layers in a
+// subdirectory of dir (os.TempDir() when empty)
+func NewInspector(dir string) *Inspector {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	cacheDir := filepath.Join(dir, cacheSubdir)
 
 	i := &Inspector{
-		cacheDir: cacheDir,
+		cacheDir:   cacheDir,
+		persistent: persistCache.Load(),
+		enrichment: newImageEnricher(),
 	}
 
-	// Clean cache directory on startup
-	i.cleanCacheDir()
+	// Start from an empty cache unless it persists across restarts, in which
+	// case what the previous run left is validated instead
+	if !i.persistent {
+		i.cleanCacheDir()
+	}
+	if err := CheckCacheDir(cacheDir); err != nil {
+		log.Printf("Warning: image layer cache directory is not writable, image inspection will fail (set --cache-dir to a writable volume): %v", err)
+	}
+	if i.persistent {
+		go i.validateCacheDir()
+	}
 
 	// Start background cleanup goroutine
 	go i.cleanupLoop()
@@ -61,6 +112,21 @@ func NewInspector() *Inspector {
 	return i
 }
 
+// CheckCacheDir verifies that dir exists (creating it if needed) and that
+// files can be written to it
+func CheckCacheDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
 // cleanCacheDir removes and recreates the cache directory
 func (i *Inspector) cleanCacheDir() {
 	i.cacheMu.Lock()
@@ -77,7 +143,7 @@ func (i *Inspector) cleanCacheDir() {
 
 // cleanupLoop periodically removes expired entries from the cache
 func (i *Inspector) cleanupLoop() {
-	ticker := time.NewTicker(time.Minute)
+	ticker := time.NewTicker(cleanupInterval())
 	defer ticker.Stop()
 
 	for range ticker.C {
@@ -97,7 +163,7 @@ func (i *Inspector) cleanupExpired() {
 
 	now := time.Now()
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !entry.IsDir() || entry.Name() == layerStoreDir {
 			continue
 		}
 
@@ -115,11 +181,13 @@ func (i *Inspector) cleanupExpired() {
 			continue
 		}
 
-		if now.Sub(meta.CachedAt) >= layerCacheTTL {
+		if now.Sub(meta.CachedAt) >= cacheTTL() {
 			os.RemoveAll(filepath.Join(i.cacheDir, entry.Name()))
 			log.Printf("Cleaned up expired layer cache for: %s", meta.ImageRef)
 		}
 	}
+
+	i.pruneUnreferencedLayers()
 }
 
 // getCacheKey returns a filesystem-safe cache key from an image digest
@@ -129,18 +197,91 @@ func getCacheKey(digest string) string {
 	return strings.ReplaceAll(digest, ":", "-")
 }
 
-// getCachedLayers returns paths to cached layer files if available and not expired
+// layerBlobPath returns the shared on-disk path for a layer digest
+func (i *Inspector) layerBlobPath(layerDigest string) string {
+	return filepath.Join(i.cacheDir, layerStoreDir, getCacheKey(layerDigest)+".tar")
+}
+
+// layerRefCounts returns how many cached images reference each layer digest.
+// Must be called with cacheMu held.
+func (i *Inspector) layerRefCounts() map[string]int {
+	refs := make(map[string]int)
+	entries, err := os.ReadDir(i.cacheDir)
+	if err != nil {
+		return refs
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == layerStoreDir {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(i.cacheDir, entry.Name(), "metadata.json"))
+		if err != nil {
+			continue
+		}
+		var meta layerCacheMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			continue
+		}
+		for _, d := range meta.Layers {
+			refs[getCacheKey(d)+".tar"]++
+		}
+	}
+	return refs
+}
+
+// pruneUnreferencedLayers deletes shared layer files no cached image references.
+// Must be called with the cacheMu write lock held.
+func (i *Inspector) pruneUnreferencedLayers() {
+	i.pruneLayers(nil)
+}
+
+// pruneLayers deletes shared layer files that no cached image references and
+// that aren't named in keep. Must be called with the cacheMu write lock held.
+func (i *Inspector) pruneLayers(keep map[string]bool) {
+	blobs, err := os.ReadDir(filepath.Join(i.cacheDir, layerStoreDir))
+	if err != nil {
+		return
+	}
+	refs := i.layerRefCounts()
+	for _, blob := range blobs {
+		if refs[blob.Name()] > 0 || keep[blob.Name()] {
+			continue
+		}
+		os.Remove(filepath.Join(i.cacheDir, layerStoreDir, blob.Name()))
+	}
+}
+
+// getCachedLayers returns paths to cached layer files if available and not
+// expired. digest is the image's own digest or a reference digest recorded
+// for it, such as the multi-platform index it was pulled through.
 func (i *Inspector) getCachedLayers(digest string) ([]string, *layerCacheMetadata, bool) {
 	i.cacheMu.RLock()
-	defer i.cacheMu.RUnlock()
+	layerPaths, meta, expired := i.readCachedLayers(digest)
+	i.cacheMu.RUnlock()
+
+	// Remove an expired image now rather than on the next sweep, so its
+	// layers don't count against the cache limits in the meantime
+	if expired {
+		i.removeExpired(meta.Digest)
+		return nil, nil, false
+	}
+	return layerPaths, meta, meta != nil
+}
 
+// readCachedLayers reads the cache entry getCachedLayers looks up. It
+// returns the layer paths and metadata of a usable entry, the metadata and
+// expired = true for an expired one, and nil metadata otherwise. Must be
+// called with cacheMu held.
+func (i *Inspector) readCachedLayers(digest string) ([]string, *layerCacheMetadata, bool) {
 	cacheKey := getCacheKey(digest)
 	imageDir := filepath.Join(i.cacheDir, cacheKey)
 	metadataPath := filepath.Join(imageDir, "metadata.json")
 
 	data, err := os.ReadFile(metadataPath)
 	if err != nil {
-		return nil, nil, false
+		if data, err = i.readAliasedMetadata(digest, resolvedPlatform); err != nil {
+			return nil, nil, false
+		}
 	}
 
 	var meta layerCacheMetadata
@@ -149,22 +290,45 @@ func (i *Inspector) getCachedLayers(digest string) ([]string, *layerCacheMetadat
 	}
 
 	// Check if expired
-	if time.Since(meta.CachedAt) >= layerCacheTTL {
-		return nil, nil, false
+	if time.Since(meta.CachedAt) >= cacheTTL() {
+		return nil, &meta, true
 	}
 
-	// Get layer files
-	layersDir := filepath.Join(imageDir, "layers")
+	// Get layer files from the shared layer store
+	if len(meta.Layers) != meta.LayerCount {
+		return nil, nil, false
+	}
 	var layerPaths []string
-	for idx := 0; idx < meta.LayerCount; idx++ {
-		layerPath := filepath.Join(layersDir, fmt.Sprintf("layer-%d.tar", idx))
+	for _, layerDigest := range meta.Layers {
+		layerPath := i.layerBlobPath(layerDigest)
 		if _, err := os.Stat(layerPath); err != nil {
 			return nil, nil, false
 		}
 		layerPaths = append(layerPaths, layerPath)
 	}
 
-	return layerPaths, &meta, true
+	return layerPaths, &meta, false
+}
+
+// removeExpired deletes the cache entry of an image digest if it is still
+// expired, along with layers no other cached image uses. The entry may have
+// been refreshed between the read that found it expired and taking the lock.
+func (i *Inspector) removeExpired(digest string) {
+	i.cacheMu.Lock()
+	defer i.cacheMu.Unlock()
+
+	imageDir := filepath.Join(i.cacheDir, getCacheKey(digest))
+	data, err := os.ReadFile(filepath.Join(imageDir, "metadata.json"))
+	if err != nil {
+		return
+	}
+	var meta layerCacheMetadata
+	if err := json.Unmarshal(data, &meta); err == nil && time.Since(meta.CachedAt) < cacheTTL() {
+		return
+	}
+	os.RemoveAll(imageDir)
+	i.pruneUnreferencedLayers()
+	log.Printf("Removed expired layer cache for: %s", meta.ImageRef)
 }
 
 // cacheLayers saves image layers to disk
@@ -180,17 +344,15 @@ func (i *Inspector) cacheLayers(ctx context.Context, img v1.Image, imageRef stri
 
 	cacheKey := getCacheKey(digest.String())
 	imageDir := filepath.Join(i.cacheDir, cacheKey)
-	layersDir := filepath.Join(imageDir, "layers")
-
-	// Check if we need to evict old entries
-	if err := i.evictOldEntries(); err != nil {
-		log.Printf("Warning: failed to evict old cache entries: %v", err)
-	}
+	blobsDir := filepath.Join(i.cacheDir, layerStoreDir)
 
 	// Create directories
-	if err := os.MkdirAll(layersDir, 0755); err != nil {
+	if err := os.MkdirAll(imageDir, 0755); err != nil {
 		return nil, nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create layer store directory: %w", err)
+	}
 
 	// Get platform info
 	configFile, _ := img.ConfigFile()
@@ -205,76 +367,218 @@ func (i *Inspector) cacheLayers(ctx context.Context, img v1.Image, imageRef stri
 		return nil, nil, fmt.Errorf("failed to get layers: %w", err)
 	}
 
-	// Save each layer to disk
-	var layerPaths []string
+	// Layers are stored once by digest and shared between images, so
+	// layerPaths keeps the image's own bottom-to-top order for tree construction
+	layerDigests := make([]string, len(layers))
+	layerPaths := make([]string, len(layers))
 	for idx, layer := range layers {
-		select {
-		case <-ctx.Done():
-			// Clean up partial cache on cancellation
-			os.RemoveAll(imageDir)
-			return nil, nil, ctx.Err()
-		default:
+		layerDigest, err := layer.Digest()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get digest for layer %d: %w", idx, err)
 		}
+		layerDigests[idx] = layerDigest.String()
+		layerPaths[idx] = i.layerBlobPath(layerDigests[idx])
+	}
+
+	// Make room before downloading. Only compressed sizes are known up front,
+	// so this is a lower bound; the limit is enforced again once the
+	// uncompressed layers are on disk.
+	incoming, err := i.missingLayersSize(layers, layerPaths)
+	if err != nil {
+		os.RemoveAll(imageDir)
+		return nil, nil, err
+	}
+	if incoming > maxTotalSize {
+		os.RemoveAll(imageDir)
+		return nil, nil, fmt.Errorf("%w: %d bytes of compressed layers (limit %d)", ErrImageTooLarge, incoming, int64(maxTotalSize))
+	}
+	keep := make(map[string]bool, len(layerPaths))
+	for _, path := range layerPaths {
+		keep[filepath.Base(path)] = true
+	}
+	if err := i.evictOldEntries(cacheKey, keep, incoming); err != nil {
+		log.Printf("Warning: failed to evict old cache entries: %v", err)
+	}
 
-		layerPath := filepath.Join(layersDir, fmt.Sprintf("layer-%d.tar", idx))
-		if err := i.saveLayer(layer, layerPath); err != nil {
-			// Clean up on error
-			os.RemoveAll(imageDir)
-			return nil, nil, fmt.Errorf("failed to save layer %d: %w", idx, err)
+	// Downloads stop at the pull deadline; a large tree build from the
+	// downloaded layers is only bounded by ctx
+	dlCtx, cancel := context.WithCancel(registryContext(ctx))
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, layerDownloadConcurrency)
+	scheduled := make(map[string]bool)
+	for idx, layer := range layers {
+		// Skip layers already in the store (shared with another image) and
+		// layers repeated within this image
+		if scheduled[layerPaths[idx]] {
+			continue
+		}
+		scheduled[layerPaths[idx]] = true
+		if _, err := os.Stat(layerPaths[idx]); err == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, layer v1.Layer) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-dlCtx.Done():
+				return
+			}
+
+			if err := i.saveLayer(dlCtx, layer, layerPaths[idx]); err != nil {
+				errMu.Lock()
+				if firstErr == nil && dlCtx.Err() == nil {
+					firstErr = fmt.Errorf("failed to save layer %d: %w", idx, err)
+				}
+				errMu.Unlock()
+				cancel()
+			}
+		}(idx, layer)
+	}
+	wg.Wait()
+
+	if pullTimedOut(ctx) {
+		os.RemoveAll(imageDir)
+		i.pruneUnreferencedLayers()
+		return nil, nil, fmt.Errorf("%w downloading layers of %s", ErrPullTimeout, imageRef)
+	}
+	if err := ctx.Err(); err != nil {
+		// Clean up partial cache on cancellation
+		os.RemoveAll(imageDir)
+		i.pruneUnreferencedLayers()
+		return nil, nil, err
+	}
+	if firstErr != nil {
+		// Clean up on error
+		os.RemoveAll(imageDir)
+		i.pruneUnreferencedLayers()
+		return nil, nil, firstErr
+	}
+
+	// Record real layer sizes now that the uncompressed layers are on disk
+	details, err := imageLayerInfos(img)
+	if err != nil || len(details) != len(layers) {
+		log.Printf("Warning: failed to get layer details for %s: %v", imageRef, err)
+		details = nil
+	}
+	for idx := range details {
+		if info, err := os.Stat(layerPaths[idx]); err == nil {
+			details[idx].UncompressedSize = info.Size()
+		}
+	}
+
+	var diffIDs []string
+	if configFile != nil && len(configFile.RootFS.DiffIDs) == len(layers) {
+		for _, diffID := range configFile.RootFS.DiffIDs {
+			diffIDs = append(diffIDs, diffID.String())
 		}
-		layerPaths = append(layerPaths, layerPath)
 	}
 
 	// Save metadata
 	meta := layerCacheMetadata{
-		ImageRef:   imageRef,
-		Digest:     digest.String(),
-		Platform:   platform,
-		LayerCount: len(layers),
-		CachedAt:   time.Now(),
+		ImageRef:     imageRef,
+		Digest:       digest.String(),
+		Platform:     platform,
+		LayerCount:   len(layers),
+		Layers:       layerDigests,
+		CachedAt:     time.Now(),
+		LayerDetails: details,
+		DiffIDs:      diffIDs,
+	}
+	if pinned := pinnedDigest(imageRef); pinned != "" && pinned != meta.Digest {
+		meta.RefDigests = []string{pinned}
 	}
 	metaData, _ := json.Marshal(meta)
 	if err := os.WriteFile(filepath.Join(imageDir, "metadata.json"), metaData, 0644); err != nil {
 		os.RemoveAll(imageDir)
+		i.pruneUnreferencedLayers()
 		return nil, nil, fmt.Errorf("failed to save metadata: %w", err)
 	}
 
+	// Drop layers of evicted images now that this image's references are
+	// recorded, so layers it shares with an evicted image are kept
+	i.pruneUnreferencedLayers()
+
+	// Uncompressed layers are usually several times their compressed size,
+	// so evict again against what is actually on disk
+	if err := i.evictOldEntries(cacheKey, nil, 0); err != nil {
+		log.Printf("Warning: failed to evict old cache entries: %v", err)
+	}
+	if usage := i.layerStoreSize(); usage > maxTotalSize {
+		log.Printf("Warning: layer cache uses %d bytes, over the %d byte limit, with only %s cached", usage, int64(maxTotalSize), imageRef)
+	}
+
 	log.Printf("Cached %d layers for image %s (digest: %s)", len(layers), imageRef, digest.String())
 	return layerPaths, &meta, nil
 }
 
-// saveLayer downloads and saves a single layer to disk
-func (i *Inspector) saveLayer(layer v1.Layer, path string) error {
+// saveLayer downloads and saves a single layer to disk.
+// It writes to a temp file and renames on success so a partially
+// downloaded layer is never visible at its shared path.
+func (i *Inspector) saveLayer(ctx context.Context, layer v1.Layer, path string) error {
 	reader, err := layer.Uncompressed()
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
 
-	file, err := os.Create(path)
+	file, err := os.CreateTemp(filepath.Dir(path), "layer-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+
+	_, err = io.Copy(file, &ctxReader{ctx: ctx, r: reader})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
-	defer file.Close()
+	return os.Rename(tmpPath, path)
+}
+
+// ctxReader aborts reads once its context is cancelled, so a failed or
+// cancelled download stops the other in-flight layer copies promptly
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
 
-	_, err = io.Copy(file, reader)
-	return err
+// cacheEntry is one image directory of the disk cache
+type cacheEntry struct {
+	name     string
+	cachedAt time.Time
 }
 
-// evictOldEntries removes oldest entries if cache exceeds maxCachedImages
-func (i *Inspector) evictOldEntries() error {
+// listCacheEntries returns the cached images, oldest first.
+// Must be called with cacheMu held.
+func (i *Inspector) listCacheEntries() ([]cacheEntry, error) {
 	entries, err := os.ReadDir(i.cacheDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Count current cached images
-	var cached []struct {
-		name     string
-		cachedAt time.Time
-	}
+	var cached []cacheEntry
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !entry.IsDir() || entry.Name() == layerStoreDir {
 			continue
 		}
 		metadataPath := filepath.Join(i.cacheDir, entry.Name(), "metadata.json")
@@ -286,27 +590,74 @@ func (i *Inspector) evictOldEntries() error {
 		if err := json.Unmarshal(data, &meta); err != nil {
 			continue
 		}
-		cached = append(cached, struct {
-			name     string
-			cachedAt time.Time
-		}{entry.Name(), meta.CachedAt})
+		cached = append(cached, cacheEntry{entry.Name(), meta.CachedAt})
 	}
+	sort.Slice(cached, func(i, j int) bool {
+		return cached[i].cachedAt.Before(cached[j].cachedAt)
+	})
+	return cached, nil
+}
 
-	// If at limit, remove oldest
-	if len(cached) >= maxCachedImages {
-		// Sort by cachedAt (oldest first)
-		sort.Slice(cached, func(i, j int) bool {
-			return cached[i].cachedAt.Before(cached[j].cachedAt)
-		})
-		// Remove oldest entries to make room
-		toRemove := len(cached) - maxCachedImages + 1
-		for idx := 0; idx < toRemove; idx++ {
-			path := filepath.Join(i.cacheDir, cached[idx].name)
-			os.RemoveAll(path)
-			log.Printf("Evicted oldest cached image: %s", cached[idx].name)
+// layerStoreSize returns the bytes used by the shared layer store.
+// Must be called with cacheMu held.
+func (i *Inspector) layerStoreSize() int64 {
+	blobs, err := os.ReadDir(filepath.Join(i.cacheDir, layerStoreDir))
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, blob := range blobs {
+		if info, err := blob.Info(); err == nil {
+			total += info.Size()
 		}
 	}
+	return total
+}
+
+// missingLayersSize returns the compressed size of the layers that aren't in
+// the layer store yet, counting layers repeated within the image once
+func (i *Inspector) missingLayersSize(layers []v1.Layer, layerPaths []string) (int64, error) {
+	var total int64
+	seen := make(map[string]bool)
+	for idx, layer := range layers {
+		if seen[layerPaths[idx]] {
+			continue
+		}
+		seen[layerPaths[idx]] = true
+		if _, err := os.Stat(layerPaths[idx]); err == nil {
+			continue
+		}
+		size, err := layer.Size()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get size of layer %d: %w", idx, err)
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// evictOldEntries removes the oldest cached images, other than skip, while
+// there are maxCachedImages or more of them or while the layer store plus
+// incoming bytes exceeds maxTotalSize. Layer files named in keep are never
+// deleted, so layers the incoming image shares with an evicted one stay.
+// Must be called with the cacheMu write lock held.
+func (i *Inspector) evictOldEntries(skip string, keep map[string]bool, incoming int64) error {
+	cached, err := i.listCacheEntries()
+	if err != nil {
+		return err
+	}
+	cached = slices.DeleteFunc(cached, func(c cacheEntry) bool { return c.name == skip })
 
+	usage := i.layerStoreSize()
+	for len(cached) > 0 && (len(cached) >= maxCachedImages || usage+incoming > maxTotalSize) {
+		os.RemoveAll(filepath.Join(i.cacheDir, cached[0].name))
+		log.Printf("Evicted oldest cached image: %s (layer cache %d bytes, %d incoming)", cached[0].name, usage, incoming)
+		cached = cached[1:]
+
+		// Shared layers are only freed once no remaining image uses them
+		i.pruneLayers(keep)
+		usage = i.layerStoreSize()
+	}
 	return nil
 }
 
@@ -324,7 +675,7 @@ func (i *Inspector) GetMetadata(ctx context.Context, req InspectRequest) (*Image
 	}
 
 	// Check if layers are cached
-	layerPaths, meta, cached := i.getCachedLayers(digest.String())
+	layerPaths, meta, cached := i.lookupCachedLayers(req.Image, digest.String())
 	if cached {
 		// Build filesystem from cached layers
 		fs, err := i.buildFilesystemFromCache(ctx, layerPaths, meta, req.Image)
@@ -344,6 +695,17 @@ func (i *Inspector) GetMetadata(ctx context.Context, req InspectRequest) (*Image
 		log.Printf("Failed to read from cache, will re-download: %v", err)
 	}
 
+	return remoteMetadata(img, req.Image, authMethod)
+}
+
+// remoteMetadata describes an uncached image from its manifest and config
+// alone, without downloading any layers
+func remoteMetadata(img v1.Image, image, authMethod string) (*ImageMetadata, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image digest: %w", err)
+	}
+
 	// Get image config for platform info
 	configFile, _ := img.ConfigFile()
 	platform := ""
@@ -364,7 +726,7 @@ func (i *Inspector) GetMetadata(ctx context.Context, req InspectRequest) (*Image
 	}
 
 	return &ImageMetadata{
-		Image:      req.Image,
+		Image:      image,
 		Digest:     digest.String(),
 		Platform:   platform,
 		TotalSize:  totalSize,
@@ -375,20 +737,29 @@ func (i *Inspector) GetMetadata(ctx context.Context, req InspectRequest) (*Image
 }
 
 // fetchImageBruteForce tries to fetch an image using anonymous auth first,
-// then falls back to authenticated access if anonymous fails
+// then falls back to authenticated access if anonymous fails. Artifacts that
+// aren't container images are rejected with ErrNotContainerImage.
 func (i *Inspector) fetchImageBruteForce(ctx context.Context, req InspectRequest) (v1.Image, string, error) {
 	ref, err := name.ParseReference(req.Image)
 	if err != nil {
 		return nil, "", fmt.Errorf("invalid image reference: %w", err)
 	}
 
+	if err := checkRegistryAllowed(ref); err != nil {
+		return nil, "", err
+	}
+
+	// Registry requests get the pull deadline; the image keeps this context
+	// for the layer downloads it makes later
+	regCtx := registryContext(ctx)
+
 	// Try anonymous first
-	img, err := remote.Image(ref,
-		remote.WithContext(ctx),
-		remote.WithAuth(authn.Anonymous),
-	)
+	img, err := remote.Image(ref, registryOptions(regCtx, remote.WithAuth(authn.Anonymous))...)
 	if err == nil {
 		log.Printf("Image %s accessible with anonymous auth", req.Image)
+		if err := checkContainerImage(img); err != nil {
+			return nil, "", err
+		}
 		return img, "anonymous", nil
 	}
 
@@ -396,21 +767,37 @@ func (i *Inspector) fetchImageBruteForce(ctx context.Context, req InspectRequest
 	log.Printf("Anonymous auth failed for %s, trying with credentials: %v", req.Image, err)
 
 	keychain := GetAuthenticatedKeychain(req.Image, req.Namespace, req.PullSecretNames)
-	img, err = remote.Image(ref,
-		remote.WithContext(ctx),
-		remote.WithAuthFromKeychain(keychain),
-	)
+	img, err = remote.Image(ref, registryOptions(regCtx, remote.WithAuthFromKeychain(keychain))...)
 	if err != nil {
+		if pullTimedOut(ctx) {
+			return nil, "", fmt.Errorf("%w fetching %s", ErrPullTimeout, req.Image)
+		}
 		return nil, "", fmt.Errorf("failed to fetch image: %w", err)
 	}
 
 	registryType := DetectRegistryType(req.Image)
 	log.Printf("Image %s accessible with %s credentials", req.Image, registryType)
+	if err := checkContainerImage(img); err != nil {
+		return nil, "", err
+	}
 	return img, string(registryType), nil
 }
 
 // Inspect retrieves the filesystem tree for a container image
 func (i *Inspector) Inspect(ctx context.Context, req InspectRequest) (*ImageFilesystem, error) {
+	// A cached digest-pinned image needs no registry round trip
+	layerPaths, meta, cached, err := i.cachedPinnedLayers(req.Image)
+	if err != nil {
+		return nil, err
+	}
+	if cached {
+		fs, err := i.buildFilesystemFromCache(ctx, layerPaths, meta, req.Image)
+		if err == nil {
+			return fs, nil
+		}
+		log.Printf("Failed to read from cache, will re-download: %v", err)
+	}
+
 	// Fetch image to get digest
 	img, _, err := i.fetchImageBruteForce(ctx, req)
 	if err != nil {
@@ -423,7 +810,7 @@ func (i *Inspector) Inspect(ctx context.Context, req InspectRequest) (*ImageFile
 	}
 
 	// Check if layers are cached
-	layerPaths, meta, cached := i.getCachedLayers(digest.String())
+	layerPaths, meta, cached = i.lookupCachedLayers(req.Image, digest.String())
 	if cached {
 		fs, err := i.buildFilesystemFromCache(ctx, layerPaths, meta, req.Image)
 		if err == nil {
@@ -445,10 +832,22 @@ func (i *Inspector) Inspect(ctx context.Context, req InspectRequest) (*ImageFile
 func (i *Inspector) buildFilesystemFromCache(ctx context.Context, layerPaths []string, meta *layerCacheMetadata, imageRef string) (*ImageFilesystem, error) {
 	// Build layer info
 	layerInfos := make([]LayerInfo, len(layerPaths))
-	for idx := range layerPaths {
-		layerInfos[idx] = LayerInfo{
-			Digest:    fmt.Sprintf("layer-%d", idx),
-			MediaType: "application/vnd.oci.image.layer.v1.tar",
+	if len(meta.LayerDetails) == len(layerPaths) {
+		copy(layerInfos, meta.LayerDetails)
+	} else {
+		// Entry cached without layer details: only the digest and size on disk are known
+		for idx, layerPath := range layerPaths {
+			layerInfos[idx] = LayerInfo{
+				Index:     idx,
+				Digest:    fmt.Sprintf("layer-%d", idx),
+				MediaType: "application/vnd.oci.image.layer.v1.tar",
+			}
+			if idx < len(meta.Layers) {
+				layerInfos[idx].Digest = meta.Layers[idx]
+			}
+			if info, err := os.Stat(layerPath); err == nil {
+				layerInfos[idx].UncompressedSize = info.Size()
+			}
 		}
 	}
 
@@ -491,6 +890,10 @@ func buildFilesystemTreeFromFiles(ctx context.Context, layerPaths []string) (*Fi
 			continue
 		}
 
+		// Paths added by the current layer, so an opaque whiteout only hides
+		// lower-layer entries regardless of where it appears in the tar
+		inLayer := make(map[string]bool)
+
 		tr := tar.NewReader(file)
 		for {
 			select {
@@ -505,7 +908,10 @@ func buildFilesystemTreeFromFiles(ctx context.Context, layerPaths []string) (*Fi
 				break
 			}
 			if err != nil {
-				continue
+				// The reader repeats the error for a truncated or non-tar
+				// layer, so keep what was read and move to the next layer
+				log.Printf("Stopped reading layer %s: %v", filepath.Base(layerPath), err)
+				break
 			}
 
 			// Safety limits
@@ -514,16 +920,18 @@ func buildFilesystemTreeFromFiles(ctx context.Context, layerPaths []string) (*Fi
 				break
 			}
 
-			path := "/" + strings.TrimPrefix(header.Name, "./")
-			path = filepath.Clean(path)
-			if path == "." {
-				path = "/"
-			}
+			path := tarEntryPath(header.Name)
 			name := filepath.Base(path)
 
+			// Opaque whiteout: the directory replaces everything below it from lower layers
+			if name == whiteoutOpaque {
+				clearLowerLayerChildren(fileMap, filepath.Dir(path), inLayer)
+				continue
+			}
+
 			// Handle whiteout files (deletions in OCI layers)
-			if strings.HasPrefix(name, ".wh.") {
-				deletedName := strings.TrimPrefix(name, ".wh.")
+			if strings.HasPrefix(name, whiteoutPrefix) {
+				deletedName := strings.TrimPrefix(name, whiteoutPrefix)
 				deletedPath := filepath.Join(filepath.Dir(path), deletedName)
 				deleteFromTree(fileMap, deletedPath)
 				continue
@@ -554,6 +962,13 @@ func buildFilesystemTreeFromFiles(ctx context.Context, layerPaths []string) (*Fi
 			case tar.TypeSymlink:
 				node.Type = "symlink"
 				node.LinkTarget = header.Linkname
+			case tar.TypeLink:
+				// Hardlinks share the target's content, so they don't add to TotalSize
+				node.Type = "hardlink"
+				node.LinkTarget = tarEntryPath(header.Linkname)
+				if target, ok := fileMap[node.LinkTarget]; ok && target.Type == "file" {
+					node.Size = target.Size
+				}
 			default:
 				node.Type = "file"
 				totalSize += header.Size
@@ -561,12 +976,23 @@ func buildFilesystemTreeFromFiles(ctx context.Context, layerPaths []string) (*Fi
 
 			ensureParentDirs(fileMap, path)
 			fileMap[path] = node
+			for p := path; p != "/" && p != "."; p = filepath.Dir(p) {
+				inLayer[p] = true
+			}
 			totalFiles++
 		}
 		file.Close()
 	}
 
-	// Build tree structure from flat map
+	linkFileTree(fileMap)
+	sortFileTree(root)
+	computeDirSizes(root)
+	return root, totalFiles, totalSize, nil
+}
+
+// linkFileTree builds the tree structure from a flat path map by attaching
+// each node to its parent directory
+func linkFileTree(fileMap map[string]*FileNode) {
 	for path, node := range fileMap {
 		if path == "/" {
 			continue
@@ -588,9 +1014,15 @@ func buildFilesystemTreeFromFiles(ctx context.Context, layerPaths []string) (*Fi
 			}
 		}
 	}
+}
 
-	sortFileTree(root)
-	return root, totalFiles, totalSize, nil
+// tarEntryPath converts a tar entry name (or hardlink target) to an absolute tree path
+func tarEntryPath(entryName string) string {
+	path := filepath.Clean("/" + strings.TrimPrefix(entryName, "./"))
+	if path == "." {
+		path = "/"
+	}
+	return path
 }
 
 // ensureParentDirs creates parent directory nodes if they don't exist
@@ -622,6 +1054,82 @@ func deleteFromTree(fileMap map[string]*FileNode, path string) {
 	}
 }
 
+// clearLowerLayerChildren removes everything under dir that was not added by the current layer
+func clearLowerLayerChildren(fileMap map[string]*FileNode, dir string, inLayer map[string]bool) {
+	prefix := dir + "/"
+	if dir == "/" {
+		prefix = "/"
+	}
+	for p := range fileMap {
+		if p != "/" && strings.HasPrefix(p, prefix) && !inLayer[p] {
+			delete(fileMap, p)
+		}
+	}
+}
+
+// computeDirSizes sets TotalSize on every directory in a single post-order pass.
+// Hardlinks and symlinks are excluded so shared content is only counted once.
+func computeDirSizes(node *FileNode) int64 {
+	if node.Type != "dir" {
+		if node.Type == "file" {
+			return node.Size
+		}
+		return 0
+	}
+	var total int64
+	for _, child := range node.Children {
+		total += computeDirSizes(child)
+	}
+	node.TotalSize = total
+	return total
+}
+
+// truncateTree drops children below depth levels, marking cut directories
+// with HasChildren. Returns true if anything was omitted.
+func truncateTree(node *FileNode, depth int) bool {
+	if node == nil || node.Type != "dir" {
+		return false
+	}
+	if depth <= 0 {
+		if len(node.Children) == 0 {
+			return false
+		}
+		node.Children = nil
+		node.HasChildren = true
+		return true
+	}
+	truncated := false
+	for _, child := range node.Children {
+		if truncateTree(child, depth-1) {
+			truncated = true
+		}
+	}
+	return truncated
+}
+
+// findNode returns the node at path within the tree, or nil
+func findNode(root *FileNode, path string) *FileNode {
+	path = tarEntryPath(path)
+	if path == "/" {
+		return root
+	}
+	node := root
+	for _, part := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		var next *FileNode
+		for _, child := range node.Children {
+			if child.Name == part {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
 // sortFileTree recursively sorts the filesystem tree
 func sortFileTree(node *FileNode) {
 	if node.Children == nil {
@@ -643,8 +1151,36 @@ func sortFileTree(node *FileNode) {
 	}
 }
 
+// ListDirectory returns the directory at dirPath with children limited to depth levels (0 = unlimited)
+func (i *Inspector) ListDirectory(ctx context.Context, req InspectRequest, dirPath string, depth int) (*FileNode, error) {
+	fs, err := i.Inspect(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	node := findNode(fs.Root, dirPath)
+	if node == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, dirPath)
+	}
+	if node.Type != "dir" {
+		return nil, fmt.Errorf("%w: %s", ErrNotDirectory, dirPath)
+	}
+
+	if depth > 0 {
+		truncateTree(node, depth)
+	}
+	return node, nil
+}
+
 // GetFileContent retrieves the content of a specific file from an image
 func (i *Inspector) GetFileContent(ctx context.Context, req InspectRequest, filePath string) ([]byte, string, error) {
+	// A cached digest-pinned image needs no registry round trip
+	if layerPaths, _, cached, err := i.cachedPinnedLayers(req.Image); err != nil {
+		return nil, "", err
+	} else if cached {
+		return readFileFromCachedLayers(ctx, layerPaths, filePath)
+	}
+
 	// Fetch image to get digest
 	img, _, err := i.fetchImageBruteForce(ctx, req)
 	if err != nil {
@@ -657,7 +1193,7 @@ func (i *Inspector) GetFileContent(ctx context.Context, req InspectRequest, file
 	}
 
 	// Check if layers are cached
-	layerPaths, _, cached := i.getCachedLayers(digest.String())
+	layerPaths, _, cached := i.lookupCachedLayers(req.Image, digest.String())
 	if !cached {
 		// Cache layers first
 		layerPaths, _, err = i.cacheLayers(ctx, img, req.Image)
@@ -672,12 +1208,20 @@ func (i *Inspector) GetFileContent(ctx context.Context, req InspectRequest, file
 
 // readFileFromCachedLayers reads a specific file from cached layer files
 func readFileFromCachedLayers(ctx context.Context, layerPaths []string, filePath string) ([]byte, string, error) {
+	return readFileFromCachedLayersDepth(ctx, layerPaths, filePath, 0)
+}
+
+// maxHardlinkDepth bounds hardlink resolution in case of malformed layers
+const maxHardlinkDepth = 8
+
+func readFileFromCachedLayersDepth(ctx context.Context, layerPaths []string, filePath string, depth int) ([]byte, string, error) {
 	targetPath := "/" + strings.TrimPrefix(filePath, "/")
 	targetPath = filepath.Clean(targetPath)
 
 	deleted := false
 	var content []byte
 	var filename string
+	var linkTarget string // Set when the final entry for the target is a hardlink
 
 	// Process layers (bottom to top)
 	for _, layerPath := range layerPaths {
@@ -686,6 +1230,8 @@ func readFileFromCachedLayers(ctx context.Context, layerPaths []string, filePath
 			continue
 		}
 
+		foundInLayer := false
+
 		tr := tar.NewReader(file)
 		for {
 			select {
@@ -700,16 +1246,27 @@ func readFileFromCachedLayers(ctx context.Context, layerPaths []string, filePath
 				break
 			}
 			if err != nil {
-				continue
+				break // Truncated or non-tar layer
 			}
 
-			path := "/" + strings.TrimPrefix(header.Name, "./")
-			path = filepath.Clean(path)
+			path := tarEntryPath(header.Name)
 			name := filepath.Base(path)
 
+			// Opaque whiteout hides the target if it lives under this directory
+			// and wasn't (re)added by the current layer
+			if name == whiteoutOpaque {
+				dir := filepath.Dir(path)
+				if !foundInLayer && (dir == "/" || strings.HasPrefix(targetPath, dir+"/")) {
+					deleted = true
+					content = nil
+					filename = ""
+				}
+				continue
+			}
+
 			// Check for whiteout (deletion)
-			if strings.HasPrefix(name, ".wh.") {
-				deletedName := strings.TrimPrefix(name, ".wh.")
+			if strings.HasPrefix(name, whiteoutPrefix) {
+				deletedName := strings.TrimPrefix(name, whiteoutPrefix)
 				deletedPath := filepath.Join(filepath.Dir(path), deletedName)
 				if deletedPath == targetPath {
 					deleted = true
@@ -720,8 +1277,19 @@ func readFileFromCachedLayers(ctx context.Context, layerPaths []string, filePath
 			}
 
 			// Check if this is our target file
+			if path == targetPath && header.Typeflag == tar.TypeLink {
+				deleted = false
+				foundInLayer = true
+				filename = filepath.Base(path)
+				linkTarget = tarEntryPath(header.Linkname)
+				content = []byte{}
+				continue
+			}
+
 			if path == targetPath && header.Typeflag != tar.TypeDir {
 				deleted = false
+				foundInLayer = true
+				linkTarget = ""
 				filename = filepath.Base(path)
 
 				data, err := io.ReadAll(tr)
@@ -736,7 +1304,19 @@ func readFileFromCachedLayers(ctx context.Context, layerPaths []string, filePath
 	}
 
 	if deleted || content == nil {
-		return nil, "", fmt.Errorf("file not found: %s", filePath)
+		return nil, "", fmt.Errorf("%w: %s", ErrPathNotFound, filePath)
+	}
+
+	// Hardlinks carry no data of their own; read the linked file instead
+	if linkTarget != "" {
+		if depth >= maxHardlinkDepth {
+			return nil, "", fmt.Errorf("too many hardlink levels resolving %s", filePath)
+		}
+		linked, _, err := readFileFromCachedLayersDepth(ctx, layerPaths, linkTarget, depth+1)
+		if err != nil {
+			return nil, "", err
+		}
+		return linked, filename, nil
 	}
 
 	return content, filename, nil
@@ -0,0 +1,69 @@
+package images
+
+import (
+	"archive/tar"
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBuildFilesystemTreeFromFiles_Whiteouts(t *testing.T) {
+	dir := t.TempDir()
+	lower := filepath.Join(dir, "lower")
+	upper := filepath.Join(dir, "upper")
+	writeTestLayer(t, lower, []testTarEntry{
+		{name: "./etc/", typeflag: tar.TypeDir},
+		{name: "./etc/config", content: "old"},
+		{name: "./etc/removed", content: "gone"},
+		{name: "./var/cache/", typeflag: tar.TypeDir},
+		{name: "./var/cache/stale", content: "stale"},
+		{name: "./var/cache/tmp/old", content: "old"},
+		{name: "./usr/bin/busybox", content: "binary"},
+		{name: "./usr/bin/sh", link: "usr/bin/busybox", typeflag: tar.TypeLink},
+	})
+	writeTestLayer(t, upper, []testTarEntry{
+		// Entries of the layer can come before its opaque marker
+		{name: "./var/cache/fresh", content: "new!"},
+		{name: "./var/cache/.wh..wh..opq"},
+		{name: "./etc/.wh.removed"},
+		{name: "./etc/config", content: "newer"},
+	})
+
+	root, _, _, err := buildFilesystemTreeFromFiles(context.Background(), []string{lower, upper})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	var etc []string
+	for _, child := range findNode(root, "/etc").Children {
+		etc = append(etc, child.Path)
+	}
+	if !slices.Equal(etc, []string{"/etc/config"}) {
+		t.Errorf("expected only /etc/config left in /etc, got %v", etc)
+	}
+	if config := findNode(root, "/etc/config"); config.Size != int64(len("newer")) {
+		t.Errorf("expected the upper layer's /etc/config, got size %d", config.Size)
+	}
+
+	cache := findNode(root, "/var/cache")
+	var cached []string
+	for _, child := range cache.Children {
+		cached = append(cached, child.Path)
+	}
+	if !slices.Equal(cached, []string{"/var/cache/fresh"}) {
+		t.Errorf("expected the opaque directory to hold only the upper layer's entries, got %v", cached)
+	}
+	if cache.TotalSize != int64(len("new!")) {
+		t.Errorf("expected /var/cache to total %d bytes, got %d", len("new!"), cache.TotalSize)
+	}
+
+	// A hardlink reports its target's size but doesn't count toward totals
+	sh := findNode(root, "/usr/bin/sh")
+	if sh == nil || sh.Type != "hardlink" || sh.LinkTarget != "/usr/bin/busybox" || sh.Size != int64(len("binary")) {
+		t.Errorf("unexpected hardlink node %+v", sh)
+	}
+	if bin := findNode(root, "/usr/bin"); bin.TotalSize != int64(len("binary")) {
+		t.Errorf("expected /usr/bin to count busybox once, got %d bytes", bin.TotalSize)
+	}
+}
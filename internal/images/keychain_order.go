@@ -0,0 +1,66 @@
+package images
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Credential sources GetAuthenticatedKeychain can use, as named in
+// --image-auth-order
+const (
+	KeychainPullSecrets  = "pull-secrets"  // The pod's imagePullSecrets and its service account's
+	KeychainCloud        = "cloud"         // Registry-specific ambient identity, e.g. Google ADC for GCR/Artifact Registry
+	KeychainDockerConfig = "docker-config" // ~/.docker/config.json and its credential helpers
+)
+
+// DefaultKeychainOrder is the credential precedence unless configured otherwise
+var DefaultKeychainOrder = []string{KeychainPullSecrets, KeychainCloud, KeychainDockerConfig}
+
+var (
+	keychainOrderMu sync.RWMutex
+	keychainOrder   = DefaultKeychainOrder
+)
+
+// ParseKeychainOrder parses a comma-separated list of credential sources such
+// as "docker-config,pull-secrets". Sources left out are disabled.
+func ParseKeychainOrder(s string) ([]string, error) {
+	var order []string
+	for _, source := range strings.Split(s, ",") {
+		source = strings.ToLower(strings.TrimSpace(source))
+		switch source {
+		case KeychainPullSecrets, KeychainCloud, KeychainDockerConfig:
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("unknown credential source %q (expected %s, %s or %s)", source, KeychainPullSecrets, KeychainCloud, KeychainDockerConfig)
+		}
+		for _, seen := range order {
+			if seen == source {
+				return nil, fmt.Errorf("credential source %q listed twice", source)
+			}
+		}
+		order = append(order, source)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no credential sources given")
+	}
+	return order, nil
+}
+
+// SetKeychainOrder sets the order in which GetAuthenticatedKeychain tries
+// credential sources; the first source with credentials for a registry
+// wins. Sources left out aren't used at all, e.g. to keep a stale pull
+// secret from shadowing working ambient credentials.
+func SetKeychainOrder(order []string) {
+	keychainOrderMu.Lock()
+	defer keychainOrderMu.Unlock()
+	keychainOrder = order
+}
+
+// KeychainOrder returns the configured credential source order
+func KeychainOrder() []string {
+	keychainOrderMu.RLock()
+	defer keychainOrderMu.RUnlock()
+	return keychainOrder
+}
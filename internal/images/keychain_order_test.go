@@ -0,0 +1,32 @@
+package images
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseKeychainOrder(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"pull-secrets,cloud,docker-config", DefaultKeychainOrder, false},
+		{" Docker-Config , pull-secrets ", []string{KeychainDockerConfig, KeychainPullSecrets}, false},
+		{"cloud", []string{KeychainCloud}, false},
+		{"", nil, true},
+		{"docker-config,docker-config", nil, true},
+		{"ecr", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseKeychainOrder(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseKeychainOrder(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseKeychainOrder(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
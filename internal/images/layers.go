@@ -0,0 +1,113 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// ImageLayers is an image's layers ranked by size, largest first
+type ImageLayers struct {
+	Image     string      `json:"image"`
+	Digest    string      `json:"digest"`
+	Platform  string      `json:"platform"`
+	TotalSize int64       `json:"totalSize"` // Total compressed size of all layers
+	Cached    bool        `json:"cached"`    // Whether uncompressed sizes are known
+	Layers    []LayerInfo `json:"layers"`
+}
+
+// GetLayers returns an image's layers with the build step that created each,
+// sorted by size. Compressed sizes come from the manifest, so nothing is
+// downloaded; uncompressed sizes are included when the image is cached, and
+// rank the layers when known since they are what a RUN step actually adds.
+func (i *Inspector) GetLayers(ctx context.Context, req InspectRequest) (*ImageLayers, error) {
+	img, _, err := i.fetchImageBruteForce(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	layers, err := imageLayerInfos(img)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImageLayers{
+		Image:  req.Image,
+		Digest: digest.String(),
+		Layers: layers,
+	}
+	if configFile, _ := img.ConfigFile(); configFile != nil {
+		result.Platform = fmt.Sprintf("%s/%s", configFile.OS, configFile.Architecture)
+	}
+	for _, layer := range layers {
+		result.TotalSize += layer.Size
+	}
+
+	if layerPaths, _, cached := i.lookupCachedLayers(req.Image, digest.String()); cached && len(layerPaths) == len(layers) {
+		result.Cached = true
+		for idx, layerPath := range layerPaths {
+			if info, err := os.Stat(layerPath); err == nil {
+				layers[idx].UncompressedSize = info.Size()
+			}
+		}
+	}
+
+	sort.SliceStable(layers, func(a, b int) bool {
+		if layers[a].UncompressedSize != layers[b].UncompressedSize {
+			return layers[a].UncompressedSize > layers[b].UncompressedSize
+		}
+		return layers[a].Size > layers[b].Size
+	})
+	return result, nil
+}
+
+// imageLayerInfos returns an image's layers bottom to top with their digest,
+// compressed size and media type from the manifest, and the command that
+// created them from the config history
+func imageLayerInfos(img v1.Image) ([]LayerInfo, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	var createdBy []string
+	if configFile, err := img.ConfigFile(); err == nil && configFile != nil {
+		createdBy = layerCreatedBy(configFile.History)
+	}
+
+	layers := make([]LayerInfo, len(manifest.Layers))
+	for idx, desc := range manifest.Layers {
+		layers[idx] = LayerInfo{
+			Index:     idx,
+			Digest:    desc.Digest.String(),
+			Size:      desc.Size,
+			MediaType: string(desc.MediaType),
+		}
+		if idx < len(createdBy) {
+			layers[idx].CreatedBy = createdBy[idx]
+		}
+	}
+	return layers, nil
+}
+
+// layerCreatedBy returns the history command for each layer. History also has
+// entries for steps that don't produce a layer (ENV, CMD, ...), which are
+// marked empty and skipped so the rest line up with the layers in order.
+func layerCreatedBy(history []v1.History) []string {
+	var result []string
+	for _, h := range history {
+		if h.EmptyLayer {
+			continue
+		}
+		result = append(result, h.CreatedBy)
+	}
+	return result
+}
@@ -0,0 +1,188 @@
+package images
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LayerFilesystem is the file tree of a single image layer: what one build
+// step added, changed or deleted, without the layers below it
+type LayerFilesystem struct {
+	Image      string    `json:"image"`
+	Digest     string    `json:"digest"`
+	Layer      LayerInfo `json:"layer"`
+	Root       *FileNode `json:"root"`
+	TotalFiles int       `json:"totalFiles"`
+	TotalSize  int64     `json:"totalSize"`
+	Deletions  int       `json:"deletions"` // Whiteouts, including opaque directories
+	Truncated  bool      `json:"truncated,omitempty"`
+}
+
+// GetLayerTree returns the file tree of the layer at index (0 = base layer),
+// read from the cached layer tar. Whiteouts are kept as "whiteout" nodes at
+// the deleted path, and opaque whiteouts mark their directory as opaque.
+func (i *Inspector) GetLayerTree(ctx context.Context, req InspectRequest, index int) (*LayerFilesystem, error) {
+	// A cached digest-pinned image needs no registry round trip
+	layerPaths, meta, cached, err := i.cachedPinnedLayers(req.Image)
+	if err != nil {
+		return nil, err
+	}
+	if !cached {
+		img, _, err := i.fetchImageBruteForce(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		digest, err := img.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image digest: %w", err)
+		}
+
+		layerPaths, meta, cached = i.lookupCachedLayers(req.Image, digest.String())
+		if !cached {
+			layerPaths, meta, err = i.cacheLayers(ctx, img, req.Image)
+			if err != nil {
+				return nil, fmt.Errorf("failed to cache layers: %w", err)
+			}
+		}
+	}
+	if index < 0 || index >= len(layerPaths) {
+		return nil, fmt.Errorf("%w: index %d (image has %d layers)", ErrLayerNotFound, index, len(layerPaths))
+	}
+
+	layer := LayerInfo{Index: index, MediaType: "application/vnd.oci.image.layer.v1.tar"}
+	if index < len(meta.LayerDetails) {
+		layer = meta.LayerDetails[index]
+	} else if index < len(meta.Layers) {
+		layer.Digest = meta.Layers[index]
+	}
+	if info, err := os.Stat(layerPaths[index]); err == nil {
+		layer.UncompressedSize = info.Size()
+	}
+
+	root, totalFiles, totalSize, deletions, err := buildLayerTree(ctx, layerPaths[index])
+	if err != nil {
+		return nil, fmt.Errorf("failed to build layer tree: %w", err)
+	}
+
+	return &LayerFilesystem{
+		Image:      req.Image,
+		Digest:     meta.Digest,
+		Layer:      layer,
+		Root:       root,
+		TotalFiles: totalFiles,
+		TotalSize:  totalSize,
+		Deletions:  deletions,
+	}, nil
+}
+
+// buildLayerTree constructs the directory tree of one layer tar. Unlike
+// buildFilesystemTreeFromFiles, whiteouts are not applied but recorded.
+func buildLayerTree(ctx context.Context, layerPath string) (*FileNode, int, int64, int, error) {
+	file, err := os.Open(layerPath)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	defer file.Close()
+
+	fileMap := make(map[string]*FileNode)
+	root := &FileNode{
+		Name:     "/",
+		Path:     "/",
+		Type:     "dir",
+		Children: []*FileNode{},
+	}
+	fileMap["/"] = root
+
+	totalFiles, deletions := 0, 0
+	var totalSize int64
+
+	tr := tar.NewReader(file)
+	for totalFiles < maxFileCount {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, 0, 0, err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, 0, 0, fmt.Errorf("failed to read layer: %w", err)
+		}
+
+		path := tarEntryPath(header.Name)
+		name := filepath.Base(path)
+
+		if name == whiteoutOpaque {
+			dir := filepath.Dir(path)
+			ensureParentDirs(fileMap, path)
+			if node, ok := fileMap[dir]; ok {
+				node.Opaque = true
+			}
+			deletions++
+			continue
+		}
+
+		if strings.HasPrefix(name, whiteoutPrefix) {
+			deletedPath := filepath.Join(filepath.Dir(path), strings.TrimPrefix(name, whiteoutPrefix))
+			ensureParentDirs(fileMap, deletedPath)
+			fileMap[deletedPath] = &FileNode{
+				Name: filepath.Base(deletedPath),
+				Path: deletedPath,
+				Type: "whiteout",
+			}
+			deletions++
+			continue
+		}
+
+		if path == "/" {
+			continue
+		}
+
+		node := &FileNode{
+			Name:        name,
+			Path:        path,
+			Size:        header.Size,
+			Permissions: header.FileInfo().Mode().String(),
+			Mode:        uint32(header.Mode),
+			ModTime:     header.ModTime.Format("2006-01-02 15:04:05"),
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			node.Type = "dir"
+			node.Children = []*FileNode{}
+			if existing, ok := fileMap[path]; ok && existing.Type == "dir" {
+				node.Children = existing.Children
+				node.Opaque = existing.Opaque
+			}
+		case tar.TypeSymlink:
+			node.Type = "symlink"
+			node.LinkTarget = header.Linkname
+		case tar.TypeLink:
+			node.Type = "hardlink"
+			node.LinkTarget = tarEntryPath(header.Linkname)
+			if target, ok := fileMap[node.LinkTarget]; ok && target.Type == "file" {
+				node.Size = target.Size
+			}
+		default:
+			node.Type = "file"
+			totalSize += header.Size
+		}
+
+		ensureParentDirs(fileMap, path)
+		fileMap[path] = node
+		totalFiles++
+	}
+
+	linkFileTree(fileMap)
+	sortFileTree(root)
+	computeDirSizes(root)
+	return root, totalFiles, totalSize, deletions, nil
+}
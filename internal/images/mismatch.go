@@ -0,0 +1,187 @@
+package images
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// ImageVariant is one image digest a container runs across a workload's pods
+type ImageVariant struct {
+	Image   string   `json:"image"`
+	Digest  string   `json:"digest"`
+	Pods    []string `json:"pods"`
+	Outlier bool     `json:"outlier,omitempty"` // Not what most of the workload's pods run
+}
+
+// ContainerImageGroup is what one container runs across a workload's pods
+type ContainerImageGroup struct {
+	Container  string         `json:"container"`
+	Init       bool           `json:"init,omitempty"`
+	Mismatched bool           `json:"mismatched"`        // Pods run more than one digest
+	Variants   []ImageVariant `json:"variants"`          // Most pods first
+	Pending    []string       `json:"pending,omitempty"` // Pods without a running digest yet, e.g. still pulling
+}
+
+// WorkloadImages groups the images of a workload's pods. Pods of all of a
+// Deployment's ReplicaSets are grouped together, which is what shows a
+// rollout stuck with mixed versions.
+type WorkloadImages struct {
+	Kind       string                `json:"kind"` // Owning controller, or Pod for pods without one
+	Name       string                `json:"name"`
+	PodCount   int                   `json:"podCount"`
+	Mismatched bool                  `json:"mismatched"`
+	Containers []ContainerImageGroup `json:"containers"`
+}
+
+// NamespaceImageReport compares the images running in one namespace's pods
+type NamespaceImageReport struct {
+	Namespace  string           `json:"namespace"`
+	Mismatched int              `json:"mismatched"` // Number of workloads with mixed digests
+	Workloads  []WorkloadImages `json:"workloads"`  // Mismatched first
+}
+
+// CompareNamespaceImages groups a namespace's pods by the workload that owns
+// them and flags containers whose running digest differs between the pods of
+// one workload. Only the pod cache is read; nothing is fetched from registries.
+func CompareNamespaceImages(namespace string) (*NamespaceImageReport, error) {
+	cache := k8s.GetResourceCache()
+	if cache == nil || cache.Pods() == nil {
+		return nil, errResourceCacheUnavailable
+	}
+	pods, err := cache.Pods().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	type workloadKey struct{ kind, name string }
+	type containerKey struct {
+		name string
+		init bool
+	}
+	type variantKey struct{ image, digest string }
+	type workloadPods struct {
+		pods       int
+		containers map[containerKey]map[variantKey][]string // Pod names per variant
+		pending    map[containerKey][]string
+	}
+	workloads := make(map[workloadKey]*workloadPods)
+
+	for _, pod := range pods {
+		kind, name := podWorkload(cache, pod)
+		key := workloadKey{kind, name}
+		wl, ok := workloads[key]
+		if !ok {
+			wl = &workloadPods{
+				containers: make(map[containerKey]map[variantKey][]string),
+				pending:    make(map[containerKey][]string),
+			}
+			workloads[key] = wl
+		}
+		wl.pods++
+
+		record := func(containers []corev1.Container, statuses []corev1.ContainerStatus, init bool) {
+			for _, c := range containers {
+				ck := containerKey{c.Name, init}
+				digest := runningDigest(findContainerStatus(statuses, c.Name))
+				if digest == "" {
+					wl.pending[ck] = append(wl.pending[ck], pod.Name)
+					continue
+				}
+				if wl.containers[ck] == nil {
+					wl.containers[ck] = make(map[variantKey][]string)
+				}
+				vk := variantKey{c.Image, digest}
+				wl.containers[ck][vk] = append(wl.containers[ck][vk], pod.Name)
+			}
+		}
+		record(pod.Spec.InitContainers, pod.Status.InitContainerStatuses, true)
+		record(pod.Spec.Containers, pod.Status.ContainerStatuses, false)
+	}
+
+	report := &NamespaceImageReport{Namespace: namespace, Workloads: []WorkloadImages{}}
+	for key, wl := range workloads {
+		result := WorkloadImages{Kind: key.kind, Name: key.name, PodCount: wl.pods}
+
+		names := make(map[containerKey]bool)
+		for ck := range wl.containers {
+			names[ck] = true
+		}
+		for ck := range wl.pending {
+			names[ck] = true
+		}
+		for ck := range names {
+			group := ContainerImageGroup{Container: ck.name, Init: ck.init, Variants: []ImageVariant{}, Pending: wl.pending[ck]}
+			for vk, podNames := range wl.containers[ck] {
+				sort.Strings(podNames)
+				group.Variants = append(group.Variants, ImageVariant{Image: vk.image, Digest: vk.digest, Pods: podNames})
+			}
+			sort.Slice(group.Variants, func(a, b int) bool {
+				va, vb := group.Variants[a], group.Variants[b]
+				if len(va.Pods) != len(vb.Pods) {
+					return len(va.Pods) > len(vb.Pods)
+				}
+				return va.Digest < vb.Digest
+			})
+			// Pods agree on the digest even if some refer to it by a different tag
+			digests := make(map[string]bool)
+			for _, v := range group.Variants {
+				digests[v.Digest] = true
+			}
+			if len(digests) > 1 {
+				group.Mismatched = true
+				result.Mismatched = true
+				for idx := range group.Variants {
+					group.Variants[idx].Outlier = group.Variants[idx].Digest != group.Variants[0].Digest
+				}
+			}
+			sort.Strings(group.Pending)
+			result.Containers = append(result.Containers, group)
+		}
+		sort.Slice(result.Containers, func(a, b int) bool {
+			ca, cb := result.Containers[a], result.Containers[b]
+			if ca.Init != cb.Init {
+				return ca.Init
+			}
+			return ca.Container < cb.Container
+		})
+
+		if result.Mismatched {
+			report.Mismatched++
+		}
+		report.Workloads = append(report.Workloads, result)
+	}
+	sort.Slice(report.Workloads, func(a, b int) bool {
+		wa, wb := report.Workloads[a], report.Workloads[b]
+		if wa.Mismatched != wb.Mismatched {
+			return wa.Mismatched
+		}
+		if wa.Kind != wb.Kind {
+			return wa.Kind < wb.Kind
+		}
+		return wa.Name < wb.Name
+	})
+	return report, nil
+}
+
+// podWorkload returns the kind and name of the controller a pod belongs to,
+// following a ReplicaSet up to its Deployment. Pods without a controller are
+// their own workload.
+func podWorkload(cache *k8s.ResourceCache, pod *corev1.Pod) (kind, name string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", pod.Name
+	}
+	if owner.Kind == "ReplicaSet" {
+		if rs, err := cache.ReplicaSets().ReplicaSets(pod.Namespace).Get(owner.Name); err == nil {
+			if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil {
+				return rsOwner.Kind, rsOwner.Name
+			}
+		}
+	}
+	return owner.Kind, owner.Name
+}
@@ -0,0 +1,263 @@
+package images
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// persistentCacheTTL is how long cached images are kept when the cache
+// persists across restarts. The default TTL is tuned for one browsing
+// session; a persistent cache is meant to stay warm between them. The image
+// count and size limits still apply.
+const persistentCacheTTL = 24 * time.Hour
+
+// defaultCleanupInterval is how often expired images are removed by default
+const defaultCleanupInterval = time.Minute
+
+// persistCache makes new inspectors keep and validate the existing cache
+// directory instead of wiping it
+var persistCache atomic.Bool
+
+// SetPersistentCache makes the layer cache survive restarts: on startup the
+// existing entries are validated against their metadata and layer digests
+// instead of being removed. Must be called before NewHandlers.
+func SetPersistentCache(enabled bool) {
+	persistCache.Store(enabled)
+}
+
+// cacheTTLOverride and cacheCleanupInterval hold the configured cache
+// lifetime in nanoseconds; zero means the default
+var (
+	cacheTTLOverride     atomic.Int64
+	cacheCleanupInterval atomic.Int64
+)
+
+// SetCacheLifetime sets how long a cached image stays valid and how often
+// the cache is swept for expired images. Zero or negative keeps the
+// defaults: a TTL of 5m (24h with a persistent cache) and a sweep every
+// minute. Must be called before NewHandlers.
+func SetCacheLifetime(ttl, cleanupInterval time.Duration) {
+	cacheTTLOverride.Store(int64(max(ttl, 0)))
+	cacheCleanupInterval.Store(int64(max(cleanupInterval, 0)))
+}
+
+// cleanupInterval returns how often expired images are removed
+func cleanupInterval() time.Duration {
+	if d := time.Duration(cacheCleanupInterval.Load()); d > 0 {
+		return d
+	}
+	return defaultCleanupInterval
+}
+
+// cacheTTL returns how long a cached image stays valid
+func cacheTTL() time.Duration {
+	if d := time.Duration(cacheTTLOverride.Load()); d > 0 {
+		return d
+	}
+	if persistCache.Load() {
+		return persistentCacheTTL
+	}
+	return layerCacheTTL
+}
+
+// CacheValidation summarizes the startup check of a persisted cache
+type CacheValidation struct {
+	ValidatedAt   time.Time `json:"validatedAt"`
+	Duration      string    `json:"duration"`
+	Kept          int       `json:"kept"`          // Images whose metadata and layers checked out
+	Removed       int       `json:"removed"`       // Images dropped as expired, incomplete or corrupt
+	RemovedLayers int       `json:"removedLayers"` // Layer files that failed their digest check
+}
+
+// CacheStatus describes the on-disk layer cache
+type CacheStatus struct {
+	Dir        string           `json:"dir"`
+	Persistent bool             `json:"persistent"` // Survives restarts (--persist-cache)
+	TTL        string           `json:"ttl"`
+	Images     int              `json:"images"`
+	Layers     int              `json:"layers"`
+	Size       int64            `json:"size"` // Bytes used by the layer store
+	Validation *CacheValidation `json:"validation,omitempty"`
+}
+
+// CacheStatus returns the current state of the layer cache
+func (i *Inspector) CacheStatus() *CacheStatus {
+	i.cacheMu.RLock()
+	defer i.cacheMu.RUnlock()
+
+	status := &CacheStatus{
+		Dir:        i.cacheDir,
+		Persistent: i.persistent,
+		TTL:        cacheTTL().String(),
+		Size:       i.layerStoreSize(),
+		Validation: i.validation,
+	}
+	if entries, err := i.listCacheEntries(); err == nil {
+		status.Images = len(entries)
+	}
+	if blobs, err := os.ReadDir(filepath.Join(i.cacheDir, layerStoreDir)); err == nil {
+		status.Layers = len(blobs)
+	}
+	return status
+}
+
+// ClearCache removes every cached image and layer
+func (i *Inspector) ClearCache() {
+	i.cleanCacheDir()
+}
+
+// validateCacheDir checks a cache directory left by a previous run and
+// removes what can't be trusted: leftover temp files, images with missing or
+// unreadable metadata, expired images, images missing a layer, and layers
+// whose size or digest doesn't match what was recorded when they were
+// downloaded. It hashes every layer, so it runs in the background; requests
+// wait on cacheMu until it's done.
+func (i *Inspector) validateCacheDir() {
+	i.cacheMu.Lock()
+	defer i.cacheMu.Unlock()
+
+	start := time.Now()
+	result := &CacheValidation{}
+
+	if err := os.MkdirAll(i.cacheDir, 0755); err != nil {
+		log.Printf("Warning: failed to create cache directory: %v", err)
+	}
+	blobsDir := filepath.Join(i.cacheDir, layerStoreDir)
+	if blobs, err := os.ReadDir(blobsDir); err == nil {
+		for _, blob := range blobs {
+			if strings.HasSuffix(blob.Name(), ".tmp") {
+				os.Remove(filepath.Join(blobsDir, blob.Name()))
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(i.cacheDir)
+	if err != nil {
+		log.Printf("Warning: failed to read cache directory: %v", err)
+		return
+	}
+
+	// Each layer is checked once, however many images share it
+	layerOK := make(map[string]bool)
+	checkLayer := func(digest, diffID string, size int64) bool {
+		path := i.layerBlobPath(digest)
+		if ok, checked := layerOK[path]; checked {
+			return ok
+		}
+		ok := verifyLayerFile(path, diffID, size)
+		layerOK[path] = ok
+		if !ok {
+			if _, err := os.Stat(path); err == nil {
+				os.Remove(path)
+				result.RemovedLayers++
+			}
+		}
+		return ok
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == layerStoreDir {
+			continue
+		}
+		entryPath := filepath.Join(i.cacheDir, entry.Name())
+		if !entry.IsDir() {
+			// Write checks and other stray files
+			os.Remove(entryPath)
+			continue
+		}
+
+		meta, reason := readCacheMetadata(entryPath)
+		if reason == "" && getCacheKey(meta.Digest) != entry.Name() {
+			reason = "digest doesn't match the cache key"
+		}
+		if reason == "" && time.Since(meta.CachedAt) >= cacheTTL() {
+			reason = "expired"
+		}
+		if reason == "" {
+			for idx, layerDigest := range meta.Layers {
+				var diffID string
+				if idx < len(meta.DiffIDs) {
+					diffID = meta.DiffIDs[idx]
+				}
+				var size int64
+				if idx < len(meta.LayerDetails) {
+					size = meta.LayerDetails[idx].UncompressedSize
+				}
+				if !checkLayer(layerDigest, diffID, size) {
+					reason = fmt.Sprintf("layer %d is missing or corrupt", idx)
+					break
+				}
+			}
+		}
+
+		if reason != "" {
+			os.RemoveAll(entryPath)
+			result.Removed++
+			log.Printf("Removed cached image %s: %s", entry.Name(), reason)
+			continue
+		}
+		result.Kept++
+	}
+
+	i.pruneUnreferencedLayers()
+
+	result.ValidatedAt = time.Now()
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	i.validation = result
+	log.Printf("Validated persisted image layer cache in %s: kept %d images, removed %d (%d corrupt layers)",
+		result.Duration, result.Kept, result.Removed, result.RemovedLayers)
+}
+
+// readCacheMetadata reads an image's cache metadata, returning the reason it
+// can't be used if it's unreadable or inconsistent
+func readCacheMetadata(imageDir string) (*layerCacheMetadata, string) {
+	data, err := os.ReadFile(filepath.Join(imageDir, "metadata.json"))
+	if err != nil {
+		return nil, "no metadata"
+	}
+	var meta layerCacheMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, "unreadable metadata"
+	}
+	if meta.Digest == "" || len(meta.Layers) != meta.LayerCount {
+		return nil, "incomplete metadata"
+	}
+	return &meta, ""
+}
+
+// verifyLayerFile reports whether the layer file at path exists and matches
+// the recorded uncompressed size and digest. Either check is skipped when
+// nothing was recorded for it (entries cached by older versions).
+func verifyLayerFile(path, diffID string, size int64) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if size > 0 && info.Size() != size {
+		return false
+	}
+	algorithm, want, ok := strings.Cut(diffID, ":")
+	if !ok || algorithm != "sha256" {
+		return true
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hash.Sum(nil)) == want
+}
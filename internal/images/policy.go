@@ -0,0 +1,88 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// ErrRegistryNotAllowed is returned when an image's registry is blocked by the registry policy
+var ErrRegistryNotAllowed = errors.New("registry not allowed")
+
+// registryPolicy restricts which registries images may be inspected from.
+// An empty allowlist allows every registry that is not denied.
+type registryPolicy struct {
+	mu    sync.RWMutex
+	allow []string
+	deny  []string
+}
+
+var policy registryPolicy
+
+// SetRegistryPolicy configures the registry allowlist and denylist.
+// Entries are registry hosts (e.g. "gcr.io", "registry.internal:5000") or
+// wildcard domains (e.g. "*.corp.example.com"). The denylist takes precedence.
+func SetRegistryPolicy(allow, deny []string) {
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	policy.allow = normalizeRegistryPatterns(allow)
+	policy.deny = normalizeRegistryPatterns(deny)
+}
+
+// checkRegistryAllowed returns ErrRegistryNotAllowed if the registry of ref is blocked
+func checkRegistryAllowed(ref name.Reference) error {
+	registry := ref.Context().RegistryStr()
+
+	policy.mu.RLock()
+	defer policy.mu.RUnlock()
+
+	if matchesRegistry(policy.deny, registry) {
+		return fmt.Errorf("%w: %s is denied by the registry policy", ErrRegistryNotAllowed, registry)
+	}
+	if len(policy.allow) > 0 && !matchesRegistry(policy.allow, registry) {
+		return fmt.Errorf("%w: %s is not in the registry allowlist", ErrRegistryNotAllowed, registry)
+	}
+	return nil
+}
+
+// normalizeRegistryPatterns trims and canonicalizes registry patterns so that
+// aliases like "docker.io" match the registry name go-containerregistry reports
+func normalizeRegistryPatterns(patterns []string) []string {
+	var result []string
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if strings.HasPrefix(p, "*.") {
+			result = append(result, p)
+			continue
+		}
+		if reg, err := name.NewRegistry(p); err == nil {
+			p = reg.RegistryStr()
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// matchesRegistry reports whether registry matches any of the patterns
+func matchesRegistry(patterns []string, registry string) bool {
+	registry = strings.ToLower(registry)
+	for _, p := range patterns {
+		if suffix, ok := strings.CutPrefix(p, "*"); ok {
+			// "*.example.com" matches subdomains, not example.com itself
+			if strings.HasSuffix(registry, suffix) {
+				return true
+			}
+			continue
+		}
+		if registry == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package images
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+const (
+	prewarmInterval = 15 * time.Second // Delay between prewarm inspections to avoid hammering registries
+	prewarmTimeout  = 5 * time.Minute  // Max time spent inspecting a single image
+)
+
+// prewarmCandidate is a distinct image running in the cluster
+type prewarmCandidate struct {
+	image     string
+	namespace string // Namespace of a pod running the image (for pull secrets)
+	pod       string // Name of a pod running the image (for pull secrets)
+	podCount  int
+}
+
+// StartPrewarm inspects up to limit of the most widely used running images in the background
+func (h *Handlers) StartPrewarm(limit int) {
+	if limit <= 0 {
+		return
+	}
+	go h.inspector.prewarm(context.Background(), limit)
+}
+
+// prewarm inspects the most common running images one at a time so they are cached on first click
+func (i *Inspector) prewarm(ctx context.Context, limit int) {
+	candidates := runningImages()
+	if len(candidates) == 0 {
+		return
+	}
+
+	// Warming more images than the cache holds would just evict the earlier ones
+	if limit > maxCachedImages {
+		limit = maxCachedImages
+	}
+	if limit > len(candidates) {
+		limit = len(candidates)
+	}
+
+	log.Printf("Prewarming image cache for %d of %d running images", limit, len(candidates))
+
+	ticker := time.NewTicker(prewarmInterval)
+	defer ticker.Stop()
+
+	for idx, c := range candidates[:limit] {
+		if idx > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		req := InspectRequest{
+			Image:           c.image,
+			Namespace:       c.namespace,
+			PodName:         c.pod,
+			PullSecretNames: GetPullSecretsFromPod(c.namespace, c.pod),
+		}
+
+		inspectCtx, cancel := context.WithTimeout(ctx, prewarmTimeout)
+		inspectCtx, cancelPull := withPullTimeout(inspectCtx)
+		_, err := i.Inspect(inspectCtx, req)
+		cancelPull()
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to prewarm image %s: %v", c.image, err)
+			continue
+		}
+		log.Printf("Prewarmed image cache for %s (%d pods)", c.image, c.podCount)
+	}
+}
+
+// runningImages returns distinct container images from the pod cache, most used first
+func runningImages() []prewarmCandidate {
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		return nil
+	}
+	podLister := cache.Pods()
+	if podLister == nil {
+		return nil
+	}
+
+	pods, err := podLister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	byImage := make(map[string]*prewarmCandidate)
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if container.Image == "" {
+				continue
+			}
+			c, ok := byImage[container.Image]
+			if !ok {
+				c = &prewarmCandidate{
+					image:     container.Image,
+					namespace: pod.Namespace,
+					pod:       pod.Name,
+				}
+				byImage[container.Image] = c
+			}
+			c.podCount++
+		}
+	}
+
+	candidates := make([]prewarmCandidate, 0, len(byImage))
+	for _, c := range byImage {
+		candidates = append(candidates, *c)
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].podCount != candidates[b].podCount {
+			return candidates[a].podCount > candidates[b].podCount
+		}
+		return candidates[a].image < candidates[b].image
+	})
+	return candidates
+}
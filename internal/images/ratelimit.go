@@ -0,0 +1,103 @@
+package images
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+)
+
+const (
+	rateLimitBurst   = 10               // Requests a client may make back-to-back before being throttled
+	rateLimitIdleTTL = 10 * time.Minute // Idle clients are forgotten after this long
+)
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// limits holds the per-client rate limit for image endpoints
+var limits = struct {
+	sync.Mutex
+	perMinute int
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}{clients: make(map[string]*clientLimiter)}
+
+// SetRateLimit limits each client to perMinute requests to the image
+// endpoints, with short bursts allowed. Zero or negative disables limiting.
+func SetRateLimit(perMinute int) {
+	limits.Lock()
+	defer limits.Unlock()
+	limits.perMinute = perMinute
+	limits.clients = make(map[string]*clientLimiter)
+}
+
+// rateLimit rejects requests over the configured limit with 429 and a
+// Retry-After header. Clients are keyed by remote IP.
+func rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := limiterFor(clientKey(r))
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reservation := limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			httperr.Write(w, http.StatusTooManyRequests, httperr.CodeRateLimited,
+				fmt.Sprintf("Too many image requests, retry in %ds", retryAfter))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limiterFor returns the limiter for a client, or nil if limiting is disabled
+func limiterFor(key string) *rate.Limiter {
+	limits.Lock()
+	defer limits.Unlock()
+
+	if limits.perMinute <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Sub(limits.lastSweep) > rateLimitIdleTTL {
+		for k, c := range limits.clients {
+			if now.Sub(c.lastSeen) > rateLimitIdleTTL {
+				delete(limits.clients, k)
+			}
+		}
+		limits.lastSweep = now
+	}
+
+	c, ok := limits.clients[key]
+	if !ok {
+		burst := min(rateLimitBurst, limits.perMinute)
+		c = &clientLimiter{limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(limits.perMinute)), burst)}
+		limits.clients[key] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
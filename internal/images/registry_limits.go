@@ -0,0 +1,183 @@
+package images
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// RegistryRateLimit is the pull budget a registry last reported, from the
+// RateLimit headers Docker Hub (and some others) send on manifest requests
+type RegistryRateLimit struct {
+	Registry      string     `json:"registry"`
+	Limit         int        `json:"limit,omitempty"`         // Pulls allowed per window (0 = not reported)
+	Remaining     int        `json:"remaining"`               // Pulls left in the window
+	WindowSeconds int        `json:"windowSeconds,omitempty"` // Length of the window, e.g. 21600 for 6 hours
+	ResetsAt      *time.Time `json:"resetsAt,omitempty"`      // When pulls are available again, if the registry said
+	Anonymous     bool       `json:"anonymous,omitempty"`     // The budget is counted per IP rather than per account
+	Throttled     bool       `json:"throttled"`               // The last response was 429 Too Many Requests
+	ObservedAt    time.Time  `json:"observedAt"`
+	Summary       string     `json:"summary"` // e.g. "0 of 100 anonymous pulls remaining, resets in 20m"
+}
+
+var (
+	registryLimits   = make(map[string]*RegistryRateLimit)
+	registryLimitsMu sync.Mutex
+)
+
+// rateLimitTransport records the rate limit headers of every registry
+// response. It sits below retryTransport so throttled attempts are seen too.
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		recordRateLimit(req.URL.Host, resp, time.Now())
+	}
+	return resp, err
+}
+
+// recordRateLimit updates the registry's budget from a response. Responses
+// without rate limit headers only clear or set the throttled state of a
+// registry already seen, so blob downloads don't erase the last budget.
+func recordRateLimit(registry string, resp *http.Response, now time.Time) {
+	limit, window, hasLimit := parseRateLimitHeader(resp.Header.Get("RateLimit-Limit"))
+	remaining, _, hasRemaining := parseRateLimitHeader(resp.Header.Get("RateLimit-Remaining"))
+	throttled := resp.StatusCode == http.StatusTooManyRequests
+
+	registryLimitsMu.Lock()
+	defer registryLimitsMu.Unlock()
+
+	entry, ok := registryLimits[registry]
+	if !ok {
+		if !hasLimit && !hasRemaining && !throttled {
+			return
+		}
+		entry = &RegistryRateLimit{Registry: registry}
+		registryLimits[registry] = entry
+	}
+
+	if hasLimit {
+		entry.Limit = limit
+		entry.WindowSeconds = window
+	}
+	if hasRemaining {
+		entry.Remaining = remaining
+	} else if throttled {
+		entry.Remaining = 0
+	}
+	if source := resp.Header.Get("Docker-RateLimit-Source"); source != "" {
+		entry.Anonymous = net.ParseIP(source) != nil
+	}
+
+	entry.ResetsAt = nil
+	if reset, err := strconv.Atoi(resp.Header.Get("RateLimit-Reset")); err == nil && reset >= 0 {
+		at := now.Add(time.Duration(reset) * time.Second)
+		entry.ResetsAt = &at
+	} else if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok && throttled {
+		at := now.Add(after)
+		entry.ResetsAt = &at
+	}
+	entry.Throttled = throttled
+	entry.ObservedAt = now
+}
+
+// parseRateLimitHeader parses a RateLimit header value like "100;w=21600"
+// into the count and the window in seconds
+func parseRateLimitHeader(value string) (count, window int, ok bool) {
+	if value == "" {
+		return 0, 0, false
+	}
+	parts := strings.Split(value, ";")
+	count, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || count < 0 {
+		return 0, 0, false
+	}
+	for _, param := range parts[1:] {
+		if w, found := strings.CutPrefix(strings.TrimSpace(param), "w="); found {
+			window, _ = strconv.Atoi(w)
+		}
+	}
+	return count, window, true
+}
+
+// RegistryRateLimits returns the last reported budget of every registry that
+// sent rate limit headers or throttled a request, by registry
+func RegistryRateLimits() []RegistryRateLimit {
+	registryLimitsMu.Lock()
+	defer registryLimitsMu.Unlock()
+
+	now := time.Now()
+	limits := make([]RegistryRateLimit, 0, len(registryLimits))
+	for _, entry := range registryLimits {
+		limit := *entry
+		limit.Summary = limit.summary(now)
+		limits = append(limits, limit)
+	}
+	sort.Slice(limits, func(a, b int) bool { return limits[a].Registry < limits[b].Registry })
+	return limits
+}
+
+// registryRateLimitFor returns the last reported budget of an image's registry
+func registryRateLimitFor(image string) (RegistryRateLimit, bool) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return RegistryRateLimit{}, false
+	}
+	registryLimitsMu.Lock()
+	defer registryLimitsMu.Unlock()
+	entry, ok := registryLimits[ref.Context().RegistryStr()]
+	if !ok {
+		return RegistryRateLimit{}, false
+	}
+	return *entry, true
+}
+
+// summary describes the budget for people, e.g. "0 of 100 anonymous pulls
+// remaining, resets in 20m" or "76 of 200 pulls remaining per 6h"
+func (l RegistryRateLimit) summary(now time.Time) string {
+	pulls := "pulls"
+	if l.Anonymous {
+		pulls = "anonymous pulls"
+	}
+
+	var text string
+	switch {
+	case l.Limit > 0:
+		text = fmt.Sprintf("%d of %d %s remaining", l.Remaining, l.Limit, pulls)
+	case l.Throttled:
+		text = "Registry is throttling requests"
+	default:
+		text = fmt.Sprintf("%d %s remaining", l.Remaining, pulls)
+	}
+
+	switch {
+	case l.ResetsAt != nil && l.ResetsAt.After(now):
+		text += ", resets in " + formatResetIn(l.ResetsAt.Sub(now))
+	case l.Limit > 0 && l.WindowSeconds > 0:
+		text += " per " + formatResetIn(time.Duration(l.WindowSeconds)*time.Second)
+	}
+	return text
+}
+
+// formatResetIn formats a duration in whole minutes, like 20m, 6h or 1h5m
+func formatResetIn(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	hours, minutes := minutes/60, minutes%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", max(minutes, 1))
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%dm", hours, minutes)
+}
@@ -0,0 +1,95 @@
+package images
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	remaining := "76;w=21600"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/blob" {
+			return
+		}
+		w.Header().Set("RateLimit-Limit", "100;w=21600")
+		w.Header().Set("RateLimit-Remaining", remaining)
+		w.Header().Set("Docker-RateLimit-Source", "203.0.113.7")
+		if remaining == "0;w=21600" {
+			w.Header().Set("Retry-After", "1200")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+	t.Cleanup(func() {
+		registryLimitsMu.Lock()
+		delete(registryLimits, host)
+		registryLimitsMu.Unlock()
+	})
+
+	client := &http.Client{Transport: &rateLimitTransport{next: http.DefaultTransport}}
+	get := func(path string) {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	limitFor := func() RegistryRateLimit {
+		for _, l := range RegistryRateLimits() {
+			if l.Registry == host {
+				return l
+			}
+		}
+		t.Fatalf("no rate limit recorded for %s", host)
+		return RegistryRateLimit{}
+	}
+
+	get("/v2/app/manifests/v1")
+	get("/blob")
+	l := limitFor()
+	if l.Limit != 100 || l.Remaining != 76 || l.WindowSeconds != 21600 || !l.Anonymous || l.Throttled {
+		t.Errorf("unexpected budget: %+v", l)
+	}
+	if l.Summary != "76 of 100 anonymous pulls remaining per 6h" {
+		t.Errorf("unexpected summary %q", l.Summary)
+	}
+
+	remaining = "0;w=21600"
+	get("/v2/app/manifests/v2")
+	l = limitFor()
+	if !l.Throttled || l.Remaining != 0 || l.ResetsAt == nil {
+		t.Errorf("expected a throttled budget with a reset time, got %+v", l)
+	}
+	if l.Summary != "0 of 100 anonymous pulls remaining, resets in 20m" {
+		t.Errorf("unexpected summary %q", l.Summary)
+	}
+}
+
+func TestParseRateLimitHeader(t *testing.T) {
+	if count, window, ok := parseRateLimitHeader("200;w=21600"); !ok || count != 200 || window != 21600 {
+		t.Errorf("got %d, %d, %v", count, window, ok)
+	}
+	if count, window, ok := parseRateLimitHeader("5"); !ok || count != 5 || window != 0 {
+		t.Errorf("got %d, %d, %v", count, window, ok)
+	}
+	if _, _, ok := parseRateLimitHeader("lots"); ok {
+		t.Error("expected an invalid header to be ignored")
+	}
+}
+
+func TestFormatResetIn(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		10 * time.Second: "1m",
+		20 * time.Minute: "20m",
+		6 * time.Hour:    "6h",
+		time.Hour + 4*time.Minute + 30*time.Second: "1h5m",
+	} {
+		if got := formatResetIn(d); got != want {
+			t.Errorf("formatResetIn(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// ResolvedReference is an image reference in fully-qualified form along with
+// the digest its tag currently points to in the registry
+type ResolvedReference struct {
+	Image        string `json:"image"`     // As given
+	Reference    string `json:"reference"` // Fully-qualified, e.g. docker.io/library/nginx:latest
+	Registry     string `json:"registry"`
+	Repository   string `json:"repository"`
+	Tag          string `json:"tag,omitempty"`
+	Digest       string `json:"digest"`                 // Digest the reference currently resolves to
+	PinnedDigest string `json:"pinnedDigest,omitempty"` // Digest given in the reference, if any
+	TagMoved     bool   `json:"tagMoved,omitempty"`     // Tag and digest were both given and the tag no longer points to the digest
+	MediaType    string `json:"mediaType,omitempty"`
+	AuthMethod   string `json:"authMethod"`
+}
+
+// parseImageReference parses a reference, keeping the tag when both a tag and
+// a digest are given (name.ParseReference drops the tag in that case)
+func parseImageReference(image string) (ref name.Reference, tag string, pinned string, err error) {
+	ref, err = name.ParseReference(image)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid image reference: %w", err)
+	}
+
+	switch r := ref.(type) {
+	case name.Tag:
+		return r, r.TagStr(), "", nil
+	case name.Digest:
+		pinned = r.DigestStr()
+		// A tag is a colon after the last path segment; earlier colons are registry ports
+		before, _, _ := strings.Cut(image, "@")
+		if strings.Contains(before[strings.LastIndex(before, "/")+1:], ":") {
+			if t, err := name.NewTag(before); err == nil {
+				tag = t.TagStr()
+			}
+		}
+		return r, tag, pinned, nil
+	}
+	return ref, "", "", nil
+}
+
+// displayRegistry returns the registry name users know, mapping Docker Hub's
+// API host back to docker.io
+func displayRegistry(repo name.Repository) string {
+	if repo.RegistryStr() == name.DefaultRegistry {
+		return "docker.io"
+	}
+	return repo.RegistryStr()
+}
+
+// ResolveReference normalizes an image reference and looks up its current digest
+func (i *Inspector) ResolveReference(ctx context.Context, req InspectRequest) (*ResolvedReference, error) {
+	ref, tag, pinned, err := parseImageReference(req.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := ref.Context()
+	registry := displayRegistry(repo)
+	result := &ResolvedReference{
+		Image:        req.Image,
+		Registry:     registry,
+		Repository:   repo.RepositoryStr(),
+		Tag:          tag,
+		PinnedDigest: pinned,
+	}
+
+	full := registry + "/" + repo.RepositoryStr()
+	if tag != "" {
+		full += ":" + tag
+	}
+	if pinned != "" {
+		full += "@" + pinned
+	}
+	result.Reference = full
+
+	// Resolve the tag rather than the digest when both are given, so a moved
+	// tag shows up
+	lookup := ref
+	if tag != "" {
+		lookup = repo.Tag(tag)
+	}
+
+	desc, authMethod, err := i.getDescriptorBruteForce(ctx, lookup, req)
+	if err != nil {
+		return nil, err
+	}
+	result.Digest = desc.Digest.String()
+	result.MediaType = string(desc.MediaType)
+	result.AuthMethod = authMethod
+
+	if pinned != "" && tag != "" {
+		result.TagMoved = !descriptorHasDigest(desc, pinned)
+	}
+
+	return result, nil
+}
+
+// getDescriptorBruteForce fetches the manifest descriptor for a reference,
+// trying anonymous auth first like fetchImageBruteForce. Unlike remote.Image,
+// this doesn't pick a platform, so the digest is the one the tag points to.
+func (i *Inspector) getDescriptorBruteForce(ctx context.Context, ref name.Reference, req InspectRequest) (*remote.Descriptor, string, error) {
+	if err := checkRegistryAllowed(ref); err != nil {
+		return nil, "", err
+	}
+
+	desc, err := remote.Get(ref, registryOptions(ctx, remote.WithAuth(authn.Anonymous))...)
+	if err == nil {
+		return desc, "anonymous", nil
+	}
+
+	log.Printf("Anonymous auth failed for %s, trying with credentials: %v", ref, err)
+
+	keychain := GetAuthenticatedKeychain(req.Image, req.Namespace, req.PullSecretNames)
+	desc, err = remote.Get(ref, registryOptions(ctx, remote.WithAuthFromKeychain(keychain))...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch image: %w", err)
+	}
+	return desc, string(DetectRegistryType(req.Image)), nil
+}
+
+// descriptorHasDigest reports whether a digest is the descriptor itself or,
+// for a multi-platform index, one of its platform manifests. Runtimes may
+// record either depending on how the image was pulled.
+func descriptorHasDigest(desc *remote.Descriptor, digest string) bool {
+	if desc.Digest.String() == digest {
+		return true
+	}
+	if !desc.MediaType.IsIndex() {
+		return false
+	}
+	index, err := desc.ImageIndex()
+	if err != nil {
+		return false
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return false
+	}
+	for _, m := range manifest.Manifests {
+		if m.Digest.String() == digest {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package images
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	registryMaxAttempts   = 4
+	registryRetryBase     = time.Second
+	registryRetryMaxDelay = 30 * time.Second // Cap for both backoff and Retry-After
+)
+
+// registryTransport retries registry requests that fail with a transient
+// status and records the rate limits registries report, shared by all
+// registry calls
+var registryTransport http.RoundTripper = &retryTransport{next: &rateLimitTransport{next: remote.DefaultTransport}}
+
+// retryTransport retries GET and HEAD requests on 429 and 5xx responses with
+// exponential backoff, honoring Retry-After. Permanent errors like 401 and 404
+// are returned straight away.
+type retryTransport struct {
+	next http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+
+	delay := registryRetryBase
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !isTransientStatus(resp.StatusCode) || attempt == registryMaxAttempts {
+			return resp, err
+		}
+
+		wait := delay
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			wait = after
+		}
+		wait = min(wait, registryRetryMaxDelay)
+
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		log.Printf("Registry returned %d for %s, retrying in %s (attempt %d/%d)", resp.StatusCode, req.URL.Host, wait, attempt+1, registryMaxAttempts)
+		if err := sleepCtx(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		delay *= 2
+	}
+}
+
+// isTransientStatus reports whether a registry status is worth retrying
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// registryOptions returns the remote options for a registry call with the
+// given auth option. Transient statuses are retried by registryTransport, so
+// go-containerregistry's own status retries are limited to request timeouts
+// to avoid retrying twice.
+func registryOptions(ctx context.Context, auth remote.Option) []remote.Option {
+	return []remote.Option{
+		remote.WithContext(ctx),
+		auth,
+		remote.WithTransport(registryTransport),
+		remote.WithRetryStatusCodes(http.StatusRequestTimeout),
+	}
+}
@@ -0,0 +1,295 @@
+package images
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	// signatureCheckTimeout bounds the extra registry lookups a signature check adds
+	signatureCheckTimeout = 15 * time.Second
+
+	// Signature results are kept per digest, since each check costs several
+	// registry requests. Signatures can be added to an image after it is
+	// pushed, so results expire.
+	signatureCacheTTL        = 10 * time.Minute
+	signatureCacheMaxEntries = 256
+)
+
+type cachedSignature struct {
+	status  *SignatureStatus
+	checked time.Time
+}
+
+var (
+	signatureCache   = make(map[string]*cachedSignature)
+	signatureCacheMu sync.Mutex
+)
+
+// Artifact types and annotations cosign uses for signatures and attestations
+const (
+	cosignSignatureArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	sigstoreBundleArtifactType  = "application/vnd.dev.sigstore.bundle.v0.3+json"
+	inTotoArtifactType          = "application/vnd.in-toto+json"
+
+	cosignCertificateAnnotation   = "dev.sigstore.cosign/certificate"
+	predicateTypeAnnotation       = "predicateType"
+	bundlePredicateTypeAnnotation = "dev.sigstore.bundle.predicateType"
+	slsaProvenancePredicatePrefix = "https://slsa.dev/provenance/"
+)
+
+// Fulcio certificate extensions holding the OIDC issuer (v2 is DER-encoded)
+var (
+	fulcioIssuerOID   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	fulcioIssuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// SignatureStatus reports the cosign signatures and attestations stored
+// alongside an image in its registry. It only checks that they exist:
+// signatures are not cryptographically verified.
+type SignatureStatus struct {
+	Signed         bool     `json:"signed"`
+	Attested       bool     `json:"attested"`
+	Provenance     bool     `json:"provenance"`               // An attestation has a SLSA provenance predicate
+	PredicateTypes []string `json:"predicateTypes,omitempty"` // Attestation predicate types, when annotated
+	Signer         string   `json:"signer,omitempty"`         // Keyless certificate identity (email or URI)
+	SignerIssuer   string   `json:"signerIssuer,omitempty"`   // OIDC issuer of the keyless certificate
+	Digest         string   `json:"digest,omitempty"`         // Digest the signature was found for
+	Error          string   `json:"error,omitempty"`          // Why the check is incomplete
+}
+
+// cachedCheckSignature is CheckSignature with results cached by repository
+// and platform digest. Incomplete checks aren't cached so they are retried.
+func (i *Inspector) cachedCheckSignature(ctx context.Context, req InspectRequest, platformDigest string) *SignatureStatus {
+	key := ""
+	if ref, err := name.ParseReference(req.Image); err == nil && platformDigest != "" {
+		key = ref.Context().Name() + "@" + platformDigest
+	}
+	if key != "" {
+		signatureCacheMu.Lock()
+		cached, ok := signatureCache[key]
+		signatureCacheMu.Unlock()
+		if ok && time.Since(cached.checked) < signatureCacheTTL {
+			status := *cached.status
+			return &status
+		}
+	}
+
+	status := i.CheckSignature(ctx, req, platformDigest)
+	if key == "" || status.Error != "" {
+		return status
+	}
+
+	signatureCacheMu.Lock()
+	defer signatureCacheMu.Unlock()
+	now := time.Now()
+	for k, cached := range signatureCache {
+		if now.Sub(cached.checked) >= signatureCacheTTL {
+			delete(signatureCache, k)
+		}
+	}
+	if len(signatureCache) >= signatureCacheMaxEntries {
+		var oldest string
+		for k, cached := range signatureCache {
+			if oldest == "" || cached.checked.Before(signatureCache[oldest].checked) {
+				oldest = k
+			}
+		}
+		delete(signatureCache, oldest)
+	}
+	signatureCache[key] = &cachedSignature{status: status, checked: now}
+	copied := *status
+	return &copied
+}
+
+// CheckSignature looks for cosign signatures and attestations of an image,
+// both as tags (sha256-<hex>.sig / .att) and through the OCI referrers API.
+// The digest the reference points to is checked first, since multi-platform
+// images are usually signed at the index, then the platform manifest digest.
+func (i *Inspector) CheckSignature(ctx context.Context, req InspectRequest, platformDigest string) *SignatureStatus {
+	ctx, cancel := context.WithTimeout(ctx, signatureCheckTimeout)
+	defer cancel()
+
+	status := &SignatureStatus{}
+	ref, err := name.ParseReference(req.Image)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	repo := ref.Context()
+
+	var digests []string
+	if desc, _, err := i.getDescriptorBruteForce(ctx, ref, req); err == nil {
+		digests = append(digests, desc.Digest.String())
+	}
+	if platformDigest != "" && !slices.Contains(digests, platformDigest) {
+		digests = append(digests, platformDigest)
+	}
+
+	for _, digest := range digests {
+		if err := i.checkDigestSignature(ctx, repo, digest, req, status); err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		if status.Signed || status.Attested {
+			status.Digest = digest
+			return status
+		}
+	}
+	return status
+}
+
+// checkDigestSignature fills in status from the signature and attestation
+// artifacts of one digest. A missing artifact is not an error.
+func (i *Inspector) checkDigestSignature(ctx context.Context, repo name.Repository, digest string, req InspectRequest, status *SignatureStatus) error {
+	hash, err := v1.NewHash(digest)
+	if err != nil {
+		return err
+	}
+	tagPrefix := hash.Algorithm + "-" + hash.Hex
+
+	// Tag-based scheme, used by cosign unless told to use referrers
+	sig, err := i.getArtifactManifest(ctx, repo.Tag(tagPrefix+".sig"), req)
+	if err != nil {
+		return err
+	}
+	if sig != nil {
+		status.Signed = true
+		for _, layer := range sig.Layers {
+			if cert := layer.Annotations[cosignCertificateAnnotation]; cert != "" && status.Signer == "" {
+				status.Signer, status.SignerIssuer = certificateIdentity(cert)
+			}
+		}
+	}
+
+	att, err := i.getArtifactManifest(ctx, repo.Tag(tagPrefix+".att"), req)
+	if err != nil {
+		return err
+	}
+	if att != nil {
+		status.Attested = true
+		for _, layer := range att.Layers {
+			status.addPredicateType(layer.Annotations[predicateTypeAnnotation])
+		}
+	}
+
+	// OCI 1.1 referrers (ggcr falls back to the referrers tag schema when the
+	// registry doesn't implement the API)
+	referrers, err := i.getReferrers(ctx, repo.Digest(digest), req)
+	if err != nil {
+		return err
+	}
+	if referrers != nil {
+		for _, m := range referrers.Manifests {
+			switch m.ArtifactType {
+			case cosignSignatureArtifactType:
+				status.Signed = true
+			case inTotoArtifactType:
+				status.Attested = true
+				status.addPredicateType(m.Annotations[predicateTypeAnnotation])
+			case sigstoreBundleArtifactType:
+				// Bundles hold either a signature or an attestation; only
+				// attestations carry a predicate type
+				if predicateType := m.Annotations[bundlePredicateTypeAnnotation]; predicateType != "" {
+					status.Attested = true
+					status.addPredicateType(predicateType)
+				} else {
+					status.Signed = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SignatureStatus) addPredicateType(predicateType string) {
+	if predicateType == "" || slices.Contains(s.PredicateTypes, predicateType) {
+		return
+	}
+	s.PredicateTypes = append(s.PredicateTypes, predicateType)
+	if strings.HasPrefix(predicateType, slsaProvenancePredicatePrefix) {
+		s.Provenance = true
+	}
+}
+
+// getArtifactManifest fetches the manifest of a signature or attestation tag,
+// returning nil if the tag doesn't exist
+func (i *Inspector) getArtifactManifest(ctx context.Context, tag name.Tag, req InspectRequest) (*v1.Manifest, error) {
+	desc, _, err := i.getDescriptorBruteForce(ctx, tag, req)
+	if err != nil {
+		if status, _ := classifyError(err); status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", tag, err)
+	}
+	return img.Manifest()
+}
+
+// getReferrers lists the referrers of a digest, trying anonymous auth first
+// like getDescriptorBruteForce. Returns nil if the registry has none.
+func (i *Inspector) getReferrers(ctx context.Context, digest name.Digest, req InspectRequest) (*v1.IndexManifest, error) {
+	index, err := remote.Referrers(digest, registryOptions(ctx, remote.WithAuth(authn.Anonymous))...)
+	if err != nil {
+		log.Printf("Anonymous referrers lookup failed for %s, trying with credentials: %v", digest, err)
+		keychain := GetAuthenticatedKeychain(req.Image, req.Namespace, req.PullSecretNames)
+		index, err = remote.Referrers(digest, registryOptions(ctx, remote.WithAuthFromKeychain(keychain))...)
+	}
+	if err != nil {
+		if status, _ := classifyError(err); status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list referrers: %w", err)
+	}
+	return index.IndexManifest()
+}
+
+// certificateIdentity returns the subject and OIDC issuer of a keyless
+// (Fulcio) signing certificate. Key-based signatures have no certificate.
+func certificateIdentity(certPEM string) (identity, issuer string) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return "", ""
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", ""
+	}
+
+	switch {
+	case len(cert.EmailAddresses) > 0:
+		identity = cert.EmailAddresses[0]
+	case len(cert.URIs) > 0:
+		identity = cert.URIs[0].String()
+	}
+
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(fulcioIssuerV2OID):
+			var value string
+			if _, err := asn1.Unmarshal(ext.Value, &value); err == nil {
+				issuer = value
+			}
+		case ext.Id.Equal(fulcioIssuerOID) && issuer == "":
+			issuer = string(ext.Value)
+		}
+	}
+	return identity, issuer
+}
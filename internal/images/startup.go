@@ -0,0 +1,280 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Where a resolved startup setting comes from
+const (
+	startupSourceImage = "image"
+	startupSourcePod   = "pod"
+)
+
+// ImageStartupConfig is the startup part of an image's config
+type ImageStartupConfig struct {
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	Cmd        []string `json:"cmd,omitempty"`
+	Env        []string `json:"env,omitempty"` // KEY=VALUE, as in the image config
+	WorkingDir string   `json:"workingDir,omitempty"`
+	User       string   `json:"user,omitempty"`
+}
+
+// PodStartupOverrides are the container spec fields that override the image config
+type PodStartupOverrides struct {
+	Command    []string        `json:"command,omitempty"`
+	Args       []string        `json:"args,omitempty"`
+	Env        []StartupEnvVar `json:"env,omitempty"`
+	EnvFrom    []string        `json:"envFrom,omitempty"` // e.g. configMap:app-config, secret:db (prefix=DB_)
+	WorkingDir string          `json:"workingDir,omitempty"`
+	RunAsUser  *int64          `json:"runAsUser,omitempty"` // Container securityContext, else pod securityContext
+}
+
+// StartupEnvVar is one environment variable of a container. Values that come
+// from secrets, config maps or the downward API aren't read; ValueFrom says
+// where they come from.
+type StartupEnvVar struct {
+	Name       string `json:"name"`
+	Value      string `json:"value,omitempty"`
+	ValueFrom  string `json:"valueFrom,omitempty"`  // e.g. secretKeyRef:db/password, fieldRef:metadata.name
+	Source     string `json:"source,omitempty"`     // image or pod
+	Overridden bool   `json:"overridden,omitempty"` // Set in the image and replaced by the pod spec
+}
+
+// ResolvedStartup is what the container runtime starts, following the
+// Kubernetes rules for combining command/args with the image's
+// ENTRYPOINT/CMD. $(VAR) references in command and args are left as written.
+type ResolvedStartup struct {
+	Command       []string        `json:"command"` // Full argv
+	CommandSource string          `json:"commandSource"`
+	ArgsSource    string          `json:"argsSource,omitempty"`
+	Env           []StartupEnvVar `json:"env"`
+	WorkingDir    string          `json:"workingDir,omitempty"`
+	User          string          `json:"user,omitempty"`
+}
+
+// ContainerStartup is a container's image config next to its pod spec
+// overrides, and the startup that results from combining them
+type ContainerStartup struct {
+	Namespace   string              `json:"namespace"`
+	Pod         string              `json:"pod"`
+	Container   string              `json:"container"`
+	Type        string              `json:"type"`    // init, container or ephemeral
+	Image       string              `json:"image"`   // Reference the config was read from
+	Running     bool                `json:"running"` // Image pinned to the digest the container runs
+	Digest      string              `json:"digest,omitempty"`
+	ImageConfig *ImageStartupConfig `json:"imageConfig,omitempty"`
+	ImageError  string              `json:"imageError,omitempty"` // Why the image config couldn't be read; resolved from the pod spec alone
+	Overrides   PodStartupOverrides `json:"overrides"`
+	Resolved    ResolvedStartup     `json:"resolved"`
+}
+
+// GetContainerStartup reads the image config of a pod's container and
+// resolves it against the container spec. The image is read at the digest
+// the container runs when it's known, so the result matches what actually
+// started rather than what the tag points to now. The container may be
+// omitted for single-container pods.
+func (i *Inspector) GetContainerStartup(ctx context.Context, namespace, podName, containerName string) (*ContainerStartup, error) {
+	pod, err := getCachedPod(namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+	container, containerType, err := findPodContainer(pod, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ContainerStartup{
+		Namespace: namespace,
+		Pod:       podName,
+		Container: container.Name,
+		Type:      containerType,
+		Image:     container.Image,
+		Overrides: podStartupOverrides(pod, container),
+	}
+	for _, c := range podContainerImages(pod) {
+		if c.Container != container.Name || c.RunningDigest == "" {
+			continue
+		}
+		if ref, err := name.ParseReference(container.Image); err == nil {
+			result.Image = ref.Context().Digest(c.RunningDigest).String()
+			result.Running = true
+		}
+	}
+
+	img, _, err := i.fetchImageBruteForce(ctx, InspectRequest{
+		Image:           result.Image,
+		Namespace:       namespace,
+		PodName:         podName,
+		PullSecretNames: GetPullSecretsFromPod(namespace, podName),
+	})
+	if err == nil {
+		if digest, derr := img.Digest(); derr == nil {
+			result.Digest = digest.String()
+		}
+		configFile, cerr := img.ConfigFile()
+		if cerr == nil && configFile != nil {
+			result.ImageConfig = &ImageStartupConfig{
+				Entrypoint: configFile.Config.Entrypoint,
+				Cmd:        configFile.Config.Cmd,
+				Env:        configFile.Config.Env,
+				WorkingDir: configFile.Config.WorkingDir,
+				User:       configFile.Config.User,
+			}
+		} else if cerr != nil {
+			err = fmt.Errorf("failed to read image config: %w", cerr)
+		}
+	}
+	if err != nil {
+		result.ImageError = err.Error()
+	}
+
+	result.Resolved = resolveStartup(result.ImageConfig, result.Overrides)
+	return result, nil
+}
+
+// findPodContainer returns the named init, regular or ephemeral container of
+// pod, or its only container when name is empty
+func findPodContainer(pod *corev1.Pod, containerName string) (*corev1.Container, string, error) {
+	if containerName == "" {
+		if len(pod.Spec.Containers) == 1 && len(pod.Spec.InitContainers) == 0 && len(pod.Spec.EphemeralContainers) == 0 {
+			return &pod.Spec.Containers[0], "container", nil
+		}
+		return nil, "", fmt.Errorf("pod %s/%s has several containers, container parameter is required", pod.Namespace, pod.Name)
+	}
+	for idx := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[idx].Name == containerName {
+			return &pod.Spec.InitContainers[idx], "init", nil
+		}
+	}
+	for idx := range pod.Spec.Containers {
+		if pod.Spec.Containers[idx].Name == containerName {
+			return &pod.Spec.Containers[idx], "container", nil
+		}
+	}
+	for _, ec := range pod.Spec.EphemeralContainers {
+		if ec.Name == containerName {
+			c := corev1.Container(ec.EphemeralContainerCommon)
+			return &c, "ephemeral", nil
+		}
+	}
+	return nil, "", fmt.Errorf("container %s not found in pod %s/%s", containerName, pod.Namespace, pod.Name)
+}
+
+// podStartupOverrides collects the startup fields set in a container spec
+func podStartupOverrides(pod *corev1.Pod, c *corev1.Container) PodStartupOverrides {
+	overrides := PodStartupOverrides{
+		Command:    c.Command,
+		Args:       c.Args,
+		WorkingDir: c.WorkingDir,
+	}
+	for _, env := range c.Env {
+		overrides.Env = append(overrides.Env, StartupEnvVar{
+			Name:      env.Name,
+			Value:     env.Value,
+			ValueFrom: describeEnvVarSource(env.ValueFrom),
+			Source:    startupSourcePod,
+		})
+	}
+	for _, from := range c.EnvFrom {
+		var source string
+		switch {
+		case from.ConfigMapRef != nil:
+			source = "configMap:" + from.ConfigMapRef.Name
+		case from.SecretRef != nil:
+			source = "secret:" + from.SecretRef.Name
+		default:
+			continue
+		}
+		if from.Prefix != "" {
+			source += " (prefix=" + from.Prefix + ")"
+		}
+		overrides.EnvFrom = append(overrides.EnvFrom, source)
+	}
+	if c.SecurityContext != nil && c.SecurityContext.RunAsUser != nil {
+		overrides.RunAsUser = c.SecurityContext.RunAsUser
+	} else if pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.RunAsUser != nil {
+		overrides.RunAsUser = pod.Spec.SecurityContext.RunAsUser
+	}
+	return overrides
+}
+
+// describeEnvVarSource names where an env var's value comes from, or "" for a literal value
+func describeEnvVarSource(from *corev1.EnvVarSource) string {
+	switch {
+	case from == nil:
+		return ""
+	case from.SecretKeyRef != nil:
+		return "secretKeyRef:" + from.SecretKeyRef.Name + "/" + from.SecretKeyRef.Key
+	case from.ConfigMapKeyRef != nil:
+		return "configMapKeyRef:" + from.ConfigMapKeyRef.Name + "/" + from.ConfigMapKeyRef.Key
+	case from.FieldRef != nil:
+		return "fieldRef:" + from.FieldRef.FieldPath
+	case from.ResourceFieldRef != nil:
+		return "resourceFieldRef:" + from.ResourceFieldRef.Resource
+	}
+	return "unknown"
+}
+
+// resolveStartup combines the image config with the container spec the way
+// the kubelet does: command replaces ENTRYPOINT and drops CMD, args replace
+// CMD, and env vars from the spec replace image env vars of the same name.
+// cfg is nil when the image config couldn't be read.
+func resolveStartup(cfg *ImageStartupConfig, overrides PodStartupOverrides) ResolvedStartup {
+	if cfg == nil {
+		cfg = &ImageStartupConfig{}
+	}
+	resolved := ResolvedStartup{
+		CommandSource: startupSourceImage,
+		WorkingDir:    cfg.WorkingDir,
+		User:          cfg.User,
+	}
+
+	command := cfg.Entrypoint
+	args := cfg.Cmd
+	if len(cfg.Cmd) > 0 {
+		resolved.ArgsSource = startupSourceImage
+	}
+	if len(overrides.Command) > 0 {
+		command = overrides.Command
+		resolved.CommandSource = startupSourcePod
+		args = nil
+		resolved.ArgsSource = ""
+	}
+	if len(overrides.Args) > 0 {
+		args = overrides.Args
+		resolved.ArgsSource = startupSourcePod
+	}
+	resolved.Command = append(append([]string{}, command...), args...)
+
+	set := make(map[string]int) // name -> index in resolved.Env
+	for _, kv := range cfg.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		set[key] = len(resolved.Env)
+		resolved.Env = append(resolved.Env, StartupEnvVar{Name: key, Value: value, Source: startupSourceImage})
+	}
+	for _, env := range overrides.Env {
+		if idx, ok := set[env.Name]; ok {
+			env.Overridden = resolved.Env[idx].Source == startupSourceImage
+			resolved.Env[idx] = env
+			continue
+		}
+		set[env.Name] = len(resolved.Env)
+		resolved.Env = append(resolved.Env, env)
+	}
+	if resolved.Env == nil {
+		resolved.Env = []StartupEnvVar{}
+	}
+
+	if overrides.WorkingDir != "" {
+		resolved.WorkingDir = overrides.WorkingDir
+	}
+	if overrides.RunAsUser != nil {
+		resolved.User = fmt.Sprint(*overrides.RunAsUser)
+	}
+	return resolved
+}
@@ -0,0 +1,87 @@
+package images
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolveStartup(t *testing.T) {
+	image := &ImageStartupConfig{
+		Entrypoint: []string{"/docker-entrypoint.sh"},
+		Cmd:        []string{"nginx", "-g", "daemon off;"},
+		Env:        []string{"PATH=/usr/bin", "NGINX_VERSION=1.25"},
+		WorkingDir: "/",
+		User:       "nginx",
+	}
+	runAs := int64(1000)
+
+	tests := []struct {
+		name          string
+		overrides     PodStartupOverrides
+		wantCommand   []string
+		commandSource string
+		argsSource    string
+	}{
+		{
+			name:          "image defaults",
+			wantCommand:   []string{"/docker-entrypoint.sh", "nginx", "-g", "daemon off;"},
+			commandSource: startupSourceImage,
+			argsSource:    startupSourceImage,
+		},
+		{
+			name:          "args replace CMD",
+			overrides:     PodStartupOverrides{Args: []string{"nginx-debug"}},
+			wantCommand:   []string{"/docker-entrypoint.sh", "nginx-debug"},
+			commandSource: startupSourceImage,
+			argsSource:    startupSourcePod,
+		},
+		{
+			name:          "command drops CMD",
+			overrides:     PodStartupOverrides{Command: []string{"sleep"}},
+			wantCommand:   []string{"sleep"},
+			commandSource: startupSourcePod,
+		},
+		{
+			name:          "command and args",
+			overrides:     PodStartupOverrides{Command: []string{"sleep"}, Args: []string{"3600"}},
+			wantCommand:   []string{"sleep", "3600"},
+			commandSource: startupSourcePod,
+			argsSource:    startupSourcePod,
+		},
+	}
+	for _, tt := range tests {
+		resolved := resolveStartup(image, tt.overrides)
+		if !slices.Equal(resolved.Command, tt.wantCommand) {
+			t.Errorf("%s: command = %q, want %q", tt.name, resolved.Command, tt.wantCommand)
+		}
+		if resolved.CommandSource != tt.commandSource || resolved.ArgsSource != tt.argsSource {
+			t.Errorf("%s: sources = %s/%s, want %s/%s", tt.name, resolved.CommandSource, resolved.ArgsSource, tt.commandSource, tt.argsSource)
+		}
+	}
+
+	resolved := resolveStartup(image, PodStartupOverrides{
+		Env: []StartupEnvVar{
+			{Name: "NGINX_VERSION", Value: "1.26", Source: startupSourcePod},
+			{Name: "DB_PASSWORD", ValueFrom: "secretKeyRef:db/password", Source: startupSourcePod},
+		},
+		WorkingDir: "/srv",
+		RunAsUser:  &runAs,
+	})
+	want := []StartupEnvVar{
+		{Name: "PATH", Value: "/usr/bin", Source: startupSourceImage},
+		{Name: "NGINX_VERSION", Value: "1.26", Source: startupSourcePod, Overridden: true},
+		{Name: "DB_PASSWORD", ValueFrom: "secretKeyRef:db/password", Source: startupSourcePod},
+	}
+	if !slices.Equal(resolved.Env, want) {
+		t.Errorf("env = %+v, want %+v", resolved.Env, want)
+	}
+	if resolved.WorkingDir != "/srv" || resolved.User != "1000" {
+		t.Errorf("expected pod working dir and user, got %q and %q", resolved.WorkingDir, resolved.User)
+	}
+
+	// Image config unavailable: only the pod spec is known
+	resolved = resolveStartup(nil, PodStartupOverrides{Args: []string{"--port=8080"}})
+	if !slices.Equal(resolved.Command, []string{"--port=8080"}) || len(resolved.Env) != 0 || resolved.Env == nil {
+		t.Errorf("unexpected startup without image config: %+v", resolved)
+	}
+}
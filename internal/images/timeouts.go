@@ -0,0 +1,97 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPullTimeout    = 2 * time.Minute  // Registry traffic of one request: manifest, config and layer downloads
+	defaultInspectTimeout = 10 * time.Minute // Whole request, including building the tree from cached layers
+)
+
+// ErrPullTimeout is returned when the registry phase of a request (fetching
+// the manifest and downloading layers) exceeds the pull timeout
+var ErrPullTimeout = errors.New("registry pull timed out")
+
+// timeouts holds the limits for image requests
+var timeouts = struct {
+	sync.RWMutex
+	pull    time.Duration
+	inspect time.Duration
+}{pull: defaultPullTimeout, inspect: defaultInspectTimeout}
+
+// SetTimeouts sets how long the registry phase of an image request may take
+// (pull) and how long the whole request may take (inspect). A slow registry
+// then fails fast while a large tree built from cached layers still gets
+// time. Zero or negative disables the respective limit.
+func SetTimeouts(pull, inspect time.Duration) {
+	timeouts.Lock()
+	defer timeouts.Unlock()
+	timeouts.pull = pull
+	timeouts.inspect = inspect
+}
+
+type pullContextKey struct{}
+
+// withPullTimeout returns ctx carrying a derived context for registry
+// traffic that ends after the pull timeout. The deadline covers both the
+// manifest fetch and the layer downloads, which go-containerregistry makes
+// lazily with the context the image was fetched with.
+func withPullTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeouts.RLock()
+	pull := timeouts.pull
+	timeouts.RUnlock()
+	if pull <= 0 {
+		return ctx, func() {}
+	}
+	pullCtx, cancel := context.WithTimeoutCause(ctx, pull, ErrPullTimeout)
+	return context.WithValue(ctx, pullContextKey{}, pullCtx), cancel
+}
+
+// registryContext returns the context registry requests made for ctx should use
+func registryContext(ctx context.Context) context.Context {
+	if pullCtx, ok := ctx.Value(pullContextKey{}).(context.Context); ok {
+		return pullCtx
+	}
+	return ctx
+}
+
+// pullTimedOut reports whether the pull deadline of ctx has passed
+func pullTimedOut(ctx context.Context) bool {
+	return errors.Is(context.Cause(registryContext(ctx)), ErrPullTimeout)
+}
+
+// requestTimeouts applies the inspect and pull timeouts to image requests.
+// Inspecting a large image can take longer than the server-wide request
+// timeout, so the request context is replaced by one that ends only when the
+// client goes away or the inspect timeout passes.
+func requestTimeouts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeouts.RLock()
+		inspect := timeouts.inspect
+		timeouts.RUnlock()
+
+		ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+		defer cancel()
+		stop := context.AfterFunc(r.Context(), func() {
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				cancel()
+			}
+		})
+		defer stop()
+
+		if inspect > 0 {
+			var cancelInspect context.CancelFunc
+			ctx, cancelInspect = context.WithTimeout(ctx, inspect)
+			defer cancelInspect()
+		}
+		ctx, cancelPull := withPullTimeout(ctx)
+		defer cancelPull()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
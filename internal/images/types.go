@@ -4,13 +4,16 @@ package images
 type FileNode struct {
 	Name        string      `json:"name"`
 	Path        string      `json:"path"`
-	Type        string      `json:"type"` // "file", "dir", "symlink"
+	Type        string      `json:"type"` // "file", "dir", "symlink", "hardlink", or "whiteout" in single-layer trees
 	Size        int64       `json:"size,omitempty"`
+	TotalSize   int64       `json:"totalSize,omitempty"` // Dirs only: aggregate size of all descendant files
 	Permissions string      `json:"permissions,omitempty"`
 	Mode        uint32      `json:"mode,omitempty"`
 	ModTime     string      `json:"modTime,omitempty"`
 	LinkTarget  string      `json:"linkTarget,omitempty"`
 	Children    []*FileNode `json:"children,omitempty"`
+	HasChildren bool        `json:"hasChildren,omitempty"` // True for dirs whose children were omitted by a depth limit
+	Opaque      bool        `json:"opaque,omitempty"`      // Single-layer trees only: the dir hides everything below it from lower layers
 }
 
 // ImageFilesystem represents the complete filesystem tree of an image
@@ -22,14 +25,18 @@ type ImageFilesystem struct {
 	TotalFiles int         `json:"totalFiles"`
 	TotalSize  int64       `json:"totalSize"`
 	Layers     []LayerInfo `json:"layers,omitempty"`
+	Truncated  bool        `json:"truncated,omitempty"` // True if a depth limit omitted part of the tree
 	Error      string      `json:"error,omitempty"`
 }
 
 // LayerInfo contains metadata about a single image layer
 type LayerInfo struct {
-	Digest    string `json:"digest"`
-	Size      int64  `json:"size"`
-	MediaType string `json:"mediaType"`
+	Index            int    `json:"index"` // Position in the image, 0 = base layer
+	Digest           string `json:"digest"`
+	Size             int64  `json:"size"`                       // Compressed size in the registry
+	UncompressedSize int64  `json:"uncompressedSize,omitempty"` // Size on disk, known once cached
+	MediaType        string `json:"mediaType"`
+	CreatedBy        string `json:"createdBy,omitempty"` // Build step from the image history
 }
 
 // InspectRequest contains the parameters for inspecting an image
@@ -50,4 +57,5 @@ type ImageMetadata struct {
 	Cached       bool        `json:"cached"`       // Whether filesystem is already cached
 	Filesystem   *ImageFilesystem `json:"filesystem,omitempty"` // Included if cached
 	AuthMethod   string      `json:"authMethod"`   // "anonymous", "credentials", etc.
+	Signature    *SignatureStatus `json:"signature,omitempty"` // Cosign signature and attestation presence
 }
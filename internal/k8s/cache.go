@@ -6,6 +6,7 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -62,6 +63,15 @@ var (
 	cacheMu       sync.Mutex
 )
 
+// Readiness tracking. cacheReady is closed once the typed informers finish
+// their initial sync and is replaced on reset, so a context switch starts
+// out unready again. cacheSyncing is true while InitResourceCache runs.
+var (
+	cacheReady   = make(chan struct{})
+	cacheReadyMu sync.RWMutex
+	cacheSyncing atomic.Bool
+)
+
 // dropManagedFields reduces memory usage by removing heavy metadata
 func dropManagedFields(obj any) (any, error) {
 	if meta, ok := obj.(metav1.Object); ok {
@@ -107,6 +117,9 @@ func dropManagedFields(obj any) (any, error) {
 func InitResourceCache() error {
 	var initErr error
 	cacheOnce.Do(func() {
+		cacheSyncing.Store(true)
+		defer cacheSyncing.Store(false)
+
 		if k8sClient == nil {
 			initErr = fmt.Errorf("cannot create resource cache: k8s client not initialized")
 			return
@@ -235,10 +248,46 @@ func InitResourceCache() error {
 			stopCh:         stopCh,
 			secretsEnabled: secretsEnabled,
 		}
+
+		cacheReadyMu.RLock()
+		close(cacheReady)
+		cacheReadyMu.RUnlock()
 	})
 	return initErr
 }
 
+// IsResourceCacheSynced reports whether the resource cache has completed its initial sync
+func IsResourceCacheSynced() bool {
+	select {
+	case <-readyChan():
+		return true
+	default:
+		return false
+	}
+}
+
+// IsResourceCacheSyncing reports whether the resource cache is currently
+// performing its initial sync (at startup or after a context switch)
+func IsResourceCacheSyncing() bool {
+	return cacheSyncing.Load()
+}
+
+// WaitForCacheSync blocks until the resource cache has synced or ctx is done
+func WaitForCacheSync(ctx context.Context) error {
+	select {
+	case <-readyChan():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func readyChan() chan struct{} {
+	cacheReadyMu.RLock()
+	defer cacheReadyMu.RUnlock()
+	return cacheReady
+}
+
 // GetResourceCache returns the singleton cache instance
 func GetResourceCache() *ResourceCache {
 	return resourceCache
@@ -256,6 +305,14 @@ func ResetResourceCache() {
 	}
 	cacheOnce = sync.Once{}
 	initialSyncComplete = false
+
+	// Only replace a channel that was already closed; waiters on an unclosed
+	// one keep waiting for the upcoming reinit
+	if IsResourceCacheSynced() {
+		cacheReadyMu.Lock()
+		cacheReady = make(chan struct{})
+		cacheReadyMu.Unlock()
+	}
 }
 
 // ReinitResourceCache reinitializes the resource cache after a context switch
@@ -828,6 +885,39 @@ func (c *ResourceCache) Events() listerscorev1.EventLister {
 	return c.factory.Core().V1().Events().Lister()
 }
 
+// WatchEvents calls handler for each K8s Event created after the call, and
+// again each time an Event repeats (its count goes up), with repeat set.
+// Updates that don't change the count, and deletions (Events expiring), are
+// not reported. Call stop to remove the handler.
+func (c *ResourceCache) WatchEvents(handler func(event *corev1.Event, repeat bool)) (stop func(), err error) {
+	if c == nil {
+		return nil, fmt.Errorf("resource cache not initialized")
+	}
+	informer := c.factory.Core().V1().Events().Informer()
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if event, ok := obj.(*corev1.Event); ok && !isInInitialList {
+				handler(event, false)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldEvent, ok1 := oldObj.(*corev1.Event)
+			newEvent, ok2 := newObj.(*corev1.Event)
+			if ok1 && ok2 && newEvent.Count > oldEvent.Count {
+				handler(newEvent, true)
+			}
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch events: %w", err)
+	}
+	return func() {
+		if err := informer.RemoveEventHandler(registration); err != nil {
+			log.Printf("Warning: failed to remove event watch handler: %v", err)
+		}
+	}, nil
+}
+
 func (c *ResourceCache) PersistentVolumeClaims() listerscorev1.PersistentVolumeClaimLister {
 	if c == nil {
 		return nil
@@ -978,6 +1068,37 @@ var knownKinds = map[string]bool{
 	"horizontalpodautoscaler": true, "horizontalpodautoscalers": true, "hpa": true, "hpas": true,
 }
 
+// typedResources are the group/resources backed by the typed informers
+var typedResources = map[schema.GroupResource]bool{
+	{Group: "", Resource: "pods"}:                                true,
+	{Group: "", Resource: "services"}:                            true,
+	{Group: "", Resource: "nodes"}:                               true,
+	{Group: "", Resource: "namespaces"}:                          true,
+	{Group: "", Resource: "configmaps"}:                          true,
+	{Group: "", Resource: "secrets"}:                             true,
+	{Group: "", Resource: "events"}:                              true,
+	{Group: "", Resource: "persistentvolumeclaims"}:              true,
+	{Group: "apps", Resource: "deployments"}:                     true,
+	{Group: "apps", Resource: "daemonsets"}:                      true,
+	{Group: "apps", Resource: "statefulsets"}:                    true,
+	{Group: "apps", Resource: "replicasets"}:                     true,
+	{Group: "networking.k8s.io", Resource: "ingresses"}:          true,
+	{Group: "batch", Resource: "jobs"}:                           true,
+	{Group: "batch", Resource: "cronjobs"}:                       true,
+	{Group: "autoscaling", Resource: "horizontalpodautoscalers"}: true,
+}
+
+// HasTypedInformer reports whether a typed informer is running for the resource
+func (c *ResourceCache) HasTypedInformer(gr schema.GroupResource) bool {
+	if c == nil || !typedResources[gr] {
+		return false
+	}
+	if gr.Group == "" && gr.Resource == "secrets" {
+		return c.secretsEnabled
+	}
+	return true
+}
+
 // IsKnownKind returns true if the kind is handled by the typed cache
 func IsKnownKind(kind string) bool {
 	return knownKinds[strings.ToLower(kind)]
@@ -1004,6 +1125,28 @@ func (c *ResourceCache) ListDynamic(ctx context.Context, kind string, namespace
 	return dynamicCache.List(gvr, namespace)
 }
 
+// ListDynamicSynced is like ListDynamic but waits up to timeout for the
+// resource's informer to sync. synced is false if it is still loading, in
+// which case the (possibly partial) result should not be treated as complete.
+func (c *ResourceCache) ListDynamicSynced(ctx context.Context, kind string, namespace string, timeout time.Duration) (items []*unstructured.Unstructured, synced bool, err error) {
+	items, err = c.ListDynamic(ctx, kind, namespace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	gvr, _ := GetResourceDiscovery().GetGVR(kind)
+	dynamicCache := GetDynamicResourceCache()
+	if dynamicCache.IsSynced(gvr) {
+		return items, true, nil
+	}
+	if !dynamicCache.WaitForSync(gvr, timeout) {
+		return items, false, nil
+	}
+
+	items, err = c.ListDynamic(ctx, kind, namespace)
+	return items, err == nil, err
+}
+
 // GetDynamic returns a single resource of any type using the dynamic cache
 func (c *ResourceCache) GetDynamic(ctx context.Context, kind string, namespace string, name string) (*unstructured.Unstructured, error) {
 	return c.GetDynamicWithGroup(ctx, kind, namespace, name, "")
@@ -1067,10 +1210,19 @@ type ResourceStatus struct {
 	Issue   string // Primary issue if unhealthy (e.g., "OOMKilled", "CrashLoopBackOff")
 }
 
-// GetResourceStatus looks up a resource and returns its status
+// GetResourceStatus looks up a resource and returns its status, or nil if it
+// can't be found or its kind has no status
 func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *ResourceStatus {
+	status, _ := c.LookupResourceStatus(kind, namespace, name)
+	return status
+}
+
+// LookupResourceStatus is GetResourceStatus reporting why a lookup failed
+// (e.g. the resource was deleted, or its kind isn't readable under RBAC).
+// Kinds without a status return nil and no error.
+func (c *ResourceCache) LookupResourceStatus(kind, namespace, name string) (*ResourceStatus, error) {
 	if c == nil {
-		return nil
+		return nil, fmt.Errorf("resource cache not available")
 	}
 
 	kindLower := strings.ToLower(kind)
@@ -1079,7 +1231,7 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 	case "pod", "pods":
 		pod, err := c.Pods().Pods(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		issue := getPodIssue(pod)
 		status := string(pod.Status.Phase)
@@ -1094,12 +1246,12 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 			Message: getPodStatusMessage(pod),
 			Summary: summary,
 			Issue:   issue,
-		}
+		}, nil
 
 	case "deployment", "deployments":
 		dep, err := c.Deployments().Deployments(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		ready := fmt.Sprintf("%d/%d", dep.Status.ReadyReplicas, dep.Status.Replicas)
 		status := "Progressing"
@@ -1128,12 +1280,12 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 			}
 		}
 
-		return result
+		return result, nil
 
 	case "statefulset", "statefulsets":
 		sts, err := c.StatefulSets().StatefulSets(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		replicas := int32(1)
 		if sts.Spec.Replicas != nil {
@@ -1166,12 +1318,12 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 			}
 		}
 
-		return result
+		return result, nil
 
 	case "daemonset", "daemonsets":
 		ds, err := c.DaemonSets().DaemonSets(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		ready := fmt.Sprintf("%d/%d", ds.Status.NumberReady, ds.Status.DesiredNumberScheduled)
 		status := "Progressing"
@@ -1199,12 +1351,12 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 			}
 		}
 
-		return result
+		return result, nil
 
 	case "replicaset", "replicasets":
 		rs, err := c.ReplicaSets().ReplicaSets(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		replicas := int32(1)
 		if rs.Spec.Replicas != nil {
@@ -1220,52 +1372,52 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 		return &ResourceStatus{
 			Status: status,
 			Ready:  ready,
-		}
+		}, nil
 
 	case "service", "services":
 		_, err := c.Services().Services(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ResourceStatus{
 			Status: "Active",
-		}
+		}, nil
 
 	case "configmap", "configmaps":
 		_, err := c.ConfigMaps().ConfigMaps(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ResourceStatus{
 			Status: "Active",
-		}
+		}, nil
 
 	case "secret", "secrets":
 		lister := c.Secrets()
 		if lister == nil {
-			return nil
+			return nil, fmt.Errorf("secrets access not available (RBAC not granted)")
 		}
 		_, err := lister.Secrets(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ResourceStatus{
 			Status: "Active",
-		}
+		}, nil
 
 	case "ingress", "ingresses":
 		_, err := c.Ingresses().Ingresses(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ResourceStatus{
 			Status: "Active",
-		}
+		}, nil
 
 	case "job", "jobs":
 		job, err := c.Jobs().Jobs(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		status := "Running"
 		if job.Status.Succeeded > 0 {
@@ -1281,12 +1433,12 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 		return &ResourceStatus{
 			Status: status,
 			Ready:  fmt.Sprintf("%d/%d", job.Status.Succeeded, completions),
-		}
+		}, nil
 
 	case "cronjob", "cronjobs":
 		cj, err := c.CronJobs().CronJobs(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		status := "Active"
 		if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
@@ -1294,30 +1446,30 @@ func (c *ResourceCache) GetResourceStatus(kind, namespace, name string) *Resourc
 		}
 		return &ResourceStatus{
 			Status: status,
-		}
+		}, nil
 
 	case "horizontalpodautoscaler", "horizontalpodautoscalers", "hpa":
 		hpa, err := c.HorizontalPodAutoscalers().HorizontalPodAutoscalers(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ResourceStatus{
 			Status: "Active",
 			Ready:  fmt.Sprintf("%d/%d", hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas),
-		}
+		}, nil
 
 	case "persistentvolumeclaim", "persistentvolumeclaims", "pvc":
 		pvc, err := c.PersistentVolumeClaims().PersistentVolumeClaims(namespace).Get(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ResourceStatus{
 			Status: string(pvc.Status.Phase),
-		}
+		}, nil
 
 	default:
 		// For unknown types, return nil (no status available)
-		return nil
+		return nil, nil
 	}
 }
 
@@ -4,10 +4,13 @@ import (
 	"context"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	authv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // Capabilities represents the features available based on RBAC permissions
@@ -16,6 +19,12 @@ type Capabilities struct {
 	Logs        bool `json:"logs"`        // Can get pods/log (log viewer)
 	PortForward bool `json:"portForward"` // Can create pods/portforward
 	Secrets     bool `json:"secrets"`     // Can list secrets
+	ReadOnly    bool `json:"readOnly"`    // Server runs with --read-only: all write actions are refused
+
+	// RBACIntrospectionUnavailable is set when creating a SelfSubjectAccessReview
+	// is itself forbidden. List access is then probed with a real list request
+	// and the other features are assumed available until the API refuses them.
+	RBACIntrospectionUnavailable bool `json:"rbacIntrospectionUnavailable,omitempty"`
 }
 
 var (
@@ -23,6 +32,10 @@ var (
 	capabilitiesMu     sync.RWMutex
 	capabilitiesExpiry time.Time
 	capabilitiesTTL    = 60 * time.Second
+
+	// ssarForbidden is set once a SelfSubjectAccessReview is denied, so later
+	// checks skip reviews that are bound to fail
+	ssarForbidden atomic.Bool
 )
 
 // CheckCapabilities checks RBAC permissions using SelfSubjectAccessReview
@@ -86,6 +99,8 @@ func CheckCapabilities(ctx context.Context) (*Capabilities, error) {
 		Logs:        logsAllowed,
 		PortForward: portForwardAllowed,
 		Secrets:     secretsAllowed,
+
+		RBACIntrospectionUnavailable: ssarForbidden.Load(),
 	}
 
 	// Cache the result
@@ -97,17 +112,27 @@ func CheckCapabilities(ctx context.Context) (*Capabilities, error) {
 
 // canI checks if the current user/service account can perform an action
 func canI(ctx context.Context, namespace, resource, verb string) bool {
+	return canIGroup(ctx, namespace, "", resource, verb)
+}
+
+// canIGroup is canI for a resource in a specific API group
+func canIGroup(ctx context.Context, namespace, group, resource, verb string) bool {
 	k8sClient := GetClient()
 	if k8sClient == nil {
 		log.Printf("Warning: K8s client nil in canI check for %s %s", verb, resource)
 		return false // Fail closed if no client
 	}
 
+	if ssarForbidden.Load() {
+		return probeAccess(ctx, namespace, group, resource, verb)
+	}
+
 	review := &authv1.SelfSubjectAccessReview{
 		Spec: authv1.SelfSubjectAccessReviewSpec{
 			ResourceAttributes: &authv1.ResourceAttributes{
 				Namespace: namespace, // Empty = cluster-wide
 				Verb:      verb,
+				Group:     group,
 				Resource:  resource,
 			},
 		},
@@ -115,6 +140,12 @@ func canI(ctx context.Context, namespace, resource, verb string) bool {
 
 	result, err := k8sClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
 	if err != nil {
+		if apierrors.IsForbidden(err) {
+			if !ssarForbidden.Swap(true) {
+				log.Printf("Warning: SelfSubjectAccessReview is forbidden, RBAC introspection unavailable; probing access on use instead: %v", err)
+			}
+			return probeAccess(ctx, namespace, group, resource, verb)
+		}
 		// Log the error and fail closed
 		log.Printf("Warning: SelfSubjectAccessReview failed for %s %s: %v", verb, resource, err)
 		return false
@@ -123,9 +154,117 @@ func canI(ctx context.Context, namespace, resource, verb string) bool {
 	return result.Status.Allowed
 }
 
+// probeAccess stands in for a SelfSubjectAccessReview when those are
+// forbidden. List access is cheap to test with a one-item list; other verbs
+// (exec, port-forward) can't be tried without side effects, so they are
+// assumed allowed and the API refuses them when used.
+func probeAccess(ctx context.Context, namespace, group, resource, verb string) bool {
+	if verb != "list" {
+		return true
+	}
+
+	gvr, ok := GetResourceDiscovery().GetGVRWithGroup(resource, group)
+	if !ok {
+		if group != "" {
+			return true
+		}
+		gvr = schema.GroupVersionResource{Version: "v1", Resource: resource}
+	}
+
+	client := GetDynamicClient()
+	if client == nil {
+		return false
+	}
+	_, err := client.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil && !apierrors.IsForbidden(err) {
+		log.Printf("Warning: access probe failed for list %s: %v", gvr.GroupResource(), err)
+	}
+	return err == nil
+}
+
 // InvalidateCapabilitiesCache forces the next CheckCapabilities call to refresh
 func InvalidateCapabilitiesCache() {
 	capabilitiesMu.Lock()
 	defer capabilitiesMu.Unlock()
 	cachedCapabilities = nil
+	capabilitiesExpiry = time.Time{}
+	ssarForbidden.Store(false)
+
+	listAccessMu.Lock()
+	listAccess = make(map[schema.GroupResource]bool)
+	listAccessMu.Unlock()
+
+	namespaceAccessMu.Lock()
+	namespaceAccess = make(map[string]bool)
+	namespaceAccessMu.Unlock()
+
+	namespacePermissionsMu.Lock()
+	namespacePermissions = make(map[string]NamespacePermissions)
+	namespacePermissionsMu.Unlock()
+}
+
+// listAccessConcurrency bounds the number of in-flight SSAR requests, since
+// checking every discovered resource type can mean hundreds of reviews
+const listAccessConcurrency = 10
+
+var (
+	listAccess       = make(map[schema.GroupResource]bool)
+	listAccessMu     sync.Mutex
+	listAccessExpiry time.Time
+)
+
+// CanListResources reports, for each resource, whether the current user can
+// list it cluster-wide. Results are cached with the same TTL as CheckCapabilities.
+func CanListResources(ctx context.Context, resources []schema.GroupResource) map[schema.GroupResource]bool {
+	result := make(map[schema.GroupResource]bool, len(resources))
+
+	listAccessMu.Lock()
+	if time.Now().After(listAccessExpiry) {
+		listAccess = make(map[schema.GroupResource]bool)
+		listAccessExpiry = time.Now().Add(capabilitiesTTL)
+	}
+	var missing []schema.GroupResource
+	for _, gr := range resources {
+		if allowed, ok := listAccess[gr]; ok {
+			result[gr] = allowed
+		} else if _, queued := result[gr]; !queued {
+			result[gr] = false
+			missing = append(missing, gr)
+		}
+	}
+	listAccessMu.Unlock()
+
+	if len(missing) == 0 {
+		return result
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, listAccessConcurrency)
+	for _, gr := range missing {
+		wg.Add(1)
+		go func(gr schema.GroupResource) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			allowed := canIGroup(ctx, "", gr.Group, gr.Resource, "list")
+			mu.Lock()
+			result[gr] = allowed
+			mu.Unlock()
+		}(gr)
+	}
+	wg.Wait()
+
+	// Don't cache results from a cancelled request; they failed closed
+	if ctx.Err() != nil {
+		return result
+	}
+
+	listAccessMu.Lock()
+	for _, gr := range missing {
+		listAccess[gr] = result[gr]
+	}
+	listAccessMu.Unlock()
+
+	return result
 }
@@ -27,6 +27,8 @@ var (
 	kubeconfigPaths []string // Multiple kubeconfig paths when using --kubeconfig-dir
 	contextName     string
 	clusterName     string
+	// contextNamespace is the namespace the current context defaults to, empty if unset
+	contextNamespace string
 	// clientMu protects access to client variables during context switches.
 	// Readers use RLock, context switch uses Lock.
 	clientMu sync.RWMutex
@@ -99,6 +101,7 @@ func doInit(opts InitOptions) error {
 			contextName = rawConfig.CurrentContext
 			if ctx, ok := rawConfig.Contexts[contextName]; ok {
 				clusterName = ctx.Cluster
+				contextNamespace = ctx.Namespace
 			}
 		}
 
@@ -227,6 +230,15 @@ func GetClusterName() string {
 	return clusterName
 }
 
+// GetContextNamespace returns the namespace the current kubeconfig context
+// defaults to, as set by kubectl config set-context --namespace or kubens.
+// It is empty in-cluster and for contexts without one.
+func GetContextNamespace() string {
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	return contextNamespace
+}
+
 // IsInCluster returns true if running inside a Kubernetes cluster
 func IsInCluster() bool {
 	return kubeconfigPath == "" && len(kubeconfigPaths) == 0
@@ -364,6 +376,44 @@ func SwitchContext(name string) error {
 	dynamicClient = newDynamicClient
 	contextName = name
 	clusterName = ctx.Cluster
+	contextNamespace = ctx.Namespace
+	clientMu.Unlock()
+
+	return nil
+}
+
+// ReloadClient rebuilds the clients for the current context from a fresh read
+// of the kubeconfig, or of the service account config when running in-cluster
+func ReloadClient() error {
+	if !IsInCluster() {
+		return SwitchContext(GetContextName())
+	}
+
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load in-cluster config: %w", err)
+	}
+
+	newK8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s clientset: %w", err)
+	}
+
+	newDiscoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	newDynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	clientMu.Lock()
+	k8sConfig = config
+	k8sClient = newK8sClient
+	discoveryClient = newDiscoveryClient
+	dynamicClient = newDynamicClient
 	clientMu.Unlock()
 
 	return nil
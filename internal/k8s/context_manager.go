@@ -144,6 +144,39 @@ func TestClusterConnection(ctx context.Context) error {
 // 5. Notifies all registered callbacks
 func PerformContextSwitch(newContext string) error {
 	log.Printf("Performing context switch to %q", newContext)
+	return rebuildClusterState(newContext, func() error {
+		log.Printf("Switching K8s client to context %q...", newContext)
+		if err := SwitchContext(newContext); err != nil {
+			return fmt.Errorf("failed to switch context: %w", err)
+		}
+		return nil
+	})
+}
+
+// PerformReload re-reads the kubeconfig (or the in-cluster service account
+// config) for the current context and rebuilds all caches in place, the same
+// way a context switch does. Callbacks are notified as for a switch to the
+// current context.
+func PerformReload() error {
+	current := GetContextName()
+	log.Printf("Reloading kubeconfig for context %q", current)
+	return rebuildClusterState(current, func() error {
+		if err := ReloadClient(); err != nil {
+			return fmt.Errorf("failed to reload kubeconfig: %w", err)
+		}
+		return nil
+	})
+}
+
+// rebuildMu serializes context switches and reloads
+var rebuildMu sync.Mutex
+
+// rebuildClusterState stops all caches, calls connect to replace the K8s
+// clients, then reinitializes the caches and notifies callbacks
+func rebuildClusterState(newContext string, connect func() error) error {
+	rebuildMu.Lock()
+	defer rebuildMu.Unlock()
+
 	reportProgress("Stopping caches...")
 
 	// Step 1: Stop all caches (order matters - stop dependent caches first)
@@ -185,9 +218,8 @@ func PerformContextSwitch(newContext string) error {
 
 	// Step 2: Switch the K8s client to the new context
 	reportProgress("Connecting to cluster...")
-	log.Printf("Switching K8s client to context %q...", newContext)
-	if err := SwitchContext(newContext); err != nil {
-		return fmt.Errorf("failed to switch context: %w", err)
+	if err := connect(); err != nil {
+		return err
 	}
 
 	// Invalidate capabilities cache - RBAC permissions may differ between clusters
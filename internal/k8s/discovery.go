@@ -260,6 +260,25 @@ func (d *ResourceDiscovery) GetResource(kindOrName string) (APIResource, bool) {
 	return res, ok
 }
 
+// GetResourceForGVR returns the APIResource served at exactly this group,
+// version and plural name. Unlike GetResource it matches any served version,
+// not just the one preferred for the kind.
+func (d *ResourceDiscovery) GetResourceForGVR(gvr schema.GroupVersionResource) (APIResource, bool) {
+	if d == nil {
+		return APIResource{}, false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, res := range d.resources {
+		if res.Group == gvr.Group && res.Version == gvr.Version && res.Name == gvr.Resource {
+			return res, true
+		}
+	}
+	return APIResource{}, false
+}
+
 // IsKnownResource checks if a kind or plural name is a known resource
 func (d *ResourceDiscovery) IsKnownResource(kindOrName string) bool {
 	_, ok := d.GetResource(kindOrName)
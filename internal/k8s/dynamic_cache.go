@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
 
@@ -464,6 +465,78 @@ func (d *DynamicResourceCache) ListWithSelector(gvr schema.GroupVersionResource,
 	return result, nil
 }
 
+// Watch calls handler for each change to resources of a GVR in namespace
+// ("" for all namespaces) that match selector. Resources already in the cache
+// are delivered first as Added events, and synced reports true once they all
+// have been. A resource whose labels change to or from matching the selector
+// is delivered as Added or Deleted. The handler runs on the informer's
+// goroutine, so it must not block. Call stop to unregister it.
+func (d *DynamicResourceCache) Watch(gvr schema.GroupVersionResource, namespace string, selector labels.Selector, handler func(watch.EventType, *unstructured.Unstructured)) (stop func(), synced func() bool, err error) {
+	if d == nil {
+		return nil, nil, fmt.Errorf("dynamic resource cache not initialized")
+	}
+	if err := d.EnsureWatching(gvr); err != nil {
+		return nil, nil, err
+	}
+
+	d.mu.RLock()
+	informer := d.informers[gvr]
+	d.mu.RUnlock()
+
+	matches := func(obj any) (*unstructured.Unstructured, bool) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, false
+		}
+		if namespace != "" && u.GetNamespace() != namespace {
+			return nil, false
+		}
+		if selector != nil && !selector.Matches(labels.Set(u.GetLabels())) {
+			return nil, false
+		}
+		return u, true
+	}
+
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if u, ok := matches(obj); ok {
+				handler(watch.Added, stripManagedFieldsUnstructured(u))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			_, oldMatch := matches(oldObj)
+			u, newMatch := matches(newObj)
+			switch {
+			case newMatch && oldMatch:
+				handler(watch.Modified, stripManagedFieldsUnstructured(u))
+			case newMatch:
+				handler(watch.Added, stripManagedFieldsUnstructured(u))
+			case oldMatch:
+				old, _ := oldObj.(*unstructured.Unstructured)
+				handler(watch.Deleted, stripManagedFieldsUnstructured(old))
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if u, ok := matches(obj); ok {
+				handler(watch.Deleted, stripManagedFieldsUnstructured(u))
+			}
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to watch %s: %w", gvr.String(), err)
+	}
+
+	stop = func() {
+		if err := informer.RemoveEventHandler(registration); err != nil {
+			log.Printf("Warning: failed to remove watch handler for %s: %v", gvr.String(), err)
+		}
+	}
+	return stop, registration.HasSynced, nil
+}
+
 // GetWatchedResources returns a list of GVRs currently being watched
 func (d *DynamicResourceCache) GetWatchedResources() []schema.GroupVersionResource {
 	if d == nil {
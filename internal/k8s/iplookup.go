@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"slices"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// LookupIP finds the Service, Pod or Node that owns an IP in the resource cache.
+// Services are checked first since a ClusterIP is the usual destination; pods
+// on the host network are skipped because they share the node's IP.
+func LookupIP(ip string) (kind, namespace, name string, ok bool) {
+	cache := GetResourceCache()
+	if cache == nil || ip == "" {
+		return "", "", "", false
+	}
+
+	if services, err := cache.Services().List(labels.Everything()); err == nil {
+		for _, svc := range services {
+			if svc.Spec.ClusterIP == ip || slices.Contains(svc.Spec.ClusterIPs, ip) {
+				return "Service", svc.Namespace, svc.Name, true
+			}
+		}
+	}
+
+	if pods, err := cache.Pods().List(labels.Everything()); err == nil {
+		for _, pod := range pods {
+			if pod.Spec.HostNetwork {
+				continue
+			}
+			if pod.Status.PodIP == ip {
+				return "Pod", pod.Namespace, pod.Name, true
+			}
+			for _, podIP := range pod.Status.PodIPs {
+				if podIP.IP == ip {
+					return "Pod", pod.Namespace, pod.Name, true
+				}
+			}
+		}
+	}
+
+	if nodes, err := cache.Nodes().List(labels.Everything()); err == nil {
+		for _, node := range nodes {
+			for _, addr := range node.Status.Addresses {
+				if addr.Address == ip {
+					return "Node", "", node.Name, true
+				}
+			}
+		}
+	}
+
+	return "", "", "", false
+}
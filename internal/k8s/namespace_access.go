@@ -0,0 +1,119 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	authv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	// ErrNamespaceNotFound is returned by CheckNamespace for a namespace that
+	// doesn't exist in the cluster
+	ErrNamespaceNotFound = errors.New("namespace not found")
+
+	// ErrNamespaceForbidden is returned by CheckNamespace for a namespace the
+	// current user has no permissions in
+	ErrNamespaceForbidden = errors.New("namespace not accessible")
+)
+
+var (
+	namespaceAccess       = make(map[string]bool)
+	namespaceAccessMu     sync.Mutex
+	namespaceAccessExpiry time.Time
+)
+
+// CheckNamespace returns ErrNamespaceNotFound if namespace doesn't exist and
+// ErrNamespaceForbidden if the current user has no permissions in it, so
+// handlers can fail early with a clear response instead of passing a typo on
+// to the API. Existence is read from the resource cache; access results are
+// cached with the same TTL as CheckCapabilities. When either can't be
+// determined (cache still syncing, rules review unavailable) the namespace
+// is let through and the API has the final say.
+func CheckNamespace(ctx context.Context, namespace string) error {
+	// "_" is the placeholder routes use for cluster-scoped resources
+	if namespace == "" || namespace == "_" {
+		return nil
+	}
+
+	cache := GetResourceCache()
+	if cache == nil || !IsResourceCacheSynced() {
+		return nil
+	}
+	if _, err := cache.Namespaces().Get(namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespace)
+		}
+		return nil
+	}
+
+	if !canAccessNamespace(ctx, namespace) {
+		return fmt.Errorf("%w: %s", ErrNamespaceForbidden, namespace)
+	}
+	return nil
+}
+
+// canAccessNamespace reports whether the current user has any permission on
+// resources in namespace, through namespace or cluster-wide bindings
+func canAccessNamespace(ctx context.Context, namespace string) bool {
+	namespaceAccessMu.Lock()
+	if time.Now().After(namespaceAccessExpiry) {
+		namespaceAccess = make(map[string]bool)
+		namespaceAccessExpiry = time.Now().Add(capabilitiesTTL)
+	}
+	allowed, ok := namespaceAccess[namespace]
+	namespaceAccessMu.Unlock()
+	if ok {
+		return allowed
+	}
+
+	allowed, conclusive := reviewNamespaceRules(ctx, namespace)
+	if conclusive {
+		namespaceAccessMu.Lock()
+		namespaceAccess[namespace] = allowed
+		namespaceAccessMu.Unlock()
+	}
+	return allowed
+}
+
+// reviewNamespaceRules runs a SelfSubjectRulesReview for namespace. The
+// review also returns the rules every authenticated user has for access
+// reviews, which alone don't make a namespace accessible. Returns
+// conclusive=false, and allowed, when the review can't be made or its rule
+// list is incomplete.
+func reviewNamespaceRules(ctx context.Context, namespace string) (allowed, conclusive bool) {
+	k8sClient := GetClient()
+	if k8sClient == nil {
+		return true, false
+	}
+
+	review := &authv1.SelfSubjectRulesReview{
+		Spec: authv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}
+	result, err := k8sClient.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		if !apierrors.IsForbidden(err) {
+			log.Printf("Warning: SelfSubjectRulesReview failed for namespace %s: %v", namespace, err)
+		}
+		return true, false
+	}
+
+	for _, rule := range result.Status.ResourceRules {
+		for _, group := range rule.APIGroups {
+			if group != "authorization.k8s.io" && group != "authentication.k8s.io" {
+				return true, true
+			}
+		}
+	}
+	// Webhook authorizers may not list their rules
+	if result.Status.Incomplete {
+		return true, false
+	}
+	return false, true
+}
@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NamespacePermissions is what the current user can do in one namespace.
+// Each action is checked against the resource the UI uses it on.
+type NamespacePermissions struct {
+	Namespace string `json:"namespace"`
+	List      bool   `json:"list"`   // list pods
+	Get       bool   `json:"get"`    // get pods
+	Edit      bool   `json:"edit"`   // update deployments
+	Delete    bool   `json:"delete"` // delete pods
+	Exec      bool   `json:"exec"`   // create pods/exec
+	Logs      bool   `json:"logs"`   // get pods/log
+}
+
+// NamespacePermissionMatrix is the access overview for a set of namespaces
+type NamespacePermissionMatrix struct {
+	Namespaces []NamespacePermissions `json:"namespaces"`
+
+	// Set when SelfSubjectAccessReviews are forbidden: list was probed and
+	// the other actions are assumed allowed, as in CheckCapabilities
+	RBACIntrospectionUnavailable bool `json:"rbacIntrospectionUnavailable,omitempty"`
+
+	// Set when all namespaces were asked for and only a page of them was
+	// checked; Continue is the ?continue= value for the next page
+	Truncated bool   `json:"truncated,omitempty"`
+	Continue  string `json:"continue,omitempty"`
+}
+
+// namespacePermissionChecks are the reviews behind NamespacePermissions
+var namespacePermissionChecks = []struct {
+	group, resource, verb string
+	set                   func(*NamespacePermissions, bool)
+}{
+	{"", "pods", "list", func(p *NamespacePermissions, v bool) { p.List = v }},
+	{"", "pods", "get", func(p *NamespacePermissions, v bool) { p.Get = v }},
+	{"apps", "deployments", "update", func(p *NamespacePermissions, v bool) { p.Edit = v }},
+	{"", "pods", "delete", func(p *NamespacePermissions, v bool) { p.Delete = v }},
+	{"", "pods/exec", "create", func(p *NamespacePermissions, v bool) { p.Exec = v }},
+	{"", "pods/log", "get", func(p *NamespacePermissions, v bool) { p.Logs = v }},
+}
+
+var (
+	namespacePermissions       = make(map[string]NamespacePermissions)
+	namespacePermissionsMu     sync.Mutex
+	namespacePermissionsExpiry time.Time
+)
+
+// CheckNamespacePermissions returns the permission matrix for namespaces, in
+// the order given with duplicates removed. Reviews for namespaces not in the
+// cache run in parallel, bounded like CanListResources, and the results are
+// cached per namespace with the same TTL as CheckCapabilities.
+func CheckNamespacePermissions(ctx context.Context, namespaces []string) *NamespacePermissionMatrix {
+	namespacePermissionsMu.Lock()
+	if time.Now().After(namespacePermissionsExpiry) {
+		namespacePermissions = make(map[string]NamespacePermissions)
+		namespacePermissionsExpiry = time.Now().Add(capabilitiesTTL)
+	}
+	var ordered, missing []string
+	seen := make(map[string]bool, len(namespaces))
+	results := make(map[string]*NamespacePermissions, len(namespaces))
+	for _, ns := range namespaces {
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		ordered = append(ordered, ns)
+		if cached, ok := namespacePermissions[ns]; ok {
+			results[ns] = &cached
+		} else {
+			results[ns] = &NamespacePermissions{Namespace: ns}
+			missing = append(missing, ns)
+		}
+	}
+	namespacePermissionsMu.Unlock()
+
+	if len(missing) > 0 && GetClient() != nil {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		sem := make(chan struct{}, listAccessConcurrency)
+		for _, ns := range missing {
+			for _, check := range namespacePermissionChecks {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+					allowed := canIGroup(ctx, ns, check.group, check.resource, check.verb)
+					mu.Lock()
+					check.set(results[ns], allowed)
+					mu.Unlock()
+				}()
+			}
+		}
+		wg.Wait()
+
+		// Don't cache results from a cancelled request; they failed closed
+		if ctx.Err() == nil {
+			namespacePermissionsMu.Lock()
+			for _, ns := range missing {
+				namespacePermissions[ns] = *results[ns]
+			}
+			namespacePermissionsMu.Unlock()
+		}
+	}
+
+	matrix := &NamespacePermissionMatrix{
+		Namespaces:                   make([]NamespacePermissions, 0, len(ordered)),
+		RBACIntrospectionUnavailable: ssarForbidden.Load(),
+	}
+	for _, ns := range ordered {
+		matrix.Namespaces = append(matrix.Namespaces, *results[ns])
+	}
+	return matrix
+}
@@ -0,0 +1,240 @@
+package k8s
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// minRecommendationSamples is how much history a recommendation needs (5 minutes at 30s intervals)
+	minRecommendationSamples = 10
+
+	// Recommended requests leave this much headroom over observed usage:
+	// CPU over the 95th percentile, since throttling is recoverable, and
+	// memory over the peak, since running out gets the container killed
+	cpuHeadroom    = 1.15
+	memoryHeadroom = 1.2
+
+	// Requests this far above usage are reported as over-provisioned
+	overProvisionedRatio = 2.0
+
+	// Differences smaller than these aren't worth acting on
+	minCPUSavings    = 50 * 1000 * 1000 // 50m in nanocores
+	minMemorySavings = 64 * 1024 * 1024
+
+	// Limits with peak usage above this fraction are reported as tight
+	nearLimitRatio = 0.9
+
+	// Floors for recommended requests
+	minCPURecommendation    = 10 * 1000 * 1000 // 10m
+	minMemoryRecommendation = 16 * 1024 * 1024
+)
+
+// Recommendation verdicts
+const (
+	VerdictOK               = "ok"
+	VerdictOverProvisioned  = "over-provisioned"
+	VerdictUnderProvisioned = "under-provisioned"
+	VerdictNoRequest        = "no-request"
+	VerdictInsufficientData = "insufficient-data"
+	VerdictNearLimit        = "near-limit"
+)
+
+// UsageStats summarizes the samples of one resource
+type UsageStats struct {
+	P50 int64 `json:"p50"`
+	P95 int64 `json:"p95"`
+	Max int64 `json:"max"`
+}
+
+// ResourceRecommendation compares one resource of a container with its
+// spec. CPU is in nanocores and memory in bytes, as in MetricsDataPoint;
+// Request and Limit are 0 when unset.
+type ResourceRecommendation struct {
+	Request     int64      `json:"request"`
+	Limit       int64      `json:"limit"`
+	Usage       UsageStats `json:"usage"`
+	Recommended int64      `json:"recommended"` // Suggested request; 0 without enough data
+	Verdict     string     `json:"verdict"`
+	Message     string     `json:"message"`
+}
+
+// ContainerRecommendation holds the CPU and memory recommendations for a container
+type ContainerRecommendation struct {
+	Name    string                 `json:"name"`
+	Samples int                    `json:"samples"`
+	CPU     ResourceRecommendation `json:"cpu"`
+	Memory  ResourceRecommendation `json:"memory"`
+}
+
+// PodRecommendations are read-only request recommendations for a pod's
+// containers, from the metrics history the explorer samples. They're only
+// as good as the window: a pod with daily peaks needs longer history than
+// the explorer keeps, so treat them as a prompt to look, not a VPA.
+type PodRecommendations struct {
+	Namespace  string                    `json:"namespace"`
+	Name       string                    `json:"name"`
+	Window     string                    `json:"window"` // Span of the samples used, e.g. 45m0s
+	MinSamples int                       `json:"minSamples"`
+	Containers []ContainerRecommendation `json:"containers"`
+}
+
+// RecommendResources compares each container's sampled usage in history
+// with its requests and limits. history may be nil when no samples were
+// collected yet.
+func RecommendResources(pod *corev1.Pod, history *PodMetricsHistory) *PodRecommendations {
+	result := &PodRecommendations{
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		MinSamples: minRecommendationSamples,
+		Containers: make([]ContainerRecommendation, 0, len(pod.Spec.Containers)),
+	}
+
+	samples := make(map[string][]MetricsDataPoint)
+	if history != nil {
+		for _, c := range history.Containers {
+			samples[c.Name] = c.DataPoints
+		}
+	}
+
+	var first, last time.Time
+	for _, c := range pod.Spec.Containers {
+		points := samples[c.Name]
+		cpu := make([]int64, 0, len(points))
+		mem := make([]int64, 0, len(points))
+		for _, p := range points {
+			cpu = append(cpu, p.CPU)
+			mem = append(mem, p.Memory)
+			if first.IsZero() || p.Timestamp.Before(first) {
+				first = p.Timestamp
+			}
+			if p.Timestamp.After(last) {
+				last = p.Timestamp
+			}
+		}
+
+		cpuRequest, cpuLimit := int64(0), int64(0)
+		if q, ok := c.Resources.Requests[corev1.ResourceCPU]; ok {
+			cpuRequest = q.MilliValue() * 1000 * 1000
+		}
+		if q, ok := c.Resources.Limits[corev1.ResourceCPU]; ok {
+			cpuLimit = q.MilliValue() * 1000 * 1000
+		}
+		memRequest, memLimit := int64(0), int64(0)
+		if q, ok := c.Resources.Requests[corev1.ResourceMemory]; ok {
+			memRequest = q.Value()
+		}
+		if q, ok := c.Resources.Limits[corev1.ResourceMemory]; ok {
+			memLimit = q.Value()
+		}
+
+		result.Containers = append(result.Containers, ContainerRecommendation{
+			Name:    c.Name,
+			Samples: len(points),
+			CPU:     recommendCPU(cpu, cpuRequest, cpuLimit),
+			Memory:  recommendMemory(mem, memRequest, memLimit),
+		})
+	}
+	if !first.IsZero() {
+		result.Window = last.Sub(first).String()
+	}
+	return result
+}
+
+// recommendCPU sizes the CPU request to the 95th percentile plus headroom
+func recommendCPU(samples []int64, request, limit int64) ResourceRecommendation {
+	rec := ResourceRecommendation{Request: request, Limit: limit, Usage: usageStats(samples)}
+	if len(samples) < minRecommendationSamples {
+		rec.Verdict = VerdictInsufficientData
+		rec.Message = fmt.Sprintf("%d of %d samples collected", len(samples), minRecommendationSamples)
+		return rec
+	}
+	rec.Recommended = roundUp(max(int64(float64(rec.Usage.P95)*cpuHeadroom), minCPURecommendation), 5*1000*1000)
+
+	switch {
+	case request == 0:
+		rec.Verdict = VerdictNoRequest
+		rec.Message = fmt.Sprintf("No CPU request; p95 usage is %s, consider requesting %s", formatCPU(rec.Usage.P95), formatCPU(rec.Recommended))
+	case rec.Usage.P95 > request:
+		rec.Verdict = VerdictUnderProvisioned
+		rec.Message = fmt.Sprintf("Requests %s but p95 usage is %s; consider %s", formatCPU(request), formatCPU(rec.Usage.P95), formatCPU(rec.Recommended))
+	case float64(request) >= float64(rec.Usage.P95)*overProvisionedRatio && request-rec.Recommended >= minCPUSavings:
+		rec.Verdict = VerdictOverProvisioned
+		rec.Message = fmt.Sprintf("Requests %s but p95 usage is %s; %s would be enough", formatCPU(request), formatCPU(rec.Usage.P95), formatCPU(rec.Recommended))
+	case limit > 0 && float64(rec.Usage.Max) >= float64(limit)*nearLimitRatio:
+		rec.Verdict = VerdictNearLimit
+		rec.Message = fmt.Sprintf("Peak usage %s is close to the %s limit; the container is likely throttled", formatCPU(rec.Usage.Max), formatCPU(limit))
+	default:
+		rec.Verdict = VerdictOK
+		rec.Message = fmt.Sprintf("Request %s fits p95 usage of %s", formatCPU(request), formatCPU(rec.Usage.P95))
+	}
+	return rec
+}
+
+// recommendMemory sizes the memory request to the peak plus headroom
+func recommendMemory(samples []int64, request, limit int64) ResourceRecommendation {
+	rec := ResourceRecommendation{Request: request, Limit: limit, Usage: usageStats(samples)}
+	if len(samples) < minRecommendationSamples {
+		rec.Verdict = VerdictInsufficientData
+		rec.Message = fmt.Sprintf("%d of %d samples collected", len(samples), minRecommendationSamples)
+		return rec
+	}
+	rec.Recommended = roundUp(max(int64(float64(rec.Usage.Max)*memoryHeadroom), minMemoryRecommendation), 1024*1024)
+
+	switch {
+	case limit > 0 && float64(rec.Usage.Max) >= float64(limit)*nearLimitRatio:
+		// Checked first: close to the limit means close to an OOM kill
+		rec.Verdict = VerdictNearLimit
+		rec.Message = fmt.Sprintf("Peak usage %s is close to the %s limit; the container risks being OOM killed", formatMemory(rec.Usage.Max), formatMemory(limit))
+	case request == 0:
+		rec.Verdict = VerdictNoRequest
+		rec.Message = fmt.Sprintf("No memory request; peak usage is %s, consider requesting %s", formatMemory(rec.Usage.Max), formatMemory(rec.Recommended))
+	case rec.Usage.Max > request:
+		rec.Verdict = VerdictUnderProvisioned
+		rec.Message = fmt.Sprintf("Requests %s but peak usage is %s; consider %s", formatMemory(request), formatMemory(rec.Usage.Max), formatMemory(rec.Recommended))
+	case float64(request) >= float64(rec.Usage.Max)*overProvisionedRatio && request-rec.Recommended >= minMemorySavings:
+		rec.Verdict = VerdictOverProvisioned
+		rec.Message = fmt.Sprintf("Requests %s but peak usage is %s; %s would be enough", formatMemory(request), formatMemory(rec.Usage.Max), formatMemory(rec.Recommended))
+	default:
+		rec.Verdict = VerdictOK
+		rec.Message = fmt.Sprintf("Request %s fits peak usage of %s", formatMemory(request), formatMemory(rec.Usage.Max))
+	}
+	return rec
+}
+
+// usageStats returns the nearest-rank percentiles of samples
+func usageStats(samples []int64) UsageStats {
+	if len(samples) == 0 {
+		return UsageStats{}
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	rank := func(p float64) int64 {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(idx, 0)]
+	}
+	return UsageStats{P50: rank(0.50), P95: rank(0.95), Max: sorted[len(sorted)-1]}
+}
+
+// roundUp rounds v up to a multiple of step
+func roundUp(v, step int64) int64 {
+	return (v + step - 1) / step * step
+}
+
+// formatCPU formats nanocores as millicores, e.g. 250m
+func formatCPU(nanocores int64) string {
+	return fmt.Sprintf("%dm", (nanocores+500*1000)/(1000*1000))
+}
+
+// formatMemory formats bytes in Mi, or Gi from 1Gi up
+func formatMemory(bytes int64) string {
+	const mi = 1024 * 1024
+	if bytes >= 1024*mi {
+		return fmt.Sprintf("%.1fGi", float64(bytes)/(1024*mi))
+	}
+	return fmt.Sprintf("%dMi", (bytes+mi/2)/mi)
+}
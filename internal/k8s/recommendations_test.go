@@ -0,0 +1,71 @@
+package k8s
+
+import "testing"
+
+// usageSamples returns n samples of v, with the last one replaced by spike
+// when set
+func usageSamples(n int, v, spike int64) []int64 {
+	samples := make([]int64, n)
+	for i := range samples {
+		samples[i] = v
+	}
+	if spike > 0 {
+		samples[n-1] = spike
+	}
+	return samples
+}
+
+func TestRecommendCPU(t *testing.T) {
+	const m = 1000 * 1000 // nanocores per millicore
+	tests := []struct {
+		name           string
+		samples        []int64
+		request, limit int64
+		want           string
+	}{
+		{"too few samples", usageSamples(minRecommendationSamples-1, 50*m, 0), 100 * m, 0, VerdictInsufficientData},
+		{"no request", usageSamples(20, 50*m, 0), 0, 0, VerdictNoRequest},
+		{"p95 above request", usageSamples(20, 200*m, 0), 100 * m, 0, VerdictUnderProvisioned},
+		{"spike above request is ignored", usageSamples(20, 50*m, 400*m), 100 * m, 0, VerdictOK},
+		{"over-provisioned", usageSamples(20, 50*m, 0), 500 * m, 0, VerdictOverProvisioned},
+		{"savings below floor", usageSamples(20, 20*m, 0), 60 * m, 0, VerdictOK},
+		{"near limit", usageSamples(20, 95*m, 0), 100 * m, 100 * m, VerdictNearLimit},
+		// Throttling is recoverable, so an undersized request is reported first
+		{"under-provisioned before near limit", usageSamples(20, 150*m, 0), 100 * m, 160 * m, VerdictUnderProvisioned},
+		{"fits", usageSamples(20, 80*m, 0), 100 * m, 200 * m, VerdictOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recommendCPU(tt.samples, tt.request, tt.limit); got.Verdict != tt.want {
+				t.Errorf("expected %s, got %s (%s)", tt.want, got.Verdict, got.Message)
+			}
+		})
+	}
+}
+
+func TestRecommendMemory(t *testing.T) {
+	const mi = 1024 * 1024
+	tests := []struct {
+		name           string
+		samples        []int64
+		request, limit int64
+		want           string
+	}{
+		{"too few samples", usageSamples(minRecommendationSamples-1, 100*mi, 0), 256 * mi, 0, VerdictInsufficientData},
+		// Close to the limit means close to an OOM kill, so it's reported first
+		{"near limit before under-provisioned", usageSamples(20, 300*mi, 0), 100 * mi, 320 * mi, VerdictNearLimit},
+		{"near limit before no request", usageSamples(20, 300*mi, 0), 0, 320 * mi, VerdictNearLimit},
+		{"no request", usageSamples(20, 100*mi, 0), 0, 0, VerdictNoRequest},
+		{"spike above request", usageSamples(20, 50*mi, 400*mi), 256 * mi, 0, VerdictUnderProvisioned},
+		{"over-provisioned", usageSamples(20, 100*mi, 0), 512 * mi, 0, VerdictOverProvisioned},
+		{"savings below floor", usageSamples(20, 40*mi, 0), 100 * mi, 0, VerdictOK},
+		{"fits", usageSamples(20, 200*mi, 0), 256 * mi, 512 * mi, VerdictOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recommendMemory(tt.samples, tt.request, tt.limit); got.Verdict != tt.want {
+				t.Errorf("expected %s, got %s (%s)", tt.want, got.Verdict, got.Message)
+			}
+		})
+	}
+}
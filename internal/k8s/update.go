@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -69,6 +70,68 @@ func UpdateResource(ctx context.Context, opts UpdateResourceOptions) (*unstructu
 	return result, nil
 }
 
+// PatchResourceOptions contains options for patching a resource
+type PatchResourceOptions struct {
+	Kind      string
+	Namespace string
+	Name      string
+	PatchType types.PatchType
+	Patch     []byte
+}
+
+// PatchResource applies a patch to a Kubernetes resource. JSON patches
+// (RFC 6902) are checked to be an array of operations before being sent, so
+// an obviously malformed body doesn't reach the API server.
+func PatchResource(ctx context.Context, opts PatchResourceOptions) (*unstructured.Unstructured, error) {
+	discovery := GetResourceDiscovery()
+	if discovery == nil {
+		return nil, fmt.Errorf("resource discovery not initialized")
+	}
+
+	dynamicClient := GetDynamicClient()
+	if dynamicClient == nil {
+		return nil, fmt.Errorf("dynamic client not initialized")
+	}
+
+	if opts.PatchType == types.JSONPatchType {
+		var ops []map[string]interface{}
+		if err := json.Unmarshal(opts.Patch, &ops); err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: expected an array of operations: %w", err)
+		}
+		if len(ops) == 0 {
+			return nil, fmt.Errorf("invalid JSON patch: no operations")
+		}
+		for idx, op := range ops {
+			if _, ok := op["op"].(string); !ok {
+				return nil, fmt.Errorf("invalid JSON patch: operation %d has no op", idx)
+			}
+			if _, ok := op["path"].(string); !ok {
+				return nil, fmt.Errorf("invalid JSON patch: operation %d has no path", idx)
+			}
+		}
+	}
+
+	// Get GVR for this resource kind
+	gvr, ok := discovery.GetGVR(opts.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown resource kind: %s", opts.Kind)
+	}
+
+	var result *unstructured.Unstructured
+	var err error
+	if opts.Namespace != "" {
+		result, err = dynamicClient.Resource(gvr).Namespace(opts.Namespace).Patch(ctx, opts.Name, opts.PatchType, opts.Patch, metav1.PatchOptions{})
+	} else {
+		result, err = dynamicClient.Resource(gvr).Patch(ctx, opts.Name, opts.PatchType, opts.Patch, metav1.PatchOptions{})
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch resource: %w", err)
+	}
+
+	return result, nil
+}
+
 // DeleteResource deletes a Kubernetes resource
 func DeleteResource(ctx context.Context, kind, namespace, name string) error {
 	discovery := GetResourceDiscovery()
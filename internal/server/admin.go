@@ -0,0 +1,46 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// requireAdminToken refuses the request unless it carries the --admin-token
+// as a bearer token. Without a configured token the admin routes are disabled.
+func (s *Server) requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			s.writeError(w, http.StatusForbidden, "Admin endpoints are disabled: start the server with --admin-token")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="radar-admin"`)
+			s.writeError(w, http.StatusUnauthorized, "Invalid or missing admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleAdminShutdown tears the server down like SIGTERM does and exits with
+// a non-zero code, so a pod's container is restarted in place.
+// POST /api/admin/shutdown
+func (s *Server) handleAdminShutdown(w http.ResponseWriter, r *http.Request) {
+	if s.onShutdown == nil {
+		s.writeError(w, http.StatusNotImplemented, "Shutdown is not available")
+		return
+	}
+
+	log.Printf("Shutdown requested via admin endpoint from %s", r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	s.writeJSON(w, map[string]string{"status": "shutting down"})
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	go s.onShutdown()
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// argoWatchRetryDelay is how long to wait before re-establishing a watch
+// that failed to start
+const argoWatchRetryDelay = 5 * time.Second
+
+// ArgoAppStatus is the part of an ArgoCD Application's status reported by
+// the watch stream
+type ArgoAppStatus struct {
+	SyncStatus       string `json:"syncStatus,omitempty"` // Synced, OutOfSync, Unknown
+	Revision         string `json:"revision,omitempty"`
+	HealthStatus     string `json:"healthStatus,omitempty"` // Healthy, Progressing, Degraded, Suspended, Missing, Unknown
+	HealthMessage    string `json:"healthMessage,omitempty"`
+	OperationPhase   string `json:"operationPhase,omitempty"` // Running, Terminating, Succeeded, Failed, Error
+	OperationMessage string `json:"operationMessage,omitempty"`
+}
+
+// argoAppStatus extracts the watched status fields from an Application
+func argoAppStatus(app *unstructured.Unstructured) ArgoAppStatus {
+	var st ArgoAppStatus
+	st.SyncStatus, _, _ = unstructured.NestedString(app.Object, "status", "sync", "status")
+	st.Revision, _, _ = unstructured.NestedString(app.Object, "status", "sync", "revision")
+	st.HealthStatus, _, _ = unstructured.NestedString(app.Object, "status", "health", "status")
+	st.HealthMessage, _, _ = unstructured.NestedString(app.Object, "status", "health", "message")
+	st.OperationPhase, _, _ = unstructured.NestedString(app.Object, "status", "operationState", "phase")
+	st.OperationMessage, _, _ = unstructured.NestedString(app.Object, "status", "operationState", "message")
+	return st
+}
+
+// handleArgoWatch streams an ArgoCD Application's sync, health and operation
+// status via SSE. The current status is sent first, then a status event each
+// time one of the fields changes. Watches that expire or are closed by the API
+// server are re-established from the last seen resourceVersion, or from a
+// fresh read when it is too old, so the client sees one continuous stream.
+// GET /api/argo/applications/{namespace}/{name}/watch
+func (s *Server) handleArgoWatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	client := k8s.GetDynamicClient()
+	if client == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "dynamic client not available")
+		return
+	}
+	apps := client.Resource(argoApplicationGVR).Namespace(namespace)
+
+	app, err := apps.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	send := func(event string, v any) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	last := argoAppStatus(app)
+	resourceVersion := app.GetResourceVersion()
+	if !send("connected", struct{}{}) || !send("status", last) {
+		return
+	}
+
+	// update reports a status change; false means the client went away
+	update := func(app *unstructured.Unstructured) bool {
+		resourceVersion = app.GetResourceVersion()
+		if st := argoAppStatus(app); st != last {
+			last = st
+			return send("status", st)
+		}
+		return true
+	}
+
+	// resync re-reads the Application after its resourceVersion expired.
+	// Returns false once the stream should end.
+	resync := func() bool {
+		app, err := apps.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				send("deleted", struct{}{})
+				return false
+			}
+			log.Printf("[argo] Watch resync failed for %s/%s, retrying: %v", namespace, name, err)
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(argoWatchRetryDelay):
+				return true
+			}
+		}
+		return update(app)
+	}
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for ctx.Err() == nil {
+		watcher, err := apps.Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: resourceVersion,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				if !resync() {
+					return
+				}
+				continue
+			}
+			log.Printf("[argo] Failed to watch %s/%s, retrying: %v", namespace, name, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(argoWatchRetryDelay):
+			}
+			continue
+		}
+
+		if !s.streamArgoWatch(watcher, heartbeat, update, resync, send) {
+			watcher.Stop()
+			return
+		}
+		watcher.Stop()
+	}
+}
+
+// streamArgoWatch forwards one watch's events until its channel closes.
+// Returns false once the stream should end (client gone or app deleted).
+func (s *Server) streamArgoWatch(watcher watch.Interface, heartbeat *time.Ticker,
+	update func(*unstructured.Unstructured) bool, resync func() bool, send func(string, any) bool) bool {
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				// Closed by the API server (watch timeout); the caller restarts it
+				return true
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				app, ok := event.Object.(*unstructured.Unstructured)
+				if ok && !update(app) {
+					return false
+				}
+			case watch.Deleted:
+				send("deleted", struct{}{})
+				return false
+			case watch.Error:
+				// Usually 410 Gone: the resourceVersion is too old to resume from
+				if status, ok := event.Object.(*metav1.Status); ok && status.Code != http.StatusGone {
+					log.Printf("[argo] Watch error: %s", status.Message)
+				}
+				return resync()
+			}
+
+		case <-heartbeat.C:
+			if !send("heartbeat", struct{}{}) {
+				return false
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// clusterEventFlushInterval is how often queued events are sent. Repeats of
+// the same Event within one interval are collapsed into a single update.
+const clusterEventFlushInterval = time.Second
+
+// maxPendingClusterEvents caps the distinct Events queued between flushes;
+// new Events beyond it are dropped and counted
+const maxPendingClusterEvents = 1000
+
+// ClusterEvent is one K8s Event in the cluster events feed
+type ClusterEvent struct {
+	UID            string                 `json:"uid"`
+	Namespace      string                 `json:"namespace"`
+	Name           string                 `json:"name"`
+	Type           string                 `json:"type"` // Normal or Warning
+	Reason         string                 `json:"reason"`
+	Message        string                 `json:"message"`
+	Count          int32                  `json:"count"`
+	FirstTimestamp time.Time              `json:"firstTimestamp"`
+	LastTimestamp  time.Time              `json:"lastTimestamp"`
+	InvolvedObject corev1.ObjectReference `json:"involvedObject"`
+	Repeat         bool                   `json:"repeat"` // An update of an Event already sent: replace it rather than adding a row
+
+	seen time.Time // When the Event last occurred, for resume tokens
+}
+
+func toClusterEvent(event *corev1.Event, repeat bool) ClusterEvent {
+	return ClusterEvent{
+		UID:            string(event.UID),
+		Namespace:      event.Namespace,
+		Name:           event.Name,
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Count:          event.Count,
+		FirstTimestamp: event.FirstTimestamp.Time,
+		LastTimestamp:  event.LastTimestamp.Time,
+		InvolvedObject: event.InvolvedObject,
+		Repeat:         repeat,
+		seen:           eventTime(event).Time,
+	}
+}
+
+// clusterEventQueue collects Events between flushes, keeping only the latest
+// state of each so a rapidly repeating Event is sent once per flush
+type clusterEventQueue struct {
+	mu      sync.Mutex
+	order   []string
+	pending map[string]ClusterEvent
+	dropped int
+}
+
+func (q *clusterEventQueue) add(event ClusterEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if queued, ok := q.pending[event.UID]; ok {
+		// Still a new row to the client if its first sighting hasn't been sent
+		event.Repeat = queued.Repeat && event.Repeat
+		q.pending[event.UID] = event
+		return
+	}
+	if len(q.pending) >= maxPendingClusterEvents {
+		q.dropped++
+		return
+	}
+	q.order = append(q.order, event.UID)
+	q.pending[event.UID] = event
+}
+
+// addReplayed queues an Event replayed on resume unless a live update of it
+// is already queued, which is newer
+func (q *clusterEventQueue) addReplayed(event ClusterEvent) {
+	q.mu.Lock()
+	_, queued := q.pending[event.UID]
+	q.mu.Unlock()
+	if !queued {
+		q.add(event)
+	}
+}
+
+// take returns the queued Events in arrival order and the number dropped
+// since the last call, and empties the queue
+func (q *clusterEventQueue) take() ([]ClusterEvent, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	events := make([]ClusterEvent, 0, len(q.order))
+	for _, uid := range q.order {
+		events = append(events, q.pending[uid])
+	}
+	dropped := q.dropped
+	q.order = nil
+	q.pending = make(map[string]ClusterEvent)
+	q.dropped = 0
+	return events, dropped
+}
+
+// handleWatchClusterEvents streams K8s Events from the whole cluster via SSE,
+// optionally filtered by namespace and type (Normal or Warning). Only Events
+// created or repeated after connecting are sent; GET /api/events has the
+// existing ones. An Event that repeats is sent again with its new count and
+// repeat set, at most once per flush interval. If events arrive faster than
+// they can be queued, a dropped event reports how many were lost. Each event
+// carries a resume token as its SSE id; reconnecting with it (Last-Event-ID
+// or ?resume=) first replays Events from the cache that changed since then.
+// Events from the same second as the token are sent again as repeats, since
+// K8s Event timestamps only have second precision.
+// GET /api/events/watch
+func (s *Server) handleWatchClusterEvents(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	eventType := r.URL.Query().Get("type")
+	if eventType != "" && eventType != corev1.EventTypeNormal && eventType != corev1.EventTypeWarning {
+		s.writeError(w, http.StatusBadRequest, "type must be Normal or Warning")
+		return
+	}
+
+	resumeFrom, resumed, err := parseResumeToken(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	matches := func(event *corev1.Event) bool {
+		return (namespace == "" || event.Namespace == namespace) && (eventType == "" || event.Type == eventType)
+	}
+	queue := &clusterEventQueue{pending: make(map[string]ClusterEvent)}
+	stop, err := cache.WatchEvents(func(event *corev1.Event, repeat bool) {
+		if matches(event) {
+			queue.add(toClusterEvent(event, repeat))
+		}
+	})
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer stop()
+
+	// Replay what changed while the client was away. The watch is already
+	// running, so nothing between the listing and the first flush is missed.
+	if resumed {
+		events, err := cache.Events().List(labels.Everything())
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		sort.Slice(events, func(i, j int) bool { return eventTime(events[i]).Before(eventTime(events[j])) })
+		for _, event := range events {
+			if !matches(event) || eventTime(event).Time.Before(resumeFrom.Truncate(time.Second)) {
+				continue
+			}
+			queue.addReplayed(toClusterEvent(event, !event.CreationTimestamp.Time.After(resumeFrom)))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	sendWithID := func(event, id string, v any) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if id != "" {
+			_, err = fmt.Fprintf(w, "event: %s\nid: %s\ndata: %s\n\n", event, id, data)
+		} else {
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		}
+		if err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+	send := func(event string, v any) bool { return sendWithID(event, "", v) }
+
+	ctx := r.Context()
+	if !send("connected", map[string]any{"namespace": namespace, "type": eventType, "resumed": resumed}) {
+		return
+	}
+
+	// Time of the newest Event sent, for the resume token
+	position := resumeFrom
+
+	flush := time.NewTicker(clusterEventFlushInterval)
+	defer flush.Stop()
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-flush.C:
+			events, dropped := queue.take()
+			for _, event := range events {
+				if event.seen.After(position) {
+					position = event.seen
+				}
+				if !sendWithID("event", encodeResumeToken(position), event) {
+					return
+				}
+			}
+			if dropped > 0 && !send("dropped", map[string]int{"count": dropped}) {
+				return
+			}
+
+		case <-heartbeat.C:
+			if !send("heartbeat", struct{}{}) {
+				return
+			}
+		}
+	}
+}
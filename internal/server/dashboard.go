@@ -195,7 +195,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 
 	cache := k8s.GetResourceCache()
 	if cache == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Resource cache not available")
+		s.writeCacheUnavailable(w)
 		return
 	}
 
@@ -0,0 +1,378 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// gvrWatchBuffer is how many events a GVR watch stream can fall behind by
+// before it is closed and the client has to list again
+const gvrWatchBuffer = 256
+
+// coreGroupPathName stands in for the core API group, which is empty, in paths
+const coreGroupPathName = "core"
+
+// gvrWatchEvent is one event queued for a GVR watch stream
+type gvrWatchEvent struct {
+	Type   watch.EventType
+	Object *unstructured.Unstructured
+	Synced bool // Marks the end of the pre-existing resources
+}
+
+// gvrRequest is a validated generic resource request
+type gvrRequest struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	selector  labels.Selector
+}
+
+// parseGVRRequest resolves the group/version/resource path of a generic
+// resource request against discovery, and checks that the resource can be
+// listed and watched and that the user is allowed to list it. Writes the
+// error response and returns false when the request can't be served.
+func (s *Server) parseGVRRequest(w http.ResponseWriter, r *http.Request) (gvrRequest, bool) {
+	group := chi.URLParam(r, "group")
+	if group == coreGroupPathName {
+		group = ""
+	}
+	req := gvrRequest{
+		gvr: schema.GroupVersionResource{
+			Group:    group,
+			Version:  chi.URLParam(r, "version"),
+			Resource: chi.URLParam(r, "resource"),
+		},
+		namespace: chi.URLParam(r, "namespace"),
+		selector:  labels.Everything(),
+	}
+
+	if raw := r.URL.Query().Get("labelSelector"); raw != "" {
+		selector, err := labels.Parse(raw)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid labelSelector: %v", err))
+			return req, false
+		}
+		req.selector = selector
+	}
+
+	res, ok := k8s.GetResourceDiscovery().GetResourceForGVR(req.gvr)
+	if !ok {
+		s.writeError(w, http.StatusNotFound, fmt.Sprintf("unknown resource: %s", req.gvr.String()))
+		return req, false
+	}
+	if req.namespace != "" && !res.Namespaced {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("%s is cluster-scoped", res.Name))
+		return req, false
+	}
+	if !slices.Contains(res.Verbs, "list") || !slices.Contains(res.Verbs, "watch") {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("%s does not support list/watch", res.Name))
+		return req, false
+	}
+
+	// The dynamic cache watches cluster-wide, so a namespace-only grant isn't enough
+	gr := req.gvr.GroupResource()
+	if !k8s.CanListResources(r.Context(), []schema.GroupResource{gr})[gr] {
+		s.writeError(w, http.StatusForbidden, fmt.Sprintf("not allowed to list %s", gr.String()))
+		return req, false
+	}
+	return req, true
+}
+
+// handleListGVR lists resources of any type by group, version and resource
+// from the dynamic cache, optionally filtered by labelSelector. The core group
+// is written as "core".
+// GET /api/resources/gvr/{group}/{version}/{resource}
+// GET /api/resources/gvr/{group}/{version}/namespaces/{namespace}/{resource}
+func (s *Server) handleListGVR(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.parseGVRRequest(w, r)
+	if !ok {
+		return
+	}
+
+	dynamicCache := k8s.GetDynamicResourceCache()
+	if dynamicCache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+
+	items, err := dynamicCache.ListWithSelector(req.gvr, req.namespace, req.selector)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !dynamicCache.IsSynced(req.gvr) {
+		if !dynamicCache.WaitForSync(req.gvr, dynamicListSyncTimeout) {
+			s.writeCacheSyncing(w)
+			return
+		}
+		if items, err = dynamicCache.ListWithSelector(req.gvr, req.namespace, req.selector); err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	s.writeJSON(w, items)
+}
+
+// handleWatchGVR streams changes to resources of any type via SSE. Existing
+// resources are sent first as added events, followed by a synced event, then
+// added, modified and deleted events as they happen. If the client falls too
+// far behind, an error event is sent and the stream ends; the client should
+// list again and reconnect.
+//
+// With resourceVersion set, the stream resumes from that version instead;
+// see watchGVRFromResourceVersion.
+// GET /api/resources/gvr/{group}/{version}/{resource}/watch
+// GET /api/resources/gvr/{group}/{version}/namespaces/{namespace}/{resource}/watch
+func (s *Server) handleWatchGVR(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.parseGVRRequest(w, r)
+	if !ok {
+		return
+	}
+	if resourceVersion := r.URL.Query().Get("resourceVersion"); resourceVersion != "" {
+		s.watchGVRFromResourceVersion(w, r, req, resourceVersion)
+		return
+	}
+
+	dynamicCache := k8s.GetDynamicResourceCache()
+	if dynamicCache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	events := make(chan gvrWatchEvent, gvrWatchBuffer)
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+
+	stop, synced, err := dynamicCache.Watch(req.gvr, req.namespace, req.selector, func(eventType watch.EventType, obj *unstructured.Unstructured) {
+		select {
+		case events <- gvrWatchEvent{Type: eventType, Object: obj}:
+		default:
+			overflowOnce.Do(func() { close(overflow) })
+		}
+	})
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	send := func(event string, v any) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	ctx := r.Context()
+	if !send("connected", map[string]string{"resource": req.gvr.String(), "namespace": req.namespace}) {
+		return
+	}
+
+	// The handler has delivered every pre-existing resource once synced
+	// reports true, so a marker queued after that follows them in order
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), synced) {
+			return
+		}
+		select {
+		case events <- gvrWatchEvent{Synced: true}:
+		case <-ctx.Done():
+		}
+	}()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-overflow:
+			send("error", map[string]string{"error": "watch fell too far behind; list again and reconnect"})
+			return
+
+		case event := <-events:
+			var ok bool
+			switch {
+			case event.Synced:
+				ok = send("synced", struct{}{})
+			case event.Type == watch.Added:
+				ok = send("added", event.Object)
+			case event.Type == watch.Modified:
+				ok = send("modified", event.Object)
+			case event.Type == watch.Deleted:
+				ok = send("deleted", event.Object)
+			}
+			if !ok {
+				return
+			}
+
+		case <-heartbeat.C:
+			if !send("heartbeat", struct{}{}) {
+				return
+			}
+		}
+	}
+}
+
+// watchGVRFromResourceVersion streams the changes to resources after
+// resourceVersion, watching the API server directly since the informer
+// behind the dynamic cache can't replay history. There's no initial list or
+// synced event. Bookmark events carry the latest resourceVersion so an idle
+// client still has a recent cursor, and watches the API server closes are
+// resumed from the last version seen.
+//
+// When the version is too old to resume from, a resync event is sent and the
+// stream ends: the client should list again, or reconnect without
+// resourceVersion to get the current state followed by live events.
+func (s *Server) watchGVRFromResourceVersion(w http.ResponseWriter, r *http.Request, req gvrRequest, resourceVersion string) {
+	client := k8s.GetDynamicClient()
+	if client == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "dynamic client not available")
+		return
+	}
+	var resources dynamic.ResourceInterface = client.Resource(req.gvr)
+	if req.namespace != "" {
+		resources = client.Resource(req.gvr).Namespace(req.namespace)
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	send := func(event string, v any) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	ctx := r.Context()
+	if !send("connected", map[string]string{"resource": req.gvr.String(), "namespace": req.namespace, "resourceVersion": resourceVersion}) {
+		return
+	}
+
+	resync := func(message string) {
+		send("resync", map[string]string{"resourceVersion": resourceVersion, "error": message})
+	}
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for ctx.Err() == nil {
+		watcher, err := resources.Watch(ctx, metav1.ListOptions{
+			LabelSelector:       req.selector.String(),
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				resync(err.Error())
+				return
+			}
+			send("error", map[string]string{"error": err.Error()})
+			return
+		}
+
+		closed := false
+		for !closed {
+			select {
+			case <-ctx.Done():
+				watcher.Stop()
+				return
+
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					// Closed by the API server (watch timeout); resume from the cursor
+					closed = true
+					continue
+				}
+				if event.Type == watch.Error {
+					watcher.Stop()
+					if status, ok := event.Object.(*metav1.Status); ok && status.Code == http.StatusGone {
+						resync(status.Message)
+					} else {
+						send("error", map[string]string{"error": apierrors.FromObject(event.Object).Error()})
+					}
+					return
+				}
+				obj, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				resourceVersion = obj.GetResourceVersion()
+
+				var sent bool
+				switch event.Type {
+				case watch.Added:
+					sent = send("added", obj)
+				case watch.Modified:
+					sent = send("modified", obj)
+				case watch.Deleted:
+					sent = send("deleted", obj)
+				case watch.Bookmark:
+					sent = send("bookmark", map[string]string{"resourceVersion": resourceVersion})
+				}
+				if !sent {
+					watcher.Stop()
+					return
+				}
+
+			case <-heartbeat.C:
+				if !send("heartbeat", struct{}{}) {
+					watcher.Stop()
+					return
+				}
+			}
+		}
+		watcher.Stop()
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+	"github.com/skyhook-io/radar/internal/topology"
+)
+
+// maxHelmOwnerDepth bounds how many controller owners are followed when
+// looking for Helm metadata, enough for Pod -> ReplicaSet -> Deployment
+const maxHelmOwnerDepth = 3
+
+// helmReleaseFor returns the Helm release managing a resource. Resources
+// created by a controller, like a Deployment's pods, carry no Helm metadata
+// of their own, so the controller owner chain is followed to find it.
+func helmReleaseFor(ctx context.Context, cache *k8s.ResourceCache, resource any) *topology.HelmReleaseRef {
+	for range maxHelmOwnerDepth + 1 {
+		obj, err := meta.Accessor(resource)
+		if err != nil {
+			return nil
+		}
+		if ref := topology.HelmReleaseOf(obj.GetAnnotations(), obj.GetLabels(), obj.GetNamespace()); ref != nil {
+			return ref
+		}
+		owner := metav1.GetControllerOf(obj)
+		if owner == nil {
+			return nil
+		}
+		group := schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind).Group
+		resource, _, err = getCachedResource(ctx, cache, strings.ToLower(owner.Kind), obj.GetNamespace(), owner.Name, group)
+		if err != nil {
+			return nil
+		}
+	}
+	return nil
+}
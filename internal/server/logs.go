@@ -48,7 +48,7 @@ func (s *Server) handlePodLogs(w http.ResponseWriter, r *http.Request) {
 	// Get pod to find containers
 	cache := k8s.GetResourceCache()
 	if cache == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Resource cache not available")
+		s.writeCacheUnavailable(w)
 		return
 	}
 
@@ -115,18 +115,18 @@ func (s *Server) handlePodLogsStream(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
-		return
-	}
-
 	client := k8s.GetClient()
 	if client == nil {
 		sendSSEError(w, flusher, "Kubernetes client not available")
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/skyhook-io/radar/internal/httperr"
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// maxBatchResources caps the items in one batch get request
+const maxBatchResources = 100
+
+// BatchResourceRef identifies one resource in a batch get, the same way as
+// the path of GET /api/resources/{kind}/{namespace}/{name}
+type BatchResourceRef struct {
+	Kind      string `json:"kind"`
+	Group     string `json:"group,omitempty"` // API group for CRD disambiguation
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// BatchResourceResult is one item of a batch get response. Exactly one of
+// Resource and Error is set.
+type BatchResourceResult struct {
+	BatchResourceRef
+	Resource any    `json:"resource,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"` // Same codes as error responses, e.g. NOT_FOUND
+}
+
+// handleBatchGetResources returns several resources from the cache in one
+// call. Items are returned in request order, and a lookup that fails is
+// reported on its item rather than failing the batch.
+// POST /api/resources/batch
+func (s *Server) handleBatchGetResources(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Resources []BatchResourceRef `json:"resources"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Resources) > maxBatchResources {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("too many resources: %d (max %d)", len(req.Resources), maxBatchResources))
+		return
+	}
+
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+
+	results := make([]BatchResourceResult, len(req.Resources))
+	for idx, ref := range req.Resources {
+		results[idx].BatchResourceRef = ref
+		if ref.Kind == "" || ref.Name == "" {
+			results[idx].Error = "kind and name are required"
+			results[idx].Code = httperr.CodeBadRequest
+			continue
+		}
+
+		namespace := ref.Namespace
+		if namespace == "_" {
+			namespace = ""
+		}
+		resource, status, err := getCachedResource(r.Context(), cache, normalizeKind(ref.Kind), namespace, ref.Name, ref.Group)
+		if err != nil {
+			results[idx].Error = err.Error()
+			results[idx].Code = httperr.CodeForStatus(status)
+			continue
+		}
+		results[idx].Resource = resource
+	}
+
+	s.writeJSON(w, map[string]any{"resources": results})
+}
@@ -0,0 +1,465 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// Edge types in a resource graph
+const (
+	graphEdgeOwns            = "owns"            // ownerReference from the child to the owner
+	graphEdgeSelects         = "selects"         // Service selector matches the pods or pod template
+	graphEdgeMounts          = "mounts"          // Volume source: ConfigMap, Secret or PVC
+	graphEdgeEnv             = "env"             // envFrom or env valueFrom: ConfigMap or Secret
+	graphEdgeServiceAccount  = "serviceAccount"  // spec.serviceAccountName
+	graphEdgeImagePullSecret = "imagePullSecret" // spec.imagePullSecrets
+)
+
+// graphKinds maps the kinds a graph can start from, singular or plural, to their display kind
+var graphKinds = map[string]string{
+	"pod": "Pod", "pods": "Pod",
+	"deployment": "Deployment", "deployments": "Deployment",
+	"statefulset": "StatefulSet", "statefulsets": "StatefulSet",
+	"daemonset": "DaemonSet", "daemonsets": "DaemonSet",
+	"replicaset": "ReplicaSet", "replicasets": "ReplicaSet",
+	"job": "Job", "jobs": "Job",
+	"cronjob": "CronJob", "cronjobs": "CronJob",
+	"service": "Service", "services": "Service",
+	"configmap": "ConfigMap", "configmaps": "ConfigMap",
+	"secret": "Secret", "secrets": "Secret",
+	"persistentvolumeclaim": "PersistentVolumeClaim", "persistentvolumeclaims": "PersistentVolumeClaim", "pvc": "PersistentVolumeClaim", "pvcs": "PersistentVolumeClaim",
+}
+
+// ResourceGraph is the dependency graph around one resource
+type ResourceGraph struct {
+	Root  string      `json:"root"` // ID of the resource the graph was built for
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a resource in a ResourceGraph. IDs are kind/namespace/name.
+type GraphNode struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Missing   bool   `json:"missing,omitempty"` // Referenced but not in the cache, e.g. a deleted ConfigMap
+}
+
+// GraphEdge points from the resource that refers to another to the one it refers to
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"` // See the graphEdge* constants
+	Detail string `json:"detail,omitempty"`
+}
+
+// graphObject is a namespace resource indexed for graph building
+type graphObject struct {
+	kind    string
+	meta    metav1.Object
+	podSpec *corev1.PodSpec   // Pods and pod templates of workloads
+	labels  map[string]string // Labels of the pods the object runs, for Service matching
+	service *corev1.Service
+}
+
+func graphNodeID(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// graphBuilder collects the nodes and edges of a ResourceGraph
+type graphBuilder struct {
+	namespace     string
+	objects       map[string]*graphObject // By node ID
+	byUID         map[types.UID]*graphObject
+	nodes         map[string]*GraphNode
+	edges         map[GraphEdge]bool
+	secretsCached bool
+}
+
+// handleGetResourceGraph returns the dependency graph around a resource as
+// nodes and edges: its owners up to the top-level controller, everything it
+// owns, the ConfigMaps, Secrets, PVCs and ServiceAccount its pods reference,
+// and the Services selecting them. For a ConfigMap, Secret, PVC or Service
+// the graph instead shows the workloads that depend on it, i.e. what a
+// change to it affects. Everything is read from the cache.
+// GET /api/resources/{kind}/{namespace}/{name}/graph
+func (s *Server) handleGetResourceGraph(w http.ResponseWriter, r *http.Request) {
+	kind, ok := graphKinds[normalizeKind(chi.URLParam(r, "kind"))]
+	if !ok {
+		s.writeError(w, http.StatusBadRequest, "graphs are supported for workloads, pods, Services, ConfigMaps, Secrets and PVCs")
+		return
+	}
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+
+	b, err := newGraphBuilder(cache, namespace)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rootID := graphNodeID(kind, namespace, name)
+	root, ok := b.objects[rootID]
+	if !ok {
+		s.writeError(w, http.StatusNotFound, kind+" "+namespace+"/"+name+" not found")
+		return
+	}
+	b.addObject(root)
+
+	switch kind {
+	case "ConfigMap", "Secret", "PersistentVolumeClaim":
+		b.addDependents(rootID)
+	case "Service":
+		b.addSelected(root)
+	default:
+		b.addOwners(root)
+		b.addOwned(root)
+		for _, obj := range b.objectsInGraph() {
+			if obj.podSpec != nil {
+				b.addSpecRefs(obj)
+				b.addSelectingServices(obj)
+			}
+		}
+	}
+
+	s.writeJSON(w, b.graph(rootID))
+}
+
+// newGraphBuilder indexes the namespace's resources from the cache
+func newGraphBuilder(cache *k8s.ResourceCache, namespace string) (*graphBuilder, error) {
+	b := &graphBuilder{
+		namespace: namespace,
+		objects:   make(map[string]*graphObject),
+		byUID:     make(map[types.UID]*graphObject),
+		nodes:     make(map[string]*GraphNode),
+		edges:     make(map[GraphEdge]bool),
+	}
+	add := func(obj *graphObject) {
+		b.objects[graphNodeID(obj.kind, namespace, obj.meta.GetName())] = obj
+		b.byUID[obj.meta.GetUID()] = obj
+	}
+
+	deployments, err := cache.Deployments().Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments {
+		add(&graphObject{kind: "Deployment", meta: d, podSpec: &d.Spec.Template.Spec, labels: d.Spec.Template.Labels})
+	}
+	statefulSets, err := cache.StatefulSets().StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, sts := range statefulSets {
+		add(&graphObject{kind: "StatefulSet", meta: sts, podSpec: &sts.Spec.Template.Spec, labels: sts.Spec.Template.Labels})
+	}
+	daemonSets, err := cache.DaemonSets().DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets {
+		add(&graphObject{kind: "DaemonSet", meta: ds, podSpec: &ds.Spec.Template.Spec, labels: ds.Spec.Template.Labels})
+	}
+	replicaSets, err := cache.ReplicaSets().ReplicaSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets {
+		add(&graphObject{kind: "ReplicaSet", meta: rs, podSpec: &rs.Spec.Template.Spec, labels: rs.Spec.Template.Labels})
+	}
+	jobs, err := cache.Jobs().Jobs(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		add(&graphObject{kind: "Job", meta: job, podSpec: &job.Spec.Template.Spec, labels: job.Spec.Template.Labels})
+	}
+	cronJobs, err := cache.CronJobs().CronJobs(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, cj := range cronJobs {
+		template := &cj.Spec.JobTemplate.Spec.Template
+		add(&graphObject{kind: "CronJob", meta: cj, podSpec: &template.Spec, labels: template.Labels})
+	}
+	pods, err := cache.Pods().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		add(&graphObject{kind: "Pod", meta: pod, podSpec: &pod.Spec, labels: pod.Labels})
+	}
+	services, err := cache.Services().Services(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range services {
+		add(&graphObject{kind: "Service", meta: svc, service: svc})
+	}
+	configMaps, err := cache.ConfigMaps().ConfigMaps(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range configMaps {
+		add(&graphObject{kind: "ConfigMap", meta: cm})
+	}
+	pvcs, err := cache.PersistentVolumeClaims().PersistentVolumeClaims(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range pvcs {
+		add(&graphObject{kind: "PersistentVolumeClaim", meta: pvc})
+	}
+	// Secrets are only cached when Radar is allowed to read them
+	if secretLister := cache.Secrets(); secretLister != nil {
+		b.secretsCached = true
+		secrets, err := secretLister.Secrets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			add(&graphObject{kind: "Secret", meta: secret})
+		}
+	}
+	return b, nil
+}
+
+// addObject adds a cached resource as a node
+func (b *graphBuilder) addObject(obj *graphObject) string {
+	return b.addNode(obj.kind, obj.meta.GetName())
+}
+
+// addNode adds a node by kind and name, marking it missing if it isn't cached.
+// ServiceAccounts, and Secrets when they aren't cached, are never marked
+// missing since their absence from the cache says nothing.
+func (b *graphBuilder) addNode(kind, name string) string {
+	id := graphNodeID(kind, b.namespace, name)
+	if _, ok := b.nodes[id]; !ok {
+		_, cached := b.objects[id]
+		b.nodes[id] = &GraphNode{
+			ID:        id,
+			Kind:      kind,
+			Namespace: b.namespace,
+			Name:      name,
+			Missing:   !cached && kind != "ServiceAccount" && (kind != "Secret" || b.secretsCached),
+		}
+	}
+	return id
+}
+
+func (b *graphBuilder) addEdge(source, target, edgeType, detail string) {
+	b.edges[GraphEdge{Source: source, Target: target, Type: edgeType, Detail: detail}] = true
+}
+
+// objectsInGraph returns the cached resources that are currently nodes
+func (b *graphBuilder) objectsInGraph() []*graphObject {
+	var objs []*graphObject
+	for id := range b.nodes {
+		if obj, ok := b.objects[id]; ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// addOwners follows ownerReferences up from obj to the top-level owner
+func (b *graphBuilder) addOwners(obj *graphObject) {
+	childID := b.addObject(obj)
+	for _, ref := range obj.meta.GetOwnerReferences() {
+		owner, ok := b.byUID[ref.UID]
+		if !ok {
+			// Owner of a kind that isn't indexed, or already deleted
+			ownerID := graphNodeID(ref.Kind, b.namespace, ref.Name)
+			if _, seen := b.nodes[ownerID]; !seen {
+				b.nodes[ownerID] = &GraphNode{ID: ownerID, Kind: ref.Kind, Namespace: b.namespace, Name: ref.Name}
+			}
+			b.addEdge(ownerID, childID, graphEdgeOwns, "")
+			continue
+		}
+		ownerID := graphNodeID(owner.kind, b.namespace, owner.meta.GetName())
+		_, seen := b.nodes[ownerID]
+		b.addEdge(b.addObject(owner), childID, graphEdgeOwns, "")
+		if !seen {
+			b.addOwners(owner)
+		}
+	}
+}
+
+// addOwned adds everything obj owns, directly or through its children
+func (b *graphBuilder) addOwned(obj *graphObject) {
+	ownerID := b.addObject(obj)
+	uid := obj.meta.GetUID()
+	for _, child := range b.objects {
+		if !isOwnedBy(child.meta.GetOwnerReferences(), uid) {
+			continue
+		}
+		childID := graphNodeID(child.kind, b.namespace, child.meta.GetName())
+		_, seen := b.nodes[childID]
+		b.addEdge(ownerID, b.addObject(child), graphEdgeOwns, "")
+		if !seen {
+			b.addOwned(child)
+		}
+	}
+}
+
+// podSpecRef is a reference from a pod spec to another resource
+type podSpecRef struct {
+	kind, name, edgeType, detail string
+}
+
+// podSpecRefs returns the ConfigMaps, Secrets, PVCs and ServiceAccount a pod spec refers to
+func podSpecRefs(spec *corev1.PodSpec) []podSpecRef {
+	var refs []podSpecRef
+	for _, vol := range spec.Volumes {
+		switch {
+		case vol.ConfigMap != nil:
+			refs = append(refs, podSpecRef{"ConfigMap", vol.ConfigMap.Name, graphEdgeMounts, vol.Name})
+		case vol.Secret != nil:
+			refs = append(refs, podSpecRef{"Secret", vol.Secret.SecretName, graphEdgeMounts, vol.Name})
+		case vol.PersistentVolumeClaim != nil:
+			refs = append(refs, podSpecRef{"PersistentVolumeClaim", vol.PersistentVolumeClaim.ClaimName, graphEdgeMounts, vol.Name})
+		case vol.Projected != nil:
+			for _, src := range vol.Projected.Sources {
+				if src.ConfigMap != nil {
+					refs = append(refs, podSpecRef{"ConfigMap", src.ConfigMap.Name, graphEdgeMounts, vol.Name})
+				}
+				if src.Secret != nil {
+					refs = append(refs, podSpecRef{"Secret", src.Secret.Name, graphEdgeMounts, vol.Name})
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				refs = append(refs, podSpecRef{"ConfigMap", from.ConfigMapRef.Name, graphEdgeEnv, c.Name})
+			}
+			if from.SecretRef != nil {
+				refs = append(refs, podSpecRef{"Secret", from.SecretRef.Name, graphEdgeEnv, c.Name})
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				refs = append(refs, podSpecRef{"ConfigMap", ref.Name, graphEdgeEnv, c.Name})
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				refs = append(refs, podSpecRef{"Secret", ref.Name, graphEdgeEnv, c.Name})
+			}
+		}
+	}
+
+	for _, secret := range spec.ImagePullSecrets {
+		refs = append(refs, podSpecRef{"Secret", secret.Name, graphEdgeImagePullSecret, ""})
+	}
+	serviceAccount := spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	return append(refs, podSpecRef{"ServiceAccount", serviceAccount, graphEdgeServiceAccount, ""})
+}
+
+// addSpecRefs adds the resources obj's pod spec refers to
+func (b *graphBuilder) addSpecRefs(obj *graphObject) {
+	id := b.addObject(obj)
+	for _, ref := range podSpecRefs(obj.podSpec) {
+		if ref.name == "" {
+			continue
+		}
+		b.addEdge(id, b.addNode(ref.kind, ref.name), ref.edgeType, ref.detail)
+	}
+}
+
+// addSelectingServices adds the Services whose selector matches obj's pods
+func (b *graphBuilder) addSelectingServices(obj *graphObject) {
+	if len(obj.labels) == 0 {
+		return
+	}
+	id := b.addObject(obj)
+	for _, svc := range b.objects {
+		if svc.service != nil && serviceSelects(svc.service, obj.labels) {
+			b.addEdge(b.addObject(svc), id, graphEdgeSelects, "")
+		}
+	}
+}
+
+// addSelected adds the pods and workloads a Service selects, with their owners
+func (b *graphBuilder) addSelected(root *graphObject) {
+	rootID := b.addObject(root)
+	for _, obj := range b.objects {
+		if obj.podSpec != nil && serviceSelects(root.service, obj.labels) {
+			b.addEdge(rootID, b.addObject(obj), graphEdgeSelects, "")
+			b.addOwners(obj)
+		}
+	}
+}
+
+// addDependents adds the pods and workloads whose pod spec refers to the
+// resource with the given ID, with their owners
+func (b *graphBuilder) addDependents(targetID string) {
+	for _, obj := range b.objects {
+		if obj.podSpec == nil {
+			continue
+		}
+		for _, ref := range podSpecRefs(obj.podSpec) {
+			if graphNodeID(ref.kind, b.namespace, ref.name) == targetID {
+				b.addEdge(b.addObject(obj), targetID, ref.edgeType, ref.detail)
+				b.addOwners(obj)
+			}
+		}
+	}
+}
+
+// serviceSelects reports whether a Service's selector matches pod labels.
+// A Service without a selector selects nothing.
+func serviceSelects(svc *corev1.Service, podLabels map[string]string) bool {
+	if len(svc.Spec.Selector) == 0 || len(podLabels) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(podLabels))
+}
+
+// graph returns the collected nodes and edges in a stable order
+func (b *graphBuilder) graph(rootID string) *ResourceGraph {
+	result := &ResourceGraph{Root: rootID, Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, node := range b.nodes {
+		result.Nodes = append(result.Nodes, *node)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].ID < result.Nodes[j].ID })
+	for edge := range b.edges {
+		result.Edges = append(result.Edges, edge)
+	}
+	sort.Slice(result.Edges, func(i, j int) bool {
+		a, c := result.Edges[i], result.Edges[j]
+		if a.Source != c.Source {
+			return a.Source < c.Source
+		}
+		if a.Target != c.Target {
+			return a.Target < c.Target
+		}
+		if a.Type != c.Type {
+			return a.Type < c.Type
+		}
+		return a.Detail < c.Detail
+	})
+	return result
+}
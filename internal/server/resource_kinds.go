@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+// ResourceKind describes a discovered resource type and how it can be explored
+type ResourceKind struct {
+	Group      string `json:"group"`
+	Version    string `json:"version"`
+	Resource   string `json:"resource"` // Plural name (e.g., "deployments")
+	Kind       string `json:"kind"`
+	Namespaced bool   `json:"namespaced"`
+	IsCRD      bool   `json:"isCrd"`
+	Watching   bool   `json:"watching"` // An informer is running for this type
+	Synced     bool   `json:"synced"`   // The informer has completed its initial list
+	CanList    bool   `json:"canList"`  // The current user can list this type cluster-wide
+}
+
+// handleResourceKinds lists the listable resource types discovered in the
+// cluster, annotated with informer state and the user's list permission
+func (s *Server) handleResourceKinds(w http.ResponseWriter, r *http.Request) {
+	discovery := k8s.GetResourceDiscovery()
+	if discovery == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Resource discovery not available")
+		return
+	}
+
+	resources, err := discovery.GetAPIResources()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resourceCache := k8s.GetResourceCache()
+	typedSynced := k8s.IsResourceCacheSynced()
+	dynamicCache := k8s.GetDynamicResourceCache()
+
+	watched := make(map[schema.GroupVersionResource]bool)
+	for _, gvr := range dynamicCache.GetWatchedResources() {
+		watched[gvr] = true
+	}
+
+	listable := make([]k8s.APIResource, 0, len(resources))
+	groupResources := make([]schema.GroupResource, 0, len(resources))
+	for _, res := range resources {
+		if !slices.Contains(res.Verbs, "list") {
+			continue
+		}
+		listable = append(listable, res)
+		groupResources = append(groupResources, schema.GroupResource{Group: res.Group, Resource: res.Name})
+	}
+
+	access := k8s.CanListResources(r.Context(), groupResources)
+
+	kinds := make([]ResourceKind, 0, len(listable))
+	for i, res := range listable {
+		gr := groupResources[i]
+		gvr := gr.WithVersion(res.Version)
+		kind := ResourceKind{
+			Group:      res.Group,
+			Version:    res.Version,
+			Resource:   res.Name,
+			Kind:       res.Kind,
+			Namespaced: res.Namespaced,
+			IsCRD:      res.IsCRD,
+			CanList:    access[gr],
+		}
+		if resourceCache.HasTypedInformer(gr) {
+			kind.Watching = true
+			kind.Synced = typedSynced
+		} else if watched[gvr] {
+			kind.Watching = true
+			kind.Synced = dynamicCache.IsSynced(gvr)
+		}
+		kinds = append(kinds, kind)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].Group != kinds[j].Group {
+			return kinds[i].Group < kinds[j].Group
+		}
+		return kinds[i].Kind < kinds[j].Kind
+	})
+
+	s.writeJSON(w, kinds)
+}
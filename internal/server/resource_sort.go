@@ -0,0 +1,129 @@
+package server
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/skyhook-io/radar/internal/timeline"
+)
+
+// Health ranks for ?sort=health, listed in this order
+const (
+	healthRankUnhealthy = iota // Failed, crashlooping, can't pull its image, no ready replicas
+	healthRankDegraded         // Not ready, pending or restarting, some replicas ready
+	healthRankHealthy
+)
+
+// sortByHealth orders a typed list from the cache with unhealthy resources
+// first, then degraded, then healthy; ties are broken by namespace and name,
+// and for pods by restart count first. It reports false for kinds it can't
+// rank.
+func sortByHealth(result any) bool {
+	now := time.Now()
+	switch items := result.(type) {
+	case []*corev1.Pod:
+		slices.SortStableFunc(items, func(a, b *corev1.Pod) int {
+			return cmp.Or(
+				cmp.Compare(podHealthRank(a, now), podHealthRank(b, now)),
+				cmp.Compare(podRestarts(b), podRestarts(a)),
+				cmp.Compare(a.Namespace, b.Namespace),
+				cmp.Compare(a.Name, b.Name),
+			)
+		})
+	case []*appsv1.Deployment:
+		sortWorkloads(items, "Deployment")
+	case []*appsv1.StatefulSet:
+		sortWorkloads(items, "StatefulSet")
+	case []*appsv1.DaemonSet:
+		slices.SortStableFunc(items, func(a, b *appsv1.DaemonSet) int {
+			return cmp.Or(
+				cmp.Compare(daemonSetHealthRank(a), daemonSetHealthRank(b)),
+				cmp.Compare(a.Namespace, b.Namespace),
+				cmp.Compare(a.Name, b.Name),
+			)
+		})
+	case []*appsv1.ReplicaSet:
+		sortWorkloads(items, "ReplicaSet")
+	case []*batchv1.Job:
+		slices.SortStableFunc(items, func(a, b *batchv1.Job) int {
+			return cmp.Or(
+				cmp.Compare(jobHealthRank(a), jobHealthRank(b)),
+				cmp.Compare(a.Namespace, b.Namespace),
+				cmp.Compare(a.Name, b.Name),
+			)
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// workload is a cached workload ranked by its ready replicas
+type workload interface {
+	*appsv1.Deployment | *appsv1.StatefulSet | *appsv1.ReplicaSet
+	GetNamespace() string
+	GetName() string
+}
+
+func sortWorkloads[T workload](items []T, kind string) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		return cmp.Or(
+			cmp.Compare(healthStateRank(timeline.DetermineHealthState(kind, a)), healthStateRank(timeline.DetermineHealthState(kind, b))),
+			cmp.Compare(a.GetNamespace(), b.GetNamespace()),
+			cmp.Compare(a.GetName(), b.GetName()),
+		)
+	})
+}
+
+func healthStateRank(state timeline.HealthState) int {
+	switch state {
+	case timeline.HealthUnhealthy:
+		return healthRankUnhealthy
+	case timeline.HealthDegraded:
+		return healthRankDegraded
+	}
+	return healthRankHealthy
+}
+
+// podHealthRank uses the dashboard's classification of pod problems, and
+// also ranks pods that are pending or not ready as degraded
+func podHealthRank(pod *corev1.Pod, now time.Time) int {
+	switch classifyPodHealth(pod, now) {
+	case "error":
+		return healthRankUnhealthy
+	case "warning":
+		return healthRankDegraded
+	}
+	return healthStateRank(timeline.DetermineHealthState("Pod", pod))
+}
+
+func podRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, cs := range pod.Status.ContainerStatuses {
+		restarts += cs.RestartCount
+	}
+	return restarts
+}
+
+// daemonSetHealthRank treats a DaemonSet scheduled on no nodes as healthy,
+// which timeline.DetermineHealthState doesn't
+func daemonSetHealthRank(ds *appsv1.DaemonSet) int {
+	if ds.Status.DesiredNumberScheduled == 0 {
+		return healthRankHealthy
+	}
+	return healthStateRank(timeline.DetermineHealthState("DaemonSet", ds))
+}
+
+func jobHealthRank(job *batchv1.Job) int {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return healthRankUnhealthy
+		}
+	}
+	return healthRankHealthy
+}
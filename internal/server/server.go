@@ -1,16 +1,25 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
 	"io/fs"
 	"log"
+	"mime"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"net/url"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,31 +31,71 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/skyhook-io/radar/internal/helm"
+	"github.com/skyhook-io/radar/internal/httperr"
 	"github.com/skyhook-io/radar/internal/images"
 	"github.com/skyhook-io/radar/internal/k8s"
 	"github.com/skyhook-io/radar/internal/timeline"
 	"github.com/skyhook-io/radar/internal/topology"
 )
 
+// dynamicListSyncTimeout bounds how long a list request waits for a newly
+// started CRD informer before reporting that the cache is still syncing
+const dynamicListSyncTimeout = 3 * time.Second
+
 // Server is the Explorer HTTP server
 type Server struct {
 	router      *chi.Mux
 	broadcaster *SSEBroadcaster
 	port        int
+	autoPort    bool
+	listener    net.Listener
+	bind        string
+	basePath    string
+	tlsCert     string
+	tlsKey      string
+	tlsSelfSign bool
 	devMode     bool
 	staticFS    fs.FS
 	startTime   time.Time
+
+	readOnly      bool
+	prewarmImages int
+	imageCacheDir string
+
+	adminToken string
+	onShutdown func()
+
+	pprofEnabled bool
 }
 
 // Config holds server configuration
 type Config struct {
-	Port       int
+	Port       int      // 0 = any free port
+	AutoPort   bool     // Fall back to a free port if Port can't be bound
+	Bind       string   // Address to listen on (empty = all interfaces)
+	BasePath   string   // URL prefix when served under a subpath, e.g. "/explorer"
 	DevMode    bool     // Serve frontend from filesystem instead of embedded
 	StaticFS   embed.FS // Embedded frontend files
 	StaticRoot string   // Path within StaticFS
+
+	TLSCertFile   string // PEM certificate file; serves HTTPS when set with TLSKeyFile
+	TLSKeyFile    string // PEM private key file
+	TLSSelfSigned bool   // Serve HTTPS with a generated self-signed certificate
+
+	ReadOnly bool // Refuse all write operations (Helm actions, GitOps actions, edit/delete, exec, port-forward)
+
+	PrewarmImages int    // Number of running images to inspect in the background on startup (0 = disabled)
+	ImageCacheDir string // Directory for the image layer cache (empty = os.TempDir())
+
+	AdminToken string // Bearer token for the admin endpoints (empty = admin endpoints disabled)
+	OnShutdown func() // Called by the admin shutdown endpoint to tear down and exit
+
+	EnablePprof bool // Serve net/http/pprof profiles under /debug/pprof
 }
 
 // New creates a new server instance
@@ -55,8 +104,23 @@ func New(cfg Config) *Server {
 		router:      chi.NewRouter(),
 		broadcaster: NewSSEBroadcaster(),
 		port:        cfg.Port,
+		autoPort:    cfg.AutoPort,
+		bind:        cfg.Bind,
+		basePath:    normalizeBasePath(cfg.BasePath),
+		tlsCert:     cfg.TLSCertFile,
+		tlsKey:      cfg.TLSKeyFile,
+		tlsSelfSign: cfg.TLSSelfSigned,
 		devMode:     cfg.DevMode,
 		startTime:   time.Now(),
+
+		readOnly:      cfg.ReadOnly,
+		prewarmImages: cfg.PrewarmImages,
+		imageCacheDir: cfg.ImageCacheDir,
+
+		adminToken: cfg.AdminToken,
+		onShutdown: cfg.OnShutdown,
+
+		pprofEnabled: cfg.EnablePprof,
 	}
 
 	// Set up static file system
@@ -81,95 +145,125 @@ func (s *Server) setupRoutes() {
 
 	// CORS for development
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:*", "http://127.0.0.1:*"},
+		AllowedOrigins:   []string{"http://localhost:*", "http://127.0.0.1:*", "https://localhost:*", "https://127.0.0.1:*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Content-Type"},
 		AllowCredentials: true,
 	}))
 
-	// pprof routes for profiling (dev mode only, but always available for debugging)
-	r.Route("/debug/pprof", func(r chi.Router) {
-		r.Get("/", pprof.Index)
-		r.Get("/cmdline", pprof.Cmdline)
-		r.Get("/profile", pprof.Profile)
-		r.Get("/symbol", pprof.Symbol)
-		r.Get("/trace", pprof.Trace)
-		r.Get("/allocs", pprof.Handler("allocs").ServeHTTP)
-		r.Get("/block", pprof.Handler("block").ServeHTTP)
-		r.Get("/goroutine", pprof.Handler("goroutine").ServeHTTP)
-		r.Get("/heap", pprof.Handler("heap").ServeHTTP)
-		r.Get("/mutex", pprof.Handler("mutex").ServeHTTP)
-		r.Get("/threadcreate", pprof.Handler("threadcreate").ServeHTTP)
-	})
+	// pprof routes for profiling. Off by default: profiles expose command
+	// line arguments and memory contents of a process holding cluster credentials.
+	if s.pprofEnabled {
+		log.Printf("Serving profiles at /debug/pprof (--pprof)")
+		r.Route("/debug/pprof", func(r chi.Router) {
+			r.Get("/", pprof.Index)
+			r.Get("/cmdline", pprof.Cmdline)
+			r.Get("/profile", pprof.Profile)
+			r.Get("/symbol", pprof.Symbol)
+			r.Get("/trace", pprof.Trace)
+			r.Get("/allocs", pprof.Handler("allocs").ServeHTTP)
+			r.Get("/block", pprof.Handler("block").ServeHTTP)
+			r.Get("/goroutine", pprof.Handler("goroutine").ServeHTTP)
+			r.Get("/heap", pprof.Handler("heap").ServeHTTP)
+			r.Get("/mutex", pprof.Handler("mutex").ServeHTTP)
+			r.Get("/threadcreate", pprof.Handler("threadcreate").ServeHTTP)
+		})
+	}
+
+	// Readiness probe: succeeds once the resource cache has synced
+	r.Get("/readyz", s.handleReadyz)
 
 	// API routes
 	r.Route("/api", func(r chi.Router) {
+		// Routes that change the cluster or open a session into it are
+		// refused up front when running with --read-only
+		writes := r.With(s.denyInReadOnly)
+
+		// Routes naming a namespace check it exists and is accessible first
+		ns := r.With(s.requireNamespace)
+		nsWrites := writes.With(s.requireNamespace)
+
 		r.Get("/health", s.handleHealth)
 		r.Get("/dashboard", s.handleDashboard)
 		r.Get("/cluster-info", s.handleClusterInfo)
 		r.Get("/capabilities", s.handleCapabilities)
+		r.Get("/capabilities/namespaces", s.handleNamespaceCapabilities)
 		r.Get("/topology", s.handleTopology)
 		r.Get("/namespaces", s.handleNamespaces)
 		r.Get("/api-resources", s.handleAPIResources)
+		r.Get("/resource-kinds", s.handleResourceKinds)
 		r.Get("/resources/{kind}", s.handleListResources)
-		r.Get("/resources/{kind}/{namespace}/{name}", s.handleGetResource)
-		r.Put("/resources/{kind}/{namespace}/{name}", s.handleUpdateResource)
-		r.Delete("/resources/{kind}/{namespace}/{name}", s.handleDeleteResource)
+		ns.Get("/resources/{kind}/{namespace}/{name}", s.handleGetResource)
+		ns.Get("/resources/{kind}/{namespace}/{name}/graph", s.handleGetResourceGraph)
+		r.Post("/resources/batch", s.handleBatchGetResources)
+		r.Get("/resources/gvr/{group}/{version}/{resource}", s.handleListGVR)
+		r.Get("/resources/gvr/{group}/{version}/{resource}/watch", s.handleWatchGVR)
+		ns.Get("/resources/gvr/{group}/{version}/namespaces/{namespace}/{resource}", s.handleListGVR)
+		ns.Get("/resources/gvr/{group}/{version}/namespaces/{namespace}/{resource}/watch", s.handleWatchGVR)
+		nsWrites.Put("/resources/{kind}/{namespace}/{name}", s.handleUpdateResource)
+		nsWrites.Delete("/resources/{kind}/{namespace}/{name}", s.handleDeleteResource)
 		r.Get("/events", s.handleEvents)
 		r.Get("/events/stream", s.broadcaster.HandleSSE)
+		r.Get("/events/watch", s.handleWatchClusterEvents)
 		r.Get("/changes", s.handleChanges)
+		r.Get("/changes/summary", s.handleChangesSummary)
 		r.Get("/changes/{kind}/{namespace}/{name}/children", s.handleChangeChildren)
 
 		// Pod logs
-		r.Get("/pods/{namespace}/{name}/logs", s.handlePodLogs)
-		r.Get("/pods/{namespace}/{name}/logs/stream", s.handlePodLogsStream)
+		ns.Get("/pods/{namespace}/{name}/logs", s.handlePodLogs)
+		ns.Get("/pods/{namespace}/{name}/logs/stream", s.handlePodLogsStream)
 
 		// Pod exec (terminal)
-		r.Get("/pods/{namespace}/{name}/exec", s.handlePodExec)
+		nsWrites.Get("/pods/{namespace}/{name}/exec", s.handlePodExec)
 
 		// Metrics (from metrics.k8s.io API)
-		r.Get("/metrics/pods/{namespace}/{name}", s.handlePodMetrics)
+		ns.Get("/metrics/pods/{namespace}/{name}", s.handlePodMetrics)
 		r.Get("/metrics/nodes/{name}", s.handleNodeMetrics)
-		r.Get("/metrics/pods/{namespace}/{name}/history", s.handlePodMetricsHistory)
+		ns.Get("/metrics/pods/{namespace}/{name}/history", s.handlePodMetricsHistory)
+		ns.Get("/metrics/pods/{namespace}/{name}/recommendations", s.handlePodResourceRecommendations)
 		r.Get("/metrics/nodes/{name}/history", s.handleNodeMetricsHistory)
 
 		// Port forwarding
 		r.Get("/portforwards", s.handleListPortForwards)
-		r.Post("/portforwards", s.handleStartPortForward)
+		writes.Post("/portforwards", s.handleStartPortForward)
 		r.Delete("/portforwards/{id}", s.handleStopPortForward)
-		r.Get("/portforwards/available/{type}/{namespace}/{name}", s.handleGetAvailablePorts)
+		ns.Get("/portforwards/available/{type}/{namespace}/{name}", s.handleGetAvailablePorts)
 
 		// Active sessions (for context switch confirmation)
 		r.Get("/sessions", s.handleGetSessions)
 
 		// CronJob operations
-		r.Post("/cronjobs/{namespace}/{name}/trigger", s.handleTriggerCronJob)
-		r.Post("/cronjobs/{namespace}/{name}/suspend", s.handleSuspendCronJob)
-		r.Post("/cronjobs/{namespace}/{name}/resume", s.handleResumeCronJob)
+		nsWrites.Post("/cronjobs/{namespace}/{name}/trigger", s.handleTriggerCronJob)
+		nsWrites.Post("/cronjobs/{namespace}/{name}/suspend", s.handleSuspendCronJob)
+		nsWrites.Post("/cronjobs/{namespace}/{name}/resume", s.handleResumeCronJob)
 
-		// Workload restart
-		r.Post("/workloads/{kind}/{namespace}/{name}/restart", s.handleRestartWorkload)
+		// Workload detail and restart
+		ns.Get("/workloads/{kind}/{namespace}/{name}", s.handleGetWorkloadDetail)
+		ns.Get("/workloads/{kind}/{namespace}/{name}/logs/stream", s.handleWorkloadLogsStream)
+		nsWrites.Post("/workloads/{kind}/{namespace}/{name}/restart", s.handleRestartWorkload)
 
 		// Helm routes
-		helmHandlers := helm.NewHandlers()
+		helmHandlers := helm.NewHandlers(s.readOnly)
 		helmHandlers.RegisterRoutes(r)
 
 		// Image inspection routes
-		imageHandlers := images.NewHandlers()
+		imageHandlers := images.NewHandlers(s.imageCacheDir)
 		imageHandlers.RegisterRoutes(r)
+		imageHandlers.StartPrewarm(s.prewarmImages)
 
 		// FluxCD routes
-		r.Post("/flux/{kind}/{namespace}/{name}/reconcile", s.handleFluxReconcile)
-		r.Post("/flux/{kind}/{namespace}/{name}/sync-with-source", s.handleFluxSyncWithSource)
-		r.Post("/flux/{kind}/{namespace}/{name}/suspend", s.handleFluxSuspend)
-		r.Post("/flux/{kind}/{namespace}/{name}/resume", s.handleFluxResume)
+		nsWrites.Post("/flux/{kind}/{namespace}/{name}/reconcile", s.handleFluxReconcile)
+		nsWrites.Post("/flux/{kind}/{namespace}/{name}/sync-with-source", s.handleFluxSyncWithSource)
+		nsWrites.Post("/flux/{kind}/{namespace}/{name}/suspend", s.handleFluxSuspend)
+		nsWrites.Post("/flux/{kind}/{namespace}/{name}/resume", s.handleFluxResume)
 
 		// ArgoCD routes
-		r.Post("/argo/applications/{namespace}/{name}/sync", s.handleArgoSync)
-		r.Post("/argo/applications/{namespace}/{name}/refresh", s.handleArgoRefresh)
-		r.Post("/argo/applications/{namespace}/{name}/terminate", s.handleArgoTerminate)
-		r.Post("/argo/applications/{namespace}/{name}/suspend", s.handleArgoSuspend)
-		r.Post("/argo/applications/{namespace}/{name}/resume", s.handleArgoResume)
+		ns.Get("/argo/applications/{namespace}/{name}/watch", s.handleArgoWatch)
+		nsWrites.Post("/argo/applications/{namespace}/{name}/sync", s.handleArgoSync)
+		nsWrites.Post("/argo/applications/{namespace}/{name}/refresh", s.handleArgoRefresh)
+		nsWrites.Post("/argo/applications/{namespace}/{name}/terminate", s.handleArgoTerminate)
+		nsWrites.Post("/argo/applications/{namespace}/{name}/suspend", s.handleArgoSuspend)
+		nsWrites.Post("/argo/applications/{namespace}/{name}/resume", s.handleArgoResume)
 
 		// Debug routes (for event pipeline diagnostics)
 		r.Get("/debug/events", s.handleDebugEvents)
@@ -179,28 +273,35 @@ func (s *Server) setupRoutes() {
 		// Traffic routes
 		r.Get("/traffic/sources", s.handleGetTrafficSources)
 		r.Get("/traffic/flows", s.handleGetTrafficFlows)
+		r.Post("/traffic/flows", s.handlePostTrafficFlows)
 		r.Get("/traffic/flows/stream", s.handleTrafficFlowsStream)
+		ns.Get("/traffic/workloads/{kind}/{namespace}/{name}", s.handleGetWorkloadTraffic)
+		r.Get("/traffic/identities", s.handleTrafficIdentities)
 		r.Get("/traffic/source", s.handleGetActiveTrafficSource)
 		r.Post("/traffic/source", s.handleSetTrafficSource)
 		r.Post("/traffic/connect", s.handleTrafficConnect)
 		r.Get("/traffic/connection", s.handleTrafficConnectionStatus)
+		r.Get("/traffic/status", s.handleTrafficStatus)
 
 		// Context routes
 		r.Get("/contexts", s.handleListContexts)
 		r.Post("/contexts/{name}", s.handleSwitchContext)
+
+		// Admin routes, only served with --admin-token
+		r.With(s.requireAdminToken).Post("/admin/shutdown", s.handleAdminShutdown)
 	})
 
 	// Static files (frontend) - SPA fallback to index.html
 	if s.staticFS != nil {
-		r.Handle("/*", spaHandler(http.FS(s.staticFS)))
+		r.Handle("/*", spaHandler(http.FS(s.staticFS), s.basePath))
 	} else if s.devMode {
 		// In dev mode, serve from web/dist
-		r.Handle("/*", spaHandler(http.Dir("web/dist")))
+		r.Handle("/*", spaHandler(http.Dir("web/dist"), s.basePath))
 	}
 }
 
 // spaHandler serves static files, falling back to index.html for SPA routing
-func spaHandler(fsys http.FileSystem) http.Handler {
+func spaHandler(fsys http.FileSystem, basePath string) http.Handler {
 	fileServer := http.FileServer(fsys)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -210,31 +311,119 @@ func spaHandler(fsys http.FileSystem) http.Handler {
 		f, err := fsys.Open(path)
 		if err != nil {
 			// File doesn't exist - serve index.html for SPA routing
-			r.URL.Path = "/"
-			fileServer.ServeHTTP(w, r)
+			serveIndex(w, r, fsys, basePath)
 			return
 		}
 		defer f.Close()
 
 		// Check if it's a directory (and not the root)
 		stat, err := f.Stat()
-		if err != nil || (stat.IsDir() && path != "/") {
+		if err != nil || stat.IsDir() || path == "/index.html" {
 			// For directories without index.html, serve root index.html
-			r.URL.Path = "/"
+			serveIndex(w, r, fsys, basePath)
+			return
 		}
 
 		fileServer.ServeHTTP(w, r)
 	})
 }
 
+// serveIndex serves index.html with a <base href> for the configured base
+// path, so relative asset URLs and the frontend's API calls resolve under it
+func serveIndex(w http.ResponseWriter, r *http.Request, fsys http.FileSystem, basePath string) {
+	f, err := fsys.Open("/index.html")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		httperr.Write(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	baseTag := fmt.Sprintf(`<head><base href="%s/">`, html.EscapeString(basePath))
+	content = bytes.Replace(content, []byte("<head>"), []byte(baseTag), 1)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(content)
+}
+
+// normalizeBasePath turns "explorer/", "/explorer/" or "/explorer" into
+// "/explorer", and "" or "/" into "" (served at the root)
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+// handler returns the root HTTP handler, mounting the router under the base path
+func (s *Server) handler() http.Handler {
+	if s.basePath == "" {
+		return s.router
+	}
+	mux := http.NewServeMux()
+	mux.Handle(s.basePath+"/", http.StripPrefix(s.basePath, s.router))
+	mux.Handle(s.basePath, http.RedirectHandler(s.basePath+"/", http.StatusMovedPermanently))
+	return mux
+}
+
 // Start starts the server
 func (s *Server) Start() error {
+	if _, err := s.Listen(); err != nil {
+		return err
+	}
+
 	s.broadcaster.Start()
 
-	addr := fmt.Sprintf(":%d", s.port)
-	log.Printf("Starting Explorer server on http://localhost%s", addr)
+	addr := net.JoinHostPort(s.bind, strconv.Itoa(s.port))
+	httpServer := &http.Server{Addr: addr, Handler: s.handler()}
+
+	switch {
+	case s.tlsCert != "" && s.tlsKey != "":
+		log.Printf("Starting Explorer server on https://%s%s/", addr, s.basePath)
+		return httpServer.ServeTLS(s.listener, s.tlsCert, s.tlsKey)
+
+	case s.tlsSelfSign:
+		cert, err := generateSelfSignedCert(s.bind)
+		if err != nil {
+			return fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+		httpServer.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		log.Printf("Starting Explorer server on https://%s%s/ (self-signed certificate)", addr, s.basePath)
+		return httpServer.ServeTLS(s.listener, "", "")
+	}
+
+	log.Printf("Starting Explorer server on http://%s%s/", addr, s.basePath)
+	return httpServer.Serve(s.listener)
+}
+
+// Listen binds the server's port and returns the port bound. With port 0, or
+// with AutoPort when the port can't be bound (usually another instance), the
+// OS picks a free port. Start calls it if it hasn't been called yet, so the
+// port can be known before serving, e.g. to open the browser on it.
+func (s *Server) Listen() (int, error) {
+	if s.listener != nil {
+		return s.port, nil
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(s.bind, strconv.Itoa(s.port)))
+	if err != nil && s.autoPort && s.port != 0 {
+		log.Printf("Cannot listen on port %d (%v), picking a free port", s.port, err)
+		ln, err = net.Listen("tcp", net.JoinHostPort(s.bind, "0"))
+	}
+	if err != nil {
+		return 0, err
+	}
 
-	return http.ListenAndServe(addr, s.router)
+	s.listener = ln
+	s.port = ln.Addr().(*net.TCPAddr).Port
+	return s.port, nil
 }
 
 // Stop gracefully stops the server
@@ -244,6 +433,24 @@ func (s *Server) Stop() {
 
 // Handlers
 
+// handleReadyz reports whether the server can serve complete resource data.
+// During startup and context switches it returns 503 until informers sync.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if k8s.GetResourceCache() != nil && k8s.IsResourceCacheSynced() {
+		s.writeJSON(w, map[string]string{"status": "ready"})
+		return
+	}
+
+	status := "not ready"
+	if k8s.IsResourceCacheSyncing() {
+		status = "syncing"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "2")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	cache := k8s.GetResourceCache()
 	status := "healthy"
@@ -283,6 +490,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, map[string]any{
 		"status":        status,
 		"resourceCount": cache.GetResourceCount(),
+		"cacheSynced":   k8s.IsResourceCacheSynced(),
 		"timeline":      timelineStats,
 		"runtime":       runtimeStats,
 	})
@@ -303,9 +511,103 @@ func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if s.readOnly {
+		caps.ReadOnly = true
+		caps.Exec = false
+		caps.PortForward = false
+	}
 	s.writeJSON(w, caps)
 }
 
+// maxPermissionNamespaces bounds the namespaces one permission matrix request
+// checks, each costing several access reviews
+const maxPermissionNamespaces = 200
+
+// handleNamespaceCapabilities returns what the user can do in each namespace
+// (list/get/edit/delete/exec/logs), for the given comma-separated namespaces
+// or every namespace in the cluster. Cluster namespaces are checked a page of
+// maxPermissionNamespaces at a time, by name; the response says where the next
+// page starts (?continue=).
+func (s *Server) handleNamespaceCapabilities(w http.ResponseWriter, r *http.Request) {
+	var namespaces []string
+	next := ""
+	if param := r.URL.Query().Get("namespaces"); param != "" {
+		for _, ns := range strings.Split(param, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				namespaces = append(namespaces, ns)
+			}
+		}
+	} else {
+		cache := k8s.GetResourceCache()
+		if cache == nil {
+			s.writeCacheUnavailable(w)
+			return
+		}
+		list, err := cache.Namespaces().List(labels.Everything())
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		after := r.URL.Query().Get("continue")
+		for _, ns := range list {
+			if ns.Name > after {
+				namespaces = append(namespaces, ns.Name)
+			}
+		}
+		sort.Strings(namespaces)
+		if len(namespaces) > maxPermissionNamespaces {
+			namespaces = namespaces[:maxPermissionNamespaces]
+			next = namespaces[len(namespaces)-1]
+		}
+	}
+	if len(namespaces) > maxPermissionNamespaces {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("too many namespaces (%d), at most %d can be checked at once", len(namespaces), maxPermissionNamespaces))
+		return
+	}
+
+	matrix := k8s.CheckNamespacePermissions(r.Context(), namespaces)
+	if next != "" {
+		matrix.Truncated = true
+		matrix.Continue = next
+	}
+	if s.readOnly {
+		for idx := range matrix.Namespaces {
+			matrix.Namespaces[idx].Edit = false
+			matrix.Namespaces[idx].Delete = false
+			matrix.Namespaces[idx].Exec = false
+		}
+	}
+	s.writeJSON(w, matrix)
+}
+
+// denyInReadOnly refuses the request with 403 when the server runs with --read-only
+func (s *Server) denyInReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.readOnly {
+			s.writeErrorCode(w, http.StatusForbidden, httperr.CodeReadOnly, "Write operations are disabled: server is running in read-only mode")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireNamespace refuses requests whose {namespace} URL parameter names a
+// namespace that doesn't exist (404) or that the user has no access to (403)
+func (s *Server) requireNamespace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace := chi.URLParam(r, "namespace")
+		if err := k8s.CheckNamespace(r.Context(), namespace); err != nil {
+			if errors.Is(err, k8s.ErrNamespaceNotFound) {
+				s.writeErrorCode(w, http.StatusNotFound, httperr.CodeNamespaceNotFound, fmt.Sprintf("Namespace %q not found", namespace))
+			} else {
+				s.writeErrorCode(w, http.StatusForbidden, httperr.CodeNamespaceForbidden, fmt.Sprintf("Namespace %q is not accessible with the current credentials", namespace))
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) handleTopology(w http.ResponseWriter, r *http.Request) {
 	namespace := r.URL.Query().Get("namespace")
 	viewMode := r.URL.Query().Get("view")
@@ -329,7 +631,7 @@ func (s *Server) handleTopology(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleNamespaces(w http.ResponseWriter, r *http.Request) {
 	cache := k8s.GetResourceCache()
 	if cache == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Resource cache not available")
+		s.writeCacheUnavailable(w)
 		return
 	}
 
@@ -369,10 +671,15 @@ func (s *Server) handleAPIResources(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleListResources(w http.ResponseWriter, r *http.Request) {
 	kind := chi.URLParam(r, "kind")
 	namespace := r.URL.Query().Get("namespace")
+	sortMode := r.URL.Query().Get("sort")
+	if sortMode != "" && sortMode != "health" {
+		s.writeError(w, http.StatusBadRequest, "sort must be health")
+		return
+	}
 
 	cache := k8s.GetResourceCache()
 	if cache == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Resource cache not available")
+		s.writeCacheUnavailable(w)
 		return
 	}
 
@@ -475,7 +782,8 @@ func (s *Server) handleListResources(w http.ResponseWriter, r *http.Request) {
 		result, err = cache.Namespaces().List(labels.Everything())
 	default:
 		// Fall back to dynamic cache for CRDs and other unknown resources
-		result, err = cache.ListDynamic(r.Context(), kind, namespace)
+		var synced bool
+		result, synced, err = cache.ListDynamicSynced(r.Context(), kind, namespace, dynamicListSyncTimeout)
 		if err != nil {
 			// Check if it's an unknown resource error
 			if strings.Contains(err.Error(), "unknown resource kind") {
@@ -485,6 +793,10 @@ func (s *Server) handleListResources(w http.ResponseWriter, r *http.Request) {
 			s.writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		if !synced {
+			s.writeCacheSyncing(w)
+			return
+		}
 	}
 
 	if err != nil {
@@ -492,6 +804,11 @@ func (s *Server) handleListResources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if sortMode == "health" && !sortByHealth(result) {
+		s.writeError(w, http.StatusBadRequest, "sort=health is supported for pods, deployments, statefulsets, daemonsets, replicasets and jobs")
+		return
+	}
+
 	s.writeJSON(w, result)
 }
 
@@ -567,10 +884,40 @@ func (s *Server) handleGetResource(w http.ResponseWriter, r *http.Request) {
 
 	cache := k8s.GetResourceCache()
 	if cache == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Resource cache not available")
+		s.writeCacheUnavailable(w)
 		return
 	}
 
+	resource, status, err := getCachedResource(r.Context(), cache, kind, namespace, name, group)
+	if err != nil {
+		s.writeError(w, status, err.Error())
+		return
+	}
+
+	// Get relationships from cached topology
+	var relationships *topology.Relationships
+	if cachedTopo := s.broadcaster.GetCachedTopology(); cachedTopo != nil {
+		relationships = topology.GetRelationships(kind, namespace, name, cachedTopo)
+	}
+	if release := helmReleaseFor(r.Context(), cache, resource); release != nil {
+		if relationships == nil {
+			relationships = &topology.Relationships{}
+		}
+		relationships.HelmRelease = release
+	}
+
+	// Return resource with relationships
+	response := topology.ResourceWithRelationships{
+		Resource:      resource,
+		Relationships: relationships,
+	}
+
+	s.writeJSON(w, response)
+}
+
+// getCachedResource looks up a resource in the typed cache, falling back to
+// the dynamic cache for CRDs. On error it returns the HTTP status to report.
+func getCachedResource(ctx context.Context, cache *k8s.ResourceCache, kind, namespace, name, group string) (any, int, error) {
 	var resource any
 	var err error
 
@@ -595,8 +942,7 @@ func (s *Server) handleGetResource(w http.ResponseWriter, r *http.Request) {
 	case "secrets", "secret":
 		lister := cache.Secrets()
 		if lister == nil {
-			s.writeError(w, http.StatusForbidden, "secrets access not available (RBAC not granted)")
-			return
+			return nil, http.StatusForbidden, fmt.Errorf("secrets access not available (RBAC not granted)")
 		}
 		resource, err = lister.Secrets(namespace).Get(name)
 	case "persistentvolumeclaims", "persistentvolumeclaim", "pvcs", "pvc":
@@ -614,42 +960,25 @@ func (s *Server) handleGetResource(w http.ResponseWriter, r *http.Request) {
 	default:
 		// Fall back to dynamic cache for CRDs and other unknown resources
 		// Use group to disambiguate when multiple API groups have similar resource names
-		resource, err = cache.GetDynamicWithGroup(r.Context(), kind, namespace, name, group)
+		resource, err = cache.GetDynamicWithGroup(ctx, kind, namespace, name, group)
 		if err != nil {
 			if strings.Contains(err.Error(), "unknown resource kind") {
-				s.writeError(w, http.StatusBadRequest, err.Error())
-				return
+				return nil, http.StatusBadRequest, err
 			}
 			if strings.Contains(err.Error(), "not found") {
-				s.writeError(w, http.StatusNotFound, err.Error())
-				return
+				return nil, http.StatusNotFound, err
 			}
-			s.writeError(w, http.StatusInternalServerError, err.Error())
-			return
+			return nil, http.StatusInternalServerError, err
 		}
 	}
 
 	if err != nil {
-		s.writeError(w, http.StatusNotFound, err.Error())
-		return
+		return nil, http.StatusNotFound, err
 	}
 
 	// Set APIVersion and Kind for typed resources (informers don't populate these)
 	setTypeMeta(resource)
-
-	// Get relationships from cached topology
-	var relationships *topology.Relationships
-	if cachedTopo := s.broadcaster.GetCachedTopology(); cachedTopo != nil {
-		relationships = topology.GetRelationships(kind, namespace, name, cachedTopo)
-	}
-
-	// Return resource with relationships
-	response := topology.ResourceWithRelationships{
-		Resource:      resource,
-		Relationships: relationships,
-	}
-
-	s.writeJSON(w, response)
+	return resource, http.StatusOK, nil
 }
 
 // handlePodMetrics fetches metrics for a specific pod from the metrics.k8s.io API
@@ -711,6 +1040,32 @@ func (s *Server) handlePodMetricsHistory(w http.ResponseWriter, r *http.Request)
 	s.writeJSON(w, history)
 }
 
+// handlePodResourceRecommendations compares each container's sampled usage
+// with its requests and limits and suggests requests that fit
+func (s *Server) handlePodResourceRecommendations(w http.ResponseWriter, r *http.Request) {
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+	pod, err := cache.Pods().Pods(namespace).Get(name)
+	if err != nil {
+		s.writeError(w, http.StatusNotFound, fmt.Sprintf("Pod %s/%s not found", namespace, name))
+		return
+	}
+
+	store := k8s.GetMetricsHistory()
+	if store == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Metrics history not available")
+		return
+	}
+
+	s.writeJSON(w, k8s.RecommendResources(pod, store.GetPodMetricsHistory(namespace, name)))
+}
+
 // handleNodeMetricsHistory returns historical metrics for a specific node
 func (s *Server) handleNodeMetricsHistory(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
@@ -738,7 +1093,7 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 
 	cache := k8s.GetResourceCache()
 	if cache == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Resource cache not available")
+		s.writeCacheUnavailable(w)
 		return
 	}
 
@@ -819,6 +1174,86 @@ func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, events)
 }
 
+// Change summary defaults and limits
+const (
+	defaultSummaryWindow  = time.Hour
+	defaultSummaryBuckets = 60
+	maxSummaryBuckets     = 1440
+	maxSummaryEvents      = 10000
+)
+
+// handleChangesSummary aggregates the change history over a window: counts by
+// kind, namespace and verb, and the change volume per bucket, for spotting
+// bursts of changes at a glance. The window is the last 'window' (a duration,
+// default 1h) or everything after 'since' (RFC 3339).
+func (s *Server) handleChangesSummary(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	until := time.Now()
+
+	since := until.Add(-defaultSummaryWindow)
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		ts, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil || !ts.Before(until) {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'since': %s (expected a past RFC 3339 timestamp)", sinceStr))
+			return
+		}
+		since = ts
+	} else if windowStr := query.Get("window"); windowStr != "" {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'window' duration: %s (expected format like '30m', '6h')", windowStr))
+			return
+		}
+		since = until.Add(-window)
+	}
+
+	bucket := until.Sub(since) / defaultSummaryBuckets
+	if bucketStr := query.Get("bucket"); bucketStr != "" {
+		var err error
+		if bucket, err = time.ParseDuration(bucketStr); err != nil || bucket <= 0 {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'bucket' duration: %s (expected format like '1m', '5m')", bucketStr))
+			return
+		}
+		if until.Sub(since)/bucket >= maxSummaryBuckets {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("'bucket' %s splits the window into more than %d buckets", bucketStr, maxSummaryBuckets))
+			return
+		}
+	}
+	bucket = max(bucket.Round(time.Second), time.Second)
+
+	store := timeline.GetStore()
+	if store == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Timeline store not available")
+		return
+	}
+
+	filterPreset := query.Get("filter")
+	if filterPreset == "" {
+		filterPreset = "default"
+	}
+	opts := timeline.QueryOptions{
+		Namespace:        query.Get("namespace"),
+		Since:            since,
+		Limit:            maxSummaryEvents,
+		IncludeManaged:   query.Get("include_managed") == "true",    // default false
+		IncludeK8sEvents: query.Get("include_k8s_events") == "true", // default false, only resource changes
+		FilterPreset:     filterPreset,
+	}
+	if kind := query.Get("kind"); kind != "" {
+		opts.Kinds = []string{kind}
+	}
+
+	events, err := store.Query(r.Context(), opts)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	summary := timeline.Summarize(events, since, until, bucket)
+	summary.Truncated = len(events) >= maxSummaryEvents
+	s.writeJSON(w, summary)
+}
+
 // handleChangeChildren returns child resource changes for a given parent workload
 func (s *Server) handleChangeChildren(w http.ResponseWriter, r *http.Request) {
 	ownerKind := chi.URLParam(r, "kind")
@@ -851,7 +1286,8 @@ func (s *Server) handleChangeChildren(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, children)
 }
 
-// handleUpdateResource updates a Kubernetes resource from YAML
+// handleUpdateResource updates a Kubernetes resource from YAML, or applies
+// an RFC 6902 JSON patch when sent as application/json-patch+json
 func (s *Server) handleUpdateResource(w http.ResponseWriter, r *http.Request) {
 	kind := chi.URLParam(r, "kind")
 	namespace := chi.URLParam(r, "namespace")
@@ -865,6 +1301,17 @@ func (s *Server) handleUpdateResource(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == string(types.JSONPatchType) {
+		s.patchResource(w, r, k8s.PatchResourceOptions{
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			PatchType: types.JSONPatchType,
+			Patch:     body,
+		})
+		return
+	}
+
 	// Update the resource
 	result, err := k8s.UpdateResource(r.Context(), k8s.UpdateResourceOptions{
 		Kind:      kind,
@@ -888,6 +1335,27 @@ func (s *Server) handleUpdateResource(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, result)
 }
 
+// patchResource applies a patch for handleUpdateResource. A patch the API
+// server can't apply (e.g. a failed test op or removing a missing path) is
+// reported as a bad request.
+func (s *Server) patchResource(w http.ResponseWriter, r *http.Request, opts k8s.PatchResourceOptions) {
+	result, err := k8s.PatchResource(r.Context(), opts)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid JSON patch") || apierrors.IsBadRequest(err) || apierrors.IsInvalid(err) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.writeJSON(w, result)
+}
+
 // handleDeleteResource deletes a Kubernetes resource
 func (s *Server) handleDeleteResource(w http.ResponseWriter, r *http.Request) {
 	kind := chi.URLParam(r, "kind")
@@ -1085,13 +1553,33 @@ func (s *Server) writeJSON(w http.ResponseWriter, data any) {
 }
 
 func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+	s.writeErrorCode(w, status, "", message)
+}
+
+// writeErrorCode writes an error with a specific machine-readable code
+func (s *Server) writeErrorCode(w http.ResponseWriter, status int, code, message string) {
+	if err := httperr.Write(w, status, code, message); err != nil {
 		log.Printf("Failed to encode error response: %v", err)
 	}
 }
 
+// writeCacheUnavailable reports a missing resource cache, distinguishing an
+// in-progress sync (startup or context switch) from a cache that failed to start
+func (s *Server) writeCacheUnavailable(w http.ResponseWriter) {
+	if k8s.IsResourceCacheSyncing() {
+		s.writeCacheSyncing(w)
+		return
+	}
+	s.writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeCacheUnavailable, "Resource cache not available")
+}
+
+// writeCacheSyncing tells the client the data is still loading, so an empty
+// list isn't mistaken for "no resources exist"
+func (s *Server) writeCacheSyncing(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "2")
+	s.writeErrorCode(w, http.StatusServiceUnavailable, httperr.CodeCacheSyncing, "Resource cache is still syncing")
+}
+
 // Debug handlers for event pipeline diagnostics
 
 // handleDebugEvents returns event pipeline metrics and recent drops
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/skyhook-io/radar/internal/httperr"
 	"github.com/skyhook-io/radar/internal/k8s"
 	"github.com/skyhook-io/radar/internal/topology"
 )
@@ -388,14 +389,14 @@ func (b *SSEBroadcaster) HandleSSE(w http.ResponseWriter, r *http.Request) {
 	// Ensure we can flush
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		httperr.Write(w, http.StatusInternalServerError, "", "Streaming not supported")
 		return
 	}
 
 	// Subscribe to events
 	eventCh := b.Subscribe(namespace, viewMode)
 	if eventCh == nil {
-		http.Error(w, "Too many SSE connections", http.StatusServiceUnavailable)
+		httperr.Write(w, http.StatusServiceUnavailable, "", "Too many SSE connections")
 		return
 	}
 	defer b.Unsubscribe(eventCh)
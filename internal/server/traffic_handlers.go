@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/skyhook-io/radar/internal/traffic"
@@ -34,8 +37,6 @@ func (s *Server) handleGetTrafficSources(w http.ResponseWriter, r *http.Request)
 // handleGetTrafficFlows returns aggregated flow data
 // GET /api/traffic/flows
 func (s *Server) handleGetTrafficFlows(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
 	manager := traffic.GetManager()
 	if manager == nil {
 		s.writeError(w, http.StatusServiceUnavailable, "Traffic manager not initialized")
@@ -44,21 +45,153 @@ func (s *Server) handleGetTrafficFlows(w http.ResponseWriter, r *http.Request) {
 
 	// Parse query parameters
 	namespace := r.URL.Query().Get("namespace")
-	sinceStr := r.URL.Query().Get("since")
 
 	opts := traffic.DefaultFlowOptions()
 	opts.Namespace = namespace
 
-	if sinceStr != "" {
-		duration, err := time.ParseDuration(sinceStr)
+	since, err := parseFlowSinceQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if since > 0 {
+		opts.Since = since
+	}
+
+	tcpFlags, err := parseTCPFlagsQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.TCPFlags = tcpFlags
+
+	states, err := parseFlowStatesQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.States = states
+
+	exclude, err := parseFlowExcludeQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.Exclude = exclude
+
+	// Requests above the configured maximum are clamped; the response reports
+	// the limit that was applied
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'limit': %s (expected a positive number)", limitStr))
+			return
+		}
+		opts.Limit = limit
+	}
+
+	// Repeated flow events are collapsed by default; aggregate=false returns raw events
+	if r.URL.Query().Get("aggregate") == "false" {
+		opts.Aggregate = false
+	}
+
+	s.writeFlows(w, r, manager, opts)
+}
+
+// handlePostTrafficFlows returns flow data like handleGetTrafficFlows, with a
+// structured filter and the other options in a JSON body
+// POST /api/traffic/flows
+func (s *Server) handlePostTrafficFlows(w http.ResponseWriter, r *http.Request) {
+	manager := traffic.GetManager()
+	if manager == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Traffic manager not initialized")
+		return
+	}
+
+	var req struct {
+		Namespace string                  `json:"namespace"`
+		Filter    *traffic.FlowFilterSpec `json:"filter"`
+		Since     string                  `json:"since"`
+		Limit     int                     `json:"limit"`
+		Aggregate *bool                   `json:"aggregate"`
+		Exclude   *string                 `json:"exclude"`  // Noise exclusions, as in ?exclude=; server defaults when unset
+		TCPFlags  []string                `json:"tcpFlags"` // Flag sets, as in ?tcpFlags=
+		States    []string                `json:"states"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	opts := traffic.DefaultFlowOptions()
+	opts.Namespace = req.Namespace
+
+	if req.Filter != nil {
+		if err := req.Filter.Validate(); err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'filter': %v", err))
+			return
+		}
+		opts.Filter = req.Filter
+	}
+
+	if req.Since != "" {
+		since, err := time.ParseDuration(req.Since)
+		if err != nil || since < 0 {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'since' duration format: %s (expected format like '5m', '1h')", req.Since))
+			return
+		}
+		if since > 0 {
+			opts.Since = since
+		}
+	}
+
+	for _, v := range req.TCPFlags {
+		flags, err := traffic.ParseTCPFlags(v)
 		if err != nil {
-			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'since' duration format: %s (expected format like '5m', '1h')", sinceStr))
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'tcpFlags': %v", err))
 			return
 		}
-		opts.Since = duration
+		opts.TCPFlags = append(opts.TCPFlags, flags)
 	}
 
-	response, err := manager.GetFlows(ctx, opts)
+	if len(req.States) > 0 {
+		states, err := traffic.ParseFlowStates(strings.Join(req.States, ","))
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'states': %v", err))
+			return
+		}
+		opts.States = states
+	}
+
+	opts.Exclude = traffic.DefaultNoiseExclusions()
+	if req.Exclude != nil {
+		exclude, err := traffic.ParseNoiseExclusions(*req.Exclude)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'exclude': %v", err))
+			return
+		}
+		opts.Exclude = exclude
+	}
+
+	if req.Limit < 0 {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'limit': %d (expected a positive number)", req.Limit))
+		return
+	}
+	if req.Limit > 0 {
+		opts.Limit = req.Limit
+	}
+
+	if req.Aggregate != nil {
+		opts.Aggregate = *req.Aggregate
+	}
+
+	s.writeFlows(w, r, manager, opts)
+}
+
+// writeFlows fetches flows for opts and writes them with their service-pair
+// aggregation
+func (s *Server) writeFlows(w http.ResponseWriter, r *http.Request, manager *traffic.Manager, opts traffic.FlowOptions) {
+	response, err := manager.GetFlows(r.Context(), opts)
 	if err != nil {
 		log.Printf("[traffic] Error getting flows: %v", err)
 		s.writeError(w, http.StatusServiceUnavailable, err.Error())
@@ -73,6 +206,10 @@ func (s *Server) handleGetTrafficFlows(w http.ResponseWriter, r *http.Request) {
 		"timestamp":  response.Timestamp,
 		"flows":      response.Flows,
 		"aggregated": aggregated,
+		"limit":      response.Limit,
+	}
+	if response.LimitClamped {
+		result["limitClamped"] = true
 	}
 	if response.Warning != "" {
 		result["warning"] = response.Warning
@@ -80,7 +217,13 @@ func (s *Server) handleGetTrafficFlows(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, result)
 }
 
-// handleTrafficFlowsStream provides SSE stream of traffic flows
+// handleTrafficFlowsStream provides SSE stream of traffic flows. With since
+// or sinceTime, flows from that window are replayed first, then new ones follow.
+// backpressure (drop-newest, drop-oldest or block, with blockTimeout) picks
+// what happens when the client falls behind; a dropped event carries the
+// running total of flows lost. Each flow carries a resume token as its SSE
+// id; reconnecting with it (Last-Event-ID or ?resume=) continues after the
+// last flow received.
 // GET /api/traffic/flows/stream
 func (s *Server) handleTrafficFlowsStream(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -94,9 +237,65 @@ func (s *Server) handleTrafficFlowsStream(w http.ResponseWriter, r *http.Request
 	// Parse query parameters
 	namespace := r.URL.Query().Get("namespace")
 
+	tcpFlags, err := parseTCPFlagsQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	states, err := parseFlowStatesQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	exclude, err := parseFlowExcludeQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	since, err := parseFlowSinceQuery(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// A resumed stream replays from the last flow the client received instead
+	// of the original look-back window
+	resumeFrom, resumed, err := parseResumeToken(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if resumed {
+		since = max(time.Since(resumeFrom), 0)
+	}
+
+	backpressure, err := traffic.ParseBackpressurePolicy(r.URL.Query().Get("backpressure"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var blockTimeout time.Duration
+	if value := r.URL.Query().Get("blockTimeout"); value != "" {
+		if blockTimeout, err = time.ParseDuration(value); err != nil || blockTimeout <= 0 {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid 'blockTimeout' duration: %s", value))
+			return
+		}
+	}
+
+	var dropped atomic.Int64
 	opts := traffic.FlowOptions{
-		Namespace: namespace,
-		Follow:    true,
+		Namespace:    namespace,
+		Since:        since,
+		Follow:       true,
+		TCPFlags:     tcpFlags,
+		States:       states,
+		Exclude:      exclude,
+		Backpressure: backpressure,
+		BlockTimeout: blockTimeout,
+		Dropped:      &dropped,
 	}
 
 	flowCh, err := manager.StreamFlows(ctx, opts)
@@ -119,15 +318,23 @@ func (s *Server) handleTrafficFlowsStream(w http.ResponseWriter, r *http.Request
 	}
 
 	// Send initial connection event
-	if _, err := w.Write([]byte("event: connected\ndata: {}\n\n")); err != nil {
+	if _, err := fmt.Fprintf(w, "event: connected\ndata: {\"resumed\":%t}\n\n", resumed); err != nil {
 		return
 	}
 	flusher.Flush()
 
+	// Position of the newest flow sent, for the resume token
+	position := resumeFrom
+
 	// Heartbeat ticker
 	heartbeat := time.NewTicker(15 * time.Second)
 	defer heartbeat.Stop()
 
+	// Report the running total of dropped flows whenever it changes
+	dropCheck := time.NewTicker(time.Second)
+	defer dropCheck.Stop()
+	var droppedReported int64
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -137,6 +344,13 @@ func (s *Server) handleTrafficFlowsStream(w http.ResponseWriter, r *http.Request
 			if !ok {
 				return
 			}
+			// Replayed flows up to the resume position were already delivered
+			if resumed && !flow.LastSeen.After(resumeFrom) {
+				continue
+			}
+			if flow.LastSeen.After(position) {
+				position = flow.LastSeen
+			}
 
 			data, err := json.Marshal(flow)
 			if err != nil {
@@ -149,7 +363,18 @@ func (s *Server) handleTrafficFlowsStream(w http.ResponseWriter, r *http.Request
 				continue
 			}
 
-			if _, err := w.Write([]byte("event: flow\ndata: " + string(data) + "\n\n")); err != nil {
+			if _, err := fmt.Fprintf(w, "event: flow\nid: %s\ndata: %s\n\n", encodeResumeToken(position), data); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-dropCheck.C:
+			total := dropped.Load()
+			if total == droppedReported {
+				continue
+			}
+			droppedReported = total
+			if _, err := fmt.Fprintf(w, "event: dropped\ndata: {\"dropped\":%d}\n\n", total); err != nil {
 				return
 			}
 			flusher.Flush()
@@ -163,6 +388,78 @@ func (s *Server) handleTrafficFlowsStream(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// parseFlowSinceQuery parses the look-back window from either since, a
+// duration like 5m, or sinceTime, an RFC 3339 timestamp. Returns 0 if neither
+// is set.
+func parseFlowSinceQuery(r *http.Request) (time.Duration, error) {
+	sinceStr := r.URL.Query().Get("since")
+	sinceTimeStr := r.URL.Query().Get("sinceTime")
+	switch {
+	case sinceStr != "" && sinceTimeStr != "":
+		return 0, fmt.Errorf("only one of 'since' and 'sinceTime' may be set")
+	case sinceStr != "":
+		duration, err := time.ParseDuration(sinceStr)
+		if err != nil || duration < 0 {
+			return 0, fmt.Errorf("invalid 'since' duration format: %s (expected format like '5m', '1h')", sinceStr)
+		}
+		return duration, nil
+	case sinceTimeStr != "":
+		sinceTime, err := time.Parse(time.RFC3339, sinceTimeStr)
+		if err != nil {
+			return 0, fmt.Errorf("invalid 'sinceTime': %s (expected RFC 3339, like '2024-01-02T15:04:05Z')", sinceTimeStr)
+		}
+		if !sinceTime.Before(time.Now()) {
+			return 0, fmt.Errorf("'sinceTime' must be in the past")
+		}
+		return time.Since(sinceTime), nil
+	}
+	return 0, nil
+}
+
+// parseTCPFlagsQuery parses the tcpFlags query parameter. Each value is a
+// comma-separated set of flags that must all be set (e.g. "SYN,ACK"); repeating
+// the parameter matches flows with any of the sets.
+func parseTCPFlagsQuery(r *http.Request) ([]traffic.TCPFlags, error) {
+	var result []traffic.TCPFlags
+	for _, v := range r.URL.Query()["tcpFlags"] {
+		flags, err := traffic.ParseTCPFlags(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'tcpFlags': %w", err)
+		}
+		result = append(result, flags)
+	}
+	return result, nil
+}
+
+// parseFlowStatesQuery parses the state query parameter, a comma-separated
+// list of connection states (new, established, closing) to keep
+func parseFlowStatesQuery(r *http.Request) ([]string, error) {
+	v := r.URL.Query().Get("state")
+	if v == "" {
+		return nil, nil
+	}
+	states, err := traffic.ParseFlowStates(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid 'state': %w", err)
+	}
+	return states, nil
+}
+
+// parseFlowExcludeQuery parses the exclude query parameter, a comma-separated
+// list of noise exclusions (kube-system, health-checks, dns, all or none).
+// Without it the server's default exclusions apply.
+func parseFlowExcludeQuery(r *http.Request) (traffic.NoiseExclusions, error) {
+	v, ok := r.URL.Query()["exclude"]
+	if !ok {
+		return traffic.DefaultNoiseExclusions(), nil
+	}
+	exclude, err := traffic.ParseNoiseExclusions(v[0])
+	if err != nil {
+		return traffic.NoiseExclusions{}, fmt.Errorf("invalid 'exclude': %w", err)
+	}
+	return exclude, nil
+}
+
 // handleSetTrafficSource sets the active traffic source
 // POST /api/traffic/source
 func (s *Server) handleSetTrafficSource(w http.ResponseWriter, r *http.Request) {
@@ -196,6 +493,26 @@ func (s *Server) handleSetTrafficSource(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// handleTrafficIdentities lists Cilium security identities with their labels
+// and the CiliumEndpoints using them, for identity pickers and decoding
+// identity numbers in flows
+// GET /api/traffic/identities?namespace=X
+func (s *Server) handleTrafficIdentities(w http.ResponseWriter, r *http.Request) {
+	manager := traffic.GetManager()
+	if manager == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Traffic manager not initialized")
+		return
+	}
+
+	result, err := manager.ListCiliumIdentities(r.Context(), r.URL.Query().Get("namespace"))
+	if err != nil {
+		s.writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	s.writeJSON(w, result)
+}
+
 // handleGetActiveTrafficSource returns the currently active traffic source
 // GET /api/traffic/source
 func (s *Server) handleGetActiveTrafficSource(w http.ResponseWriter, r *http.Request) {
@@ -234,6 +551,25 @@ func (s *Server) handleTrafficConnect(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, connInfo)
 }
 
+// handleTrafficStatus returns the combined status used to render the Traffic view
+// GET /api/traffic/status
+func (s *Server) handleTrafficStatus(w http.ResponseWriter, r *http.Request) {
+	manager := traffic.GetManager()
+	if manager == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Traffic manager not initialized")
+		return
+	}
+
+	status, err := manager.Status(r.Context())
+	if err != nil {
+		log.Printf("[traffic] Error getting status: %v", err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to get traffic status")
+		return
+	}
+
+	s.writeJSON(w, status)
+}
+
 // handleTrafficConnectionStatus returns current connection status
 // GET /api/traffic/connection
 func (s *Server) handleTrafficConnectionStatus(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+	"github.com/skyhook-io/radar/internal/topology"
+)
+
+// maxWorkloadEvents caps the events returned with a workload detail
+const maxWorkloadEvents = 50
+
+// WorkloadDetail is everything a workload's detail page shows, in one response
+type WorkloadDetail struct {
+	Workload      any                     `json:"workload"`
+	Status        *k8s.ResourceStatus     `json:"status,omitempty"`
+	Rollout       WorkloadRollout         `json:"rollout"`
+	ReplicaSets   []*appsv1.ReplicaSet    `json:"replicaSets,omitempty"` // Deployments only, newest first
+	Pods          []*corev1.Pod           `json:"pods"`
+	Events        []corev1.Event          `json:"events"` // For the workload, its ReplicaSets and pods, newest first
+	Relationships *topology.Relationships `json:"relationships,omitempty"`
+}
+
+// WorkloadRollout summarizes rollout progress the way kubectl rollout status does
+type WorkloadRollout struct {
+	Complete bool   `json:"complete"`
+	Failed   bool   `json:"failed,omitempty"` // Deployment exceeded its progress deadline
+	Message  string `json:"message"`
+	Revision string `json:"revision,omitempty"` // Deployment revision or StatefulSet update revision
+}
+
+// handleGetWorkloadDetail returns a Deployment, StatefulSet or DaemonSet with
+// its ReplicaSets, pods, rollout status and recent events from the cache.
+// Pods are matched by the workload's selector and kept only if the workload
+// owns them, directly or through one of its ReplicaSets.
+// GET /api/workloads/{kind}/{namespace}/{name}
+func (s *Server) handleGetWorkloadDetail(w http.ResponseWriter, r *http.Request) {
+	kind := normalizeKind(chi.URLParam(r, "kind"))
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+
+	var (
+		detail   WorkloadDetail
+		uid      types.UID
+		selector *metav1.LabelSelector
+		err      error
+	)
+	switch strings.TrimSuffix(kind, "s") {
+	case "deployment":
+		var dep *appsv1.Deployment
+		if dep, err = cache.Deployments().Deployments(namespace).Get(name); err == nil {
+			detail.Workload, uid, selector = dep, dep.UID, dep.Spec.Selector
+			detail.Rollout = deploymentRollout(dep)
+			detail.Status = cache.GetResourceStatus("Deployment", namespace, name)
+		}
+	case "statefulset":
+		var sts *appsv1.StatefulSet
+		if sts, err = cache.StatefulSets().StatefulSets(namespace).Get(name); err == nil {
+			detail.Workload, uid, selector = sts, sts.UID, sts.Spec.Selector
+			detail.Rollout = statefulSetRollout(sts)
+			detail.Status = cache.GetResourceStatus("StatefulSet", namespace, name)
+		}
+	case "daemonset":
+		var ds *appsv1.DaemonSet
+		if ds, err = cache.DaemonSets().DaemonSets(namespace).Get(name); err == nil {
+			detail.Workload, uid, selector = ds, ds.UID, ds.Spec.Selector
+			detail.Rollout = daemonSetRollout(ds)
+			detail.Status = cache.GetResourceStatus("DaemonSet", namespace, name)
+		}
+	default:
+		s.writeError(w, http.StatusBadRequest, "only Deployments, StatefulSets and DaemonSets have workload details")
+		return
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	setTypeMeta(detail.Workload)
+
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, fmt.Sprintf("invalid selector: %v", err))
+		return
+	}
+
+	// Pods of a Deployment are owned by its ReplicaSets, not by it directly
+	owners := map[types.UID]bool{uid: true}
+	if _, ok := detail.Workload.(*appsv1.Deployment); ok {
+		replicaSets, err := cache.ReplicaSets().ReplicaSets(namespace).List(podSelector)
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, rs := range replicaSets {
+			if isOwnedBy(rs.OwnerReferences, uid) {
+				setTypeMeta(rs)
+				detail.ReplicaSets = append(detail.ReplicaSets, rs)
+				owners[rs.UID] = true
+			}
+		}
+		sort.Slice(detail.ReplicaSets, func(i, j int) bool {
+			return detail.ReplicaSets[j].CreationTimestamp.Before(&detail.ReplicaSets[i].CreationTimestamp)
+		})
+	}
+
+	pods, err := cache.Pods().Pods(namespace).List(podSelector)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	detail.Pods = []*corev1.Pod{}
+	for _, pod := range pods {
+		for owner := range owners {
+			if isOwnedBy(pod.OwnerReferences, owner) {
+				setTypeMeta(pod)
+				detail.Pods = append(detail.Pods, pod)
+				break
+			}
+		}
+	}
+	sort.Slice(detail.Pods, func(i, j int) bool { return detail.Pods[i].Name < detail.Pods[j].Name })
+
+	related := make(map[types.UID]bool, len(owners)+len(detail.Pods))
+	for owner := range owners {
+		related[owner] = true
+	}
+	for _, pod := range detail.Pods {
+		related[pod.UID] = true
+	}
+	detail.Events, err = recentEventsFor(cache, namespace, related)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if cachedTopo := s.broadcaster.GetCachedTopology(); cachedTopo != nil {
+		detail.Relationships = topology.GetRelationships(kind, namespace, name, cachedTopo)
+	}
+
+	s.writeJSON(w, detail)
+}
+
+// isOwnedBy reports whether refs include the owner with the given UID
+func isOwnedBy(refs []metav1.OwnerReference, uid types.UID) bool {
+	return slices.ContainsFunc(refs, func(ref metav1.OwnerReference) bool { return ref.UID == uid })
+}
+
+// recentEventsFor returns the newest events in a namespace about any of the
+// given objects, at most maxWorkloadEvents of them
+func recentEventsFor(cache *k8s.ResourceCache, namespace string, uids map[types.UID]bool) ([]corev1.Event, error) {
+	all, err := cache.Events().Events(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	events := []corev1.Event{}
+	for _, event := range all {
+		if uids[event.InvolvedObject.UID] {
+			events = append(events, *event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(&events[j]).Before(eventTime(&events[i]))
+	})
+	if len(events) > maxWorkloadEvents {
+		events = events[:maxWorkloadEvents]
+	}
+	return events, nil
+}
+
+// eventTime is when an event last happened, for events that don't set LastTimestamp
+func eventTime(event *corev1.Event) *metav1.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return &event.LastTimestamp
+	case !event.EventTime.IsZero():
+		return &metav1.Time{Time: event.EventTime.Time}
+	}
+	return &event.CreationTimestamp
+}
+
+// deploymentRollout mirrors kubectl's rollout status for Deployments
+func deploymentRollout(dep *appsv1.Deployment) WorkloadRollout {
+	rollout := WorkloadRollout{Revision: dep.Annotations["deployment.kubernetes.io/revision"]}
+	if dep.Generation > dep.Status.ObservedGeneration {
+		rollout.Message = "Waiting for the rollout to be observed"
+		return rollout
+	}
+	for _, cond := range dep.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			rollout.Failed = true
+			rollout.Message = fmt.Sprintf("Rollout exceeded its progress deadline: %s", cond.Message)
+			return rollout
+		}
+	}
+
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	switch {
+	case dep.Status.UpdatedReplicas < replicas:
+		rollout.Message = fmt.Sprintf("Waiting for rollout to finish: %d of %d new replicas have been updated", dep.Status.UpdatedReplicas, replicas)
+	case dep.Status.Replicas > dep.Status.UpdatedReplicas:
+		rollout.Message = fmt.Sprintf("Waiting for rollout to finish: %d old replicas are pending termination", dep.Status.Replicas-dep.Status.UpdatedReplicas)
+	case dep.Status.AvailableReplicas < dep.Status.UpdatedReplicas:
+		rollout.Message = fmt.Sprintf("Waiting for rollout to finish: %d of %d updated replicas are available", dep.Status.AvailableReplicas, dep.Status.UpdatedReplicas)
+	default:
+		rollout.Complete = true
+		rollout.Message = "Successfully rolled out"
+	}
+	return rollout
+}
+
+// statefulSetRollout mirrors kubectl's rollout status for StatefulSets
+func statefulSetRollout(sts *appsv1.StatefulSet) WorkloadRollout {
+	rollout := WorkloadRollout{Revision: sts.Status.UpdateRevision}
+	if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		rollout.Message = "Rollout status is not available with the OnDelete update strategy"
+		return rollout
+	}
+	if sts.Generation > sts.Status.ObservedGeneration {
+		rollout.Message = "Waiting for the rollout to be observed"
+		return rollout
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if sts.Status.ReadyReplicas < replicas {
+		rollout.Message = fmt.Sprintf("Waiting for %d pods to be ready", replicas-sts.Status.ReadyReplicas)
+		return rollout
+	}
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil && *ru.Partition > 0 {
+		if sts.Status.UpdatedReplicas < replicas-*ru.Partition {
+			rollout.Message = fmt.Sprintf("Waiting for partitioned rollout to finish: %d of %d new pods have been updated", sts.Status.UpdatedReplicas, replicas-*ru.Partition)
+			return rollout
+		}
+		rollout.Complete = true
+		rollout.Message = fmt.Sprintf("Partitioned rollout complete: %d new pods have been updated", sts.Status.UpdatedReplicas)
+		return rollout
+	}
+	if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+		rollout.Message = fmt.Sprintf("Waiting for rollout to finish: %d of %d pods have been updated", sts.Status.UpdatedReplicas, replicas)
+		return rollout
+	}
+	rollout.Complete = true
+	rollout.Message = "Successfully rolled out"
+	return rollout
+}
+
+// daemonSetRollout mirrors kubectl's rollout status for DaemonSets
+func daemonSetRollout(ds *appsv1.DaemonSet) WorkloadRollout {
+	var rollout WorkloadRollout
+	if ds.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType {
+		rollout.Message = "Rollout status is not available with the OnDelete update strategy"
+		return rollout
+	}
+	if ds.Generation > ds.Status.ObservedGeneration {
+		rollout.Message = "Waiting for the rollout to be observed"
+		return rollout
+	}
+
+	desired := ds.Status.DesiredNumberScheduled
+	switch {
+	case ds.Status.UpdatedNumberScheduled < desired:
+		rollout.Message = fmt.Sprintf("Waiting for rollout to finish: %d of %d updated pods have been scheduled", ds.Status.UpdatedNumberScheduled, desired)
+	case ds.Status.NumberAvailable < desired:
+		rollout.Message = fmt.Sprintf("Waiting for rollout to finish: %d of %d updated pods are available", ds.Status.NumberAvailable, desired)
+	default:
+		rollout.Complete = true
+		rollout.Message = "Successfully rolled out"
+	}
+	return rollout
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/skyhook-io/radar/internal/k8s"
+)
+
+const (
+	// workloadLogsPollInterval is how often a workload log stream looks for
+	// pods and containers that started since the last look
+	workloadLogsPollInterval = 2 * time.Second
+
+	// maxWorkloadLogPods caps the pods one stream follows at a time
+	maxWorkloadLogPods = 25
+)
+
+// workloadLogLine is one log line of a workload's pod
+type workloadLogLine struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Timestamp string `json:"timestamp"`
+	Content   string `json:"content"`
+}
+
+// followedContainer identifies a container a workload log stream follows
+type followedContainer struct {
+	pod       types.UID
+	container string
+}
+
+// followEnd reports a followed container whose log stream closed, e.g.
+// because the container restarted or the pod was deleted, or failed
+type followEnd struct {
+	key     followedContainer
+	pod     string
+	endedAt time.Time
+	err     error
+}
+
+// handleWorkloadLogsStream streams the logs of all pods of a Deployment,
+// StatefulSet, DaemonSet or Service via SSE, interleaved as they arrive and
+// each line tagged with its pod and container. Pods that start later, such as
+// the new pods of a rollout, are picked up and followed from their first
+// line. Events: connected, pod when a container starts or stops being
+// followed or its logs can't be read, log, warning when pods are left out
+// and heartbeat. ?container= follows one container of each pod instead of
+// all, and ?tailLines= sets the lines of history per container for pods
+// already running.
+// GET /api/workloads/{kind}/{namespace}/{name}/logs/stream
+func (s *Server) handleWorkloadLogsStream(w http.ResponseWriter, r *http.Request) {
+	kind := normalizeKind(chi.URLParam(r, "kind"))
+	namespace := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+	container := r.URL.Query().Get("container")
+
+	tailLines := int64(100) // default for streaming
+	if v := r.URL.Query().Get("tailLines"); v != "" {
+		if t, err := strconv.ParseInt(v, 10, 64); err == nil && t > 0 {
+			tailLines = t
+		}
+	}
+
+	client := k8s.GetClient()
+	if client == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Kubernetes client not available")
+		return
+	}
+	cache := k8s.GetResourceCache()
+	if cache == nil {
+		s.writeCacheUnavailable(w)
+		return
+	}
+
+	selector, err := workloadPodSelector(cache, kind, namespace, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	// Set SSE headers
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	lines := make(chan workloadLogLine, 256)
+	ended := make(chan followEnd, 16)
+	var followers sync.WaitGroup
+	defer followers.Wait()
+	defer cancel()
+
+	// Containers being followed, and when the stream of one that stopped
+	// ended so following it again doesn't repeat lines already sent
+	followed := make(map[followedContainer]bool)
+	endedAt := make(map[followedContainer]time.Time)
+	limited := false
+	connectedAt := time.Now()
+
+	follow := func(initial bool) {
+		pods, err := cache.Pods().Pods(namespace).List(selector)
+		if err != nil {
+			return
+		}
+		sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+		// Forget pods that are gone
+		current := make(map[types.UID]bool, len(pods))
+		for _, pod := range pods {
+			current[pod.UID] = true
+		}
+		for key := range endedAt {
+			if !current[key.pod] {
+				delete(endedAt, key)
+			}
+		}
+		for key := range followed {
+			if !current[key.pod] {
+				delete(followed, key)
+			}
+		}
+
+		following := make(map[types.UID]bool)
+		for key := range followed {
+			following[key.pod] = true
+		}
+
+		for _, pod := range pods {
+			if !following[pod.UID] && len(following) >= maxWorkloadLogPods {
+				if !limited {
+					limited = true
+					sendSSEEvent(w, flusher, "warning", map[string]string{
+						"message": fmt.Sprintf("Following the first %d pods only", maxWorkloadLogPods),
+					})
+				}
+				continue
+			}
+			for _, c := range runningContainers(pod, container) {
+				key := followedContainer{pod: pod.UID, container: c}
+				if followed[key] {
+					continue
+				}
+				// Containers running at connect time start from their tail;
+				// ones seen later get everything since connecting, which for
+				// a new pod is its whole log
+				opts := &corev1.PodLogOptions{
+					Container:  c,
+					Follow:     true,
+					Timestamps: true,
+				}
+				if since, ok := endedAt[key]; ok {
+					opts.SinceTime = &metav1.Time{Time: since}
+				} else if initial {
+					opts.TailLines = &tailLines
+				} else {
+					opts.SinceTime = &metav1.Time{Time: connectedAt}
+				}
+
+				followed[key] = true
+				following[pod.UID] = true
+				sendSSEEvent(w, flusher, "pod", map[string]string{"pod": pod.Name, "container": c, "status": "following"})
+				followers.Add(1)
+				go func() {
+					defer followers.Done()
+					err := followWorkloadLogs(ctx, pod, opts, lines)
+					select {
+					case ended <- followEnd{key: key, pod: pod.Name, endedAt: time.Now(), err: err}:
+					case <-ctx.Done():
+					}
+				}()
+			}
+		}
+	}
+
+	sendSSEEvent(w, flusher, "connected", map[string]any{
+		"kind":      kind,
+		"namespace": namespace,
+		"name":      name,
+		"selector":  selector.String(),
+		"container": container,
+	})
+	follow(true)
+
+	poll := time.NewTicker(workloadLogsPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case line := <-lines:
+			sendSSEEvent(w, flusher, "log", line)
+
+		case end := <-ended:
+			// A container whose logs can't be read stays marked as followed,
+			// so it isn't retried on every poll
+			if end.err != nil {
+				sendSSEEvent(w, flusher, "pod", map[string]string{"pod": end.pod, "container": end.key.container, "status": "failed", "error": end.err.Error()})
+				continue
+			}
+			delete(followed, end.key)
+			endedAt[end.key] = end.endedAt
+			sendSSEEvent(w, flusher, "pod", map[string]string{"pod": end.pod, "container": end.key.container, "status": "ended"})
+
+		case <-poll.C:
+			follow(false)
+
+		case <-heartbeat.C:
+			sendSSEEvent(w, flusher, "heartbeat", struct{}{})
+		}
+	}
+}
+
+// runningContainers returns the containers of a pod whose logs can be
+// followed now, limited to only when set. Waiting containers have no log yet.
+func runningContainers(pod *corev1.Pod, only string) []string {
+	var names []string
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running == nil || (only != "" && status.Name != only) {
+			continue
+		}
+		names = append(names, status.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// followWorkloadLogs forwards the log lines of one pod container until its
+// stream ends or ctx is cancelled. It returns an error only when the stream
+// couldn't be opened, e.g. because logs aren't readable.
+func followWorkloadLogs(ctx context.Context, pod *corev1.Pod, opts *corev1.PodLogOptions, out chan<- workloadLogLine) error {
+	client := k8s.GetClient()
+	if client == nil {
+		return fmt.Errorf("kubernetes client not available")
+	}
+	stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer stream.Close()
+
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			timestamp, content := parseLogLine(line)
+			select {
+			case out <- workloadLogLine{Pod: pod.Name, Container: opts.Container, Timestamp: timestamp, Content: content}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		// A broken connection ends the stream like EOF, so the container is
+		// followed again on the next poll
+		if err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package timeline
+
+import (
+	"sort"
+	"time"
+)
+
+// Change verbs counted by a summary
+const (
+	VerbCreate = "create"
+	VerbUpdate = "update"
+	VerbDelete = "delete"
+	VerbEvent  = "event" // K8s Events, when included
+)
+
+// SummaryCount is the number of changes for one kind, namespace or verb
+type SummaryCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// SummaryBucket is the change volume in one interval of a summary window
+type SummaryBucket struct {
+	Start  time.Time `json:"start"`
+	Count  int       `json:"count"`
+	Create int       `json:"create,omitempty"`
+	Update int       `json:"update,omitempty"`
+	Delete int       `json:"delete,omitempty"`
+}
+
+// ChangeSummary aggregates the change history over a window
+type ChangeSummary struct {
+	Since       time.Time       `json:"since"`
+	Until       time.Time       `json:"until"`
+	Bucket      string          `json:"bucket"` // Width of each timeline bucket
+	Total       int             `json:"total"`
+	ByKind      []SummaryCount  `json:"byKind"`      // Most changes first
+	ByNamespace []SummaryCount  `json:"byNamespace"` // Most changes first; cluster-scoped resources count as ""
+	ByVerb      []SummaryCount  `json:"byVerb"`      // Most changes first
+	Timeline    []SummaryBucket `json:"timeline"`    // Oldest first, covering the whole window
+	Peak        *SummaryBucket  `json:"peak,omitempty"`
+	Truncated   bool            `json:"truncated,omitempty"` // Only the most recent events of the window were counted
+}
+
+// changeVerb returns the verb an event counts under
+func changeVerb(eventType EventType) string {
+	switch eventType {
+	case EventTypeAdd:
+		return VerbCreate
+	case EventTypeUpdate:
+		return VerbUpdate
+	case EventTypeDelete:
+		return VerbDelete
+	}
+	return VerbEvent
+}
+
+// Summarize counts events by kind, namespace and verb, and their volume per
+// bucket from since to until. Events outside the window are ignored; a
+// bucket of zero or less puts the whole window in one bucket.
+func Summarize(events []TimelineEvent, since, until time.Time, bucket time.Duration) *ChangeSummary {
+	window := until.Sub(since)
+	if bucket <= 0 || bucket > window {
+		bucket = max(window, time.Second)
+	}
+
+	buckets := int((window + bucket - 1) / bucket)
+	summary := &ChangeSummary{
+		Since:    since,
+		Until:    until,
+		Bucket:   bucket.String(),
+		Timeline: make([]SummaryBucket, max(buckets, 1)),
+	}
+	for idx := range summary.Timeline {
+		summary.Timeline[idx].Start = since.Add(time.Duration(idx) * bucket)
+	}
+
+	byKind := make(map[string]int)
+	byNamespace := make(map[string]int)
+	byVerb := make(map[string]int)
+	for _, event := range events {
+		if event.Timestamp.Before(since) || event.Timestamp.After(until) {
+			continue
+		}
+		verb := changeVerb(event.EventType)
+		summary.Total++
+		byKind[event.Kind]++
+		byNamespace[event.Namespace]++
+		byVerb[verb]++
+
+		idx := min(int(event.Timestamp.Sub(since)/bucket), len(summary.Timeline)-1)
+		b := &summary.Timeline[idx]
+		b.Count++
+		switch verb {
+		case VerbCreate:
+			b.Create++
+		case VerbUpdate:
+			b.Update++
+		case VerbDelete:
+			b.Delete++
+		}
+	}
+
+	summary.ByKind = sortedCounts(byKind)
+	summary.ByNamespace = sortedCounts(byNamespace)
+	summary.ByVerb = sortedCounts(byVerb)
+
+	for idx := range summary.Timeline {
+		if b := &summary.Timeline[idx]; b.Count > 0 && (summary.Peak == nil || b.Count > summary.Peak.Count) {
+			peak := *b
+			summary.Peak = &peak
+		}
+	}
+	return summary
+}
+
+// sortedCounts returns counts sorted by count, highest first, then by key
+func sortedCounts(counts map[string]int) []SummaryCount {
+	result := make([]SummaryCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, SummaryCount{Key: key, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
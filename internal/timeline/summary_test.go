@@ -0,0 +1,95 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	since := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	until := since.Add(10 * time.Minute)
+	at := func(minutes int) time.Time { return since.Add(time.Duration(minutes) * time.Minute) }
+
+	events := []TimelineEvent{
+		{Timestamp: at(0), Kind: "Deployment", Namespace: "shop", EventType: EventTypeAdd},
+		{Timestamp: at(3), Kind: "Pod", Namespace: "shop", EventType: EventTypeUpdate},
+		{Timestamp: at(3), Kind: "Pod", Namespace: "shop", EventType: EventTypeUpdate},
+		{Timestamp: at(4), Kind: "Pod", Namespace: "payments", EventType: EventTypeDelete},
+		{Timestamp: until, Kind: "Node", EventType: EventTypeUpdate},                                 // End of the window counts
+		{Timestamp: at(-1), Kind: "Pod", Namespace: "shop", EventType: EventTypeAdd},                 // Before the window
+		{Timestamp: until.Add(time.Second), Kind: "Pod", Namespace: "shop", EventType: EventTypeAdd}, // After the window
+	}
+
+	summary := Summarize(events, since, until, 2*time.Minute)
+
+	if summary.Total != 5 {
+		t.Errorf("expected 5 changes, got %d", summary.Total)
+	}
+	if summary.Bucket != "2m0s" {
+		t.Errorf("expected bucket 2m0s, got %s", summary.Bucket)
+	}
+
+	wantKinds := []SummaryCount{{"Pod", 3}, {"Deployment", 1}, {"Node", 1}}
+	if len(summary.ByKind) != len(wantKinds) {
+		t.Fatalf("expected %v by kind, got %v", wantKinds, summary.ByKind)
+	}
+	for idx, want := range wantKinds {
+		if summary.ByKind[idx] != want {
+			t.Errorf("byKind[%d]: expected %v, got %v", idx, want, summary.ByKind[idx])
+		}
+	}
+
+	wantNamespaces := []SummaryCount{{"shop", 3}, {"", 1}, {"payments", 1}}
+	for idx, want := range wantNamespaces {
+		if idx >= len(summary.ByNamespace) || summary.ByNamespace[idx] != want {
+			t.Errorf("byNamespace: expected %v, got %v", wantNamespaces, summary.ByNamespace)
+			break
+		}
+	}
+
+	wantVerbs := map[string]int{VerbCreate: 1, VerbUpdate: 3, VerbDelete: 1}
+	for _, count := range summary.ByVerb {
+		if wantVerbs[count.Key] != count.Count {
+			t.Errorf("verb %s: expected %d, got %d", count.Key, wantVerbs[count.Key], count.Count)
+		}
+	}
+
+	wantCounts := []int{1, 2, 1, 0, 1}
+	if len(summary.Timeline) != len(wantCounts) {
+		t.Fatalf("expected %d buckets, got %d", len(wantCounts), len(summary.Timeline))
+	}
+	for idx, want := range wantCounts {
+		b := summary.Timeline[idx]
+		if b.Count != want {
+			t.Errorf("bucket %d: expected %d changes, got %d", idx, want, b.Count)
+		}
+		if !b.Start.Equal(at(2 * idx)) {
+			t.Errorf("bucket %d: expected start %s, got %s", idx, at(2*idx), b.Start)
+		}
+	}
+	if b := summary.Timeline[1]; b.Update != 2 || b.Create != 0 {
+		t.Errorf("bucket 1: expected 2 updates, got %+v", b)
+	}
+	if b := summary.Timeline[2]; b.Delete != 1 {
+		t.Errorf("bucket 2: expected 1 delete, got %+v", b)
+	}
+
+	if summary.Peak == nil || !summary.Peak.Start.Equal(at(2)) || summary.Peak.Count != 2 {
+		t.Errorf("expected peak at %s with 2 changes, got %+v", at(2), summary.Peak)
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	since := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	summary := Summarize(nil, since, since.Add(time.Hour), 0)
+
+	if summary.Total != 0 || summary.Peak != nil {
+		t.Errorf("expected an empty summary, got total=%d peak=%v", summary.Total, summary.Peak)
+	}
+	if len(summary.Timeline) != 1 || summary.Bucket != "1h0m0s" {
+		t.Errorf("expected the window in a single bucket, got %d buckets of %s", len(summary.Timeline), summary.Bucket)
+	}
+	if summary.ByKind == nil || summary.ByNamespace == nil || summary.ByVerb == nil {
+		t.Error("expected empty counts to be non-nil so they encode as []")
+	}
+}
@@ -244,3 +244,28 @@ func GetRelationshipsForAll(resources []ResourceRef, topo *Topology) map[string]
 
 	return result
 }
+
+// Annotations and labels Helm sets on the resources of a release
+const (
+	helmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+	managedByLabel                 = "app.kubernetes.io/managed-by"
+	instanceLabel                  = "app.kubernetes.io/instance"
+)
+
+// HelmReleaseOf returns the Helm release managing a resource, from the
+// release annotations Helm 3 sets, or else from the managed-by and instance
+// labels most charts set. Returns nil if the resource isn't Helm-managed.
+func HelmReleaseOf(annotations, labels map[string]string, namespace string) *HelmReleaseRef {
+	if name := annotations[helmReleaseNameAnnotation]; name != "" {
+		ref := &HelmReleaseRef{Name: name, Namespace: annotations[helmReleaseNamespaceAnnotation]}
+		if ref.Namespace == "" {
+			ref.Namespace = namespace
+		}
+		return ref
+	}
+	if labels[managedByLabel] == "Helm" && labels[instanceLabel] != "" {
+		return &HelmReleaseRef{Name: labels[instanceLabel], Namespace: namespace}
+	}
+	return nil
+}
@@ -124,14 +124,21 @@ type ResourceRef struct {
 
 // Relationships holds computed relationships for a resource
 type Relationships struct {
-	Owner       *ResourceRef  `json:"owner,omitempty"`       // Parent via ownerReference (manages edge)
-	Children    []ResourceRef `json:"children,omitempty"`    // Resources this owns (manages edge)
-	Services    []ResourceRef `json:"services,omitempty"`    // Services selecting/exposing this
-	Ingresses   []ResourceRef `json:"ingresses,omitempty"`   // Ingresses routing to this
-	ConfigRefs  []ResourceRef `json:"configRefs,omitempty"`  // ConfigMaps/Secrets used by this
-	HPA         *ResourceRef  `json:"hpa,omitempty"`         // HPA scaling this
-	ScaleTarget *ResourceRef  `json:"scaleTarget,omitempty"` // For HPA: what it scales
-	Pods        []ResourceRef `json:"pods,omitempty"`        // For Service: pods it routes to
+	Owner       *ResourceRef    `json:"owner,omitempty"`       // Parent via ownerReference (manages edge)
+	Children    []ResourceRef   `json:"children,omitempty"`    // Resources this owns (manages edge)
+	Services    []ResourceRef   `json:"services,omitempty"`    // Services selecting/exposing this
+	Ingresses   []ResourceRef   `json:"ingresses,omitempty"`   // Ingresses routing to this
+	ConfigRefs  []ResourceRef   `json:"configRefs,omitempty"`  // ConfigMaps/Secrets used by this
+	HPA         *ResourceRef    `json:"hpa,omitempty"`         // HPA scaling this
+	ScaleTarget *ResourceRef    `json:"scaleTarget,omitempty"` // For HPA: what it scales
+	Pods        []ResourceRef   `json:"pods,omitempty"`        // For Service: pods it routes to
+	HelmRelease *HelmReleaseRef `json:"helmRelease,omitempty"` // Helm release managing this, directly or via its owner
+}
+
+// HelmReleaseRef identifies the Helm release that manages a resource
+type HelmReleaseRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
 }
 
 // ResourceWithRelationships wraps a K8s resource with computed relationships
@@ -0,0 +1,82 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BackpressurePolicy decides what a flow stream does with a flow when its
+// channel is full because the reader is slower than the source
+type BackpressurePolicy string
+
+const (
+	BackpressureDropNewest BackpressurePolicy = "drop-newest" // Discard the incoming flow (default)
+	BackpressureDropOldest BackpressurePolicy = "drop-oldest" // Discard the oldest queued flow to make room
+	BackpressureBlock      BackpressurePolicy = "block"       // Wait up to BlockTimeout, then discard the incoming flow
+)
+
+// defaultBlockTimeout is how long BackpressureBlock waits for room
+const defaultBlockTimeout = 5 * time.Second
+
+// ParseBackpressurePolicy validates a policy name; empty means drop-newest
+func ParseBackpressurePolicy(s string) (BackpressurePolicy, error) {
+	switch p := BackpressurePolicy(s); p {
+	case "":
+		return BackpressureDropNewest, nil
+	case BackpressureDropNewest, BackpressureDropOldest, BackpressureBlock:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid backpressure policy %q (expected drop-newest, drop-oldest or block)", s)
+}
+
+// sendFlow queues a flow on a stream channel according to opts.Backpressure,
+// counting discarded flows in opts.Dropped. Returns false once ctx is done.
+func sendFlow(ctx context.Context, ch chan Flow, flow Flow, opts FlowOptions) bool {
+	select {
+	case ch <- flow:
+		return true
+	case <-ctx.Done():
+		return false
+	default:
+	}
+
+	switch opts.Backpressure {
+	case BackpressureDropOldest:
+		// The reader may drain the channel in between, so either receive can miss
+		select {
+		case <-ch:
+			countDropped(opts)
+		default:
+		}
+		select {
+		case ch <- flow:
+			return true
+		default:
+		}
+
+	case BackpressureBlock:
+		timeout := opts.BlockTimeout
+		if timeout <= 0 {
+			timeout = defaultBlockTimeout
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case ch <- flow:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+	}
+
+	countDropped(opts)
+	return true
+}
+
+func countDropped(opts FlowOptions) {
+	if opts.Dropped != nil {
+		opts.Dropped.Add(1)
+	}
+}
@@ -0,0 +1,114 @@
+package traffic
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Capability statuses, from most to least usable
+const (
+	CapabilityAvailable    = "available"     // Installed and Radar can read flows from it
+	CapabilityUnsupported  = "unsupported"   // Installed, but Radar can't read flows from it yet
+	CapabilityDisabled     = "disabled"      // Its platform is installed with flow visibility turned off
+	CapabilityError        = "error"         // Detection failed
+	CapabilityNotInstalled = "not_installed" // Not found in the cluster
+)
+
+// trafficRequirement is shown when no backend can provide traffic data
+const trafficRequirement = "Traffic visibility requires Cilium with Hubble enabled, Istio with Prometheus, or Caretta"
+
+// TrafficCapability reports whether one traffic backend can be used in the cluster
+type TrafficCapability struct {
+	Backend string `json:"backend"` // hubble, istio, caretta, calico-flow-logs
+	Label   string `json:"label"`   // Display name
+	Status  string `json:"status"`  // See the Capability* constants
+	Active  bool   `json:"active,omitempty"`
+	Message string `json:"message,omitempty"` // What was found, or what to do to enable it
+}
+
+// capabilityLabels are the display names of the traffic backends
+var capabilityLabels = map[string]string{
+	"hubble":           "Cilium / Hubble",
+	"istio":            "Istio",
+	"caretta":          "Caretta",
+	"calico-flow-logs": "Calico flow logs",
+}
+
+// probeCapabilities combines source detection with what the cluster info
+// implies about each backend. Must be called with m.mu held.
+func (m *Manager) probeCapabilities(ctx context.Context, info *ClusterInfo, detected []SourceStatus) []TrafficCapability {
+	byName := make(map[string]SourceStatus, len(detected))
+	for _, s := range detected {
+		byName[s.Name] = s
+	}
+
+	var caps []TrafficCapability
+	for _, name := range sourcePriority {
+		if _, ok := m.sources[name]; !ok {
+			continue
+		}
+		capability := TrafficCapability{Backend: name, Label: capabilityLabels[name], Status: CapabilityNotInstalled}
+		if s, ok := byName[name]; ok {
+			capability.Message = s.Message
+			switch s.Status {
+			case "available":
+				capability.Status = CapabilityAvailable
+				capability.Active = m.activeSource != nil && m.activeSource.Name() == name
+			case "error":
+				capability.Status = CapabilityError
+			}
+		}
+
+		// Cilium without a reachable Hubble Relay is the common half-configured case
+		if name == "hubble" && capability.Status == CapabilityNotInstalled && info.CNI == "cilium" {
+			capability.Status = CapabilityDisabled
+			capability.Message = "Cilium is installed but Hubble Relay was not found; enable Hubble to see flows"
+			if info.Platform == "gke" {
+				capability.Message = "GKE Dataplane V2 is enabled but its observability (Hubble) is not; enable it with --enable-dataplane-v2-observability"
+			}
+		}
+		caps = append(caps, capability)
+	}
+
+	return append(caps, m.probeCalicoFlowLogs(ctx, info))
+}
+
+// probeCalicoFlowLogs checks for the Calico components that collect flow
+// logs: Goldmane (Calico 3.30+) or the Calico Enterprise log collector
+func (m *Manager) probeCalicoFlowLogs(ctx context.Context, info *ClusterInfo) TrafficCapability {
+	capability := TrafficCapability{
+		Backend: "calico-flow-logs",
+		Label:   capabilityLabels["calico-flow-logs"],
+		Status:  CapabilityNotInstalled,
+	}
+	if info.CNI != "calico" {
+		return capability
+	}
+
+	if _, err := m.k8sClient.CoreV1().Services("calico-system").Get(ctx, "goldmane", metav1.GetOptions{}); err == nil {
+		capability.Status = CapabilityUnsupported
+		capability.Message = "Calico Goldmane flow aggregation is installed, but it is not supported as a traffic source yet"
+		return capability
+	}
+	if _, err := m.k8sClient.AppsV1().DaemonSets("tigera-fluentd").Get(ctx, "fluentd-node", metav1.GetOptions{}); err == nil {
+		capability.Status = CapabilityUnsupported
+		capability.Message = "Calico Enterprise flow logs are enabled, but they are not supported as a traffic source yet"
+		return capability
+	}
+
+	capability.Status = CapabilityDisabled
+	capability.Message = "Calico is installed without flow logs; Calico 3.30+ collects them with Goldmane"
+	return capability
+}
+
+// capabilityRequirement returns the message to show when no capability is
+// available, or "" if one is
+func capabilityRequirement(caps []TrafficCapability) string {
+	for _, c := range caps {
+		if c.Status == CapabilityAvailable {
+			return ""
+		}
+	}
+	return trafficRequirement
+}
@@ -21,11 +21,7 @@ const (
 )
 
 // Known Prometheus/VictoriaMetrics service locations to check
-var metricsServiceLocations = []struct {
-	namespace string
-	name      string
-	port      int // 0 means use service's first port
-}{
+var metricsServiceLocations = []metricsServiceLocation{
 	// VictoriaMetrics (Caretta's default)
 	{"caretta", "caretta-vm", 8428},
 	// Standard Prometheus locations
@@ -146,6 +142,32 @@ func (c *CarettaSource) Detect(ctx context.Context) (*DetectionResult, error) {
 
 // GetFlows retrieves flows from Caretta via Prometheus metrics
 func (c *CarettaSource) GetFlows(ctx context.Context, opts FlowOptions) (*FlowsResponse, error) {
+	// Caretta aggregates connections in Prometheus and has no per-packet flags
+	if len(opts.TCPFlags) > 0 {
+		return &FlowsResponse{
+			Source:    "caretta",
+			Timestamp: time.Now(),
+			Flows:     []Flow{},
+			Warning:   "TCP flag filtering is not supported by Caretta",
+		}, nil
+	}
+	if len(opts.States) > 0 {
+		return &FlowsResponse{
+			Source:    "caretta",
+			Timestamp: time.Now(),
+			Flows:     []Flow{},
+			Warning:   "Connection state filtering is not supported by Caretta",
+		}, nil
+	}
+	if opts.Filter != nil {
+		return &FlowsResponse{
+			Source:    "caretta",
+			Timestamp: time.Now(),
+			Flows:     []Flow{},
+			Warning:   "Structured flow filters are not supported by Caretta",
+		}, nil
+	}
+
 	c.mu.RLock()
 	connected := c.isConnected
 	promAddr := c.prometheusAddr
@@ -259,31 +281,9 @@ func (c *CarettaSource) queryPrometheusForFlows(ctx context.Context, promAddr st
 			opts.Namespace, opts.Namespace)
 	}
 
-	// Query Prometheus API
-	queryURL := fmt.Sprintf("%s/api/v1/query?query=%s", promAddr, url.QueryEscape(query))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
+	promResp, err := queryPrometheus(ctx, c.httpClient, promAddr, query)
 	if err != nil {
-		return nil, fmt.Errorf("querying prometheus: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("prometheus returned status %d", resp.StatusCode)
-	}
-
-	var promResp prometheusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&promResp); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
-	}
-
-	if promResp.Status != "success" {
-		return nil, fmt.Errorf("prometheus query failed: %s", promResp.Status)
+		return nil, err
 	}
 
 	// Parse results into flows
@@ -351,6 +351,37 @@ func (c *CarettaSource) queryPrometheusForFlows(ctx context.Context, promAddr st
 	return flows, nil
 }
 
+// queryPrometheus runs an instant query against a Prometheus-compatible API
+func queryPrometheus(ctx context.Context, httpClient *http.Client, promAddr, query string) (*prometheusResponse, error) {
+	queryURL := fmt.Sprintf("%s/api/v1/query?query=%s", promAddr, url.QueryEscape(query))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prometheus returned status %d", resp.StatusCode)
+	}
+
+	var promResp prometheusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&promResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if promResp.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", promResp.Status)
+	}
+
+	return &promResp, nil
+}
+
 // prometheusResponse represents the Prometheus API response structure
 type prometheusResponse struct {
 	Status string `json:"status"`
@@ -506,8 +537,24 @@ type metricsServiceInfo struct {
 
 // findMetricsServiceLocked finds a metrics service (caller must hold lock)
 func (c *CarettaSource) findMetricsServiceLocked(ctx context.Context) *metricsServiceInfo {
-	for _, loc := range metricsServiceLocations {
-		svc, err := c.k8sClient.CoreV1().Services(loc.namespace).Get(ctx, loc.name, metav1.GetOptions{})
+	info := findMetricsService(ctx, c.k8sClient, metricsServiceLocations)
+	if info != nil {
+		log.Printf("[caretta] Found metrics service: %s/%s:%d", info.namespace, info.name, info.port)
+	}
+	return info
+}
+
+// metricsServiceLocation is a place a Prometheus-compatible service may live
+type metricsServiceLocation struct {
+	namespace string
+	name      string
+	port      int // 0 means use service's first port
+}
+
+// findMetricsService returns the first of the given services that exists
+func findMetricsService(ctx context.Context, client kubernetes.Interface, locations []metricsServiceLocation) *metricsServiceInfo {
+	for _, loc := range locations {
+		svc, err := client.CoreV1().Services(loc.namespace).Get(ctx, loc.name, metav1.GetOptions{})
 		if err != nil {
 			continue
 		}
@@ -530,7 +577,6 @@ func (c *CarettaSource) findMetricsServiceLocked(ctx context.Context) *metricsSe
 			clusterAddr = fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", svc.Name, svc.Namespace, port)
 		}
 
-		log.Printf("[caretta] Found metrics service: %s/%s:%d", svc.Namespace, svc.Name, port)
 		return &metricsServiceInfo{
 			namespace:   svc.Namespace,
 			name:        svc.Name,
@@ -544,6 +590,11 @@ func (c *CarettaSource) findMetricsServiceLocked(ctx context.Context) *metricsSe
 
 // tryMetricsEndpointLocked checks if endpoint is reachable (caller must hold lock)
 func (c *CarettaSource) tryMetricsEndpointLocked(ctx context.Context, addr string) bool {
+	return tryMetricsEndpoint(ctx, c.httpClient, addr)
+}
+
+// tryMetricsEndpoint checks if a Prometheus-compatible endpoint answers queries
+func tryMetricsEndpoint(ctx context.Context, httpClient *http.Client, addr string) bool {
 	testCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -552,7 +603,7 @@ func (c *CarettaSource) tryMetricsEndpointLocked(ctx context.Context, addr strin
 		return false
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return false
 	}
@@ -0,0 +1,341 @@
+package traffic
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	flowpb "github.com/cilium/cilium/api/v1/flow"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// maxHubbleFilters caps the filters one spec may expand to. Each dimension
+// matched on either endpoint doubles the count.
+const maxHubbleFilters = 64
+
+// Protocols a FlowFilterSpec can select, as Hubble names them
+var filterProtocols = []string{"tcp", "udp", "sctp", "icmp", "icmpv4", "icmpv6", "http", "dns", "kafka"}
+
+// Verdicts a FlowFilterSpec can select, as reported in Flow.Verdict
+var filterVerdicts = []string{"forwarded", "dropped", "error", "audit", "redirected", "traced", "translated"}
+
+var httpStatusFilter = regexp.MustCompile(`^[1-5]([0-9]{2}|[0-9]?\+)$`)
+
+// FlowWorkload names a workload whose pods' flows are selected
+type FlowWorkload struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind,omitempty"` // e.g. Deployment; any kind when empty
+}
+
+// FlowPodSelector selects the pods of a namespace matching a label selector,
+// e.g. a workload's pod selector
+type FlowPodSelector struct {
+	Namespace string `json:"namespace"`
+	Selector  string `json:"selector"` // Label selector, e.g. "app=web,tier in (frontend)"
+}
+
+// L7FilterSpec selects flows by their HTTP or DNS details. Only flows with L7
+// visibility have these, so setting any field leaves out plain L3/L4 flows.
+type L7FilterSpec struct {
+	HTTPMethods []string `json:"httpMethods,omitempty"`
+	HTTPPaths   []string `json:"httpPaths,omitempty"`  // Regexes matched against the request path
+	HTTPStatus  []string `json:"httpStatus,omitempty"` // Exact codes or prefixes, e.g. 404, 5+ or 40+
+	DNSQueries  []string `json:"dnsQueries,omitempty"` // Regexes matched against the query name
+}
+
+// FlowFilterSpec is a structured flow filter. A flow matches when it matches
+// every dimension that is set, and within a dimension any of its values.
+// Namespaces, workloads, pods and identities match either endpoint of a flow;
+// ports match the destination port. Flows matching Exclude are left out.
+type FlowFilterSpec struct {
+	Namespaces []string          `json:"namespaces,omitempty"`
+	Workloads  []FlowWorkload    `json:"workloads,omitempty"`
+	Pods       []FlowPodSelector `json:"pods,omitempty"`
+	Protocols  []string          `json:"protocols,omitempty"`  // tcp, udp, icmp, http, dns, ...
+	Ports      []int             `json:"ports,omitempty"`      // Destination ports
+	Verdicts   []string          `json:"verdicts,omitempty"`   // forwarded, dropped, error, ...
+	Identities []uint32          `json:"identities,omitempty"` // Cilium security identities
+	Directions []string          `json:"directions,omitempty"` // ingress, egress
+	L7         *L7FilterSpec     `json:"l7,omitempty"`
+	Exclude    *FlowFilterSpec   `json:"exclude,omitempty"`
+}
+
+// IncludesNamespace reports whether the spec selects flows of namespace by name
+func (s *FlowFilterSpec) IncludesNamespace(namespace string) bool {
+	if s == nil {
+		return false
+	}
+	if slices.Contains(s.Namespaces, namespace) {
+		return true
+	}
+	return slices.ContainsFunc(s.Workloads, func(w FlowWorkload) bool { return w.Namespace == namespace }) ||
+		slices.ContainsFunc(s.Pods, func(p FlowPodSelector) bool { return p.Namespace == namespace })
+}
+
+// Validate checks the values of every dimension and that the spec translates
+// to few enough Hubble filters
+func (s *FlowFilterSpec) Validate() error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if s.Exclude != nil {
+		if s.Exclude.Exclude != nil {
+			return fmt.Errorf("exclude can't be nested")
+		}
+		if err := s.Exclude.validate(); err != nil {
+			return fmt.Errorf("exclude: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *FlowFilterSpec) validate() error {
+	for _, ns := range s.Namespaces {
+		if ns == "" || strings.Contains(ns, "/") {
+			return fmt.Errorf("invalid namespace %q", ns)
+		}
+	}
+	for _, w := range s.Workloads {
+		if w.Namespace == "" || w.Name == "" {
+			return fmt.Errorf("workloads need a namespace and a name")
+		}
+	}
+	for _, p := range s.Pods {
+		if p.Namespace == "" || p.Selector == "" {
+			return fmt.Errorf("pod selectors need a namespace and a selector")
+		}
+		if _, err := labels.Parse(p.Selector); err != nil {
+			return fmt.Errorf("invalid pod selector %q: %w", p.Selector, err)
+		}
+	}
+	for _, p := range s.Protocols {
+		if !slices.Contains(filterProtocols, strings.ToLower(p)) {
+			return fmt.Errorf("unknown protocol %q (expected one of %s)", p, strings.Join(filterProtocols, ", "))
+		}
+	}
+	for _, p := range s.Ports {
+		if p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port %d", p)
+		}
+	}
+	for _, v := range s.Verdicts {
+		if !slices.Contains(filterVerdicts, strings.ToLower(v)) {
+			return fmt.Errorf("unknown verdict %q (expected one of %s)", v, strings.Join(filterVerdicts, ", "))
+		}
+	}
+	for _, d := range s.Directions {
+		if d := strings.ToLower(d); d != "ingress" && d != "egress" {
+			return fmt.Errorf("unknown direction %q (expected ingress or egress)", d)
+		}
+	}
+	if l7 := s.L7; l7 != nil {
+		for _, p := range slices.Concat(l7.HTTPPaths, l7.DNSQueries) {
+			if _, err := regexp.Compile(p); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+		for _, code := range l7.HTTPStatus {
+			if !httpStatusFilter.MatchString(code) {
+				return fmt.Errorf("invalid HTTP status %q (expected a code like 404 or a prefix like 5+)", code)
+			}
+		}
+	}
+	if n := s.filterCount(); n > maxHubbleFilters {
+		return fmt.Errorf("filter expands to %d Hubble filters (max %d); select fewer namespaces or workloads", n, maxHubbleFilters)
+	}
+	return nil
+}
+
+// filterCount is the number of Hubble filters hubbleFilters returns
+func (s *FlowFilterSpec) filterCount() int {
+	n := 1
+	if len(s.Namespaces) > 0 {
+		n *= 2
+	}
+	if len(s.Workloads) > 0 {
+		n *= 2 * len(workloadsByNamespace(s.Workloads))
+	}
+	if len(s.Pods) > 0 {
+		n *= 2 * len(s.Pods)
+	}
+	if len(s.Identities) > 0 {
+		n *= 2
+	}
+	return n
+}
+
+// hubbleFilters translates the spec to Hubble flow filters, which match a
+// flow if any filter does and a filter if all its fields do. Dimensions that
+// apply to the flow itself go into every filter; each dimension matched on
+// either endpoint multiplies the filters by a source and a destination
+// variant. Workloads in different namespaces and each pod selector are
+// separate variants, since a filter's pod namespace and workload name or
+// labels can't be paired otherwise.
+func (s *FlowFilterSpec) hubbleFilters(tcpFlags []*flowpb.TCPFlags) []*flowpb.FlowFilter {
+	base := &flowpb.FlowFilter{TcpFlags: tcpFlags}
+	for _, p := range s.Protocols {
+		base.Protocol = append(base.Protocol, strings.ToLower(p))
+	}
+	for _, p := range s.Ports {
+		base.DestinationPort = append(base.DestinationPort, strconv.Itoa(p))
+	}
+	for _, v := range s.Verdicts {
+		base.Verdict = append(base.Verdict, flowpb.Verdict(flowpb.Verdict_value[strings.ToUpper(v)]))
+	}
+	for _, d := range s.Directions {
+		base.TrafficDirection = append(base.TrafficDirection, flowpb.TrafficDirection(flowpb.TrafficDirection_value[strings.ToUpper(d)]))
+	}
+	if l7 := s.L7; l7 != nil {
+		for _, m := range l7.HTTPMethods {
+			base.HttpMethod = append(base.HttpMethod, strings.ToUpper(m))
+		}
+		base.HttpPath = l7.HTTPPaths
+		base.HttpStatusCode = l7.HTTPStatus
+		base.DnsQuery = l7.DNSQueries
+	}
+
+	filters := []*flowpb.FlowFilter{base}
+
+	if len(s.Namespaces) > 0 {
+		prefixes := make([]string, 0, len(s.Namespaces))
+		for _, ns := range s.Namespaces {
+			prefixes = append(prefixes, ns+"/")
+		}
+		filters = expandEndpointFilters(filters, []func(f *flowpb.FlowFilter, source bool){
+			func(f *flowpb.FlowFilter, source bool) { setPods(f, source, prefixes) },
+		})
+	}
+
+	if len(s.Workloads) > 0 {
+		byNamespace := workloadsByNamespace(s.Workloads)
+		variants := make([]func(*flowpb.FlowFilter, bool), 0, len(byNamespace))
+		for _, ns := range sortedKeys(byNamespace) {
+			workloads := byNamespace[ns]
+			variants = append(variants, func(f *flowpb.FlowFilter, source bool) {
+				// Pods of the namespace that belong to one of its workloads
+				setPods(f, source, []string{ns + "/"})
+				if source {
+					f.SourceWorkload = workloads
+				} else {
+					f.DestinationWorkload = workloads
+				}
+			})
+		}
+		filters = expandEndpointFilters(filters, variants)
+	}
+
+	if len(s.Pods) > 0 {
+		variants := make([]func(*flowpb.FlowFilter, bool), 0, len(s.Pods))
+		for _, p := range s.Pods {
+			variants = append(variants, func(f *flowpb.FlowFilter, source bool) {
+				// Hubble matches labels without a source prefix from any source
+				setPods(f, source, []string{p.Namespace + "/"})
+				if source {
+					f.SourceLabel = []string{p.Selector}
+				} else {
+					f.DestinationLabel = []string{p.Selector}
+				}
+			})
+		}
+		filters = expandEndpointFilters(filters, variants)
+	}
+
+	if len(s.Identities) > 0 {
+		filters = expandEndpointFilters(filters, []func(f *flowpb.FlowFilter, source bool){
+			func(f *flowpb.FlowFilter, source bool) {
+				if source {
+					f.SourceIdentity = s.Identities
+				} else {
+					f.DestinationIdentity = s.Identities
+				}
+			},
+		})
+	}
+
+	return filters
+}
+
+// expandEndpointFilters returns a copy of every filter for each variant of a
+// dimension, applied once to the source and once to the destination
+func expandEndpointFilters(filters []*flowpb.FlowFilter, variants []func(f *flowpb.FlowFilter, source bool)) []*flowpb.FlowFilter {
+	expanded := make([]*flowpb.FlowFilter, 0, len(filters)*len(variants)*2)
+	for _, f := range filters {
+		for _, apply := range variants {
+			for _, source := range []bool{true, false} {
+				clone := cloneFlowFilter(f)
+				apply(clone, source)
+				expanded = append(expanded, clone)
+			}
+		}
+	}
+	return expanded
+}
+
+// setPods restricts one endpoint to pod name prefixes. A filter already
+// restricted to other pods on that endpoint keeps only the prefixes both
+// allow, which for namespace prefixes means the namespaces in common.
+func setPods(f *flowpb.FlowFilter, source bool, prefixes []string) {
+	current := &f.DestinationPod
+	if source {
+		current = &f.SourcePod
+	}
+	if len(*current) == 0 {
+		*current = prefixes
+		return
+	}
+	var common []string
+	for _, p := range prefixes {
+		if slices.Contains(*current, p) {
+			common = append(common, p)
+		}
+	}
+	if len(common) == 0 {
+		// The endpoint can't be in both; no pod name has this prefix
+		common = []string{"/"}
+	}
+	*current = common
+}
+
+// cloneFlowFilter copies the fields hubbleFilters sets, so variants don't share slices
+func cloneFlowFilter(f *flowpb.FlowFilter) *flowpb.FlowFilter {
+	return &flowpb.FlowFilter{
+		SourcePod:           slices.Clone(f.SourcePod),
+		DestinationPod:      slices.Clone(f.DestinationPod),
+		SourceWorkload:      slices.Clone(f.SourceWorkload),
+		SourceLabel:         slices.Clone(f.SourceLabel),
+		DestinationLabel:    slices.Clone(f.DestinationLabel),
+		DestinationWorkload: slices.Clone(f.DestinationWorkload),
+		SourceIdentity:      slices.Clone(f.SourceIdentity),
+		DestinationIdentity: slices.Clone(f.DestinationIdentity),
+		Protocol:            f.Protocol,
+		DestinationPort:     f.DestinationPort,
+		Verdict:             f.Verdict,
+		TrafficDirection:    f.TrafficDirection,
+		HttpMethod:          f.HttpMethod,
+		HttpPath:            f.HttpPath,
+		HttpStatusCode:      f.HttpStatusCode,
+		DnsQuery:            f.DnsQuery,
+		TcpFlags:            f.TcpFlags,
+	}
+}
+
+// workloadsByNamespace groups workloads as Hubble workload filters by namespace
+func workloadsByNamespace(workloads []FlowWorkload) map[string][]*flowpb.Workload {
+	byNamespace := make(map[string][]*flowpb.Workload)
+	for _, w := range workloads {
+		byNamespace[w.Namespace] = append(byNamespace[w.Namespace], &flowpb.Workload{Name: w.Name, Kind: w.Kind})
+	}
+	return byNamespace
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,215 @@
+package traffic
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	flowpb "github.com/cilium/cilium/api/v1/flow"
+)
+
+func TestHubbleFilters_FlowDimensions(t *testing.T) {
+	spec := &FlowFilterSpec{
+		Protocols:  []string{"TCP"},
+		Ports:      []int{80, 443},
+		Verdicts:   []string{"dropped"},
+		Directions: []string{"ingress"},
+		L7:         &L7FilterSpec{HTTPMethods: []string{"get"}, HTTPStatus: []string{"5+"}},
+	}
+	filters := spec.hubbleFilters(nil)
+	if len(filters) != 1 {
+		t.Fatalf("expected one filter, got %d", len(filters))
+	}
+	f := filters[0]
+	if !slices.Equal(f.Protocol, []string{"tcp"}) || !slices.Equal(f.DestinationPort, []string{"80", "443"}) {
+		t.Errorf("unexpected protocol or ports: %v %v", f.Protocol, f.DestinationPort)
+	}
+	if !slices.Equal(f.Verdict, []flowpb.Verdict{flowpb.Verdict_DROPPED}) {
+		t.Errorf("unexpected verdicts: %v", f.Verdict)
+	}
+	if !slices.Equal(f.TrafficDirection, []flowpb.TrafficDirection{flowpb.TrafficDirection_INGRESS}) {
+		t.Errorf("unexpected directions: %v", f.TrafficDirection)
+	}
+	if !slices.Equal(f.HttpMethod, []string{"GET"}) || !slices.Equal(f.HttpStatusCode, []string{"5+"}) {
+		t.Errorf("unexpected L7 fields: %v %v", f.HttpMethod, f.HttpStatusCode)
+	}
+}
+
+func TestHubbleFilters_EndpointDimensions(t *testing.T) {
+	spec := &FlowFilterSpec{
+		Namespaces: []string{"shop", "payments"},
+		Identities: []uint32{1234},
+		Ports:      []int{8080},
+	}
+	filters := spec.hubbleFilters(nil)
+
+	// (source or destination in the namespaces) AND (source or destination identity)
+	var got []string
+	for _, f := range filters {
+		if !slices.Equal(f.DestinationPort, []string{"8080"}) {
+			t.Errorf("port missing from %v", f)
+		}
+		got = append(got, "src="+strings.Join(f.SourcePod, ",")+"/"+identities(f.SourceIdentity)+
+			" dst="+strings.Join(f.DestinationPod, ",")+"/"+identities(f.DestinationIdentity))
+	}
+	want := []string{
+		"src=shop/,payments//1234 dst=/",
+		"src=shop/,payments// dst=/1234",
+		"src=/1234 dst=shop/,payments//",
+		"src=/ dst=shop/,payments//1234",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("filters:\n got %q\nwant %q", got, want)
+	}
+}
+
+func identities(ids []uint32) string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(s, ",")
+}
+
+func TestHubbleFilters_Workloads(t *testing.T) {
+	spec := &FlowFilterSpec{Workloads: []FlowWorkload{
+		{Namespace: "shop", Name: "cart", Kind: "Deployment"},
+		{Namespace: "shop", Name: "checkout"},
+		{Namespace: "payments", Name: "api"},
+	}}
+	filters := spec.hubbleFilters(nil)
+	if len(filters) != 4 {
+		t.Fatalf("expected a source and destination filter per namespace, got %d", len(filters))
+	}
+
+	// Namespaces sort, so payments comes first
+	f := filters[0]
+	if !slices.Equal(f.SourcePod, []string{"payments/"}) || len(f.SourceWorkload) != 1 || f.SourceWorkload[0].Name != "api" {
+		t.Errorf("unexpected payments source filter: %v", f)
+	}
+	f = filters[3]
+	if !slices.Equal(f.DestinationPod, []string{"shop/"}) || len(f.DestinationWorkload) != 2 || len(f.SourcePod) != 0 {
+		t.Errorf("unexpected shop destination filter: %v", f)
+	}
+	if f.DestinationWorkload[0].Kind != "Deployment" || f.DestinationWorkload[1].Kind != "" {
+		t.Errorf("unexpected workload kinds: %v", f.DestinationWorkload)
+	}
+}
+
+func TestHubbleFilters_NamespaceAndWorkloadOnSameEndpoint(t *testing.T) {
+	spec := &FlowFilterSpec{
+		Namespaces: []string{"shop"},
+		Workloads:  []FlowWorkload{{Namespace: "payments", Name: "api"}},
+	}
+	filters := spec.hubbleFilters(nil)
+	if len(filters) != 4 {
+		t.Fatalf("expected 4 filters, got %d", len(filters))
+	}
+	// Source in shop and source the payments workload can't both hold
+	if f := filters[0]; !slices.Equal(f.SourcePod, []string{"/"}) {
+		t.Errorf("expected an unmatchable source, got %v", f.SourcePod)
+	}
+	// Source in shop, destination the payments workload
+	if f := filters[1]; !slices.Equal(f.SourcePod, []string{"shop/"}) || !slices.Equal(f.DestinationPod, []string{"payments/"}) {
+		t.Errorf("unexpected cross-endpoint filter: %v", f)
+	}
+}
+
+func TestBuildFlowFilters_Spec(t *testing.T) {
+	opts := FlowOptions{
+		Namespace: "shop",
+		TCPFlags:  []TCPFlags{{SYN: true}},
+		Filter:    &FlowFilterSpec{Protocols: []string{"tcp"}},
+	}
+	filters := buildFlowFilters(opts)
+	if len(filters) != 2 {
+		t.Fatalf("expected the namespace to add a source and destination filter, got %d", len(filters))
+	}
+	for _, f := range filters {
+		if len(f.TcpFlags) != 1 || !f.TcpFlags[0].SYN || !slices.Equal(f.Protocol, []string{"tcp"}) {
+			t.Errorf("expected TCP flags and protocol in every filter, got %v", f)
+		}
+	}
+	if opts.Filter.Namespaces != nil {
+		t.Error("the caller's spec was modified")
+	}
+
+	// The spec's own namespaces take precedence
+	opts.Filter = &FlowFilterSpec{Namespaces: []string{"payments"}}
+	if f := buildFlowFilters(opts)[0]; !slices.Equal(f.SourcePod, []string{"payments/"}) {
+		t.Errorf("unexpected source pods: %v", f.SourcePod)
+	}
+}
+
+func TestBuildFlowBlacklist_SpecExclude(t *testing.T) {
+	opts := FlowOptions{
+		Exclude: NoiseExclusions{KubeSystem: true},
+		Filter: &FlowFilterSpec{
+			Namespaces: []string{"kube-system"},
+			Exclude:    &FlowFilterSpec{Verdicts: []string{"forwarded"}},
+		},
+	}
+	filters := buildFlowBlacklist(opts)
+	if len(filters) != 1 {
+		t.Fatalf("expected only the spec's exclusion, got %d filters", len(filters))
+	}
+	if !slices.Equal(filters[0].Verdict, []flowpb.Verdict{flowpb.Verdict_FORWARDED}) {
+		t.Errorf("unexpected exclusion: %v", filters[0])
+	}
+}
+
+func TestFlowFilterSpec_Validate(t *testing.T) {
+	valid := &FlowFilterSpec{
+		Namespaces: []string{"shop"},
+		Protocols:  []string{"http"},
+		Verdicts:   []string{"DROPPED"},
+		Directions: []string{"egress"},
+		L7:         &L7FilterSpec{HTTPPaths: []string{"^/api/"}, HTTPStatus: []string{"404", "5+", "40+"}},
+		Exclude:    &FlowFilterSpec{Ports: []int{53}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected a valid spec, got %v", err)
+	}
+
+	many := make([]FlowWorkload, 40)
+	for i := range many {
+		many[i] = FlowWorkload{Namespace: "ns" + strconv.Itoa(i), Name: "app"}
+	}
+
+	for name, spec := range map[string]*FlowFilterSpec{
+		"protocol":       {Protocols: []string{"quic"}},
+		"port":           {Ports: []int{70000}},
+		"verdict":        {Verdicts: []string{"allowed"}},
+		"direction":      {Directions: []string{"inbound"}},
+		"namespace":      {Namespaces: []string{"shop/cart"}},
+		"workload":       {Workloads: []FlowWorkload{{Name: "cart"}}},
+		"path regex":     {L7: &L7FilterSpec{HTTPPaths: []string{"(unclosed"}}},
+		"status":         {L7: &L7FilterSpec{HTTPStatus: []string{"600"}}},
+		"nested exclude": {Exclude: &FlowFilterSpec{Exclude: &FlowFilterSpec{}}},
+		"exclude value":  {Exclude: &FlowFilterSpec{Verdicts: []string{"allowed"}}},
+		"too many":       {Workloads: many},
+	} {
+		if err := spec.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestHubbleFilters_PodSelectors(t *testing.T) {
+	spec := &FlowFilterSpec{Pods: []FlowPodSelector{{Namespace: "shop", Selector: "app=cart,tier in (web)"}}}
+	filters := spec.hubbleFilters(nil)
+	if len(filters) != 2 {
+		t.Fatalf("expected a source and destination filter, got %d", len(filters))
+	}
+	if f := filters[0]; !slices.Equal(f.SourcePod, []string{"shop/"}) || !slices.Equal(f.SourceLabel, []string{"app=cart,tier in (web)"}) {
+		t.Errorf("unexpected source filter: %v", f)
+	}
+	if f := filters[1]; !slices.Equal(f.DestinationPod, []string{"shop/"}) || len(f.SourceLabel) != 0 || len(f.DestinationLabel) != 1 {
+		t.Errorf("unexpected destination filter: %v", f)
+	}
+
+	if err := (&FlowFilterSpec{Pods: []FlowPodSelector{{Namespace: "shop", Selector: "app in ("}}}).Validate(); err == nil {
+		t.Error("expected an invalid selector to be rejected")
+	}
+}
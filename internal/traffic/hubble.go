@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -26,6 +27,10 @@ const (
 	hubbleRelayService    = "hubble-relay"
 	hubbleRelayLabel      = "k8s-app=hubble-relay"
 	hubbleRelayCertSecret = "hubble-relay-client-certs"
+
+	// flowAggregationTTL is how far apart two identical flow events may be and
+	// still be collapsed into one flow
+	flowAggregationTTL = 30 * time.Second
 )
 
 // HubbleSource implements TrafficSource for Hubble/Cilium
@@ -397,6 +402,56 @@ func (h *HubbleSource) testConnection(ctx context.Context) bool {
 	return true
 }
 
+// RelayStatus returns the relay configuration found by Detect, or nil if
+// the relay hasn't been discovered
+func (h *HubbleSource) RelayStatus() *RelayStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.relayNamespace == "" {
+		return nil
+	}
+	return &RelayStatus{
+		Namespace: h.relayNamespace,
+		Port:      h.relayPort,
+		TLS:       h.useTLS,
+	}
+}
+
+// ServerStatus returns Hubble Relay's status counters, or nil if not connected
+func (h *HubbleSource) ServerStatus(ctx context.Context) (*HubbleServerStatus, error) {
+	h.mu.RLock()
+	client := h.observerClient
+	h.mu.RUnlock()
+
+	if client == nil {
+		return nil, nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	resp, err := client.ServerStatus(reqCtx, &observerpb.ServerStatusRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server status: %w", err)
+	}
+
+	status := &HubbleServerStatus{
+		Version:          resp.GetVersion(),
+		NumFlows:         resp.GetNumFlows(),
+		MaxFlows:         resp.GetMaxFlows(),
+		SeenFlows:        resp.GetSeenFlows(),
+		FlowsRate:        resp.GetFlowsRate(),
+		UptimeSeconds:    time.Duration(resp.GetUptimeNs()).Seconds(),
+		UnavailableNodes: resp.GetUnavailableNodes(),
+	}
+	if n := resp.GetNumConnectedNodes(); n != nil {
+		connected := n.GetValue()
+		status.ConnectedNodes = &connected
+	}
+	return status, nil
+}
+
 // closeConnectionLocked closes the gRPC connection (caller must hold lock)
 func (h *HubbleSource) closeConnectionLocked() {
 	if h.grpcConn != nil {
@@ -454,24 +509,14 @@ func (h *HubbleSource) fetchFlowsViaGRPC(ctx context.Context, opts FlowOptions)
 	}
 
 	// Build request
+	limit, _ := ClampFlowLimit(opts.Limit)
 	req := &observerpb.GetFlowsRequest{
-		Number: 1000, // Default limit
+		Number: uint64(limit),
 		Follow: false,
 	}
 
-	if opts.Limit > 0 {
-		req.Number = uint64(opts.Limit)
-	}
-
-	// Add namespace filter if specified
-	// Use separate filters for source OR destination (each filter is AND within itself,
-	// but multiple filters are OR'd together)
-	if opts.Namespace != "" {
-		req.Whitelist = []*flowpb.FlowFilter{
-			{SourcePod: []string{opts.Namespace + "/"}},
-			{DestinationPod: []string{opts.Namespace + "/"}},
-		}
-	}
+	req.Whitelist = buildFlowFilters(opts)
+	req.Blacklist = buildFlowBlacklist(opts)
 
 	// Add time filter based on Since
 	if opts.Since > 0 {
@@ -510,13 +555,155 @@ func (h *HubbleSource) fetchFlowsViaGRPC(ctx context.Context, opts FlowOptions)
 		}
 
 		flow := convertHubbleFlow(pbFlow)
+		if !matchesFlowStates(flow, opts.States) {
+			continue
+		}
 		flows = append(flows, flow)
 	}
 
+	// Hubble Relay has no server-side flow aggregation in the OSS observer API,
+	// so repeated events are grouped here
+	if opts.Aggregate {
+		events := len(flows)
+		flows = collapseRepeatedFlows(flows, flowAggregationTTL)
+		log.Printf("[hubble] Retrieved %d flows (%d after aggregation)", events, len(flows))
+		return flows, nil
+	}
+
 	log.Printf("[hubble] Retrieved %d flows", len(flows))
 	return flows, nil
 }
 
+// flowAggregationKey identifies flow events that describe the same traffic
+type flowAggregationKey struct {
+	src, dst   string
+	protocol   string
+	port       int
+	verdict    string
+	l7Protocol string
+	httpMethod string
+	httpPath   string
+	httpStatus int
+	state      string
+}
+
+// collapseRepeatedFlows merges identical flow events into a single flow with a
+// Count. An event only joins a group last seen within ttl, so a connection
+// that goes quiet and comes back shows up as a new flow.
+func collapseRepeatedFlows(flows []Flow, ttl time.Duration) []Flow {
+	result := make([]Flow, 0, len(flows))
+	groups := make(map[flowAggregationKey]int) // key -> index in result
+
+	for _, f := range flows {
+		key := flowAggregationKey{
+			src:        f.Source.Namespace + "/" + f.Source.Name + "/" + f.Source.IP,
+			dst:        f.Destination.Namespace + "/" + f.Destination.Name + "/" + f.Destination.IP,
+			protocol:   f.Protocol,
+			port:       f.Port,
+			verdict:    f.Verdict,
+			l7Protocol: f.L7Protocol,
+			httpMethod: f.HTTPMethod,
+			httpPath:   f.HTTPPath,
+			httpStatus: f.HTTPStatus,
+			state:      f.State,
+		}
+
+		if i, ok := groups[key]; ok {
+			g := &result[i]
+			gap := f.LastSeen.Sub(g.LastSeen)
+			if gap < 0 {
+				gap = -gap
+			}
+			if gap <= ttl {
+				count := max(f.Count, 1)
+				if f.LatencyMs > 0 {
+					if g.LatencyMs > 0 {
+						// Count-weighted mean; events in a group share a status, so
+						// they either all carry latency or none do
+						g.LatencyMs = (g.LatencyMs*float64(g.Count) + f.LatencyMs*float64(count)) / float64(g.Count+count)
+					} else {
+						g.LatencyMs = f.LatencyMs
+					}
+				}
+				g.Count += count
+				g.Connections += f.Connections
+				g.BytesSent += f.BytesSent
+				g.BytesRecv += f.BytesRecv
+				if f.TCPFlags != nil {
+					if g.TCPFlags == nil {
+						g.TCPFlags = &TCPFlags{}
+					}
+					g.TCPFlags.SYN = g.TCPFlags.SYN || f.TCPFlags.SYN
+					g.TCPFlags.ACK = g.TCPFlags.ACK || f.TCPFlags.ACK
+					g.TCPFlags.FIN = g.TCPFlags.FIN || f.TCPFlags.FIN
+					g.TCPFlags.RST = g.TCPFlags.RST || f.TCPFlags.RST
+				}
+				if f.LastSeen.After(g.LastSeen) {
+					g.LastSeen = f.LastSeen
+				}
+				continue
+			}
+		}
+
+		f.Count = max(f.Count, 1)
+		if f.TCPFlags != nil {
+			flags := *f.TCPFlags
+			f.TCPFlags = &flags
+		}
+		groups[key] = len(result)
+		result = append(result, f)
+	}
+
+	return result
+}
+
+// buildFlowFilters builds the whitelist for a GetFlows request.
+// Fields within a filter are AND'd and filters are OR'd, so the namespace
+// filter is split into source and destination filters, and each TCP flag set
+// is repeated in both. A structured filter is translated by its spec, with
+// the namespace added as one more dimension.
+func buildFlowFilters(opts FlowOptions) []*flowpb.FlowFilter {
+	var tcpFlags []*flowpb.TCPFlags
+	for _, f := range opts.TCPFlags {
+		tcpFlags = append(tcpFlags, &flowpb.TCPFlags{SYN: f.SYN, ACK: f.ACK, FIN: f.FIN, RST: f.RST})
+	}
+
+	if opts.Filter != nil {
+		spec := *opts.Filter
+		if opts.Namespace != "" && len(spec.Namespaces) == 0 {
+			spec.Namespaces = []string{opts.Namespace}
+		}
+		return spec.hubbleFilters(tcpFlags)
+	}
+
+	var filters []*flowpb.FlowFilter
+	if opts.Namespace != "" {
+		filters = []*flowpb.FlowFilter{
+			{SourcePod: []string{opts.Namespace + "/"}},
+			{DestinationPod: []string{opts.Namespace + "/"}},
+		}
+	}
+
+	if len(tcpFlags) == 0 {
+		return filters
+	}
+
+	if len(filters) == 0 {
+		return []*flowpb.FlowFilter{{TcpFlags: tcpFlags}}
+	}
+	for _, f := range filters {
+		f.TcpFlags = tcpFlags
+	}
+	return filters
+}
+
+// matchesFlowStates reports whether a flow is in one of the given connection
+// states. Hubble can filter on flags but not on their absence, so the state
+// filter is applied to converted flows rather than sent to the relay.
+func matchesFlowStates(flow Flow, states []string) bool {
+	return len(states) == 0 || slices.Contains(states, flow.State)
+}
+
 // convertHubbleFlow converts a Hubble protobuf Flow to our internal Flow type
 func convertHubbleFlow(pbFlow *flowpb.Flow) Flow {
 	// Extract IP addresses safely (IP may be nil for some flow types)
@@ -533,12 +720,56 @@ func convertHubbleFlow(pbFlow *flowpb.Flow) Flow {
 		Connections: 1,
 	}
 
+	switch pbFlow.GetTrafficDirection() {
+	case flowpb.TrafficDirection_INGRESS:
+		flow.Direction = "ingress"
+	case flowpb.TrafficDirection_EGRESS:
+		flow.Direction = "egress"
+	}
+
+	if pbFlow.GetVerdict() == flowpb.Verdict_DROPPED {
+		flow.DropReason = strings.ToLower(pbFlow.GetDropReasonDesc().String())
+
+		// Set when the drop matched an explicit deny rule
+		deniedBy := pbFlow.GetIngressDeniedBy()
+		if len(deniedBy) == 0 {
+			deniedBy = pbFlow.GetEgressDeniedBy()
+		}
+		if len(deniedBy) > 0 {
+			p := deniedBy[0]
+			flow.Policy = &PolicyRef{Kind: p.GetKind(), Name: p.GetName(), Namespace: p.GetNamespace()}
+			if flow.Policy.Kind == "" {
+				flow.Policy.Kind = kindCiliumPolicy
+				if p.GetNamespace() == "" {
+					flow.Policy.Kind = kindCiliumCCPolicy
+				}
+			}
+		}
+	}
+
+	// Hubble knows the DNS names of external IPs when DNS visibility is on
+	if names := pbFlow.GetSourceNames(); len(names) > 0 && flow.Source.Kind == "External" {
+		flow.Source.Name = names[0]
+	}
+	if names := pbFlow.GetDestinationNames(); len(names) > 0 && flow.Destination.Kind == "External" {
+		flow.Destination.Name = names[0]
+	}
+
 	// Extract L4 info
 	l4 := pbFlow.GetL4()
 	if l4 != nil {
 		if tcp := l4.GetTCP(); tcp != nil {
 			flow.Protocol = "tcp"
 			flow.Port = int(tcp.GetDestinationPort())
+			if flags := tcp.GetFlags(); flags != nil {
+				flow.TCPFlags = &TCPFlags{
+					SYN: flags.GetSYN(),
+					ACK: flags.GetACK(),
+					FIN: flags.GetFIN(),
+					RST: flags.GetRST(),
+				}
+			}
+			flow.State = connectionState(flow.TCPFlags)
 		} else if udp := l4.GetUDP(); udp != nil {
 			flow.Protocol = "udp"
 			flow.Port = int(udp.GetDestinationPort())
@@ -563,6 +794,10 @@ func convertHubbleFlow(pbFlow *flowpb.Flow) Flow {
 		} else if dns := l7.GetDns(); dns != nil {
 			flow.L7Protocol = "DNS"
 		}
+		// Cilium measures latency itself and reports it on the response record
+		if ns := l7.GetLatencyNs(); ns > 0 {
+			flow.LatencyMs = float64(ns) / float64(time.Millisecond)
+		}
 	}
 
 	// Parse timestamp
@@ -588,6 +823,7 @@ func convertEndpoint(ep *flowpb.Endpoint, ip string) Endpoint {
 	endpoint := Endpoint{
 		Namespace: ep.GetNamespace(),
 		IP:        ip,
+		Identity:  ep.GetIdentity(),
 	}
 
 	// Determine the name and kind
@@ -607,6 +843,10 @@ func convertEndpoint(ep *flowpb.Endpoint, ip string) Endpoint {
 		if endpoint.Name == "" {
 			endpoint.Kind = "External"
 			endpoint.Name = ip
+			// Reserved identities are fixed, so the number alone names them
+			if name, ok := reservedIdentities[ep.GetIdentity()]; ok && ip == "" {
+				endpoint.Name = name
+			}
 		}
 	} else {
 		endpoint.Kind = "External"
@@ -615,10 +855,29 @@ func convertEndpoint(ep *flowpb.Endpoint, ip string) Endpoint {
 
 	// Extract workload name from labels
 	endpoint.Workload = extractWorkloadFromHubbleLabels(ep.GetLabels())
+	endpoint.Labels = k8sLabelsFromHubble(ep.GetLabels())
 
 	return endpoint
 }
 
+// k8sLabelsFromHubble returns the pod labels from Hubble's "k8s:key=value"
+// identity labels, used to match flows against policy selectors
+func k8sLabelsFromHubble(labels []string) map[string]string {
+	var result map[string]string
+	for _, l := range labels {
+		kv, ok := strings.CutPrefix(l, "k8s:")
+		if !ok {
+			continue
+		}
+		key, value, _ := strings.Cut(kv, "=")
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[key] = value
+	}
+	return result
+}
+
 // extractWorkloadFromHubbleLabels extracts workload name from Hubble labels
 func extractWorkloadFromHubbleLabels(labels []string) string {
 	labelMap := make(map[string]string)
@@ -660,11 +919,13 @@ func (h *HubbleSource) StreamFlows(ctx context.Context, opts FlowOptions) (<-cha
 			Follow: true,
 		}
 
-		if opts.Namespace != "" {
-			req.Whitelist = []*flowpb.FlowFilter{
-				{SourcePod: []string{opts.Namespace + "/"}},
-				{DestinationPod: []string{opts.Namespace + "/"}},
-			}
+		req.Whitelist = buildFlowFilters(opts)
+		req.Blacklist = buildFlowBlacklist(opts)
+
+		// Replay flows from the Since window before following new ones
+		startedAt := time.Now()
+		if opts.Since > 0 {
+			req.Since = timestamppb.New(startedAt.Add(-opts.Since))
 		}
 
 		stream, err := client.GetFlows(ctx, req)
@@ -692,13 +953,23 @@ func (h *HubbleSource) StreamFlows(ctx context.Context, opts FlowOptions) (<-cha
 			}
 
 			flow := convertHubbleFlow(pbFlow)
+			if !matchesFlowStates(flow, opts.States) {
+				continue
+			}
+
+			// Replayed flows arrive in a burst; wait for the reader rather
+			// than dropping the backfill
+			if pbFlow.GetTime().AsTime().Before(startedAt) {
+				select {
+				case flowCh <- flow:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
 
-			select {
-			case flowCh <- flow:
-			case <-ctx.Done():
+			if !sendFlow(ctx, flowCh, flow, opts) {
 				return
-			default:
-				// Channel full, drop flow
 			}
 		}
 	}()
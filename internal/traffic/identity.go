@@ -0,0 +1,314 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	identityCacheTTL    = time.Minute
+	identityListTimeout = 10 * time.Second
+
+	// Cilium allocates identities below this for its reserved labels
+	maxReservedIdentity = 255
+)
+
+var (
+	ciliumIdentityGVR = schema.GroupVersionResource{Group: "cilium.io", Version: "v2", Resource: "ciliumidentities"}
+	ciliumEndpointGVR = schema.GroupVersionResource{Group: "cilium.io", Version: "v2", Resource: "ciliumendpoints"}
+)
+
+// reservedIdentities are Cilium's fixed identities for traffic that isn't
+// from a pod, by number
+var reservedIdentities = map[uint32]string{
+	1:  "host",
+	2:  "world",
+	3:  "unmanaged",
+	4:  "health",
+	5:  "init",
+	6:  "remote-node",
+	7:  "kube-apiserver",
+	8:  "ingress",
+	9:  "world-ipv4",
+	10: "world-ipv6",
+}
+
+// CiliumIdentity is a Cilium security identity and the labels it stands for
+type CiliumIdentity struct {
+	ID        uint32   `json:"id"`
+	Name      string   `json:"name"` // Reserved name, or the workload the labels select
+	Namespace string   `json:"namespace,omitempty"`
+	Reserved  bool     `json:"reserved,omitempty"`
+	Labels    []string `json:"labels"`    // Hubble format, e.g. k8s:app=web, reserved:host
+	Endpoints int      `json:"endpoints"` // CiliumEndpoints with this identity
+}
+
+// CiliumEndpoint is a pod as Cilium manages it
+type CiliumEndpoint struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Identity  uint32   `json:"identity"`
+	State     string   `json:"state,omitempty"` // ready, waiting-for-identity, regenerating, ...
+	IPs       []string `json:"ips,omitempty"`
+}
+
+// CiliumIdentities lists the identities and endpoints Cilium knows about
+type CiliumIdentities struct {
+	Identities []CiliumIdentity `json:"identities"` // Sorted by ID
+	Endpoints  []CiliumEndpoint `json:"endpoints"`
+	ListedAt   time.Time        `json:"listedAt"`
+	Warning    string           `json:"warning,omitempty"` // CRDs missing or not readable
+}
+
+// identityCache lists CiliumIdentities and CiliumEndpoints through the
+// dynamic client and keeps them for identityCacheTTL. Flows carry only the
+// identity number for endpoints Hubble has no labels for, such as pods in
+// another cluster of a cluster mesh; the cache turns those into names.
+type identityCache struct {
+	client   dynamic.Interface
+	snapshot *CiliumIdentities
+	byID     map[uint32]*CiliumIdentity
+	expires  time.Time
+	mu       sync.Mutex
+}
+
+func newIdentityCache(config *rest.Config) *identityCache {
+	if config == nil {
+		return nil
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Printf("[traffic] Cilium identity lookup disabled: %v", err)
+		return nil
+	}
+	return &identityCache{client: client}
+}
+
+// list returns the cached identities and endpoints, refreshing them when stale
+func (c *identityCache) list(ctx context.Context) *CiliumIdentities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.snapshot != nil && time.Now().Before(c.expires) {
+		return c.snapshot
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, identityListTimeout)
+	defer cancel()
+
+	result := &CiliumIdentities{ListedAt: time.Now()}
+	byID := make(map[uint32]*CiliumIdentity)
+	for id, name := range reservedIdentities {
+		byID[id] = &CiliumIdentity{ID: id, Name: name, Reserved: true, Labels: []string{"reserved:" + name}}
+	}
+
+	var warnings []string
+	if list, err := c.client.Resource(ciliumIdentityGVR).List(listCtx, metav1.ListOptions{}); err != nil {
+		// CRD not installed (kvstore identity mode, other CNIs) or no RBAC
+		log.Printf("[traffic] Failed to list %s: %v", ciliumIdentityGVR.Resource, err)
+		warnings = append(warnings, fmt.Sprintf("failed to list %s: %v", ciliumIdentityGVR.Resource, err))
+	} else {
+		for i := range list.Items {
+			if identity, ok := parseCiliumIdentity(&list.Items[i]); ok {
+				byID[identity.ID] = identity
+			}
+		}
+	}
+
+	if list, err := c.client.Resource(ciliumEndpointGVR).List(listCtx, metav1.ListOptions{}); err != nil {
+		log.Printf("[traffic] Failed to list %s: %v", ciliumEndpointGVR.Resource, err)
+		warnings = append(warnings, fmt.Sprintf("failed to list %s: %v", ciliumEndpointGVR.Resource, err))
+	} else {
+		for i := range list.Items {
+			endpoint, labels := parseCiliumEndpoint(&list.Items[i])
+			result.Endpoints = append(result.Endpoints, endpoint)
+			identity, ok := byID[endpoint.Identity]
+			if !ok && endpoint.Identity != 0 {
+				// Identities allocated before the CRD list, or from the kvstore
+				identity = newCiliumIdentity(endpoint.Identity, labels)
+				byID[endpoint.Identity] = identity
+			}
+			if identity != nil {
+				identity.Endpoints++
+			}
+		}
+	}
+
+	for _, identity := range byID {
+		result.Identities = append(result.Identities, *identity)
+	}
+	sort.Slice(result.Identities, func(i, j int) bool { return result.Identities[i].ID < result.Identities[j].ID })
+	sort.Slice(result.Endpoints, func(i, j int) bool {
+		if result.Endpoints[i].Namespace != result.Endpoints[j].Namespace {
+			return result.Endpoints[i].Namespace < result.Endpoints[j].Namespace
+		}
+		return result.Endpoints[i].Name < result.Endpoints[j].Name
+	})
+	if result.Endpoints == nil {
+		result.Endpoints = []CiliumEndpoint{}
+	}
+	result.Warning = strings.Join(warnings, "; ")
+
+	c.snapshot = result
+	c.byID = byID
+	c.expires = time.Now().Add(identityCacheTTL)
+	return result
+}
+
+// lookup returns the identity with the given number, if Cilium knows it
+func (c *identityCache) lookup(ctx context.Context, id uint32) (*CiliumIdentity, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.list(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	identity, ok := c.byID[id]
+	return identity, ok
+}
+
+// describe names an endpoint that Hubble reported with only its identity
+// number, from the labels the identity stands for
+func (c *identityCache) describe(ctx context.Context, ep *Endpoint) {
+	if c == nil || ep.Identity <= maxReservedIdentity || ep.Kind != "External" || ep.Name != ep.IP {
+		return
+	}
+	identity, ok := c.lookup(ctx, ep.Identity)
+	if !ok || identity.Name == "" {
+		return
+	}
+	ep.Name = identity.Name
+	ep.Workload = identity.Name
+	if ep.Namespace == "" {
+		ep.Namespace = identity.Namespace
+	}
+	if ep.Labels == nil {
+		ep.Labels = k8sLabelsFromHubble(identity.Labels)
+	}
+}
+
+// parseCiliumIdentity reads a CiliumIdentity, whose name is the identity
+// number and whose security-labels map holds its labels
+func parseCiliumIdentity(obj *unstructured.Unstructured) (*CiliumIdentity, bool) {
+	id, err := strconv.ParseUint(obj.GetName(), 10, 32)
+	if err != nil {
+		return nil, false
+	}
+	securityLabels, _, _ := unstructured.NestedStringMap(obj.Object, "security-labels")
+	labels := make([]string, 0, len(securityLabels))
+	for key, value := range securityLabels {
+		if value == "" {
+			labels = append(labels, key)
+		} else {
+			labels = append(labels, key+"="+value)
+		}
+	}
+	return newCiliumIdentity(uint32(id), labels), true
+}
+
+// parseCiliumEndpoint reads a CiliumEndpoint and the identity labels in its status
+func parseCiliumEndpoint(obj *unstructured.Unstructured) (CiliumEndpoint, []string) {
+	endpoint := CiliumEndpoint{Name: obj.GetName(), Namespace: obj.GetNamespace()}
+	if id, ok, _ := unstructured.NestedInt64(obj.Object, "status", "identity", "id"); ok && id > 0 {
+		endpoint.Identity = uint32(id)
+	}
+	endpoint.State, _, _ = unstructured.NestedString(obj.Object, "status", "state")
+	labels, _, _ := unstructured.NestedStringSlice(obj.Object, "status", "identity", "labels")
+
+	addressing, _, _ := unstructured.NestedSlice(obj.Object, "status", "networking", "addressing")
+	for _, item := range addressing {
+		addr, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, family := range []string{"ipv4", "ipv6"} {
+			if ip, ok := addr[family].(string); ok && ip != "" {
+				endpoint.IPs = append(endpoint.IPs, ip)
+			}
+		}
+	}
+	return endpoint, labels
+}
+
+// newCiliumIdentity builds an identity from its labels, naming it after the
+// reserved label or the workload and namespace the labels select
+func newCiliumIdentity(id uint32, labels []string) *CiliumIdentity {
+	slices.Sort(labels)
+	identity := &CiliumIdentity{ID: id, Labels: labels}
+	if name, ok := reservedIdentities[id]; ok {
+		identity.Name = name
+		identity.Reserved = true
+	}
+	for _, label := range labels {
+		if name, ok := strings.CutPrefix(label, "reserved:"); ok {
+			identity.Name = name
+			identity.Reserved = true
+		}
+	}
+	if identity.Reserved {
+		return identity
+	}
+
+	k8sLabels := k8sLabelsFromHubble(labels)
+	identity.Namespace = k8sLabels["io.kubernetes.pod.namespace"]
+	for _, key := range []string{"app.kubernetes.io/name", "app", "k8s-app", "name"} {
+		if name := k8sLabels[key]; name != "" {
+			identity.Name = name
+			break
+		}
+	}
+	if identity.Name == "" {
+		if sa := k8sLabels["io.cilium.k8s.policy.serviceaccount"]; sa != "" {
+			identity.Name = "sa:" + sa
+		}
+	}
+	if cluster := k8sLabels["io.cilium.k8s.policy.cluster"]; cluster != "" && identity.Name != "" {
+		identity.Name = cluster + "/" + identity.Name
+	}
+	return identity
+}
+
+// ListCiliumIdentities returns the Cilium identities and endpoints in the
+// cluster, limited to namespace if set. Reserved identities are always
+// included. Results are cached for a minute.
+func (m *Manager) ListCiliumIdentities(ctx context.Context, namespace string) (*CiliumIdentities, error) {
+	if m.identities == nil {
+		return nil, fmt.Errorf("cilium identity lookup not available")
+	}
+	all := m.identities.list(ctx)
+	if namespace == "" {
+		return all, nil
+	}
+
+	result := &CiliumIdentities{ListedAt: all.ListedAt, Warning: all.Warning, Endpoints: []CiliumEndpoint{}}
+	for _, identity := range all.Identities {
+		if identity.Reserved || identity.Namespace == namespace {
+			result.Identities = append(result.Identities, identity)
+		}
+	}
+	for _, endpoint := range all.Endpoints {
+		if endpoint.Namespace == namespace {
+			result.Endpoints = append(result.Endpoints, endpoint)
+		}
+	}
+	return result, nil
+}
+
+// describeFlowIdentities names flow endpoints known only by their identity
+func (m *Manager) describeFlowIdentities(ctx context.Context, f *Flow) {
+	m.identities.describe(ctx, &f.Source)
+	m.identities.describe(ctx, &f.Destination)
+}
@@ -0,0 +1,100 @@
+package traffic
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseCiliumIdentity(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "48213"},
+		"security-labels": map[string]any{
+			"k8s:app":                                 "checkout",
+			"k8s:io.kubernetes.pod.namespace":         "shop",
+			"k8s:io.cilium.k8s.policy.serviceaccount": "checkout",
+			"k8s:io.cilium.k8s.policy.cluster":        "eu-west",
+		},
+	}}
+
+	identity, ok := parseCiliumIdentity(obj)
+	if !ok {
+		t.Fatal("expected identity to parse")
+	}
+	if identity.ID != 48213 || identity.Namespace != "shop" || identity.Name != "eu-west/checkout" || identity.Reserved {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+	want := []string{
+		"k8s:app=checkout",
+		"k8s:io.cilium.k8s.policy.cluster=eu-west",
+		"k8s:io.cilium.k8s.policy.serviceaccount=checkout",
+		"k8s:io.kubernetes.pod.namespace=shop",
+	}
+	if !slices.Equal(identity.Labels, want) {
+		t.Errorf("labels = %v, want %v", identity.Labels, want)
+	}
+
+	obj.SetName("not-a-number")
+	if _, ok := parseCiliumIdentity(obj); ok {
+		t.Error("expected a non-numeric name to be skipped")
+	}
+}
+
+func TestParseCiliumEndpoint(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "web-7d9f", "namespace": "shop"},
+		"status": map[string]any{
+			"state": "ready",
+			"identity": map[string]any{
+				"id":     int64(1234),
+				"labels": []any{"k8s:app=web"},
+			},
+			"networking": map[string]any{
+				"addressing": []any{map[string]any{"ipv4": "10.0.1.5", "ipv6": "fd00::5"}},
+			},
+		},
+	}}
+
+	endpoint, labels := parseCiliumEndpoint(obj)
+	if endpoint.Name != "web-7d9f" || endpoint.Namespace != "shop" || endpoint.Identity != 1234 || endpoint.State != "ready" {
+		t.Errorf("unexpected endpoint: %+v", endpoint)
+	}
+	if !slices.Equal(endpoint.IPs, []string{"10.0.1.5", "fd00::5"}) {
+		t.Errorf("unexpected IPs: %v", endpoint.IPs)
+	}
+	if !slices.Equal(labels, []string{"k8s:app=web"}) {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+}
+
+func TestNewCiliumIdentity_Reserved(t *testing.T) {
+	if identity := newCiliumIdentity(7, nil); identity.Name != "kube-apiserver" || !identity.Reserved {
+		t.Errorf("expected reserved kube-apiserver identity, got %+v", identity)
+	}
+	if identity := newCiliumIdentity(16777217, []string{"reserved:world"}); identity.Name != "world" || !identity.Reserved {
+		t.Errorf("expected reserved label to name the identity, got %+v", identity)
+	}
+}
+
+func TestIdentityCacheDescribe(t *testing.T) {
+	c := &identityCache{}
+	remote := newCiliumIdentity(70000, []string{"k8s:app=api", "k8s:io.kubernetes.pod.namespace=backend"})
+	c.byID = map[uint32]*CiliumIdentity{remote.ID: remote}
+	c.snapshot = &CiliumIdentities{}
+	c.expires = time.Now().Add(time.Hour)
+
+	ep := Endpoint{Kind: "External", IP: "10.8.0.9", Name: "10.8.0.9", Identity: 70000}
+	c.describe(t.Context(), &ep)
+	if ep.Name != "api" || ep.Namespace != "backend" || ep.Labels["app"] != "api" {
+		t.Errorf("expected endpoint named from its identity, got %+v", ep)
+	}
+
+	// Reserved identities and named endpoints are left alone
+	world := Endpoint{Kind: "External", IP: "1.1.1.1", Name: "1.1.1.1", Identity: 2}
+	c.describe(t.Context(), &world)
+	if world.Name != "1.1.1.1" {
+		t.Errorf("reserved identity was renamed: %+v", world)
+	}
+}
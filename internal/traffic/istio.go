@@ -0,0 +1,534 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	istiodLabel = "app=istiod"
+
+	// istioUnknown is what Istio reports for peers outside the mesh
+	istioUnknown = "unknown"
+)
+
+// Prometheus locations used with Istio, checked in order
+var istioMetricsServiceLocations = []metricsServiceLocation{
+	// Istio's Prometheus addon (samples/addons/prometheus.yaml)
+	{"istio-system", "prometheus", 9090},
+	// kube-prometheus-stack and standalone Prometheus
+	{"monitoring", "prometheus-operated", 9090},
+	{"monitoring", "kube-prometheus-stack-prometheus", 9090},
+	{"monitoring", "prometheus-server", 0},
+	{"prometheus", "prometheus-server", 0},
+	{"kube-system", "prometheus", 0},
+}
+
+// IstioSource implements TrafficSource for Istio, synthesizing flows from the
+// standard Envoy metrics (istio_requests_total, istio_tcp_*) in Prometheus
+type IstioSource struct {
+	k8sClient        kubernetes.Interface
+	httpClient       *http.Client
+	prometheusAddr   string
+	metricsNamespace string // namespace where Prometheus was found
+	metricsService   string // service name for port-forward
+	currentContext   string // current K8s context name
+	mu               sync.RWMutex
+}
+
+// NewIstioSource creates a new Istio traffic source
+func NewIstioSource(client kubernetes.Interface) *IstioSource {
+	return &IstioSource{
+		k8sClient: client,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Name returns the source identifier
+func (i *IstioSource) Name() string {
+	return "istio"
+}
+
+// Detect checks if istiod is running in the cluster
+func (i *IstioSource) Detect(ctx context.Context) (*DetectionResult, error) {
+	result := &DetectionResult{
+		Available: false,
+	}
+
+	pods, err := i.k8sClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		LabelSelector: istiodLabel,
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to search for istiod pods: %w", err)
+	}
+
+	if len(pods.Items) == 0 {
+		result.Message = "Istio not detected. Install Istio with Prometheus for mesh traffic visibility."
+		return result, nil
+	}
+
+	runningPods := 0
+	var namespace string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			runningPods++
+			if namespace == "" {
+				namespace = pod.Namespace
+				result.Version = istioVersion(&pod)
+			}
+		}
+	}
+
+	if runningPods == 0 {
+		result.Message = fmt.Sprintf("istiod pods found (%d) but none are running", len(pods.Items))
+		return result, nil
+	}
+
+	result.Available = true
+	result.Message = fmt.Sprintf("Istio detected in %s with %d running istiod pod(s)", namespace, runningPods)
+	return result, nil
+}
+
+// istioVersion reads the Istio version from istiod's labels or image tag
+func istioVersion(pod *corev1.Pod) string {
+	if ver, ok := pod.Labels["app.kubernetes.io/version"]; ok {
+		return ver
+	}
+	for _, c := range pod.Spec.Containers {
+		if idx := strings.LastIndex(c.Image, ":"); idx != -1 && !strings.Contains(c.Image[idx:], "/") {
+			return c.Image[idx+1:]
+		}
+	}
+	return ""
+}
+
+// GetFlows synthesizes flows from Istio's Prometheus metrics
+func (i *IstioSource) GetFlows(ctx context.Context, opts FlowOptions) (*FlowsResponse, error) {
+	if warning := istioUnsupportedOptions(opts); warning != "" {
+		return &FlowsResponse{
+			Source:    "istio",
+			Timestamp: time.Now(),
+			Flows:     []Flow{},
+			Warning:   warning,
+		}, nil
+	}
+
+	promAddr := i.discoverPrometheus(ctx)
+	if promAddr == "" {
+		return &FlowsResponse{
+			Source:    "istio",
+			Timestamp: time.Now(),
+			Flows:     []Flow{},
+			Warning:   "Prometheus not found or not reachable. Istio traffic requires Prometheus scraping the mesh; use the Traffic view to connect.",
+		}, nil
+	}
+
+	flows, err := i.queryFlows(ctx, promAddr, opts)
+	if err != nil {
+		log.Printf("[istio] Error querying Prometheus: %v", err)
+		return &FlowsResponse{
+			Source:    "istio",
+			Timestamp: time.Now(),
+			Flows:     []Flow{},
+			Warning:   fmt.Sprintf("Failed to query Prometheus: %v", err),
+		}, nil
+	}
+
+	return &FlowsResponse{
+		Source:    "istio",
+		Timestamp: time.Now(),
+		Flows:     flows,
+	}, nil
+}
+
+// istioUnsupportedOptions returns why Istio can't answer opts, if it can't.
+// Istio metrics are per request/connection totals, with no packet-level flags.
+func istioUnsupportedOptions(opts FlowOptions) string {
+	switch {
+	case len(opts.TCPFlags) > 0:
+		return "TCP flag filtering is not supported by Istio"
+	case len(opts.States) > 0:
+		return "Connection state filtering is not supported by Istio"
+	case opts.Filter != nil:
+		return "Structured flow filters are not supported by Istio"
+	}
+	return ""
+}
+
+// discoverPrometheus returns a reachable Prometheus address, preferring a
+// cached one, then the managed port-forward, then the in-cluster address
+func (i *IstioSource) discoverPrometheus(ctx context.Context) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.prometheusAddr != "" {
+		if tryMetricsEndpoint(ctx, i.httpClient, i.prometheusAddr) {
+			return i.prometheusAddr
+		}
+		i.prometheusAddr = ""
+	}
+
+	if pfAddr := GetMetricsAddress(i.currentContext); pfAddr != "" {
+		if tryMetricsEndpoint(ctx, i.httpClient, pfAddr) {
+			i.prometheusAddr = pfAddr
+			return pfAddr
+		}
+	}
+
+	info := findMetricsService(ctx, i.k8sClient, istioMetricsServiceLocations)
+	if info == nil {
+		log.Printf("[istio] No Prometheus service found")
+		return ""
+	}
+	i.metricsNamespace = info.namespace
+	i.metricsService = info.name
+
+	if tryMetricsEndpoint(ctx, i.httpClient, info.clusterAddr) {
+		i.prometheusAddr = info.clusterAddr
+		return info.clusterAddr
+	}
+
+	log.Printf("[istio] Prometheus %s/%s found but not reachable. Call Connect() to establish port-forward.",
+		info.namespace, info.name)
+	return ""
+}
+
+// istioFlowLabels are the labels flows are grouped by. Metrics reported by
+// the destination proxy are used so each request is counted once.
+const istioFlowLabels = "source_workload, source_workload_namespace, destination_workload, destination_workload_namespace, destination_service_name, destination_service_namespace"
+
+// queryFlows builds flows from HTTP/gRPC request counts and TCP connection
+// counts over the Since window
+func (i *IstioSource) queryFlows(ctx context.Context, promAddr string, opts FlowOptions) ([]Flow, error) {
+	window := opts.Since
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	rangeStr := fmt.Sprintf("%ds", int(window.Seconds()))
+
+	return i.queryCounterFlows(ctx, promAddr, opts,
+		fmt.Sprintf(`increase(istio_requests_total{reporter="destination"}[%s])`, rangeStr),
+		fmt.Sprintf(`increase(istio_tcp_connections_opened_total{reporter="destination"}[%s])`, rangeStr))
+}
+
+// queryTotalFlows builds flows from the request and connection counters
+// themselves, i.e. the totals since each proxy started
+func (i *IstioSource) queryTotalFlows(ctx context.Context, promAddr string, opts FlowOptions) ([]Flow, error) {
+	return i.queryCounterFlows(ctx, promAddr, opts,
+		`istio_requests_total{reporter="destination"}`,
+		`istio_tcp_connections_opened_total{reporter="destination"}`)
+}
+
+// queryCounterFlows builds flows from a request count and a TCP connection
+// count expression, summed by istioFlowLabels
+func (i *IstioSource) queryCounterFlows(ctx context.Context, promAddr string, opts FlowOptions, requestExpr, tcpExpr string) ([]Flow, error) {
+	requestQuery := fmt.Sprintf(`sum by (%s, request_protocol, response_code) (%s) > 0`, istioFlowLabels, requestExpr)
+	requests, err := queryPrometheus(ctx, i.httpClient, promAddr, requestQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	tcpQuery := fmt.Sprintf(`sum by (%s) (%s) > 0`, istioFlowLabels, tcpExpr)
+	connections, err := queryPrometheus(ctx, i.httpClient, promAddr, tcpQuery)
+	if err != nil {
+		// Clusters without TCP services may not export the metric at all
+		log.Printf("[istio] TCP connection query failed: %v", err)
+		connections = &prometheusResponse{}
+	}
+
+	now := time.Now()
+	flows := make([]Flow, 0, len(requests.Data.Result)+len(connections.Data.Result))
+
+	for _, result := range requests.Data.Result {
+		flow := istioFlow(result.Metric, now)
+		count := int64(sampleValue(result.Value))
+		flow.Connections = count
+		flow.Count = count
+		if proto := strings.ToLower(result.Metric["request_protocol"]); proto == "grpc" {
+			flow.Protocol = "grpc"
+			flow.L7Protocol = "gRPC"
+		} else {
+			flow.Protocol = "http"
+			flow.L7Protocol = "HTTP"
+		}
+		if code, err := strconv.Atoi(result.Metric["response_code"]); err == nil {
+			flow.HTTPStatus = code
+		}
+		if istioMatchesNamespace(flow, opts.Namespace) {
+			flows = append(flows, flow)
+		}
+	}
+
+	for _, result := range connections.Data.Result {
+		flow := istioFlow(result.Metric, now)
+		flow.Protocol = "tcp"
+		flow.Connections = int64(sampleValue(result.Value))
+		if istioMatchesNamespace(flow, opts.Namespace) {
+			flows = append(flows, flow)
+		}
+	}
+
+	log.Printf("[istio] Retrieved %d flows from Prometheus", len(flows))
+	return flows, nil
+}
+
+// istioFlow converts the grouping labels of an Istio metric into a flow
+func istioFlow(metric map[string]string, lastSeen time.Time) Flow {
+	flow := Flow{
+		Source: Endpoint{
+			Name:      metric["source_workload"],
+			Namespace: metric["source_workload_namespace"],
+			Kind:      "Pod",
+			Workload:  metric["source_workload"],
+		},
+		Destination: Endpoint{
+			Name:      metric["destination_workload"],
+			Namespace: metric["destination_workload_namespace"],
+			Kind:      "Pod",
+			Workload:  metric["destination_workload"],
+		},
+		Verdict:  "forwarded",
+		LastSeen: lastSeen,
+	}
+
+	if flow.Source.Name == "" || flow.Source.Name == istioUnknown {
+		flow.Source = Endpoint{Name: "external", Kind: "External"}
+	}
+
+	// Traffic to a ServiceEntry or non-mesh service has no destination workload
+	if flow.Destination.Name == "" || flow.Destination.Name == istioUnknown {
+		name := metric["destination_service_name"]
+		if name == "" || name == istioUnknown {
+			name = "external"
+		}
+		flow.Destination = Endpoint{
+			Name:      name,
+			Namespace: metric["destination_service_namespace"],
+			Kind:      "External",
+		}
+		if flow.Destination.Namespace == istioUnknown {
+			flow.Destination.Namespace = ""
+		}
+		if flow.Destination.Namespace != "" {
+			flow.Destination.Kind = "Service"
+		}
+	}
+
+	return flow
+}
+
+func istioMatchesNamespace(flow Flow, namespace string) bool {
+	return namespace == "" || flow.Source.Namespace == namespace || flow.Destination.Namespace == namespace
+}
+
+// sampleValue parses the value of an instant-query sample ([timestamp, "value"])
+func sampleValue(value []interface{}) float64 {
+	if len(value) < 2 {
+		return 0
+	}
+	valStr, ok := value[1].(string)
+	if !ok {
+		return 0
+	}
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		return 0
+	}
+	return val
+}
+
+// StreamFlows polls Prometheus and emits the traffic of each poll interval.
+// The Since window is backfilled first as totals over the window; after that
+// each flow carries only the requests and connections since the previous
+// poll, taken as the difference of the counters, so clients can add them up.
+func (i *IstioSource) StreamFlows(ctx context.Context, opts FlowOptions) (<-chan Flow, error) {
+	flowCh := make(chan Flow, 100)
+
+	go func() {
+		defer close(flowCh)
+
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+
+		// Backfill the Since window right away instead of waiting a poll
+		// interval
+		if opts.Since > 0 {
+			response, err := i.GetFlows(ctx, opts)
+			if err != nil {
+				log.Printf("[istio] Error fetching flows: %v", err)
+			} else {
+				for _, flow := range response.Flows {
+					select {
+					case flowCh <- flow:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+		if istioUnsupportedOptions(opts) != "" {
+			<-ctx.Done()
+			return
+		}
+
+		// totals holds the counters of the previous poll, by flow
+		var totals map[string]Flow
+		poll := func(emit bool) bool {
+			promAddr := i.discoverPrometheus(ctx)
+			if promAddr == "" {
+				return true
+			}
+			flows, err := i.queryTotalFlows(ctx, promAddr, opts)
+			if err != nil {
+				log.Printf("[istio] Error fetching flows: %v", err)
+				return true
+			}
+
+			var deltas []Flow
+			totals, deltas = istioFlowDeltas(totals, flows)
+			if !emit {
+				return true
+			}
+			for _, flow := range deltas {
+				if !sendFlow(ctx, flowCh, flow, opts) {
+					return false
+				}
+			}
+			return true
+		}
+
+		// The first poll only records the counters to diff against
+		if !poll(false) {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !poll(true) {
+					return
+				}
+			}
+		}
+	}()
+
+	return flowCh, nil
+}
+
+// istioFlowDeltas sums the counter flows of one poll by istioFlowKey and
+// returns those totals along with the traffic since the previous poll's
+// totals. Series that map to the same flow, such as one workload reached
+// through two Services, are added up so they're diffed as one.
+func istioFlowDeltas(previous map[string]Flow, flows []Flow) (map[string]Flow, []Flow) {
+	totals := make(map[string]Flow, len(flows))
+	var keys []string
+	for _, flow := range flows {
+		key := istioFlowKey(flow)
+		if sum, ok := totals[key]; ok {
+			sum.Connections += flow.Connections
+			sum.Count += flow.Count
+			totals[key] = sum
+			continue
+		}
+		totals[key] = flow
+		keys = append(keys, key)
+	}
+
+	var deltas []Flow
+	for _, key := range keys {
+		flow := totals[key]
+		last, seen := previous[key]
+		// A counter that went down belongs to a proxy that restarted or went
+		// away; that interval is skipped rather than guessed
+		if !seen || flow.Connections <= last.Connections {
+			continue
+		}
+		flow.Connections -= last.Connections
+		flow.Count = max(flow.Count-last.Count, 0)
+		deltas = append(deltas, flow)
+	}
+	return totals, deltas
+}
+
+// istioFlowKey identifies the flows of one poll that continue those of the
+// previous one
+func istioFlowKey(f Flow) string {
+	return fmt.Sprintf("%s/%s/%s>%s/%s/%s|%s|%d",
+		f.Source.Kind, f.Source.Namespace, f.Source.Name,
+		f.Destination.Kind, f.Destination.Namespace, f.Destination.Name,
+		f.Protocol, f.HTTPStatus)
+}
+
+// Connect establishes a connection to Prometheus, starting a port-forward if needed
+func (i *IstioSource) Connect(ctx context.Context, contextName string) (*MetricsConnectionInfo, error) {
+	i.mu.Lock()
+	if i.currentContext != contextName {
+		i.prometheusAddr = ""
+		i.currentContext = contextName
+	}
+	i.mu.Unlock()
+
+	if addr := i.discoverPrometheus(ctx); addr != "" {
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+		return &MetricsConnectionInfo{
+			Connected:   true,
+			Address:     addr,
+			Namespace:   i.metricsNamespace,
+			ServiceName: i.metricsService,
+			ContextName: contextName,
+		}, nil
+	}
+
+	info := findMetricsService(ctx, i.k8sClient, istioMetricsServiceLocations)
+	if info == nil {
+		return &MetricsConnectionInfo{
+			Connected: false,
+			Error:     "No Prometheus service found for Istio metrics",
+		}, nil
+	}
+
+	log.Printf("[istio] Starting port-forward to %s/%s:%d", info.namespace, info.name, info.port)
+	connInfo, err := StartMetricsPortForward(ctx, info.namespace, info.name, info.port, contextName)
+	if err != nil {
+		return &MetricsConnectionInfo{
+			Connected:   false,
+			Namespace:   info.namespace,
+			ServiceName: info.name,
+			Error:       fmt.Sprintf("Failed to start port-forward: %v", err),
+		}, nil
+	}
+
+	if !connInfo.Connected {
+		return connInfo, nil
+	}
+
+	i.mu.Lock()
+	i.prometheusAddr = connInfo.Address
+	i.mu.Unlock()
+	log.Printf("[istio] Connected via port-forward at %s", connInfo.Address)
+
+	return connInfo, nil
+}
+
+// Close cleans up resources
+func (i *IstioSource) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.prometheusAddr = ""
+	i.currentContext = ""
+	return nil
+}
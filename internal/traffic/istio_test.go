@@ -0,0 +1,70 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIstioFlowDeltas(t *testing.T) {
+	now := time.Now()
+	// series returns an HTTP 200 flow from web to cart reached through the
+	// given Service, with the counter at total
+	series := func(service string, total int64) Flow {
+		flow := istioFlow(map[string]string{
+			"source_workload":                "web",
+			"source_workload_namespace":      "shop",
+			"destination_workload":           "cart",
+			"destination_workload_namespace": "shop",
+			"destination_service_name":       service,
+			"destination_service_namespace":  "shop",
+		}, now)
+		flow.Protocol = "http"
+		flow.HTTPStatus = 200
+		flow.Count = total
+		flow.Connections = total
+		return flow
+	}
+	tcp := func(total int64) Flow {
+		flow := istioFlow(map[string]string{
+			"source_workload":                "cart",
+			"source_workload_namespace":      "shop",
+			"destination_workload":           "postgres",
+			"destination_workload_namespace": "data",
+		}, now)
+		flow.Protocol = "tcp"
+		flow.Connections = total
+		return flow
+	}
+
+	// The first poll only records the counters
+	totals, deltas := istioFlowDeltas(nil, []Flow{series("cart", 100), series("cart-headless", 40), tcp(7)})
+	if len(deltas) != 0 {
+		t.Errorf("expected no deltas from the first poll, got %+v", deltas)
+	}
+	if len(totals) != 2 {
+		t.Fatalf("expected the two Services' series summed into one flow, got %+v", totals)
+	}
+	if total := totals[istioFlowKey(series("cart", 0))]; total.Count != 140 || total.Connections != 140 {
+		t.Errorf("expected the summed counter to be 140, got %+v", total)
+	}
+
+	// Both Services' counters grew, in the opposite order; the TCP counter
+	// went down because the proxy restarted
+	totals, deltas = istioFlowDeltas(totals, []Flow{series("cart-headless", 45), series("cart", 110), tcp(2)})
+	if len(deltas) != 1 {
+		t.Fatalf("expected one delta, got %+v", deltas)
+	}
+	if d := deltas[0]; d.Protocol != "http" || d.Count != 15 || d.Connections != 15 {
+		t.Errorf("expected 15 requests since the last poll, got %+v", d)
+	}
+
+	// The restarted counter is diffed from its new value on the next poll,
+	// and a flow without new traffic is left out
+	_, deltas = istioFlowDeltas(totals, []Flow{series("cart-headless", 45), series("cart", 110), tcp(5)})
+	if len(deltas) != 1 {
+		t.Fatalf("expected one delta, got %+v", deltas)
+	}
+	if d := deltas[0]; d.Protocol != "tcp" || d.Connections != 3 {
+		t.Errorf("expected 3 connections since the restart, got %+v", d)
+	}
+}
@@ -0,0 +1,57 @@
+package traffic
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Flow limits used unless changed with --traffic-flow-limit and
+// --traffic-max-flow-limit
+const (
+	DefaultFlowLimit    = 1000
+	DefaultMaxFlowLimit = 10000
+)
+
+var (
+	flowLimitsMu sync.RWMutex
+	flowLimit    = DefaultFlowLimit
+	maxFlowLimit = DefaultMaxFlowLimit
+)
+
+// SetFlowLimits sets how many flows a request fetches when it doesn't ask for
+// a number, and the most it may ask for. The ceiling keeps a single request
+// from making Hubble Relay stream its whole buffer.
+func SetFlowLimits(defaultLimit, maxLimit int) error {
+	if defaultLimit <= 0 || maxLimit <= 0 {
+		return fmt.Errorf("flow limits must be positive")
+	}
+	if defaultLimit > maxLimit {
+		return fmt.Errorf("default flow limit %d exceeds the maximum %d", defaultLimit, maxLimit)
+	}
+	flowLimitsMu.Lock()
+	defer flowLimitsMu.Unlock()
+	flowLimit = defaultLimit
+	maxFlowLimit = maxLimit
+	return nil
+}
+
+// FlowLimits returns the default and maximum number of flows per request
+func FlowLimits() (defaultLimit, maxLimit int) {
+	flowLimitsMu.RLock()
+	defer flowLimitsMu.RUnlock()
+	return flowLimit, maxFlowLimit
+}
+
+// ClampFlowLimit returns the number of flows to fetch for a requested limit:
+// the default for 0 or less, and at most the maximum. clamped is true when
+// the request asked for more than the maximum.
+func ClampFlowLimit(limit int) (effective int, clamped bool) {
+	defaultLimit, maxLimit := FlowLimits()
+	switch {
+	case limit <= 0:
+		return defaultLimit, false
+	case limit > maxLimit:
+		return maxLimit, true
+	}
+	return limit, false
+}
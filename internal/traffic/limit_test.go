@@ -0,0 +1,42 @@
+package traffic
+
+import "testing"
+
+func TestClampFlowLimit(t *testing.T) {
+	if err := SetFlowLimits(100, 500); err != nil {
+		t.Fatal(err)
+	}
+	defer SetFlowLimits(DefaultFlowLimit, DefaultMaxFlowLimit)
+
+	tests := []struct {
+		limit       int
+		wantLimit   int
+		wantClamped bool
+	}{
+		{0, 100, false},
+		{-1, 100, false},
+		{250, 250, false},
+		{500, 500, false},
+		{501, 500, true},
+		{1 << 30, 500, true},
+	}
+	for _, tt := range tests {
+		limit, clamped := ClampFlowLimit(tt.limit)
+		if limit != tt.wantLimit || clamped != tt.wantClamped {
+			t.Errorf("ClampFlowLimit(%d) = %d, %v; want %d, %v", tt.limit, limit, clamped, tt.wantLimit, tt.wantClamped)
+		}
+	}
+}
+
+func TestSetFlowLimits_Invalid(t *testing.T) {
+	defer SetFlowLimits(DefaultFlowLimit, DefaultMaxFlowLimit)
+
+	for _, limits := range [][2]int{{0, 100}, {100, 0}, {200, 100}} {
+		if err := SetFlowLimits(limits[0], limits[1]); err == nil {
+			t.Errorf("SetFlowLimits(%d, %d): expected an error", limits[0], limits[1])
+		}
+	}
+	if defaultLimit, maxLimit := FlowLimits(); defaultLimit != DefaultFlowLimit || maxLimit != DefaultMaxFlowLimit {
+		t.Errorf("invalid limits were applied: %d, %d", defaultLimit, maxLimit)
+	}
+}
@@ -21,6 +21,8 @@ type Manager struct {
 	activeSource TrafficSource
 	clusterInfo  *ClusterInfo
 	contextName  string // current K8s context name
+	policies     *policyCorrelator
+	identities   *identityCache
 	mu           sync.RWMutex
 }
 
@@ -43,10 +45,13 @@ func InitializeWithConfig(client kubernetes.Interface, config *rest.Config, cont
 			k8sConfig:   config,
 			sources:     make(map[string]TrafficSource),
 			contextName: contextName,
+			policies:    newPolicyCorrelator(config),
+			identities:  newIdentityCache(config),
 		}
 		// Register available sources
 		manager.sources["hubble"] = NewHubbleSource(client)
 		manager.sources["caretta"] = NewCarettaSource(client)
+		manager.sources["istio"] = NewIstioSource(client)
 
 		// Set K8s clients for port-forward functionality
 		if config != nil {
@@ -56,6 +61,10 @@ func InitializeWithConfig(client kubernetes.Interface, config *rest.Config, cont
 	return initErr
 }
 
+// sourcePriority is the order sources are detected in; the first available
+// one becomes active. Flow-level sources come before metrics-based ones.
+var sourcePriority = []string{"hubble", "caretta", "istio"}
+
 // GetManager returns the global traffic manager
 func GetManager() *Manager {
 	return manager
@@ -80,8 +89,12 @@ func (m *Manager) DetectSources(ctx context.Context) (*SourcesResponse, error) {
 		NotDetected: []string{},
 	}
 
-	// Check each registered source
-	for name, source := range m.sources {
+	// Check each registered source in priority order
+	for _, name := range sourcePriority {
+		source, ok := m.sources[name]
+		if !ok {
+			continue
+		}
 		result, err := source.Detect(ctx)
 		if err != nil {
 			log.Printf("[traffic] Error detecting %s: %v", name, err)
@@ -114,6 +127,9 @@ func (m *Manager) DetectSources(ctx context.Context) (*SourcesResponse, error) {
 	// Generate recommendation based on cluster type
 	response.Recommended = m.generateRecommendation(clusterInfo, response.Detected)
 
+	response.Capabilities = m.probeCapabilities(ctx, clusterInfo, response.Detected)
+	response.Requirement = capabilityRequirement(response.Capabilities)
+
 	return response, nil
 }
 
@@ -362,7 +378,21 @@ func (m *Manager) GetFlows(ctx context.Context, opts FlowOptions) (*FlowsRespons
 		return nil, fmt.Errorf("no traffic source available")
 	}
 
-	return source.GetFlows(ctx, opts)
+	limit, clamped := ClampFlowLimit(opts.Limit)
+	opts.Limit = limit
+
+	response, err := source.GetFlows(ctx, opts)
+	if err != nil || response == nil {
+		return response, err
+	}
+	response.Limit = limit
+	response.LimitClamped = clamped
+	for i := range response.Flows {
+		m.describeFlowIdentities(ctx, &response.Flows[i])
+		resolveFlowEndpoints(&response.Flows[i])
+		m.policies.attribute(ctx, &response.Flows[i])
+	}
+	return response, nil
 }
 
 // StreamFlows returns a channel of flows from the active source
@@ -375,7 +405,78 @@ func (m *Manager) StreamFlows(ctx context.Context, opts FlowOptions) (<-chan Flo
 		return nil, fmt.Errorf("no traffic source available")
 	}
 
-	return source.StreamFlows(ctx, opts)
+	flows, err := source.StreamFlows(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(chan Flow, cap(flows))
+	go func() {
+		defer close(resolved)
+		for f := range flows {
+			m.describeFlowIdentities(ctx, &f)
+			resolveFlowEndpoints(&f)
+			m.policies.attribute(ctx, &f)
+			select {
+			case resolved <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return resolved, nil
+}
+
+// Status reports everything the Traffic view needs in one call: the active
+// source, whether it is available and connected, and for Hubble the relay
+// configuration, server counters and manual access instructions
+func (m *Manager) Status(ctx context.Context) (*TrafficStatus, error) {
+	m.mu.RLock()
+	source := m.activeSource
+	m.mu.RUnlock()
+
+	// Nothing picked yet: run detection, which selects the first available source
+	if source == nil {
+		if _, err := m.DetectSources(ctx); err != nil {
+			return nil, err
+		}
+		m.mu.RLock()
+		source = m.activeSource
+		m.mu.RUnlock()
+	}
+
+	status := &TrafficStatus{
+		Connection: GetConnectionInfo(),
+	}
+	if source == nil {
+		status.Message = "No traffic source detected"
+		return status, nil
+	}
+	status.Source = source.Name()
+
+	detection, err := source.Detect(ctx)
+	if err != nil {
+		detection = &DetectionResult{Message: err.Error()}
+	}
+	status.Detection = detection
+	status.Available = detection.Available
+
+	if hubble, ok := source.(*HubbleSource); ok {
+		status.Relay = hubble.RelayStatus()
+		if status.Connection.Connected {
+			server, err := hubble.ServerStatus(ctx)
+			if err != nil {
+				log.Printf("[hubble] %v", err)
+				status.Message = err.Error()
+			}
+			status.Server = server
+		}
+		if !status.Connection.Connected || status.Server == nil {
+			status.Instructions = hubble.GetPortForwardInstructions()
+		}
+	}
+
+	return status, nil
 }
 
 // SetActiveSource sets the active traffic source by name
@@ -407,6 +508,8 @@ func (m *Manager) GetActiveSourceName() string {
 func AggregateFlows(flows []Flow) []AggregatedFlow {
 	// Key: source-ns/source-name|dest-ns/dest-name|port
 	aggregated := make(map[string]*AggregatedFlow)
+	latencyTotal := make(map[string]float64) // key -> sum of latency over latencyCount events
+	latencyCount := make(map[string]int64)
 
 	for _, f := range flows {
 		key := fmt.Sprintf("%s/%s|%s/%s|%d",
@@ -414,8 +517,13 @@ func AggregateFlows(flows []Flow) []AggregatedFlow {
 			f.Destination.Namespace, f.Destination.Name,
 			f.Port)
 
+		count := max(f.Count, 1)
+		if f.LatencyMs > 0 {
+			latencyTotal[key] += f.LatencyMs * float64(count)
+			latencyCount[key] += count
+		}
 		if agg, ok := aggregated[key]; ok {
-			agg.FlowCount++
+			agg.FlowCount += count
 			agg.BytesSent += f.BytesSent
 			agg.BytesRecv += f.BytesRecv
 			agg.Connections += f.Connections
@@ -428,7 +536,7 @@ func AggregateFlows(flows []Flow) []AggregatedFlow {
 				Destination: f.Destination,
 				Protocol:    f.Protocol,
 				Port:        f.Port,
-				FlowCount:   1,
+				FlowCount:   count,
 				BytesSent:   f.BytesSent,
 				BytesRecv:   f.BytesRecv,
 				Connections: f.Connections,
@@ -438,7 +546,10 @@ func AggregateFlows(flows []Flow) []AggregatedFlow {
 	}
 
 	result := make([]AggregatedFlow, 0, len(aggregated))
-	for _, agg := range aggregated {
+	for key, agg := range aggregated {
+		if n := latencyCount[key]; n > 0 {
+			agg.AvgLatencyMs = latencyTotal[key] / float64(n)
+		}
 		result = append(result, *agg)
 	}
 	return result
@@ -471,6 +582,7 @@ func Reset() {
 		manager.Close()
 	}
 	manager = nil
+	resetResolver()
 	initOnce = sync.Once{}
 }
 
@@ -510,6 +622,10 @@ func (m *Manager) Connect(ctx context.Context) (*MetricsConnectionInfo, error) {
 		return hubble.Connect(ctx, contextName)
 	}
 
+	if istio, ok := source.(*IstioSource); ok {
+		return istio.Connect(ctx, contextName)
+	}
+
 	// For sources without Connect support, just return connected
 	return &MetricsConnectionInfo{
 		Connected: true,
@@ -540,13 +656,23 @@ func (m *Manager) SetContextName(name string) {
 		hubble.currentContext = name
 		hubble.mu.Unlock()
 	}
+
+	// Update istio source context
+	if istio, ok := m.sources["istio"].(*IstioSource); ok {
+		istio.mu.Lock()
+		istio.currentContext = name
+		istio.mu.Unlock()
+	}
 }
 
 // DefaultFlowOptions returns sensible defaults
 func DefaultFlowOptions() FlowOptions {
+	defaultLimit, _ := FlowLimits()
 	return FlowOptions{
-		Since: 5 * time.Minute,
-		Limit: 1000,
+		Since:     5 * time.Minute,
+		Limit:     defaultLimit,
+		Aggregate: true,
+		Exclude:   DefaultNoiseExclusions(),
 	}
 }
 
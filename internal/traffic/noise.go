@@ -0,0 +1,141 @@
+package traffic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	flowpb "github.com/cilium/cilium/api/v1/flow"
+)
+
+// Names of the noise exclusions, as used in the exclude query parameter and
+// the --traffic-exclude flag
+const (
+	ExcludeKubeSystem   = "kube-system"
+	ExcludeHealthChecks = "health-checks"
+	ExcludeDNS          = "dns"
+)
+
+// healthCheckPorts are the node health endpoints that see steady polling:
+// Cilium health (4240), kubelet healthz (10248) and kube-proxy healthz (10256)
+var healthCheckPorts = []int{4240, 10248, 10256}
+
+// NoiseExclusions selects the background traffic left out of flow results.
+// Most flows in a cluster are kubelet probes and kube-system chatter, which
+// hide the application traffic the Traffic view is about.
+type NoiseExclusions struct {
+	KubeSystem   bool // Flows from or to pods in kube-system
+	HealthChecks bool // Kubelet probes from the node and flows to health-check ports
+	DNS          bool // DNS lookups to kube-dns
+}
+
+// None reports whether no exclusion is enabled
+func (e NoiseExclusions) None() bool {
+	return !e.KubeSystem && !e.HealthChecks && !e.DNS
+}
+
+// String returns the exclusions as a comma-separated list, or "none"
+func (e NoiseExclusions) String() string {
+	var names []string
+	if e.KubeSystem {
+		names = append(names, ExcludeKubeSystem)
+	}
+	if e.HealthChecks {
+		names = append(names, ExcludeHealthChecks)
+	}
+	if e.DNS {
+		names = append(names, ExcludeDNS)
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseNoiseExclusions parses a comma-separated exclusion list such as
+// "kube-system,dns". "all" enables every exclusion; "none" or an empty
+// string disables them.
+func ParseNoiseExclusions(s string) (NoiseExclusions, error) {
+	var e NoiseExclusions
+	for _, name := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case ExcludeKubeSystem:
+			e.KubeSystem = true
+		case ExcludeHealthChecks:
+			e.HealthChecks = true
+		case ExcludeDNS:
+			e.DNS = true
+		case "all":
+			e = NoiseExclusions{KubeSystem: true, HealthChecks: true, DNS: true}
+		case "none", "":
+		default:
+			return NoiseExclusions{}, fmt.Errorf("unknown exclusion %q (expected kube-system, health-checks, dns, all or none)", name)
+		}
+	}
+	return e, nil
+}
+
+var (
+	defaultExclusionsMu sync.RWMutex
+	defaultExclusions   = NoiseExclusions{KubeSystem: true, HealthChecks: true, DNS: true}
+)
+
+// SetDefaultNoiseExclusions sets the exclusions applied to flow requests that
+// don't choose their own
+func SetDefaultNoiseExclusions(e NoiseExclusions) {
+	defaultExclusionsMu.Lock()
+	defer defaultExclusionsMu.Unlock()
+	defaultExclusions = e
+}
+
+// DefaultNoiseExclusions returns the exclusions applied to flow requests that
+// don't choose their own
+func DefaultNoiseExclusions() NoiseExclusions {
+	defaultExclusionsMu.RLock()
+	defer defaultExclusionsMu.RUnlock()
+	return defaultExclusions
+}
+
+// buildFlowBlacklist builds the blacklist for a GetFlows request from the
+// noise exclusions and the structured filter's Exclude. A flow matching any
+// filter is dropped by the relay. The kube-system exclusion is skipped when
+// kube-system is a namespace asked for, since it would otherwise drop its
+// flows.
+func buildFlowBlacklist(opts FlowOptions) []*flowpb.FlowFilter {
+	e := opts.Exclude
+	var filters []*flowpb.FlowFilter
+
+	if e.KubeSystem && opts.Namespace != "kube-system" && !opts.Filter.IncludesNamespace("kube-system") {
+		filters = append(filters,
+			&flowpb.FlowFilter{SourcePod: []string{"kube-system/"}},
+			&flowpb.FlowFilter{DestinationPod: []string{"kube-system/"}},
+		)
+	}
+
+	if e.HealthChecks {
+		ports := make([]string, 0, len(healthCheckPorts))
+		for _, p := range healthCheckPorts {
+			ports = append(ports, strconv.Itoa(p))
+		}
+		filters = append(filters,
+			// Kubelet probes come from the node's host identity
+			&flowpb.FlowFilter{SourceLabel: []string{"reserved:host"}},
+			&flowpb.FlowFilter{SourceLabel: []string{"reserved:health"}},
+			&flowpb.FlowFilter{DestinationPort: ports},
+		)
+	}
+
+	if e.DNS {
+		filters = append(filters, &flowpb.FlowFilter{
+			DestinationLabel: []string{"k8s:k8s-app=kube-dns"},
+			DestinationPort:  []string{"53"},
+		})
+	}
+
+	if opts.Filter != nil && opts.Filter.Exclude != nil {
+		filters = append(filters, opts.Filter.Exclude.hubbleFilters(nil)...)
+	}
+
+	return filters
+}
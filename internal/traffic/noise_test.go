@@ -0,0 +1,136 @@
+package traffic
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseNoiseExclusions(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    NoiseExclusions
+		wantErr bool
+	}{
+		{"", NoiseExclusions{}, false},
+		{"none", NoiseExclusions{}, false},
+		{"all", NoiseExclusions{KubeSystem: true, HealthChecks: true, DNS: true}, false},
+		{"kube-system", NoiseExclusions{KubeSystem: true}, false},
+		{"dns, Health-Checks", NoiseExclusions{HealthChecks: true, DNS: true}, false},
+		{"kube-system,bogus", NoiseExclusions{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseNoiseExclusions(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNoiseExclusions(%q): error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseNoiseExclusions(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNoiseExclusions_StringRoundTrip(t *testing.T) {
+	for _, e := range []NoiseExclusions{
+		{},
+		{KubeSystem: true},
+		{HealthChecks: true, DNS: true},
+		{KubeSystem: true, HealthChecks: true, DNS: true},
+	} {
+		got, err := ParseNoiseExclusions(e.String())
+		if err != nil {
+			t.Fatalf("ParseNoiseExclusions(%q) failed: %v", e.String(), err)
+		}
+		if got != e {
+			t.Errorf("round trip of %+v gave %+v", e, got)
+		}
+	}
+}
+
+func TestBuildFlowBlacklist_None(t *testing.T) {
+	if filters := buildFlowBlacklist(FlowOptions{}); len(filters) != 0 {
+		t.Errorf("expected no filters without exclusions, got %d", len(filters))
+	}
+}
+
+func TestBuildFlowBlacklist_KubeSystem(t *testing.T) {
+	filters := buildFlowBlacklist(FlowOptions{Exclude: NoiseExclusions{KubeSystem: true}})
+	if len(filters) != 2 {
+		t.Fatalf("expected 2 filters, got %d", len(filters))
+	}
+	// Source and destination must be separate filters so either side matches
+	if !slices.Equal(filters[0].SourcePod, []string{"kube-system/"}) || len(filters[0].DestinationPod) != 0 {
+		t.Errorf("unexpected source filter: %v", filters[0])
+	}
+	if !slices.Equal(filters[1].DestinationPod, []string{"kube-system/"}) || len(filters[1].SourcePod) != 0 {
+		t.Errorf("unexpected destination filter: %v", filters[1])
+	}
+}
+
+func TestBuildFlowBlacklist_KubeSystemNamespaceRequested(t *testing.T) {
+	opts := FlowOptions{Namespace: "kube-system", Exclude: NoiseExclusions{KubeSystem: true, DNS: true}}
+	filters := buildFlowBlacklist(opts)
+	if len(filters) != 1 {
+		t.Fatalf("expected only the DNS filter, got %d filters", len(filters))
+	}
+	if len(filters[0].SourcePod) != 0 || len(filters[0].DestinationPod) != 0 {
+		t.Errorf("kube-system filter applied although kube-system was requested: %v", filters[0])
+	}
+}
+
+func TestBuildFlowBlacklist_HealthChecks(t *testing.T) {
+	filters := buildFlowBlacklist(FlowOptions{Exclude: NoiseExclusions{HealthChecks: true}})
+
+	var hostSource, ports bool
+	for _, f := range filters {
+		if slices.Contains(f.SourceLabel, "reserved:host") {
+			hostSource = true
+		}
+		if len(f.DestinationPort) > 0 {
+			ports = true
+			for _, p := range []string{"4240", "10248", "10256"} {
+				if !slices.Contains(f.DestinationPort, p) {
+					t.Errorf("health-check port %s missing from %v", p, f.DestinationPort)
+				}
+			}
+			if len(f.DestinationLabel) != 0 || len(f.SourcePod) != 0 {
+				t.Errorf("port filter narrowed by other fields: %v", f)
+			}
+		}
+	}
+	if !hostSource {
+		t.Error("expected a filter for kubelet probes from the host identity")
+	}
+	if !ports {
+		t.Error("expected a filter for health-check ports")
+	}
+}
+
+func TestBuildFlowBlacklist_DNS(t *testing.T) {
+	filters := buildFlowBlacklist(FlowOptions{Exclude: NoiseExclusions{DNS: true}})
+	if len(filters) != 1 {
+		t.Fatalf("expected 1 filter, got %d", len(filters))
+	}
+	// Label and port in one filter, so only DNS to kube-dns is dropped
+	f := filters[0]
+	if !slices.Equal(f.DestinationLabel, []string{"k8s:k8s-app=kube-dns"}) {
+		t.Errorf("unexpected destination label: %v", f.DestinationLabel)
+	}
+	if !slices.Equal(f.DestinationPort, []string{"53"}) {
+		t.Errorf("unexpected destination port: %v", f.DestinationPort)
+	}
+}
+
+func TestBuildFlowBlacklist_LeavesWhitelistAlone(t *testing.T) {
+	opts := FlowOptions{Namespace: "shop", Exclude: NoiseExclusions{KubeSystem: true, HealthChecks: true, DNS: true}}
+	whitelist := buildFlowFilters(opts)
+	if len(whitelist) != 2 {
+		t.Fatalf("expected the namespace whitelist to be unchanged, got %d filters", len(whitelist))
+	}
+	for _, f := range whitelist {
+		if len(f.SourceLabel) != 0 || len(f.DestinationPort) != 0 {
+			t.Errorf("exclusion leaked into the whitelist: %v", f)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package traffic
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	policyCacheTTL     = 30 * time.Second
+	policyListTimeout  = 5 * time.Second
+	kindCiliumPolicy   = "CiliumNetworkPolicy"
+	kindCiliumCCPolicy = "CiliumClusterwideNetworkPolicy"
+)
+
+var (
+	cnpGVR  = schema.GroupVersionResource{Group: "cilium.io", Version: "v2", Resource: "ciliumnetworkpolicies"}
+	ccnpGVR = schema.GroupVersionResource{Group: "cilium.io", Version: "v2", Resource: "ciliumclusterwidenetworkpolicies"}
+)
+
+// policyRule is one spec of a Cilium policy, reduced to what is needed to
+// tell which endpoints it puts under enforcement
+type policyRule struct {
+	ref      PolicyRef
+	selector labels.Selector
+	ingress  bool // Has ingress or ingressDeny rules
+	egress   bool // Has egress or egressDeny rules
+}
+
+// policyCorrelator attributes policy drops to the Cilium policy that most
+// likely caused them, for drops where Cilium didn't report the policy itself
+type policyCorrelator struct {
+	client  dynamic.Interface
+	rules   []policyRule
+	expires time.Time
+	mu      sync.Mutex
+}
+
+func newPolicyCorrelator(config *rest.Config) *policyCorrelator {
+	if config == nil {
+		return nil
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Printf("[traffic] Policy correlation disabled: %v", err)
+		return nil
+	}
+	return &policyCorrelator{client: client}
+}
+
+// isPolicyDrop reports whether a drop reason comes from policy enforcement
+func isPolicyDrop(reason string) bool {
+	return reason == "policy_denied" || reason == "policy_deny"
+}
+
+// attribute sets f.Policy for a policy drop by finding a policy that selects
+// the endpoint the verdict was made for. A policy selecting an endpoint puts
+// it in default-deny, so when nothing explicitly denied the flow, the
+// selecting policy is the one that failed to allow it.
+func (p *policyCorrelator) attribute(ctx context.Context, f *Flow) {
+	if p == nil || f.Policy != nil || f.Verdict != "dropped" || !isPolicyDrop(f.DropReason) {
+		return
+	}
+
+	rules := p.getRules(ctx)
+	if len(rules) == 0 {
+		return
+	}
+
+	switch f.Direction {
+	case "ingress":
+		f.Policy = matchPolicy(rules, f.Destination, true)
+	case "egress":
+		f.Policy = matchPolicy(rules, f.Source, false)
+	default:
+		if f.Policy = matchPolicy(rules, f.Destination, true); f.Policy == nil {
+			f.Policy = matchPolicy(rules, f.Source, false)
+		}
+	}
+}
+
+// matchPolicy returns the first policy with rules for the given direction
+// that selects the endpoint. Namespaced policies are checked first.
+func matchPolicy(rules []policyRule, ep Endpoint, ingress bool) *PolicyRef {
+	if ep.Kind != "Pod" || len(ep.Labels) == 0 {
+		return nil
+	}
+	set := labels.Set(ep.Labels)
+	for _, rule := range rules {
+		if ingress && !rule.ingress || !ingress && !rule.egress {
+			continue
+		}
+		if rule.ref.Namespace != "" && rule.ref.Namespace != ep.Namespace {
+			continue
+		}
+		if rule.selector.Matches(set) {
+			ref := rule.ref
+			return &ref
+		}
+	}
+	return nil
+}
+
+// getRules returns the cached policy rules, refreshing them when stale
+func (p *policyCorrelator) getRules(ctx context.Context) []policyRule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Now().Before(p.expires) {
+		return p.rules
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, policyListTimeout)
+	defer cancel()
+
+	var rules []policyRule
+	for _, src := range []struct {
+		gvr  schema.GroupVersionResource
+		kind string
+	}{{cnpGVR, kindCiliumPolicy}, {ccnpGVR, kindCiliumCCPolicy}} {
+		list, err := p.client.Resource(src.gvr).List(listCtx, metav1.ListOptions{})
+		if err != nil {
+			// CRD not installed or no RBAC: correlate with whatever we could list
+			log.Printf("[traffic] Failed to list %s for policy correlation: %v", src.gvr.Resource, err)
+			continue
+		}
+		for i := range list.Items {
+			rules = append(rules, parsePolicyRules(&list.Items[i], src.kind)...)
+		}
+	}
+
+	p.rules = rules
+	p.expires = time.Now().Add(policyCacheTTL)
+	return rules
+}
+
+// parsePolicyRules extracts the rules from a policy's spec and specs fields
+func parsePolicyRules(obj *unstructured.Unstructured, kind string) []policyRule {
+	ref := PolicyRef{Kind: kind, Name: obj.GetName(), Namespace: obj.GetNamespace(), Inferred: true}
+
+	var specs []map[string]any
+	if spec, ok, _ := unstructured.NestedMap(obj.Object, "spec"); ok {
+		specs = append(specs, spec)
+	}
+	if list, ok, _ := unstructured.NestedSlice(obj.Object, "specs"); ok {
+		for _, item := range list {
+			if spec, ok := item.(map[string]any); ok {
+				specs = append(specs, spec)
+			}
+		}
+	}
+
+	var rules []policyRule
+	for _, spec := range specs {
+		// Rules with only a nodeSelector apply to hosts, not pods
+		raw, ok := spec["endpointSelector"].(map[string]any)
+		if !ok {
+			continue
+		}
+		var ls metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &ls); err != nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(normalizeCiliumSelector(&ls))
+		if err != nil {
+			continue
+		}
+		_, hasIngress := spec["ingress"]
+		_, hasIngressDeny := spec["ingressDeny"]
+		_, hasEgress := spec["egress"]
+		_, hasEgressDeny := spec["egressDeny"]
+		rules = append(rules, policyRule{
+			ref:      ref,
+			selector: selector,
+			ingress:  hasIngress || hasIngressDeny,
+			egress:   hasEgress || hasEgressDeny,
+		})
+	}
+	return rules
+}
+
+// normalizeCiliumSelector strips Cilium label source prefixes ("k8s:",
+// "any:") so the selector matches the plain labels on flow endpoints
+func normalizeCiliumSelector(ls *metav1.LabelSelector) *metav1.LabelSelector {
+	out := &metav1.LabelSelector{}
+	if len(ls.MatchLabels) > 0 {
+		out.MatchLabels = make(map[string]string, len(ls.MatchLabels))
+		for k, v := range ls.MatchLabels {
+			out.MatchLabels[stripLabelSource(k)] = v
+		}
+	}
+	for _, expr := range ls.MatchExpressions {
+		expr.Key = stripLabelSource(expr.Key)
+		out.MatchExpressions = append(out.MatchExpressions, expr)
+	}
+	return out
+}
+
+func stripLabelSource(key string) string {
+	for _, prefix := range []string{"k8s:", "any:"} {
+		if strings.HasPrefix(key, prefix) {
+			return strings.TrimPrefix(key, prefix)
+		}
+	}
+	return key
+}
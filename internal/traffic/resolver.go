@@ -0,0 +1,156 @@
+package traffic
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	resolvedNameTTL   = 10 * time.Minute // How long a found name is reused
+	unresolvedNameTTL = 2 * time.Minute  // How long to wait before retrying an IP with no name
+	reverseDNSTimeout = 2 * time.Second
+	maxPendingLookups = 16   // Lookups in flight at once; further IPs are retried on a later flow
+	maxResolvedNames  = 4096 // Cached names; busy clusters talk to far more external IPs over time
+)
+
+// IPLookupFunc finds the in-cluster object (Service, Pod, Node) that owns an IP
+type IPLookupFunc func(ip string) (kind, namespace, name string, ok bool)
+
+type resolvedName struct {
+	kind      string // Empty when only a DNS name was found
+	namespace string
+	name      string // Empty when nothing was found
+	expires   time.Time
+}
+
+// nameResolver gives names to External endpoints. Lookups run in the
+// background and results are cached, so the first flow for an IP is
+// delivered as-is and later flows carry the resolved name.
+type nameResolver struct {
+	lookupIP IPLookupFunc
+	cache    map[string]resolvedName
+	pending  map[string]bool
+	mu       sync.Mutex
+}
+
+var resolver = &nameResolver{
+	cache:   make(map[string]resolvedName),
+	pending: make(map[string]bool),
+}
+
+// SetIPLookup sets the function used to resolve external IPs to cluster objects
+func SetIPLookup(fn IPLookupFunc) {
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+	resolver.lookupIP = fn
+}
+
+// resetResolver drops cached names, e.g. after a context switch
+func resetResolver() {
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+	resolver.cache = make(map[string]resolvedName)
+}
+
+// resolveFlowEndpoints fills in names for the External endpoints of a flow
+func resolveFlowEndpoints(f *Flow) {
+	resolver.resolve(&f.Source)
+	resolver.resolve(&f.Destination)
+}
+
+// resolve applies a cached name to an External endpoint, starting a
+// lookup in the background if there is none yet
+func (r *nameResolver) resolve(ep *Endpoint) {
+	// Only unnamed endpoints: Hubble already names reserved identities (host,
+	// kube-apiserver) and "world" is the generic name for anything outside
+	if ep.Kind != "External" || ep.IP == "" || (ep.Name != ep.IP && ep.Name != "world") {
+		return
+	}
+
+	r.mu.Lock()
+	res, ok := r.cache[ep.IP]
+	if ok && time.Now().After(res.expires) {
+		delete(r.cache, ep.IP)
+		ok = false
+	}
+	if !ok {
+		if !r.pending[ep.IP] && len(r.pending) < maxPendingLookups {
+			r.pending[ep.IP] = true
+			go r.lookup(ep.IP)
+		}
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+
+	if res.name == "" {
+		return
+	}
+	ep.Name = res.name
+	if res.kind != "" {
+		ep.Kind = res.kind
+		ep.Namespace = res.namespace
+	}
+}
+
+// lookup resolves an IP against the cluster first, then reverse DNS
+func (r *nameResolver) lookup(ip string) {
+	r.mu.Lock()
+	lookupIP := r.lookupIP
+	r.mu.Unlock()
+
+	res := resolvedName{expires: time.Now().Add(unresolvedNameTTL)}
+	if lookupIP != nil {
+		if kind, namespace, name, ok := lookupIP(ip); ok {
+			res = resolvedName{kind: kind, namespace: namespace, name: name, expires: time.Now().Add(resolvedNameTTL)}
+		}
+	}
+	if res.name == "" {
+		if name := reverseDNS(ip); name != "" {
+			res = resolvedName{name: name, expires: time.Now().Add(resolvedNameTTL)}
+		}
+	}
+
+	r.mu.Lock()
+	r.store(ip, res, time.Now())
+	delete(r.pending, ip)
+	r.mu.Unlock()
+}
+
+// store caches a name, first dropping expired names when the cache is full
+// and then, if it still is, the name closest to expiring. Must be called
+// with mu held.
+func (r *nameResolver) store(ip string, res resolvedName, now time.Time) {
+	if _, ok := r.cache[ip]; !ok && len(r.cache) >= maxResolvedNames {
+		for cachedIP, cached := range r.cache {
+			if now.After(cached.expires) {
+				delete(r.cache, cachedIP)
+			}
+		}
+		if len(r.cache) >= maxResolvedNames {
+			var oldest string
+			for cachedIP, cached := range r.cache {
+				if oldest == "" || cached.expires.Before(r.cache[oldest].expires) {
+					oldest = cachedIP
+				}
+			}
+			delete(r.cache, oldest)
+		}
+	}
+	r.cache[ip] = res
+}
+
+// reverseDNS returns the first PTR name for an IP, without the trailing dot
+func reverseDNS(ip string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), reverseDNSTimeout)
+	defer cancel()
+
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(names[0], ".")
+}
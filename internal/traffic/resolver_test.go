@@ -0,0 +1,30 @@
+package traffic
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNameResolverStoreBounded(t *testing.T) {
+	r := &nameResolver{cache: make(map[string]resolvedName)}
+	now := time.Now()
+
+	r.store("10.0.0.1", resolvedName{name: "expired", expires: now.Add(-time.Second)}, now)
+	for n := 1; n < maxResolvedNames; n++ {
+		r.store(fmt.Sprintf("10.1.%d.%d", n/256, n%256), resolvedName{name: "live", expires: now.Add(time.Duration(n) * time.Second)}, now)
+	}
+
+	// Full: the expired name goes first, then the name closest to expiring
+	r.store("10.2.0.1", resolvedName{name: "new", expires: now.Add(time.Hour)}, now)
+	if _, ok := r.cache["10.0.0.1"]; ok {
+		t.Error("expected the expired name to be dropped")
+	}
+	r.store("10.2.0.2", resolvedName{name: "new", expires: now.Add(time.Hour)}, now)
+	if _, ok := r.cache["10.1.0.1"]; ok {
+		t.Error("expected the name closest to expiring to be dropped")
+	}
+	if len(r.cache) != maxResolvedNames {
+		t.Errorf("cache holds %d names, want %d", len(r.cache), maxResolvedNames)
+	}
+}
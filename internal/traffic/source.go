@@ -3,6 +3,10 @@ package traffic
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,27 +38,131 @@ type DetectionResult struct {
 
 // FlowOptions contains options for querying flows
 type FlowOptions struct {
-	Namespace string        // Filter by namespace (empty = all)
-	Since     time.Duration // Look back period (default: 5 minutes)
-	Follow    bool          // Stream new flows
-	Limit     int           // Max flows to return (0 = no limit)
+	Namespace string          // Filter by namespace (empty = all)
+	Since     time.Duration   // Look back period (default: 5 minutes)
+	Follow    bool            // Stream new flows
+	Limit     int             // Max flows to return (0 = default; capped by ClampFlowLimit)
+	TCPFlags  []TCPFlags      // Only TCP flows with all flags of any one set (Hubble only; empty = no filter)
+	States    []string        // Only TCP flows in one of these connection states (Hubble only; empty = no filter)
+	Aggregate bool            // Collapse repeated flow events into one flow with a count
+	Exclude   NoiseExclusions // Background traffic to leave out (Hubble only)
+	Filter    *FlowFilterSpec // Structured filter, combined with Namespace (Hubble only; nil = no filter)
+
+	// Streaming only: what to do when the reader falls behind, and a counter
+	// of the flows lost to it (nil = not counted)
+	Backpressure BackpressurePolicy
+	BlockTimeout time.Duration // For BackpressureBlock (0 = defaultBlockTimeout)
+	Dropped      *atomic.Int64
 }
 
 // Flow represents a single network flow between two endpoints
 type Flow struct {
-	Source      Endpoint  `json:"source"`
-	Destination Endpoint  `json:"destination"`
-	Protocol    string    `json:"protocol"` // tcp, udp, http, grpc
-	Port        int       `json:"port"`
-	L7Protocol  string    `json:"l7Protocol,omitempty"` // HTTP, gRPC, DNS (if L7 visibility)
-	HTTPMethod  string    `json:"httpMethod,omitempty"`
-	HTTPPath    string    `json:"httpPath,omitempty"`
-	HTTPStatus  int       `json:"httpStatus,omitempty"`
-	BytesSent   int64     `json:"bytesSent"`
-	BytesRecv   int64     `json:"bytesRecv"`
-	Connections int64     `json:"connections"`
-	Verdict     string    `json:"verdict"` // forwarded, dropped, error
-	LastSeen    time.Time `json:"lastSeen"`
+	Source      Endpoint   `json:"source"`
+	Destination Endpoint   `json:"destination"`
+	Protocol    string     `json:"protocol"` // tcp, udp, http, grpc
+	Port        int        `json:"port"`
+	L7Protocol  string     `json:"l7Protocol,omitempty"` // HTTP, gRPC, DNS (if L7 visibility)
+	HTTPMethod  string     `json:"httpMethod,omitempty"`
+	HTTPPath    string     `json:"httpPath,omitempty"`
+	HTTPStatus  int        `json:"httpStatus,omitempty"`
+	LatencyMs   float64    `json:"latencyMs,omitempty"` // L7 request-to-response time, on response flows (0 if not reported)
+	BytesSent   int64      `json:"bytesSent"`
+	BytesRecv   int64      `json:"bytesRecv"`
+	Connections int64      `json:"connections"`
+	Count       int64      `json:"count,omitempty"`      // Flow events collapsed into this flow (0 or 1 = single event)
+	Verdict     string     `json:"verdict"`              // forwarded, dropped, error
+	Direction   string     `json:"direction,omitempty"`  // ingress, egress (where the verdict was made)
+	DropReason  string     `json:"dropReason,omitempty"` // e.g. policy_denied (dropped flows only)
+	Policy      *PolicyRef `json:"policy,omitempty"`     // Policy the drop is attributed to
+	TCPFlags    *TCPFlags  `json:"tcpFlags,omitempty"`   // Set on TCP flows when the source reports flags
+	State       string     `json:"state,omitempty"`      // new, established, closing (derived from TCPFlags)
+	LastSeen    time.Time  `json:"lastSeen"`
+}
+
+// PolicyRef identifies the network policy a dropped flow is attributed to
+type PolicyRef struct {
+	Kind      string `json:"kind"` // CiliumNetworkPolicy, CiliumClusterwideNetworkPolicy
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Inferred is true when the policy was matched by its endpoint selector
+	// rather than reported by Cilium, so it is the likely but not certain cause
+	Inferred bool `json:"inferred,omitempty"`
+}
+
+// TCPFlags are the TCP control flags of a flow, or a set of flags to filter on
+type TCPFlags struct {
+	SYN bool `json:"syn,omitempty"`
+	ACK bool `json:"ack,omitempty"`
+	FIN bool `json:"fin,omitempty"`
+	RST bool `json:"rst,omitempty"`
+}
+
+// ParseTCPFlags parses a comma-separated flag list such as "SYN,ACK"
+func ParseTCPFlags(s string) (TCPFlags, error) {
+	var flags TCPFlags
+	for _, name := range strings.Split(s, ",") {
+		switch strings.ToUpper(strings.TrimSpace(name)) {
+		case "SYN":
+			flags.SYN = true
+		case "ACK":
+			flags.ACK = true
+		case "FIN":
+			flags.FIN = true
+		case "RST":
+			flags.RST = true
+		case "":
+		default:
+			return TCPFlags{}, fmt.Errorf("unknown TCP flag %q (expected SYN, ACK, FIN or RST)", name)
+		}
+	}
+	if flags == (TCPFlags{}) {
+		return TCPFlags{}, fmt.Errorf("no TCP flags given")
+	}
+	return flags, nil
+}
+
+// Connection states derived from a TCP flow's flags
+const (
+	FlowStateNew         = "new"         // Handshake: SYN or SYN-ACK
+	FlowStateEstablished = "established" // Data or keepalive on an open connection
+	FlowStateClosing     = "closing"     // Teardown: FIN or RST
+)
+
+// connectionState classifies a TCP flow by its flags. Teardown wins over
+// handshake so a SYN answered with RST (a refused connection) counts as
+// closing. Flows without flags have no state.
+func connectionState(flags *TCPFlags) string {
+	switch {
+	case flags == nil:
+		return ""
+	case flags.FIN || flags.RST:
+		return FlowStateClosing
+	case flags.SYN:
+		return FlowStateNew
+	default:
+		return FlowStateEstablished
+	}
+}
+
+// ParseFlowStates parses a comma-separated state list such as "new,closing"
+func ParseFlowStates(s string) ([]string, error) {
+	var states []string
+	for _, name := range strings.Split(s, ",") {
+		state := strings.ToLower(strings.TrimSpace(name))
+		switch state {
+		case FlowStateNew, FlowStateEstablished, FlowStateClosing:
+			if !slices.Contains(states, state) {
+				states = append(states, state)
+			}
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown connection state %q (expected new, established or closing)", name)
+		}
+	}
+	if len(states) == 0 {
+		return nil, fmt.Errorf("no connection states given")
+	}
+	return states, nil
 }
 
 // Endpoint represents a source or destination in a flow
@@ -66,6 +174,7 @@ type Endpoint struct {
 	Labels    map[string]string `json:"labels,omitempty"`   // K8s labels
 	Workload  string            `json:"workload,omitempty"` // Parent workload name (Deployment, etc.)
 	Port      int               `json:"port,omitempty"`     // Port number
+	Identity  uint32            `json:"identity,omitempty"` // Cilium security identity (Hubble only)
 }
 
 // FlowsResponse contains the flows and metadata
@@ -74,6 +183,11 @@ type FlowsResponse struct {
 	Timestamp time.Time `json:"timestamp"` // When this data was collected
 	Flows     []Flow    `json:"flows"`
 	Warning   string    `json:"warning,omitempty"` // Non-fatal warning (e.g., query errors)
+
+	// Limit is the number of flows requested from the source after
+	// clamping; LimitClamped is set when the caller asked for more
+	Limit        int  `json:"limit,omitempty"`
+	LimitClamped bool `json:"limitClamped,omitempty"`
 }
 
 // AggregatedFlow represents flows aggregated by service pair
@@ -139,4 +253,39 @@ type SourcesResponse struct {
 	Detected    []SourceStatus  `json:"detected"`
 	NotDetected []string        `json:"notDetected"`
 	Recommended *Recommendation `json:"recommended,omitempty"`
+	// Capabilities reports every known backend, including ones that are
+	// installed but disabled or not readable by Radar
+	Capabilities []TrafficCapability `json:"capabilities"`
+	Requirement  string              `json:"requirement,omitempty"` // Set when no backend is available
+}
+
+// TrafficStatus is the response for GET /api/traffic/status
+type TrafficStatus struct {
+	Source       string                 `json:"source,omitempty"` // Active source (empty = none detected)
+	Available    bool                   `json:"available"`
+	Detection    *DetectionResult       `json:"detection,omitempty"`
+	Connection   *MetricsConnectionInfo `json:"connection"`
+	Relay        *RelayStatus           `json:"relay,omitempty"`        // Hubble only
+	Server       *HubbleServerStatus    `json:"server,omitempty"`       // Hubble only, when connected
+	Instructions string                 `json:"instructions,omitempty"` // Manual access steps, when not connected
+	Message      string                 `json:"message,omitempty"`
+}
+
+// RelayStatus describes the discovered Hubble Relay
+type RelayStatus struct {
+	Namespace string `json:"namespace"`
+	Port      int    `json:"port,omitempty"`
+	TLS       bool   `json:"tls"`
+}
+
+// HubbleServerStatus contains the counters reported by Hubble Relay
+type HubbleServerStatus struct {
+	Version          string   `json:"version,omitempty"`
+	NumFlows         uint64   `json:"numFlows"`  // Flows currently buffered
+	MaxFlows         uint64   `json:"maxFlows"`  // Buffer capacity
+	SeenFlows        uint64   `json:"seenFlows"` // Flows seen since start
+	FlowsRate        float64  `json:"flowsRate"` // Flows per second
+	UptimeSeconds    float64  `json:"uptimeSeconds"`
+	ConnectedNodes   *uint32  `json:"connectedNodes,omitempty"`
+	UnavailableNodes []string `json:"unavailableNodes,omitempty"`
 }
@@ -0,0 +1,130 @@
+package traffic
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// TrafficPeer is an endpoint a workload exchanged flows with. Pods are
+// grouped by their workload, so a peer is a workload, a pod without one, a
+// service or an external address.
+type TrafficPeer struct {
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"` // Workload, Pod, Service, External
+	Flows     int64     `json:"flows"`
+	Dropped   int64     `json:"dropped"`
+	Ports     []int     `json:"ports,omitempty"` // Destination ports seen, ascending
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// WorkloadTraffic summarizes the flows to and from a workload's pods
+type WorkloadTraffic struct {
+	Flows      int64            `json:"flows"`   // Flow events
+	Dropped    int64            `json:"dropped"` // Flow events dropped either way
+	DropReason map[string]int64 `json:"dropReasons"`
+	Inbound    []TrafficPeer    `json:"inbound"`  // Peers sending to the workload, busiest first
+	Outbound   []TrafficPeer    `json:"outbound"` // Peers the workload sends to, busiest first
+}
+
+// SummarizeWorkloadTraffic ranks the peers of the pods in namespace matching
+// selector by flow count, keeping the top ones each way, and counts drops.
+// A flow between two of the workload's pods counts as inbound.
+func SummarizeWorkloadTraffic(flows []Flow, namespace string, selector labels.Selector, top int) *WorkloadTraffic {
+	summary := &WorkloadTraffic{DropReason: map[string]int64{}}
+	inbound := map[string]*TrafficPeer{}
+	outbound := map[string]*TrafficPeer{}
+
+	isWorkload := func(ep Endpoint) bool {
+		return ep.Namespace == namespace && ep.Kind != "External" && selector.Matches(labels.Set(ep.Labels))
+	}
+
+	for _, f := range flows {
+		var peers map[string]*TrafficPeer
+		var peer Endpoint
+		switch {
+		case isWorkload(f.Destination):
+			peers, peer = inbound, f.Source
+		case isWorkload(f.Source):
+			peers, peer = outbound, f.Destination
+		default:
+			continue
+		}
+
+		count := max(f.Count, 1)
+		summary.Flows += count
+		dropped := f.Verdict == "dropped"
+		if dropped {
+			summary.Dropped += count
+			reason := f.DropReason
+			if reason == "" {
+				reason = "unknown"
+			}
+			summary.DropReason[reason] += count
+		}
+
+		key, p := peerOf(peer)
+		entry, ok := peers[key]
+		if !ok {
+			entry = &p
+			peers[key] = entry
+		}
+		entry.Flows += count
+		if dropped {
+			entry.Dropped += count
+		}
+		if f.Port > 0 && !slices.Contains(entry.Ports, f.Port) {
+			entry.Ports = append(entry.Ports, f.Port)
+		}
+		if f.LastSeen.After(entry.LastSeen) {
+			entry.LastSeen = f.LastSeen
+		}
+	}
+
+	summary.Inbound = topPeers(inbound, top)
+	summary.Outbound = topPeers(outbound, top)
+	return summary
+}
+
+// peerOf returns the grouping key of an endpoint and an empty peer for it
+func peerOf(ep Endpoint) (string, TrafficPeer) {
+	switch {
+	case ep.Kind == "External" || (ep.Name == "" && ep.Namespace == ""):
+		name := ep.Name
+		if name == "" {
+			name = ep.IP
+		}
+		return "external/" + name, TrafficPeer{Name: name, Kind: "External"}
+	case ep.Workload != "":
+		return ep.Namespace + "/workload/" + ep.Workload, TrafficPeer{Namespace: ep.Namespace, Name: ep.Workload, Kind: "Workload"}
+	}
+	kind := ep.Kind
+	if kind == "" {
+		kind = "Pod"
+	}
+	return ep.Namespace + "/" + kind + "/" + ep.Name, TrafficPeer{Namespace: ep.Namespace, Name: ep.Name, Kind: kind}
+}
+
+// topPeers returns up to n peers by flow count, then drops, then name
+func topPeers(peers map[string]*TrafficPeer, n int) []TrafficPeer {
+	result := make([]TrafficPeer, 0, len(peers))
+	for _, p := range peers {
+		slices.Sort(p.Ports)
+		result = append(result, *p)
+	}
+	slices.SortFunc(result, func(a, b TrafficPeer) int {
+		return cmp.Or(
+			cmp.Compare(b.Flows, a.Flows),
+			cmp.Compare(b.Dropped, a.Dropped),
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package traffic
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestSummarizeWorkloadTraffic(t *testing.T) {
+	cart := Endpoint{Namespace: "shop", Name: "cart-7d9f-abc", Kind: "Pod", Labels: map[string]string{"app": "cart"}}
+	web := Endpoint{Namespace: "shop", Name: "web-1", Kind: "Pod", Workload: "web", Labels: map[string]string{"app": "web"}}
+	web2 := Endpoint{Namespace: "shop", Name: "web-2", Kind: "Pod", Workload: "web", Labels: map[string]string{"app": "web"}}
+	db := Endpoint{Namespace: "data", Name: "postgres-0", Kind: "Pod", Labels: map[string]string{"app": "postgres"}}
+	world := Endpoint{Name: "world", Kind: "External"}
+	now := time.Now()
+
+	flows := []Flow{
+		{Source: web, Destination: cart, Port: 8080, Verdict: "forwarded", Count: 5, LastSeen: now},
+		{Source: web2, Destination: cart, Port: 8080, Verdict: "forwarded", LastSeen: now.Add(time.Second)},
+		{Source: world, Destination: cart, Port: 8443, Verdict: "dropped", DropReason: "policy_denied", Count: 2},
+		{Source: cart, Destination: db, Port: 5432, Verdict: "forwarded", Count: 3},
+		{Source: cart, Destination: db, Port: 5433, Verdict: "dropped"},
+		{Source: web, Destination: db, Port: 5432, Verdict: "forwarded"}, // Not the workload's
+	}
+
+	summary := SummarizeWorkloadTraffic(flows, "shop", labels.SelectorFromSet(labels.Set{"app": "cart"}), 10)
+	if summary.Flows != 12 || summary.Dropped != 3 {
+		t.Errorf("expected 12 flows and 3 dropped, got %d and %d", summary.Flows, summary.Dropped)
+	}
+	if summary.DropReason["policy_denied"] != 2 || summary.DropReason["unknown"] != 1 {
+		t.Errorf("unexpected drop reasons: %v", summary.DropReason)
+	}
+
+	if len(summary.Inbound) != 2 {
+		t.Fatalf("expected 2 inbound peers, got %+v", summary.Inbound)
+	}
+	if p := summary.Inbound[0]; p.Name != "web" || p.Kind != "Workload" || p.Flows != 6 || !p.LastSeen.Equal(now.Add(time.Second)) {
+		t.Errorf("expected the web pods grouped as the top peer, got %+v", p)
+	}
+	if p := summary.Inbound[1]; p.Name != "world" || p.Kind != "External" || p.Dropped != 2 {
+		t.Errorf("unexpected external peer: %+v", p)
+	}
+
+	if len(summary.Outbound) != 1 {
+		t.Fatalf("expected 1 outbound peer, got %+v", summary.Outbound)
+	}
+	if p := summary.Outbound[0]; p.Name != "postgres-0" || p.Flows != 4 || p.Dropped != 1 || !slices.Equal(p.Ports, []int{5432, 5433}) {
+		t.Errorf("unexpected outbound peer: %+v", p)
+	}
+
+	if top := SummarizeWorkloadTraffic(flows, "shop", labels.SelectorFromSet(labels.Set{"app": "cart"}), 1); len(top.Inbound) != 1 {
+		t.Errorf("expected the top 1 inbound peer, got %d", len(top.Inbound))
+	}
+}
@@ -1,13 +1,12 @@
 import { useQuery } from '@tanstack/react-query'
-import type { APIResource } from '../types'
-
-const API_BASE = '/api'
+import type { APIResource, ResourceKind } from '../types'
+import { toApiError } from './errors'
+import { API_BASE } from '../utils/base-path'
 
 async function fetchJSON<T>(path: string): Promise<T> {
   const response = await fetch(`${API_BASE}${path}`)
   if (!response.ok) {
-    const error = await response.json().catch(() => ({ error: 'Unknown error' }))
-    throw new Error(error.error || `HTTP ${response.status}`)
+    throw await toApiError(response)
   }
   return response.json()
 }
@@ -21,6 +20,15 @@ export function useAPIResources() {
   })
 }
 
+// Fetch listable resource kinds with informer state and list permission
+export function useResourceKinds() {
+  return useQuery<ResourceKind[]>({
+    queryKey: ['resource-kinds'],
+    queryFn: () => fetchJSON('/resource-kinds'),
+    staleTime: 60 * 1000, // Permissions are cached server-side for 60s
+  })
+}
+
 // Group resources by category for sidebar display
 export interface ResourceCategory {
   name: string
@@ -3,34 +3,41 @@ import type {
   Topology,
   ClusterInfo,
   Capabilities,
+  NamespacePermissionMatrix,
   ContextInfo,
   Namespace,
   TimelineEvent,
+  ChangeSummary,
   TimeRange,
   ResourceWithRelationships,
+  ResourceGraph,
+  WorkloadDetail,
   HelmRelease,
   HelmReleaseDetail,
   HelmValues,
   ManifestDiff,
   UpgradeInfo,
   BatchUpgradeInfo,
+  ChartProvenance,
   ValuesPreviewResponse,
+  RollbackPreview,
   HelmRepository,
   ChartSearchResult,
   ChartDetail,
   InstallChartRequest,
+  HelmTemplateRequest,
+  HelmTemplateResponse,
   ArtifactHubSearchResult,
   ArtifactHubChartDetail,
 } from '../types'
 import type { GitOpsOperationResponse } from '../types/gitops'
-
-const API_BASE = '/api'
+import { toApiError } from './errors'
+import { API_BASE } from '../utils/base-path'
 
 async function fetchJSON<T>(path: string): Promise<T> {
   const response = await fetch(`${API_BASE}${path}`)
   if (!response.ok) {
-    const error = await response.json().catch(() => ({ error: 'Unknown error' }))
-    throw new Error(error.error || `HTTP ${response.status}`)
+    throw await toApiError(response)
   }
   return response.json()
 }
@@ -225,6 +232,16 @@ export function useCapabilities() {
   })
 }
 
+// Per-namespace access matrix; all namespaces when none are given
+export function useNamespacePermissions(namespaces?: string[]) {
+  const params = namespaces?.length ? `?namespaces=${encodeURIComponent(namespaces.join(','))}` : ''
+  return useQuery<NamespacePermissionMatrix>({
+    queryKey: ['namespace-permissions', namespaces],
+    queryFn: () => fetchJSON(`/capabilities/namespaces${params}`),
+    staleTime: 60000, // 1 minute - cached on backend too
+  })
+}
+
 // Namespaces
 export function useNamespaces() {
   return useQuery<Namespace[]>({
@@ -248,6 +265,16 @@ export function useTopology(namespace: string, viewMode: string = 'resources') {
   })
 }
 
+// Deployment, StatefulSet or DaemonSet with its ReplicaSets, pods, rollout
+// status and recent events in one call
+export function useWorkloadDetail<T = unknown>(kind: string, namespace: string, name: string) {
+  return useQuery<WorkloadDetail<T>>({
+    queryKey: ['workload-detail', kind, namespace, name],
+    queryFn: () => fetchJSON(`/workloads/${kind}/${namespace}/${name}`),
+    enabled: Boolean(kind && namespace && name),
+  })
+}
+
 // Generic resource fetching - returns resource with relationships
 // Uses '_' as placeholder for cluster-scoped resources (empty namespace)
 export function useResource<T>(kind: string, namespace: string, name: string, group?: string) {
@@ -271,6 +298,15 @@ export function useResource<T>(kind: string, namespace: string, name: string, gr
   }
 }
 
+// Dependency graph around a resource, for export and blast-radius views
+export function useResourceGraph(kind: string, namespace: string, name: string, enabled = true) {
+  return useQuery<ResourceGraph>({
+    queryKey: ['resource-graph', kind, namespace, name],
+    queryFn: () => fetchJSON(`/resources/${kind}/${namespace}/${name}/graph`),
+    enabled: enabled && Boolean(kind && namespace && name),
+  })
+}
+
 // Hook that returns full response with relationships explicitly
 export function useResourceWithRelationships<T>(kind: string, namespace: string, name: string, group?: string) {
   const ns = namespace || '_'
@@ -286,14 +322,16 @@ export function useResourceWithRelationships<T>(kind: string, namespace: string,
 }
 
 // List resources - queryKey includes group for cache sharing with ResourcesView
-export function useResources<T>(kind: string, namespace?: string, group?: string) {
+// sort 'health' lists unhealthy resources first (pods and workloads only)
+export function useResources<T>(kind: string, namespace?: string, group?: string, sort?: 'health') {
   const params = new URLSearchParams()
   if (namespace) params.set('namespace', namespace)
   if (group) params.set('group', group)
+  if (sort) params.set('sort', sort)
   const queryString = params.toString()
 
   return useQuery<T[]>({
-    queryKey: ['resources', kind, group, namespace],
+    queryKey: ['resources', kind, group, namespace, sort],
     queryFn: () => fetchJSON(`/resources/${kind}${queryString ? `?${queryString}` : ''}`),
     staleTime: 30000, // 30 seconds - matches refetchInterval in ResourcesView
   })
@@ -355,6 +393,39 @@ export function useChanges(options: UseChangesOptions = {}) {
   })
 }
 
+// Change activity over a window: counts by kind, namespace and verb, plus volume per bucket
+export interface UseChangesSummaryOptions {
+  namespace?: string
+  kind?: string
+  window?: string // Duration like '30m', '6h' (default 1h)
+  bucket?: string // Duration like '1m' (default window/60)
+  filter?: string
+  includeK8sEvents?: boolean
+  includeManaged?: boolean
+}
+
+export function useChangesSummary(options: UseChangesSummaryOptions = {}) {
+  const { namespace, kind, window, bucket, filter, includeK8sEvents = false, includeManaged = false } = options
+
+  const params = new URLSearchParams()
+  if (namespace) params.set('namespace', namespace)
+  if (kind) params.set('kind', kind)
+  if (window) params.set('window', window)
+  if (bucket) params.set('bucket', bucket)
+  if (filter) params.set('filter', filter)
+  if (includeK8sEvents) params.set('include_k8s_events', 'true')
+  if (includeManaged) params.set('include_managed', 'true')
+
+  const queryString = params.toString()
+
+  return useQuery<ChangeSummary>({
+    queryKey: ['changes-summary', namespace, kind, window, bucket, filter, includeK8sEvents, includeManaged],
+    queryFn: () => fetchJSON(`/changes/summary${queryString ? `?${queryString}` : ''}`),
+    staleTime: 5000,
+    refetchInterval: 30000,
+  })
+}
+
 // Children changes for a parent workload (e.g., ReplicaSets and Pods under a Deployment)
 export function useResourceChildren(kind: string, namespace: string, name: string, timeRange: TimeRange = '1h') {
   const sinceDate = getTimeRangeDate(timeRange)
@@ -489,6 +560,43 @@ export function usePodMetricsHistory(namespace: string, podName: string) {
   })
 }
 
+// Request recommendations from the sampled metrics history. CPU values are in
+// nanocores and memory in bytes, as in MetricsDataPoint; 0 means unset.
+export interface ResourceRecommendation {
+  request: number
+  limit: number
+  usage: { p50: number; p95: number; max: number }
+  recommended: number // Suggested request; 0 without enough data
+  verdict: 'ok' | 'over-provisioned' | 'under-provisioned' | 'no-request' | 'insufficient-data' | 'near-limit'
+  message: string
+}
+
+export interface ContainerRecommendation {
+  name: string
+  samples: number
+  cpu: ResourceRecommendation
+  memory: ResourceRecommendation
+}
+
+export interface PodRecommendations {
+  namespace: string
+  name: string
+  window: string
+  minSamples: number
+  containers: ContainerRecommendation[]
+}
+
+// Fetch read-only request recommendations for a pod's containers
+export function usePodResourceRecommendations(namespace: string, podName: string) {
+  return useQuery<PodRecommendations>({
+    queryKey: ['pod-recommendations', namespace, podName],
+    queryFn: () => fetchJSON(`/metrics/pods/${namespace}/${podName}/recommendations`),
+    enabled: Boolean(namespace && podName),
+    staleTime: 25000,
+    refetchInterval: 60000,
+  })
+}
+
 // Fetch historical metrics for a node (last ~1 hour)
 export function useNodeMetricsHistory(nodeName: string) {
   return useQuery<NodeMetricsHistory>({
@@ -513,7 +621,7 @@ export interface LogsResponse {
 }
 
 export interface LogStreamEvent {
-  event: 'connected' | 'log' | 'end' | 'error'
+  event: 'connected' | 'log' | 'end' | 'error' | 'pod' | 'warning' | 'heartbeat'
   data: {
     timestamp?: string
     content?: string
@@ -522,6 +630,8 @@ export interface LogStreamEvent {
     namespace?: string
     reason?: string
     error?: string
+    status?: 'following' | 'ended' | 'failed' // pod events of a workload stream
+    message?: string
   }
 }
 
@@ -564,6 +674,24 @@ export function createLogStream(
   return new EventSource(`${API_BASE}/pods/${namespace}/${podName}/logs/stream${queryString ? `?${queryString}` : ''}`)
 }
 
+// Create SSE connection following the logs of all pods of a workload, tagged by pod
+export function createWorkloadLogStream(
+  kind: string,
+  namespace: string,
+  name: string,
+  options?: {
+    container?: string
+    tailLines?: number
+  }
+): EventSource {
+  const params = new URLSearchParams()
+  if (options?.container) params.set('container', options.container)
+  if (options?.tailLines) params.set('tailLines', String(options.tailLines))
+  const queryString = params.toString()
+
+  return new EventSource(`${API_BASE}/workloads/${kind}/${namespace}/${name}/logs/stream${queryString ? `?${queryString}` : ''}`)
+}
+
 // ============================================================================
 // Port Forwarding
 // ============================================================================
@@ -617,6 +745,140 @@ export function useUpdateResource() {
   })
 }
 
+export interface BatchResourceRef {
+  kind: string
+  group?: string // API group for CRD disambiguation
+  namespace?: string // '_' or empty for cluster-scoped resources
+  name: string
+}
+
+export interface BatchResourceResult<T = unknown> extends BatchResourceRef {
+  resource?: T
+  error?: string
+  code?: string // e.g. NOT_FOUND
+}
+
+// Fetch several resources from the cache in one request
+export function useBatchResources<T = unknown>(refs: BatchResourceRef[], enabled = true) {
+  return useQuery<BatchResourceResult<T>[]>({
+    queryKey: ['resources-batch', refs],
+    queryFn: async () => {
+      const response = await fetch(`${API_BASE}/resources/batch`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ resources: refs }),
+      })
+      if (!response.ok) {
+        const error = await response.json().catch(() => ({ error: 'Unknown error' }))
+        throw new Error(error.error || `HTTP ${response.status}`)
+      }
+      const data: { resources: BatchResourceResult<T>[] } = await response.json()
+      return data.resources
+    },
+    enabled: enabled && refs.length > 0,
+  })
+}
+
+export interface GVRRef {
+  group: string // '' or 'core' for the core API group
+  version: string
+  resource: string // Plural name, e.g. 'rollouts'
+  namespace?: string // Omit for cluster-scoped resources or all namespaces
+}
+
+function gvrPath({ group, version, resource, namespace }: GVRRef): string {
+  const base = `/resources/gvr/${group || 'core'}/${version}`
+  return namespace ? `${base}/namespaces/${namespace}/${resource}` : `${base}/${resource}`
+}
+
+// List resources of any type by group/version/resource from the dynamic cache
+export function useGVRResources<T = unknown>(ref: GVRRef, labelSelector?: string, enabled = true) {
+  const params = labelSelector ? `?labelSelector=${encodeURIComponent(labelSelector)}` : ''
+  return useQuery<T[]>({
+    queryKey: ['gvr-resources', ref, labelSelector],
+    queryFn: () => fetchJSON(`${gvrPath(ref)}${params}`),
+    enabled,
+  })
+}
+
+// Watch resources of any type via SSE. Events: added, modified and deleted
+// carry the object; synced follows the existing objects; error means the
+// stream fell behind and the list should be refetched.
+//
+// With resourceVersion, only changes after that version are streamed (no
+// synced event), with bookmark events carrying newer versions; resync means
+// the version is too old and the client should relist or watch without one.
+export function createGVRWatch(ref: GVRRef, labelSelector?: string, resourceVersion?: string): EventSource {
+  const params = new URLSearchParams()
+  if (labelSelector) params.set('labelSelector', labelSelector)
+  if (resourceVersion) params.set('resourceVersion', resourceVersion)
+  const query = params.toString()
+  return new EventSource(`${API_BASE}${gvrPath(ref)}/watch${query ? `?${query}` : ''}`)
+}
+
+// One K8s Event from the cluster events feed
+export interface ClusterEvent {
+  uid: string
+  namespace: string
+  name: string
+  type: 'Normal' | 'Warning'
+  reason: string
+  message: string
+  count: number
+  firstTimestamp: string
+  lastTimestamp: string
+  involvedObject: { kind: string; namespace?: string; name: string; uid?: string }
+  repeat: boolean // Same uid as an event already received: update it in place
+}
+
+// Watch K8s events cluster-wide via SSE. Each event message carries a
+// ClusterEvent; dropped reports how many were lost under load.
+export function createClusterEventsWatch(namespace?: string, type?: 'Normal' | 'Warning', resume?: string): EventSource {
+  const params = new URLSearchParams()
+  if (namespace) params.set('namespace', namespace)
+  if (type) params.set('type', type)
+  if (resume) params.set('resume', resume) // lastEventId of a previous stream
+  const query = params.toString()
+  return new EventSource(`${API_BASE}/events/watch${query ? `?${query}` : ''}`)
+}
+
+// RFC 6902 JSON patch operation
+export interface JSONPatchOperation {
+  op: 'add' | 'remove' | 'replace' | 'move' | 'copy' | 'test'
+  path: string
+  value?: unknown
+  from?: string
+}
+
+// Apply a JSON patch to a resource, for precise edits without sending the whole object
+export function usePatchResource() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async ({ kind, namespace, name, patch }: { kind: string; namespace: string; name: string; patch: JSONPatchOperation[] }) => {
+      const response = await fetch(`${API_BASE}/resources/${kind}/${namespace}/${name}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json-patch+json' },
+        body: JSON.stringify(patch),
+      })
+      if (!response.ok) {
+        const error = await response.json().catch(() => ({ error: 'Unknown error' }))
+        throw new Error(error.error || `HTTP ${response.status}`)
+      }
+      return response.json()
+    },
+    meta: {
+      errorMessage: 'Failed to patch resource',
+      successMessage: 'Resource updated',
+    },
+    onSuccess: (_, variables) => {
+      queryClient.invalidateQueries({ queryKey: ['resource', variables.kind, variables.namespace, variables.name] })
+      queryClient.invalidateQueries({ queryKey: ['resources', variables.kind] })
+      queryClient.invalidateQueries({ queryKey: ['topology'] })
+    },
+  })
+}
+
 // Delete a resource
 export function useDeleteResource() {
   const queryClient = useQueryClient()
@@ -762,15 +1024,37 @@ export function useRestartWorkload() {
 // ============================================================================
 
 // List all Helm releases
-export function useHelmReleases(namespace?: string) {
-  const params = namespace ? `?namespace=${namespace}` : ''
+export interface HelmReleaseFilters {
+  status?: string[] // deployed, failed, pending (any pending-*), superseded, ...
+  chart?: string    // Chart name, case-insensitive
+  sort?: 'updated' | 'name' | 'revision' // Default: updated
+  order?: 'asc' | 'desc' // Default: desc, or asc when sorting by name
+  fields?: (keyof HelmRelease)[] // Only return these fields; health is skipped unless requested
+}
+
+export function useHelmReleases(namespace?: string, filters: HelmReleaseFilters = {}) {
+  const params = new URLSearchParams()
+  if (namespace) params.set('namespace', namespace)
+  if (filters.status?.length) params.set('status', filters.status.join(','))
+  if (filters.chart) params.set('chart', filters.chart)
+  if (filters.sort) params.set('sort', filters.sort)
+  if (filters.order) params.set('order', filters.order)
+  if (filters.fields?.length) params.set('fields', filters.fields.join(','))
+  const query = params.toString()
   return useQuery<HelmRelease[]>({
-    queryKey: ['helm-releases', namespace],
-    queryFn: () => fetchJSON(`/helm/releases${params}`),
+    queryKey: ['helm-releases', namespace, filters.status?.join(','), filters.chart, filters.sort, filters.order, filters.fields?.join(',')],
+    queryFn: () => fetchJSON(`/helm/releases${query ? `?${query}` : ''}`),
     staleTime: 30000, // 30 seconds
   })
 }
 
+// Follow the hooks of a release's newest revision via SSE while an upgrade,
+// install or rollback runs. Events: release, hook, pod, log, failed (a failed
+// hook pod's recent logs by container) and done, after which the stream ends.
+export function createHelmHooksWatch(namespace: string, name: string): EventSource {
+  return new EventSource(`${API_BASE}/helm/releases/${namespace}/${name}/hooks/watch`)
+}
+
 // Get details for a specific Helm release
 export function useHelmRelease(namespace: string, name: string) {
   return useQuery<HelmReleaseDetail>({
@@ -799,6 +1083,36 @@ export function useHelmManifest(namespace: string, name: string, revision?: numb
   })
 }
 
+// Fetch a plain-text field of a Helm release (notes, readme)
+async function fetchHelmReleaseText(namespace: string, name: string, field: 'notes' | 'readme', revision?: number): Promise<string> {
+  const params = revision ? `?revision=${revision}` : ''
+  const response = await fetch(`${API_BASE}/helm/releases/${namespace}/${name}/${field}${params}`)
+  if (!response.ok) {
+    throw await toApiError(response)
+  }
+  return response.text()
+}
+
+// Get rendered NOTES.txt for a Helm release (lazy-loaded by the Notes tab)
+export function useHelmNotes(namespace: string, name: string, revision?: number, enabled = true) {
+  return useQuery<string>({
+    queryKey: ['helm-notes', namespace, name, revision],
+    queryFn: () => fetchHelmReleaseText(namespace, name, 'notes', revision),
+    enabled: Boolean(namespace && name && enabled),
+    staleTime: 60000,
+  })
+}
+
+// Get the chart README for a Helm release (lazy-loaded by the README tab)
+export function useHelmReadme(namespace: string, name: string, revision?: number, enabled = true) {
+  return useQuery<string>({
+    queryKey: ['helm-readme', namespace, name, revision],
+    queryFn: () => fetchHelmReleaseText(namespace, name, 'readme', revision),
+    enabled: Boolean(namespace && name && enabled),
+    staleTime: 300000, // 5 minutes - README only changes on upgrade
+  })
+}
+
 // Get values for a Helm release
 export function useHelmValues(namespace: string, name: string, allValues?: boolean) {
   const params = allValues ? '?all=true' : ''
@@ -837,6 +1151,17 @@ export function useHelmUpgradeInfo(namespace: string, name: string, enabled = tr
   })
 }
 
+// Resolve the source of a release's chart and whether it is still reachable
+export function useHelmChartProvenance(namespace: string, name: string, enabled = true) {
+  return useQuery<ChartProvenance>({
+    queryKey: ['helm-provenance', namespace, name],
+    queryFn: () => fetchJSON(`/helm/releases/${namespace}/${name}/provenance`),
+    enabled: Boolean(namespace && name && enabled),
+    staleTime: 300000, // 5 minutes
+    retry: false,
+  })
+}
+
 // Batch check for upgrade availability (for list view)
 export function useHelmBatchUpgradeInfo(namespace?: string, enabled = true) {
   const params = namespace ? `?namespace=${namespace}` : ''
@@ -951,6 +1276,40 @@ export function useHelmPreviewValues() {
   })
 }
 
+// Preview a rollback (dry-run)
+export function useHelmPreviewRollback() {
+  return useMutation<RollbackPreview, Error, { namespace: string; name: string; revision: number }>({
+    mutationFn: async ({ namespace, name, revision }) => {
+      const response = await fetch(`${API_BASE}/helm/releases/${namespace}/${name}/rollback?revision=${revision}&dryRun=true`, {
+        method: 'POST',
+      })
+      if (!response.ok) {
+        const error = await response.json().catch(() => ({ error: 'Unknown error' }))
+        throw new Error(error.error || `HTTP ${response.status}`)
+      }
+      return response.json()
+    },
+  })
+}
+
+// Render a chart locally without installing it (like helm template)
+export function useHelmTemplate() {
+  return useMutation<HelmTemplateResponse, Error, HelmTemplateRequest>({
+    mutationFn: async (req) => {
+      const response = await fetch(`${API_BASE}/helm/template`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(req),
+      })
+      if (!response.ok) {
+        const error = await response.json().catch(() => ({ error: 'Unknown error' }))
+        throw new Error(error.error || `HTTP ${response.status}`)
+      }
+      return response.json()
+    },
+  })
+}
+
 // Apply new values to a release
 export function useHelmApplyValues() {
   const queryClient = useQueryClient()
@@ -1338,6 +1697,12 @@ export function useArgoRefresh() {
   })
 }
 
+// Watch an ArgoCD Application's sync/health/operation status via SSE.
+// Emits "status" (ArgoAppStatus) on each change and "deleted" if the app goes away.
+export function createArgoAppWatch(namespace: string, name: string): EventSource {
+  return new EventSource(`${API_BASE}/argo/applications/${namespace}/${name}/watch`)
+}
+
 // ============================================================================
 // Context Switching API hooks
 // ============================================================================
@@ -1408,7 +1773,43 @@ export function useSwitchContext() {
 // Image Filesystem Inspection
 // ============================================================================
 
-import type { ImageFilesystem, ImageMetadata } from '../types'
+import type { BatchImageMetadataResult, BatchImageRef, ClusterImage, ContainerStartup, ImageAuthCheck, ImageCacheStatus, ImageFileDiff, ImageFilesystem, ImageGrepResult, ImageLayers, ImageMetadata, LayerFilesystem, NamespaceImageReport, PodImageDrift, PodImages, RegistryRateLimit, ResolvedImageReference } from '../types'
+
+// List distinct images running in the cluster
+// withWorkloads groups each image's pods by the workload running them
+export function useClusterImages(namespace?: string, withWorkloads = false) {
+  const params = new URLSearchParams()
+  if (namespace) params.set('namespace', namespace)
+  if (withWorkloads) params.set('workloads', 'true')
+  const queryString = params.toString()
+  return useQuery<ClusterImage[]>({
+    queryKey: ['cluster-images', namespace, withWorkloads],
+    queryFn: () => fetchJSON(`/images${queryString ? `?${queryString}` : ''}`),
+    staleTime: 30000,
+  })
+}
+
+// List the images of all of a pod's containers, including init and ephemeral ones
+export function usePodImages(namespace: string, podName: string, enabled = true) {
+  return useQuery<PodImages>({
+    queryKey: ['pod-images', namespace, podName],
+    queryFn: () => fetchJSON(`/images/pod?namespace=${encodeURIComponent(namespace)}&pod=${encodeURIComponent(podName)}`),
+    enabled: enabled && Boolean(namespace && podName),
+    staleTime: 30000,
+  })
+}
+
+// Resolve what a pod's container actually runs: image ENTRYPOINT/CMD/env combined with the pod spec
+export function useContainerStartup(namespace: string, podName: string, container: string, enabled = true) {
+  const params = new URLSearchParams({ namespace, pod: podName })
+  if (container) params.set('container', container)
+  return useQuery<ContainerStartup>({
+    queryKey: ['container-startup', namespace, podName, container],
+    queryFn: () => fetchJSON(`/images/startup?${params.toString()}`),
+    enabled: enabled && Boolean(namespace && podName),
+    staleTime: 60000,
+  })
+}
 
 // Fetch image metadata (lightweight, checks if cached)
 export function useImageMetadata(
@@ -1416,16 +1817,18 @@ export function useImageMetadata(
   namespace: string,
   podName: string,
   pullSecrets: string[],
-  enabled = true
+  enabled = true,
+  running = false // Inspect the digest the pod is running instead of the tag
 ) {
   const params = new URLSearchParams()
   params.set('image', image)
   if (namespace) params.set('namespace', namespace)
   if (podName) params.set('pod', podName)
   if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+  if (running) params.set('running', 'true')
 
   return useQuery<ImageMetadata>({
-    queryKey: ['image-metadata', image, namespace, podName, pullSecrets.join(',')],
+    queryKey: ['image-metadata', image, namespace, podName, pullSecrets.join(','), running],
     queryFn: () => fetchJSON(`/images/metadata?${params.toString()}`),
     enabled: enabled && Boolean(image),
     staleTime: 60000, // 1 minute - metadata is lightweight
@@ -1433,24 +1836,286 @@ export function useImageMetadata(
   })
 }
 
+// Fetch metadata for many images in one request, e.g. for a grid of image cards
+export function useImageMetadataBatch(refs: BatchImageRef[], enabled = true) {
+  return useQuery<BatchImageMetadataResult[]>({
+    queryKey: ['image-metadata-batch', refs],
+    queryFn: async () => {
+      const response = await fetch(`${API_BASE}/images/metadata/batch`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ images: refs }),
+      })
+      if (!response.ok) {
+        throw await toApiError(response)
+      }
+      const data: { images: BatchImageMetadataResult[] } = await response.json()
+      return data.images
+    },
+    enabled: enabled && refs.length > 0,
+    staleTime: 60000,
+    retry: false,
+  })
+}
+
+// Search the content of an image's text files for a regex (downloads layers if not cached)
+export function useImageGrep(
+  image: string,
+  namespace: string,
+  podName: string,
+  pullSecrets: string[],
+  pattern: string,
+  ignoreCase = false,
+  enabled = true
+) {
+  const params = new URLSearchParams()
+  params.set('image', image)
+  params.set('pattern', pattern)
+  if (ignoreCase) params.set('ignoreCase', 'true')
+  if (namespace) params.set('namespace', namespace)
+  if (podName) params.set('pod', podName)
+  if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+
+  return useQuery<ImageGrepResult>({
+    queryKey: ['image-grep', image, namespace, podName, pullSecrets.join(','), pattern, ignoreCase],
+    queryFn: () => fetchJSON(`/images/grep?${params.toString()}`),
+    enabled: enabled && Boolean(image && pattern),
+    staleTime: 300000, // 5 minutes - image content doesn't change for a digest
+    retry: false,
+  })
+}
+
+// Explain which credentials were tried for an image, e.g. after an inspect 401
+export function useImageAuthCheck(
+  image: string,
+  namespace: string,
+  podName: string,
+  pullSecrets: string[],
+  enabled = true
+) {
+  const params = new URLSearchParams()
+  params.set('image', image)
+  if (namespace) params.set('namespace', namespace)
+  if (podName) params.set('pod', podName)
+  if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+
+  return useQuery<ImageAuthCheck>({
+    queryKey: ['image-auth-check', image, namespace, podName, pullSecrets.join(',')],
+    queryFn: () => fetchJSON(`/images/auth-check?${params.toString()}`),
+    enabled: enabled && Boolean(image),
+    staleTime: 30000,
+    retry: false,
+  })
+}
+
+// Get an image's layers ranked by size with the build step that created each
+export function useImageLayers(
+  image: string,
+  namespace: string,
+  podName: string,
+  pullSecrets: string[],
+  enabled = true,
+  running = false
+) {
+  const params = new URLSearchParams()
+  params.set('image', image)
+  if (namespace) params.set('namespace', namespace)
+  if (podName) params.set('pod', podName)
+  if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+  if (running) params.set('running', 'true')
+
+  return useQuery<ImageLayers>({
+    queryKey: ['image-layers', image, namespace, podName, pullSecrets.join(','), running],
+    queryFn: () => fetchJSON(`/images/layers?${params.toString()}`),
+    enabled: enabled && Boolean(image),
+    staleTime: 60000,
+    retry: false,
+  })
+}
+
+// Get the file tree of a single layer, i.e. what one build step changed (downloads layers if not cached)
+export function useImageLayerTree(
+  image: string,
+  index: number,
+  namespace: string,
+  podName: string,
+  pullSecrets: string[],
+  enabled = true
+) {
+  const params = new URLSearchParams()
+  params.set('image', image)
+  params.set('index', String(index))
+  if (namespace) params.set('namespace', namespace)
+  if (podName) params.set('pod', podName)
+  if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+
+  return useQuery<LayerFilesystem>({
+    queryKey: ['image-layer-tree', image, index, namespace, podName, pullSecrets.join(',')],
+    queryFn: () => fetchJSON(`/images/layer?${params.toString()}`),
+    enabled: enabled && Boolean(image) && index >= 0,
+    staleTime: 60000,
+    retry: false,
+  })
+}
+
+// Image layer cache status
+export function useImageCacheStatus() {
+  return useQuery<ImageCacheStatus>({
+    queryKey: ['image-cache'],
+    queryFn: () => fetchJSON('/images/cache'),
+    staleTime: 10000,
+  })
+}
+
+// Pull budgets registries last reported, to explain throttled inspections
+export function useRegistryRateLimits(enabled = true) {
+  return useQuery<{ registries: RegistryRateLimit[] }>({
+    queryKey: ['image-rate-limits'],
+    queryFn: () => fetchJSON('/images/rate-limits'),
+    staleTime: 10000,
+    enabled,
+  })
+}
+
+// Remove all cached image layers
+export function useClearImageCache() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async () => {
+      const response = await fetch(`${API_BASE}/images/cache`, { method: 'DELETE' })
+      if (!response.ok) {
+        throw await toApiError(response)
+      }
+      return response.json() as Promise<ImageCacheStatus>
+    },
+    meta: {
+      errorMessage: 'Failed to clear image cache',
+      successMessage: 'Image cache cleared',
+    },
+    onSuccess: (status) => {
+      queryClient.setQueryData(['image-cache'], status)
+    },
+  })
+}
+
+// View a text file from an image inline (downloads layers if not cached).
+// Files over 1 MiB fail with IMAGE_FILE_TOO_LARGE and binary files with
+// IMAGE_BINARY_FILE; offer a download for those instead.
+export function useImageFileView(
+  image: string,
+  path: string,
+  namespace: string,
+  podName: string,
+  pullSecrets: string[],
+  enabled = true
+) {
+  const params = new URLSearchParams()
+  params.set('image', image)
+  params.set('path', path)
+  if (namespace) params.set('namespace', namespace)
+  if (podName) params.set('pod', podName)
+  if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+
+  return useQuery<string>({
+    queryKey: ['image-file-view', image, path, namespace, podName, pullSecrets.join(',')],
+    queryFn: async () => {
+      const response = await fetch(`${API_BASE}/images/view?${params.toString()}`)
+      if (!response.ok) {
+        throw await toApiError(response)
+      }
+      return response.text()
+    },
+    enabled: enabled && Boolean(image && path),
+    staleTime: 60000,
+    retry: false,
+  })
+}
+
+// Diff one file's contents between two images (downloads layers if not cached)
+export function useImageFileDiff(
+  from: string,
+  to: string,
+  path: string,
+  namespace: string,
+  podName: string,
+  pullSecrets: string[],
+  enabled = true
+) {
+  const params = new URLSearchParams()
+  params.set('from', from)
+  params.set('to', to)
+  params.set('path', path)
+  if (namespace) params.set('namespace', namespace)
+  if (podName) params.set('pod', podName)
+  if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+
+  return useQuery<ImageFileDiff>({
+    queryKey: ['image-file-diff', from, to, path, namespace, podName, pullSecrets.join(',')],
+    queryFn: () => fetchJSON(`/images/file/diff?${params.toString()}`),
+    enabled: enabled && Boolean(from && to && path),
+    staleTime: 60000,
+    retry: false,
+  })
+}
+
+// Normalize an image reference and resolve its tag to the current digest
+export function useImageResolve(image: string, namespace: string, podName: string, enabled = true) {
+  const params = new URLSearchParams()
+  params.set('image', image)
+  if (namespace) params.set('namespace', namespace)
+  if (podName) params.set('pod', podName)
+
+  return useQuery<ResolvedImageReference>({
+    queryKey: ['image-resolve', image, namespace, podName],
+    queryFn: () => fetchJSON(`/images/resolve?${params.toString()}`),
+    enabled: enabled && Boolean(image),
+    staleTime: 60000, // Tags can move, so don't keep the digest for long
+    retry: false,
+  })
+}
+
+// Compare a pod's running image digests to the registry's current tags
+export function usePodImageDrift(namespace: string, podName: string, enabled = true) {
+  return useQuery<PodImageDrift>({
+    queryKey: ['image-drift', namespace, podName],
+    queryFn: () => fetchJSON(`/images/drift?namespace=${encodeURIComponent(namespace)}&pod=${encodeURIComponent(podName)}`),
+    enabled: enabled && Boolean(namespace && podName),
+    staleTime: 60000,
+    retry: false,
+  })
+}
+
+// Compare the image digests pods run within each workload of a namespace
+export function useNamespaceImageMismatches(namespace: string, enabled = true) {
+  return useQuery<NamespaceImageReport>({
+    queryKey: ['image-mismatches', namespace],
+    queryFn: () => fetchJSON(`/images/mismatches?namespace=${encodeURIComponent(namespace)}`),
+    enabled: enabled && Boolean(namespace),
+    staleTime: 30000,
+  })
+}
+
 // Fetch full image filesystem (downloads layers if not cached)
 export function useImageFilesystem(
   image: string,
   namespace: string,
   podName: string,
   pullSecrets: string[],
-  enabled = true
+  enabled = true,
+  running = false // Inspect the digest the pod is running instead of the tag
 ) {
   const params = new URLSearchParams()
   params.set('image', image)
   if (namespace) params.set('namespace', namespace)
   if (podName) params.set('pod', podName)
   if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+  if (running) params.set('running', 'true')
 
   const shouldFetch = enabled && Boolean(image)
 
   return useQuery<ImageFilesystem>({
-    queryKey: ['image-filesystem', image, namespace, podName, pullSecrets.join(',')],
+    queryKey: ['image-filesystem', image, namespace, podName, pullSecrets.join(','), running],
     // Use skipToken to completely prevent the query from running when disabled
     queryFn: shouldFetch
       ? () => fetchJSON(`/images/inspect?${params.toString()}`)
@@ -0,0 +1,19 @@
+// Error thrown for non-2xx API responses. `code` is the machine-readable code
+// from the response body (e.g. "IMAGE_UNAUTHORIZED", "CACHE_SYNCING").
+export class ApiError extends Error {
+  readonly status: number
+  readonly code?: string
+
+  constructor(message: string, status: number, code?: string) {
+    super(message)
+    this.name = 'ApiError'
+    this.status = status
+    this.code = code
+  }
+}
+
+// Builds an ApiError from a failed response, tolerating non-JSON bodies
+export async function toApiError(response: Response, fallback = `HTTP ${response.status}`): Promise<ApiError> {
+  const body = await response.json().catch(() => ({}))
+  return new ApiError(body.error || fallback, response.status, body.code)
+}
@@ -1,13 +1,12 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
-import type { TrafficSourcesResponse, TrafficFlowsResponse } from '../types'
-
-const API_BASE = '/api'
+import type { TrafficSourcesResponse, TrafficFlowsResponse, CiliumIdentities, FlowFilterSpec, WorkloadTrafficResponse } from '../types'
+import { toApiError } from './errors'
+import { API_BASE } from '../utils/base-path'
 
 async function fetchJSON<T>(path: string): Promise<T> {
   const response = await fetch(`${API_BASE}${path}`)
   if (!response.ok) {
-    const error = await response.json().catch(() => ({ error: 'Unknown error' }))
-    throw new Error(error.error || `HTTP ${response.status}`)
+    throw await toApiError(response)
   }
   return response.json()
 }
@@ -37,26 +36,94 @@ export function useTrafficSources() {
 export interface UseTrafficFlowsOptions {
   namespace?: string
   since?: string // Duration like "5m", "1h"
+  exclude?: string // Noise exclusions, e.g. "kube-system,dns" or "none" (default: server's --traffic-exclude)
+  limit?: number // Max flows (default: server's --traffic-flow-limit; clamped to its maximum)
+  filter?: FlowFilterSpec // Structured filter, sent as a POST body (Hubble only)
   enabled?: boolean
 }
 
+async function postFlows(body: object): Promise<TrafficFlowsResponse> {
+  const response = await fetch(`${API_BASE}/traffic/flows`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(body),
+  })
+  if (!response.ok) {
+    throw await toApiError(response)
+  }
+  return response.json()
+}
+
 export function useTrafficFlows(options: UseTrafficFlowsOptions = {}) {
-  const { namespace, since, enabled = true } = options
+  const { namespace, since, exclude, limit, filter, enabled = true } = options
 
   const params = new URLSearchParams()
   if (namespace) params.set('namespace', namespace)
   if (since) params.set('since', since)
+  if (exclude) params.set('exclude', exclude)
+  if (limit) params.set('limit', String(limit))
   const queryString = params.toString()
 
   return useQuery<TrafficFlowsResponse>({
-    queryKey: ['traffic-flows', namespace, since],
-    queryFn: () => fetchJSON(`/traffic/flows${queryString ? `?${queryString}` : ''}`),
+    queryKey: ['traffic-flows', namespace, since, exclude, limit, filter],
+    queryFn: () => filter
+      ? postFlows({ namespace, since, exclude, limit, filter })
+      : fetchJSON(`/traffic/flows${queryString ? `?${queryString}` : ''}`),
     staleTime: 5000, // 5 seconds
     enabled,
     retry: 1,
   })
 }
 
+// Top talkers and drops for the pods of a Deployment, StatefulSet, DaemonSet or Service
+export function useWorkloadTraffic(kind: string, namespace: string, name: string, since = '15m', enabled = true) {
+  return useQuery<WorkloadTrafficResponse>({
+    queryKey: ['workload-traffic', kind, namespace, name, since],
+    queryFn: () => fetchJSON(`/traffic/workloads/${kind}/${namespace}/${name}?since=${encodeURIComponent(since)}`),
+    staleTime: 15000,
+    enabled: enabled && Boolean(kind && namespace && name),
+    retry: false,
+  })
+}
+
+// List Cilium identities (id -> labels) and endpoints, for pickers and decoding identity numbers
+export function useCiliumIdentities(namespace?: string, enabled = true) {
+  const params = namespace ? `?namespace=${encodeURIComponent(namespace)}` : ''
+  return useQuery<CiliumIdentities>({
+    queryKey: ['traffic-identities', namespace],
+    queryFn: () => fetchJSON(`/traffic/identities${params}`),
+    staleTime: 60000, // Server caches for a minute
+    enabled,
+  })
+}
+
+export interface TrafficFlowStreamOptions {
+  namespace?: string
+  since?: string // Duration like "5m": replay recent flows before following
+  sinceTime?: string // RFC 3339 alternative to since
+  exclude?: string // Noise exclusions, e.g. "kube-system,dns" or "none" (default: server's --traffic-exclude)
+  backpressure?: 'drop-newest' | 'drop-oldest' | 'block' // When the client falls behind (default: drop-newest)
+  blockTimeout?: string // For block, e.g. "5s"
+  resume?: string // lastEventId of a previous stream, to continue after its last flow
+}
+
+// Stream traffic flows via SSE. flow events carry a Flow; dropped events
+// carry { dropped: number }, the running total of flows lost to backpressure.
+// EventSource resumes on its own after a network error; pass resume when
+// opening a new stream to pick up where an old one stopped.
+export function createTrafficFlowStream(options: TrafficFlowStreamOptions = {}): EventSource {
+  const params = new URLSearchParams()
+  if (options.namespace) params.set('namespace', options.namespace)
+  if (options.since) params.set('since', options.since)
+  if (options.sinceTime) params.set('sinceTime', options.sinceTime)
+  if (options.exclude) params.set('exclude', options.exclude)
+  if (options.backpressure) params.set('backpressure', options.backpressure)
+  if (options.blockTimeout) params.set('blockTimeout', options.blockTimeout)
+  if (options.resume) params.set('resume', options.resume)
+  const queryString = params.toString()
+  return new EventSource(`${API_BASE}/traffic/flows/stream${queryString ? `?${queryString}` : ''}`)
+}
+
 // Get active traffic source
 export function useActiveTrafficSource() {
   return useQuery<{ active: string }>({
@@ -109,6 +176,46 @@ export function useTrafficConnectionStatus() {
   })
 }
 
+// Combined status for the Traffic view
+export interface TrafficStatus {
+  source?: string // Active source (empty = none detected)
+  available: boolean
+  detection?: {
+    available: boolean
+    version?: string
+    native: boolean
+    message?: string
+  }
+  connection: TrafficConnectionInfo
+  relay?: {
+    namespace: string
+    port?: number
+    tls: boolean
+  }
+  server?: {
+    version?: string
+    numFlows: number
+    maxFlows: number
+    seenFlows: number
+    flowsRate: number
+    uptimeSeconds: number
+    connectedNodes?: number
+    unavailableNodes?: string[]
+  }
+  instructions?: string // Manual access steps, when not connected
+  message?: string
+}
+
+// Get everything needed to render the Traffic view in one request
+export function useTrafficStatus() {
+  return useQuery<TrafficStatus>({
+    queryKey: ['traffic-status'],
+    queryFn: () => fetchJSON('/traffic/status'),
+    staleTime: 5000, // 5 seconds
+    retry: 1,
+  })
+}
+
 // Connect to traffic source (starts port-forward if needed)
 export function useTrafficConnect() {
   const queryClient = useQueryClient()
@@ -129,6 +236,7 @@ export function useTrafficConnect() {
       // Invalidate flows to refetch with new connection
       queryClient.invalidateQueries({ queryKey: ['traffic-flows'] })
       queryClient.invalidateQueries({ queryKey: ['traffic-connection'] })
+      queryClient.invalidateQueries({ queryKey: ['traffic-status'] })
     },
   })
 }
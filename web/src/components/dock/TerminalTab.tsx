@@ -6,6 +6,7 @@ import '@xterm/xterm/css/xterm.css'
 import { RefreshCw, ChevronDown } from 'lucide-react'
 import { clsx } from 'clsx'
 import { Tooltip } from '../ui/Tooltip'
+import { API_BASE } from '../../utils/base-path'
 
 interface TerminalTabProps {
   namespace: string
@@ -110,7 +111,7 @@ export function TerminalTab({
 
     // Connect WebSocket
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-    const wsUrl = `${protocol}//${window.location.host}/api/pods/${namespace}/${podName}/exec?container=${selectedContainer}`
+    const wsUrl = `${protocol}//${window.location.host}${API_BASE}/pods/${namespace}/${podName}/exec?container=${selectedContainer}`
 
     const ws = new WebSocket(wsUrl)
     wsRef.current = ws
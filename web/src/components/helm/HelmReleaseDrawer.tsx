@@ -2,10 +2,10 @@ import { useState, useCallback, useEffect, useRef } from 'react'
 import { useRefreshAnimation } from '../../hooks/useRefreshAnimation'
 import { X, Copy, Check, RefreshCw, Package, Code, History, FileText, Settings, Link2, Anchor, GitFork, BookOpen, ArrowUpCircle, Trash2 } from 'lucide-react'
 import { clsx } from 'clsx'
-import { useHelmRelease, useHelmManifest, useHelmValues, useHelmManifestDiff, useHelmUpgradeInfo, useHelmRollback, useHelmUninstall, useHelmUpgrade } from '../../api/client'
+import { useHelmRelease, useHelmManifest, useHelmValues, useHelmManifestDiff, useHelmUpgradeInfo, useHelmChartProvenance, useHelmRollback, useHelmUninstall, useHelmUpgrade } from '../../api/client'
 import { ConfirmDialog } from '../ui/ConfirmDialog'
 import { Markdown } from '../ui/Markdown'
-import type { SelectedHelmRelease, HelmHook, ChartDependency } from '../../types'
+import type { SelectedHelmRelease, HelmHook, ChartDependency, ChartProvenance } from '../../types'
 import { formatDate } from './helm-utils'
 import { getHelmStatusColor } from '../../utils/badge-colors'
 import { RevisionHistory } from './RevisionHistory'
@@ -74,6 +74,12 @@ export function HelmReleaseDrawer({ release, onClose, onNavigateToResource }: He
     release.name
   )
 
+  const { data: provenance } = useHelmChartProvenance(
+    release.namespace,
+    release.name,
+    activeTab === 'overview'
+  )
+
   // Mutations for actions
   const rollbackMutation = useHelmRollback()
   const uninstallMutation = useHelmUninstall()
@@ -316,7 +322,7 @@ export function HelmReleaseDrawer({ release, onClose, onNavigateToResource }: He
         ) : (
           <>
             {activeTab === 'overview' && (
-              <OverviewTab release={releaseDetail} onCopy={copyToClipboard} copied={copied} />
+              <OverviewTab release={releaseDetail} provenance={provenance} onCopy={copyToClipboard} copied={copied} />
             )}
             {activeTab === 'history' && (
               <RevisionHistory
@@ -429,11 +435,12 @@ interface OverviewTabProps {
     readme?: string
     dependencies?: ChartDependency[]
   }
+  provenance?: ChartProvenance
   onCopy: (text: string, key: string) => void
   copied: string | null
 }
 
-function OverviewTab({ release, onCopy, copied }: OverviewTabProps) {
+function OverviewTab({ release, provenance, onCopy, copied }: OverviewTabProps) {
   return (
     <div className="p-4 space-y-4">
       {/* Chart info */}
@@ -463,6 +470,9 @@ function OverviewTab({ release, onCopy, copied }: OverviewTabProps) {
         </dl>
       </div>
 
+      {/* Chart source */}
+      {provenance && <ChartSourceSection provenance={provenance} />}
+
       {/* Description */}
       {release.description && (
         <div className="bg-theme-elevated/30 rounded-lg p-4">
@@ -548,6 +558,44 @@ function OverviewTab({ release, onCopy, copied }: OverviewTabProps) {
   )
 }
 
+// Where the chart came from, and whether it can still be fetched from there
+function ChartSourceSection({ provenance }: { provenance: ChartProvenance }) {
+  const unreachable = provenance.reachability && !provenance.reachability.reachable
+  const others = provenance.candidates.filter((c) => c !== provenance.resolved && c.repository !== provenance.resolved?.repository)
+  return (
+    <div className="bg-theme-elevated/30 rounded-lg p-4">
+      <div className="flex items-center justify-between mb-2">
+        <div className="flex items-center gap-2">
+          <Link2 className="w-4 h-4 text-theme-text-secondary" />
+          <h3 className="text-sm font-medium text-theme-text-secondary">Chart Source</h3>
+        </div>
+        <span className={clsx(
+          'px-1.5 py-0.5 text-xs rounded',
+          provenance.sourceType === 'unknown' || unreachable
+            ? 'bg-yellow-500/20 text-yellow-400'
+            : 'bg-green-500/20 text-green-400'
+        )}>
+          {provenance.sourceType === 'flux' ? 'Flux' : provenance.resolved ? provenance.resolved.repository : 'unknown'}
+        </span>
+      </div>
+      <p className="text-sm text-theme-text-secondary">{provenance.message}</p>
+      {provenance.resolved?.chartUrl && (
+        <p className="mt-2 text-xs text-theme-text-tertiary font-mono break-all">{provenance.resolved.chartUrl}</p>
+      )}
+      {others.length > 0 && (
+        <div className="mt-2 text-xs text-theme-text-tertiary">
+          Also in: {others.map((c) => `${c.repository}${c.hasVersion ? '' : ` (up to ${c.latestVersion})`}`).join(', ')}
+        </div>
+      )}
+      {provenance.sources && provenance.sources.length > 0 && (
+        <div className="mt-2 text-xs text-theme-text-tertiary break-all">
+          Sources: {provenance.sources.join(', ')}
+        </div>
+      )}
+    </div>
+  )
+}
+
 // Hooks tab content
 interface HooksTabProps {
   hooks: HelmHook[]
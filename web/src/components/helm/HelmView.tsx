@@ -279,7 +279,7 @@ function ReleaseRow({ release, upgradeInfo, isSelected, onClick }: ReleaseRowPro
     }
 
     const style = healthStyles[release.resourceHealth] || healthStyles.healthy
-    const tooltipContent = getActionableTooltip(release.healthIssue, release.healthSummary, release.resourceHealth)
+    const tooltipContent = getActionableTooltip(release.healthIssue, release.healthMessage || release.healthSummary, release.resourceHealth)
 
     return (
       <Tooltip content={tooltipContent}>
@@ -10,6 +10,7 @@ import { useOpenTerminal, useOpenLogs } from '../dock'
 import { useStartPortForward } from '../portforward/PortForwardManager'
 import { useAvailablePorts } from '../../api/client'
 import { useCanExec, useCanViewLogs, useCanPortForward } from '../../contexts/CapabilitiesContext'
+import { API_BASE } from '../../utils/base-path'
 
 interface OwnedResourcesProps {
   resources: HelmOwnedResource[]
@@ -304,7 +305,7 @@ function PodQuickActions({ namespace, podName, isRunning }: PodQuickActionsProps
     return queryClient.fetchQuery({
       queryKey: ['resource', 'pods', namespace, podName],
       queryFn: async () => {
-        const response = await fetch(`/api/resources/pods/${namespace}/${podName}`)
+        const response = await fetch(`${API_BASE}/resources/pods/${namespace}/${podName}`)
         if (!response.ok) throw new Error('Failed to fetch pod')
         return response.json()
       },
@@ -13,6 +13,7 @@ import {
   Plug,
 } from 'lucide-react'
 import { clsx } from 'clsx'
+import { API_BASE } from '../../utils/base-path'
 
 interface PortForwardSession {
   id: string
@@ -44,7 +45,7 @@ export function PortForwardManager({
   const { data: sessions = [], isLoading } = useQuery<PortForwardSession[]>({
     queryKey: ['portforwards'],
     queryFn: async () => {
-      const res = await fetch('/api/portforwards')
+      const res = await fetch(`${API_BASE}/portforwards`)
       if (!res.ok) throw new Error('Failed to fetch port forwards')
       return res.json()
     },
@@ -54,7 +55,7 @@ export function PortForwardManager({
   // Stop port forward mutation
   const stopMutation = useMutation({
     mutationFn: async (id: string) => {
-      const res = await fetch(`/api/portforwards/${id}`, { method: 'DELETE' })
+      const res = await fetch(`${API_BASE}/portforwards/${id}`, { method: 'DELETE' })
       if (!res.ok) throw new Error('Failed to stop port forward')
       return res.json()
     },
@@ -243,7 +244,7 @@ export function useStartPortForward() {
       podPort: number
       localPort?: number
     }) => {
-      const res = await fetch('/api/portforwards', {
+      const res = await fetch(`${API_BASE}/portforwards`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(req),
@@ -269,7 +270,7 @@ export function usePortForwardCount() {
   const { data: sessions = [] } = useQuery<PortForwardSession[]>({
     queryKey: ['portforwards'],
     queryFn: async () => {
-      const res = await fetch('/api/portforwards')
+      const res = await fetch(`${API_BASE}/portforwards`)
       if (!res.ok) return []
       return res.json()
     },
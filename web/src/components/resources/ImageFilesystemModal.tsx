@@ -1,32 +1,65 @@
 import { useState, useRef, useEffect, useMemo, useCallback } from 'react'
 import { X, Folder, File, Link2, ChevronRight, ChevronDown, AlertTriangle, Loader2, Search, Download, HardDrive, Shield, ShieldCheck, Terminal, Copy, Check } from 'lucide-react'
 import { clsx } from 'clsx'
-import { useImageMetadata } from '../../api/client'
-import type { FileNode, ImageFilesystem } from '../../types'
+import { useImageAuthCheck, useImageGrep, useImageMetadata } from '../../api/client'
+import type { FileNode, ImageAuthCheck, ImageFilesystem, ImageGrepResult } from '../../types'
+import { ApiError, toApiError } from '../../api/errors'
+import { API_BASE } from '../../utils/base-path'
 
-const API_BASE = '/api'
-
-// Manual fetch function for filesystem (not a hook - gives us full control)
-async function fetchImageFilesystem(
+// Query parameters identifying the image and the credentials to pull it with
+function imageRequestParams(
   image: string,
   namespace: string,
   podName: string,
   pullSecrets: string[]
-): Promise<ImageFilesystem> {
+): URLSearchParams {
   const params = new URLSearchParams()
   params.set('image', image)
   if (namespace) params.set('namespace', namespace)
   if (podName) params.set('pod', podName)
   if (pullSecrets.length > 0) params.set('pullSecrets', pullSecrets.join(','))
+  return params
+}
 
+// Manual fetch function for filesystem (not a hook - gives us full control)
+async function fetchImageFilesystem(
+  image: string,
+  namespace: string,
+  podName: string,
+  pullSecrets: string[]
+): Promise<ImageFilesystem> {
+  const params = imageRequestParams(image, namespace, podName, pullSecrets)
   const response = await fetch(`${API_BASE}/images/inspect?${params.toString()}`)
   if (!response.ok) {
-    const error = await response.json().catch(() => ({ error: 'Request failed' }))
-    throw new Error(error.error || `HTTP ${response.status}`)
+    throw await toApiError(response, 'Request failed')
   }
   return response.json()
 }
 
+// Headline for an inspect failure, based on the API error code
+function inspectErrorTitle(error: unknown): string {
+  switch (error instanceof ApiError ? error.code : undefined) {
+    case 'IMAGE_UNAUTHORIZED':
+      return 'Registry authentication required'
+    case 'IMAGE_NOT_FOUND':
+      return 'Image not found'
+    case 'IMAGE_REGISTRY_BLOCKED':
+      return 'Registry not allowed'
+    case 'IMAGE_REGISTRY_THROTTLED':
+      return 'Registry rate limit reached'
+    case 'IMAGE_TOO_LARGE':
+      return 'Image too large to inspect'
+    case 'IMAGE_PULL_TIMEOUT':
+      return 'Registry too slow to respond'
+    case 'IMAGE_INSPECT_TIMEOUT':
+      return 'Image inspection timed out'
+    case 'IMAGE_NOT_CONTAINER':
+      return 'Not a container image'
+    default:
+      return 'Failed to inspect image'
+  }
+}
+
 interface ImageFilesystemModalProps {
   open: boolean
   onClose: () => void
@@ -46,6 +79,9 @@ export function ImageFilesystemModal({
 }: ImageFilesystemModalProps) {
   const dialogRef = useRef<HTMLDivElement>(null)
   const [searchQuery, setSearchQuery] = useState('')
+  // Content search runs on Enter, against the pattern submitted
+  const [searchContents, setSearchContents] = useState(false)
+  const [grepPattern, setGrepPattern] = useState('')
 
   // Manual fetch state (no automatic React Query fetching)
   const [filesystem, setFilesystem] = useState<ImageFilesystem | null>(null)
@@ -59,6 +95,18 @@ export function ImageFilesystemModal({
     error: metadataError
   } = useImageMetadata(image, namespace, podName, pullSecrets, open)
 
+  // On an auth failure, ask the server which credentials it tried
+  const authFailed = [metadataError, filesystemError].some(
+    (e) => e instanceof ApiError && e.code === 'IMAGE_UNAUTHORIZED'
+  )
+  const { data: authCheck } = useImageAuthCheck(image, namespace, podName, pullSecrets, open && authFailed)
+
+  const {
+    data: grepResult,
+    isFetching: isGrepping,
+    error: grepError,
+  } = useImageGrep(image, namespace, podName, pullSecrets, grepPattern, true, open && searchContents)
+
   // Use cached filesystem from metadata if available
   const displayFilesystem: ImageFilesystem | undefined = metadata?.cached
     ? metadata.filesystem
@@ -82,6 +130,8 @@ export function ImageFilesystemModal({
   useEffect(() => {
     if (!open) {
       setSearchQuery('')
+      setSearchContents(false)
+      setGrepPattern('')
       setFilesystem(null)
       setFilesystemError(null)
       setIsLoadingFilesystem(false)
@@ -137,9 +187,20 @@ export function ImageFilesystemModal({
               </p>
             )}
           </div>
+          {showFilesystem && (
+            <a
+              href={`${API_BASE}/images/export?${imageRequestParams(image, namespace, podName, pullSecrets).toString()}`}
+              download
+              className="flex items-center gap-1.5 px-3 py-1.5 text-sm text-theme-text-secondary hover:text-theme-text-primary hover:bg-theme-elevated rounded ml-4"
+              title="Download the merged filesystem as a tar archive"
+            >
+              <Download className="w-4 h-4" />
+              Export .tar
+            </a>
+          )}
           <button
             onClick={onClose}
-            className="p-2 text-theme-text-secondary hover:text-theme-text-primary hover:bg-theme-elevated rounded ml-4"
+            className="p-2 text-theme-text-secondary hover:text-theme-text-primary hover:bg-theme-elevated rounded ml-2"
           >
             <X className="w-5 h-5" />
           </button>
@@ -148,15 +209,35 @@ export function ImageFilesystemModal({
         {/* Search bar - only show when filesystem is loaded */}
         {showFilesystem && (
           <div className="p-3 border-b border-theme-border shrink-0">
-            <div className="relative">
-              <Search className="absolute left-3 top-1/2 -translate-y-1/2 w-4 h-4 text-theme-text-tertiary" />
-              <input
-                type="text"
-                placeholder="Search files..."
-                value={searchQuery}
-                onChange={(e) => setSearchQuery(e.target.value)}
-                className="w-full pl-10 pr-4 py-2 bg-theme-base border border-theme-border rounded-lg text-sm text-theme-text-primary placeholder-theme-text-tertiary focus:outline-none focus:ring-2 focus:ring-blue-500"
-              />
+            <div className="flex items-center gap-2">
+              <div className="relative flex-1">
+                <Search className="absolute left-3 top-1/2 -translate-y-1/2 w-4 h-4 text-theme-text-tertiary" />
+                <input
+                  type="text"
+                  placeholder={searchContents ? 'Search file contents (regex), press Enter...' : 'Search files...'}
+                  value={searchQuery}
+                  onChange={(e) => setSearchQuery(e.target.value)}
+                  onKeyDown={(e) => {
+                    if (searchContents && e.key === 'Enter') setGrepPattern(searchQuery.trim())
+                  }}
+                  className="w-full pl-10 pr-4 py-2 bg-theme-base border border-theme-border rounded-lg text-sm text-theme-text-primary placeholder-theme-text-tertiary focus:outline-none focus:ring-2 focus:ring-blue-500"
+                />
+              </div>
+              <button
+                onClick={() => {
+                  setSearchContents(!searchContents)
+                  setGrepPattern('')
+                }}
+                title="Search inside text files instead of file names"
+                className={clsx(
+                  'px-3 py-2 text-xs rounded-lg border',
+                  searchContents
+                    ? 'bg-blue-500/20 border-blue-500/40 text-blue-400'
+                    : 'border-theme-border text-theme-text-secondary hover:text-theme-text-primary hover:bg-theme-elevated'
+                )}
+              >
+                Contents
+              </button>
             </div>
           </div>
         )}
@@ -184,10 +265,11 @@ export function ImageFilesystemModal({
               <div className="flex items-start gap-3">
                 <AlertTriangle className="w-5 h-5 text-red-400 shrink-0 mt-0.5" />
                 <div>
-                  <div className="font-medium text-red-400">Failed to inspect image</div>
+                  <div className="font-medium text-red-400">{inspectErrorTitle(error)}</div>
                   <div className="text-sm text-theme-text-secondary mt-1">
                     {error instanceof Error ? error.message : 'Unknown error'}
                   </div>
+                  {authCheck && <AuthCheckDetails check={authCheck} />}
                 </div>
               </div>
             </div>
@@ -202,8 +284,13 @@ export function ImageFilesystemModal({
             />
           )}
 
+          {/* Content search results */}
+          {showFilesystem && searchContents && grepPattern && (
+            <GrepResults result={grepResult} isLoading={isGrepping} error={grepError} />
+          )}
+
           {/* Filesystem tree */}
-          {showFilesystem && (
+          {showFilesystem && !(searchContents && grepPattern) && (
             <FileTreeView
               root={displayFilesystem.root}
               searchQuery={searchQuery}
@@ -233,6 +320,50 @@ export function ImageFilesystemModal({
   )
 }
 
+// ============================================================================
+// Auth Check Details Component
+// ============================================================================
+
+function AuthCheckDetails({ check }: { check: ImageAuthCheck }) {
+  return (
+    <div className="mt-3 text-xs text-theme-text-secondary space-y-2">
+      <div>{check.summary}</div>
+      {check.pullSecrets.length > 0 && (
+        <div>
+          <div className="font-medium text-theme-text-primary">Pull secrets</div>
+          <ul className="mt-1 space-y-0.5">
+            {check.pullSecrets.map((ps) => (
+              <li key={ps.name}>
+                <span className="font-mono">{ps.name}</span>
+                <span className="text-theme-text-tertiary"> ({ps.source})</span>
+                {ps.matches ? ' has credentials for ' + check.registry : ps.problem ? ': ' + ps.problem : ''}
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+      <div>
+        <div className="font-medium text-theme-text-primary">Credential sources, in order</div>
+        <ul className="mt-1 space-y-0.5">
+          {check.sources.map((source) => (
+            <li key={source.source}>
+              <span className="font-mono">{source.source}</span>
+              {': '}
+              {!source.enabled
+                ? 'disabled by --image-auth-order'
+                : source.used
+                  ? 'used'
+                  : source.hasCredentials
+                    ? 'has credentials, shadowed by an earlier source'
+                    : 'no credentials for ' + check.registry}
+            </li>
+          ))}
+        </ul>
+      </div>
+    </div>
+  )
+}
+
 // ============================================================================
 // Download Confirmation Component
 // ============================================================================
@@ -490,7 +621,8 @@ function FileTreeNode({ node, depth, defaultExpanded = true, image, namespace, p
   const [downloading, setDownloading] = useState(false)
   const isDir = node.type === 'dir'
   const isSymlink = node.type === 'symlink'
-  const isFile = node.type === 'file'
+  const isHardlink = node.type === 'hardlink'
+  const isFile = node.type === 'file' || isHardlink
 
   const handleDownload = async (e: React.MouseEvent) => {
     e.stopPropagation()
@@ -569,6 +701,12 @@ function FileTreeNode({ node, depth, defaultExpanded = true, image, namespace, p
           </span>
         )}
 
+        {isHardlink && node.linkTarget && (
+          <span className="text-xs text-theme-text-tertiary truncate max-w-48" title="Hardlink">
+            = {node.linkTarget}
+          </span>
+        )}
+
         {!isDir && node.size !== undefined && (
           <span className="text-xs text-theme-text-tertiary ml-2">
             {formatBytes(node.size)}
@@ -648,3 +786,55 @@ function filterTree(node: FileNode, query: string): FileNode | null {
 
   return null
 }
+
+// Files whose content matched the search, with their matching lines
+function GrepResults({ result, isLoading, error }: { result?: ImageGrepResult; isLoading: boolean; error: Error | null }) {
+  if (isLoading) {
+    return (
+      <div className="flex items-center gap-2 text-sm text-theme-text-secondary">
+        <Loader2 className="w-4 h-4 animate-spin" />
+        Searching file contents...
+      </div>
+    )
+  }
+  if (error) {
+    return <div className="text-sm text-red-400">{error.message}</div>
+  }
+  if (!result) return null
+
+  const skipped = [
+    result.skippedLarge > 0 && `${result.skippedLarge} over ${formatBytes(result.maxFileSize)}`,
+    result.skippedBinary > 0 && `${result.skippedBinary} binary`,
+  ].filter(Boolean)
+
+  return (
+    <div className="space-y-3">
+      <div className="text-xs text-theme-text-tertiary">
+        {result.totalMatches.toLocaleString()} matches in {result.files.length.toLocaleString()} of {result.filesSearched.toLocaleString()} text files
+        {skipped.length > 0 && ` (skipped ${skipped.join(', ')})`}
+        {result.truncated && ' - stopped at the match limit, narrow the pattern'}
+      </div>
+      {result.files.length === 0 && (
+        <div className="text-center text-theme-text-tertiary py-8">No file contents match</div>
+      )}
+      {result.files.map((file) => (
+        <div key={file.path} className="bg-theme-elevated/30 rounded-lg p-3">
+          <div className="flex items-center gap-2 text-sm">
+            <File className="w-4 h-4 text-theme-text-tertiary shrink-0" />
+            <span className="font-mono text-theme-text-primary break-all">{file.path}</span>
+            <span className="text-xs text-theme-text-tertiary">{formatBytes(file.size)}</span>
+          </div>
+          <div className="mt-2 space-y-0.5">
+            {file.matches.map((m) => (
+              <div key={m.line} className="flex gap-3 font-mono text-xs">
+                <span className="text-theme-text-tertiary w-10 text-right shrink-0">{m.line}</span>
+                <span className="text-theme-text-secondary whitespace-pre-wrap break-all">{m.text}</span>
+              </div>
+            ))}
+            {file.moreMatches && <div className="text-xs text-theme-text-tertiary pl-12">More matches not shown</div>}
+          </div>
+        </div>
+      ))}
+    </div>
+  )
+}
@@ -151,6 +151,7 @@ import {
 } from './resource-utils'
 import { Tooltip } from '../ui/Tooltip'
 import { getResourceIcon } from '../../utils/resource-icons'
+import { API_BASE } from '../../utils/base-path'
 
 // Filter options for different resource kinds
 const POD_PHASES = ['Running', 'Pending', 'Succeeded', 'Failed', 'Unknown'] as const
@@ -878,7 +879,7 @@ export function ResourcesView({ namespace, selectedResource, onResourceClick, on
         const params = new URLSearchParams()
         if (namespace) params.set('namespace', namespace)
         if (resource.group) params.set('group', resource.group)
-        const res = await fetch(`/api/resources/${resource.name}?${params}`)
+        const res = await fetch(`${API_BASE}/resources/${resource.name}?${params}`)
         if (!res.ok) return []
         return res.json()
       },
@@ -341,7 +341,8 @@ export function RelatedResourcesSection({ relationships, onNavigate }: RelatedRe
     (relationships.pods && relationships.pods.length > 0) ||
     (relationships.configRefs && relationships.configRefs.length > 0) ||
     relationships.hpa ||
-    relationships.scaleTarget
+    relationships.scaleTarget ||
+    relationships.helmRelease
 
   if (!hasRelationships) return null
 
@@ -387,6 +388,11 @@ export function RelatedResourcesSection({ relationships, onNavigate }: RelatedRe
         {relationships.scaleTarget && (
           <RelationshipGroup label="Scale Target" refs={[relationships.scaleTarget]} onNavigate={onNavigate} />
         )}
+
+        {/* Helm release managing this resource (shown in the Helm view) */}
+        {relationships.helmRelease && (
+          <RelationshipGroup label="Helm Release" refs={[{ kind: 'helm', ...relationships.helmRelease }]} />
+        )}
       </div>
     </Section>
   )
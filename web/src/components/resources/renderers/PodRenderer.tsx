@@ -7,7 +7,8 @@ import { PortForwardInlineButton } from '../../portforward/PortForwardButton'
 import { useOpenTerminal, useOpenLogs } from '../../dock'
 import { Tooltip } from '../../ui/Tooltip'
 import { useCanExec, useCanViewLogs, useCanPortForward } from '../../../contexts/CapabilitiesContext'
-import { usePodMetrics, usePodMetricsHistory } from '../../../api/client'
+import { usePodMetrics, usePodMetricsHistory, usePodResourceRecommendations } from '../../../api/client'
+import type { ContainerRecommendation } from '../../../api/client'
 import { MetricsChart } from '../../ui/MetricsChart'
 import { ImageFilesystemModal } from '../ImageFilesystemModal'
 
@@ -100,6 +101,7 @@ export function PodRenderer({ data, onCopy, copied }: PodRendererProps) {
   // Fetch pod metrics (current and historical)
   const { data: metrics } = usePodMetrics(namespace, podName)
   const { data: metricsHistory } = usePodMetricsHistory(namespace, podName)
+  const { data: recommendations } = usePodResourceRecommendations(namespace, podName)
 
   // Check for problems
   const problems = getPodProblems(data)
@@ -339,6 +341,7 @@ export function PodRenderer({ data, onCopy, copied }: PodRendererProps) {
                   <div className="flex items-center justify-between mb-3">
                     <span className="text-sm font-medium text-theme-text-primary">{historyContainer.name}</span>
                   </div>
+                  <RecommendationHints recommendation={recommendations?.containers.find(c => c.name === historyContainer.name)} />
 
                   {dataPoints && dataPoints.length > 0 ? (
                     <div className="grid grid-cols-2 gap-6">
@@ -419,3 +422,29 @@ export function PodRenderer({ data, onCopy, copied }: PodRendererProps) {
     </>
   )
 }
+
+// Show request recommendations worth acting on; ok and not-enough-data verdicts stay quiet
+function RecommendationHints({ recommendation }: { recommendation?: ContainerRecommendation }) {
+  if (!recommendation) return null
+  const hints = [
+    { label: 'CPU', rec: recommendation.cpu },
+    { label: 'Memory', rec: recommendation.memory },
+  ].filter(({ rec }) => rec.verdict !== 'ok' && rec.verdict !== 'insufficient-data')
+  if (hints.length === 0) return null
+
+  return (
+    <div className="mb-3 space-y-1">
+      {hints.map(({ label, rec }) => (
+        <div
+          key={label}
+          className={clsx(
+            'text-xs px-2 py-1 rounded',
+            rec.verdict === 'over-provisioned' ? 'bg-blue-500/10 text-blue-400' : 'bg-yellow-500/10 text-yellow-400'
+          )}
+        >
+          {label}: {rec.message}
+        </div>
+      ))}
+    </div>
+  )
+}
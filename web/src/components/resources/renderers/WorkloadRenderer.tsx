@@ -1,6 +1,8 @@
-import { Server, AlertTriangle, ExternalLink } from 'lucide-react'
+import { Server, AlertTriangle, ExternalLink, Network } from 'lucide-react'
 import { useNavigate } from 'react-router-dom'
 import { Section, PropertyList, Property, ConditionsSection, PodTemplateSection } from '../drawer-components'
+import { useWorkloadTraffic } from '../../../api/traffic'
+import type { TrafficPeer } from '../../../types'
 
 interface WorkloadRendererProps {
   kind: string
@@ -168,6 +170,10 @@ export function WorkloadRenderer({ kind, data }: WorkloadRendererProps) {
         </PropertyList>
       </Section>
 
+      <Section title="Network Traffic" icon={Network} defaultExpanded={false}>
+        <WorkloadTraffic kind={kind} namespace={metadata.namespace} name={metadata.name} />
+      </Section>
+
       <Section title="Pod Template" defaultExpanded={false}>
         <PodTemplateSection template={spec.template} />
       </Section>
@@ -176,3 +182,72 @@ export function WorkloadRenderer({ kind, data }: WorkloadRendererProps) {
     </>
   )
 }
+
+// Who the workload's pods talk to over the last 15 minutes, from the active
+// traffic source. Mounted only when the section is expanded.
+function WorkloadTraffic({ kind, namespace, name }: { kind: string; namespace: string; name: string }) {
+  const navigate = useNavigate()
+  const { data, isLoading, error } = useWorkloadTraffic(kind, namespace, name)
+
+  if (isLoading) {
+    return <div className="text-xs text-theme-text-tertiary">Loading traffic...</div>
+  }
+  if (error) {
+    return <div className="text-xs text-red-400">{error instanceof Error ? error.message : 'Failed to load traffic'}</div>
+  }
+  if (!data) return null
+  if (data.warning) {
+    return <div className="text-xs text-yellow-400">{data.warning}</div>
+  }
+
+  const { traffic } = data
+  const dropReasons = Object.entries(traffic.dropReasons).sort((a, b) => b[1] - a[1])
+
+  return (
+    <div className="space-y-3">
+      <PropertyList>
+        <Property label="Flows" value={`${traffic.flows} in the last ${data.since}`} />
+        <Property label="Dropped" value={traffic.dropped > 0 ? String(traffic.dropped) : 'None'} />
+      </PropertyList>
+      {dropReasons.length > 0 && (
+        <div className="text-xs text-red-300">
+          {dropReasons.map(([reason, count]) => `${reason} (${count})`).join(', ')}
+        </div>
+      )}
+      <TrafficPeers title="Inbound" peers={traffic.inbound} />
+      <TrafficPeers title="Outbound" peers={traffic.outbound} />
+      <button
+        onClick={() => navigate(`/traffic?namespace=${encodeURIComponent(namespace)}`)}
+        className="flex items-center gap-1.5 px-3 py-1.5 text-xs font-medium text-blue-400 hover:text-blue-300 bg-blue-500/10 hover:bg-blue-500/20 border border-blue-500/30 rounded transition-colors"
+      >
+        <ExternalLink className="w-3 h-3" />
+        Open Traffic View
+      </button>
+    </div>
+  )
+}
+
+function TrafficPeers({ title, peers }: { title: string; peers: TrafficPeer[] }) {
+  return (
+    <div>
+      <div className="text-xs font-medium text-theme-text-secondary mb-1">{title}</div>
+      {peers.length === 0 ? (
+        <div className="text-xs text-theme-text-tertiary">No flows</div>
+      ) : (
+        <ul className="space-y-0.5">
+          {peers.map((peer) => (
+            <li key={`${peer.kind}/${peer.namespace}/${peer.name}`} className="flex items-center justify-between gap-2 text-xs">
+              <span className="truncate text-theme-text-primary" title={peer.ports?.length ? `Ports: ${peer.ports.join(', ')}` : undefined}>
+                {peer.namespace ? `${peer.namespace}/${peer.name}` : peer.name}
+              </span>
+              <span className="shrink-0 text-theme-text-tertiary">
+                {peer.flows}
+                {peer.dropped > 0 && <span className="text-red-400"> · {peer.dropped} dropped</span>}
+              </span>
+            </li>
+          ))}
+        </ul>
+      )}
+    </div>
+  )
+}
@@ -385,6 +385,8 @@ export function TrafficView({ namespace }: TrafficViewProps) {
   } = useTrafficFlows({
     namespace,
     since: timeRange,
+    // Showing system traffic also turns off the server-side noise exclusions
+    exclude: hideSystem ? undefined : 'none',
     enabled: wizardState === 'ready',
   })
   const [refetchFlows, isRefreshAnimating] = useRefreshAnimation(refetchFlowsRaw)
@@ -66,3 +66,9 @@ export function useCanPortForward(): boolean {
 export function useCanViewSecrets(): boolean {
   return useContext(CapabilitiesContext).secrets
 }
+
+// True when the cluster forbids SelfSubjectAccessReviews, so capabilities are
+// probed or assumed rather than known; features may fail when used
+export function useRBACIntrospectionUnavailable(): boolean {
+  return useContext(CapabilitiesContext).rbacIntrospectionUnavailable ?? false
+}
@@ -1,5 +1,6 @@
 import { useState, useEffect, useCallback, useRef } from 'react'
 import type { Topology, K8sEvent, ViewMode } from '../types'
+import { API_BASE } from '../utils/base-path'
 
 interface UseEventSourceReturn {
   topology: Topology | null
@@ -66,7 +67,7 @@ export function useEventSource(
     if (viewMode && viewMode !== 'resources') {
       params.set('view', viewMode)
     }
-    const url = `/api/events/stream${params.toString() ? `?${params}` : ''}`
+    const url = `${API_BASE}/events/stream${params.toString() ? `?${params}` : ''}`
 
     // Create new EventSource
     const es = new EventSource(url)
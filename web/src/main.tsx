@@ -5,6 +5,7 @@ import { BrowserRouter } from 'react-router-dom'
 import App from './App'
 import { ToastProvider, showApiError, showApiSuccess } from './components/ui/Toast'
 import { ThemeProvider } from './context/ThemeContext'
+import { BASE_PATH } from './utils/base-path'
 import './index.css'
 
 // Type the meta property for mutations
@@ -53,7 +54,7 @@ const queryClient = new QueryClient({
 
 ReactDOM.createRoot(document.getElementById('root')!).render(
   <React.StrictMode>
-    <BrowserRouter>
+    <BrowserRouter basename={BASE_PATH || undefined}>
       <ThemeProvider>
         <QueryClientProvider client={queryClient}>
           <ToastProvider>
@@ -6,6 +6,26 @@ export interface Capabilities {
   logs: boolean        // Log viewer (pods/log)
   portForward: boolean // Port forwarding (pods/portforward)
   secrets: boolean     // List secrets
+  readOnly: boolean    // Server runs with --read-only: all write actions are refused
+  rbacIntrospectionUnavailable?: boolean // SelfSubjectAccessReview is forbidden: list access was probed, the rest is assumed
+}
+
+// What the user can do in one namespace (GET /capabilities/namespaces)
+export interface NamespacePermissions {
+  namespace: string
+  list: boolean   // list pods
+  get: boolean    // get pods
+  edit: boolean   // update deployments
+  delete: boolean // delete pods
+  exec: boolean   // create pods/exec
+  logs: boolean   // get pods/log
+}
+
+export interface NamespacePermissionMatrix {
+  namespaces: NamespacePermissions[]
+  rbacIntrospectionUnavailable?: boolean
+  truncated?: boolean // Only the first page of cluster namespaces was checked
+  continue?: string   // Pass as ?continue= for the next page
 }
 
 export type NodeKind =
@@ -131,6 +151,33 @@ export interface TimelineEvent {
   correlationId?: string
 }
 
+// Change history summary (from /api/changes/summary)
+export interface ChangeSummaryCount {
+  key: string
+  count: number
+}
+
+export interface ChangeSummaryBucket {
+  start: string // ISO date string
+  count: number
+  create?: number
+  update?: number
+  delete?: number
+}
+
+export interface ChangeSummary {
+  since: string
+  until: string
+  bucket: string // Go duration, e.g. '1m0s'
+  total: number
+  byKind: ChangeSummaryCount[] // Most changes first
+  byNamespace: ChangeSummaryCount[] // '' for cluster-scoped resources
+  byVerb: ChangeSummaryCount[] // 'create', 'update', 'delete' ('event' with K8s events)
+  timeline: ChangeSummaryBucket[] // Oldest first
+  peak?: ChangeSummaryBucket
+  truncated?: boolean // Only the most recent changes of the window were counted
+}
+
 // Helper to check if event is a change (vs K8s event)
 export function isChangeEvent(event: TimelineEvent): boolean {
   return event.source === 'informer' || event.source === 'historical'
@@ -252,6 +299,30 @@ export interface Relationships {
   hpa?: ResourceRef
   scaleTarget?: ResourceRef
   pods?: ResourceRef[]
+  helmRelease?: { name: string; namespace: string } // Helm release managing this, directly or via its owner
+}
+
+// Dependency graph around a resource (GET /api/resources/{kind}/{ns}/{name}/graph)
+export interface ResourceGraph {
+  root: string // ID of the resource the graph was built for
+  nodes: ResourceGraphNode[]
+  edges: ResourceGraphEdge[]
+}
+
+export interface ResourceGraphNode {
+  id: string // kind/namespace/name
+  kind: string
+  namespace: string
+  name: string
+  missing?: boolean // Referenced but not in the cache
+}
+
+// Points from the resource that refers to another to the one it refers to
+export interface ResourceGraphEdge {
+  source: string
+  target: string
+  type: 'owns' | 'selects' | 'mounts' | 'env' | 'serviceAccount' | 'imagePullSecret'
+  detail?: string // Volume or container name
 }
 
 // Resource with computed relationships (API response wrapper)
@@ -260,6 +331,25 @@ export interface ResourceWithRelationships<T = unknown> {
   relationships?: Relationships
 }
 
+// Rollout progress of a workload, as kubectl rollout status reports it
+export interface WorkloadRollout {
+  complete: boolean
+  failed?: boolean // Deployment exceeded its progress deadline
+  message: string
+  revision?: string
+}
+
+// Response from GET /api/workloads/{kind}/{namespace}/{name}
+export interface WorkloadDetail<T = unknown> {
+  workload: T
+  status?: { status: string; ready?: string; message?: string; summary?: string; issue?: string }
+  rollout: WorkloadRollout
+  replicaSets?: unknown[] // Deployments only, newest first
+  pods: unknown[]
+  events: unknown[] // Newest first, for the workload, its ReplicaSets and pods
+  relationships?: Relationships
+}
+
 // API Resource (from discovery endpoint)
 export interface APIResource {
   group: string
@@ -271,6 +361,19 @@ export interface APIResource {
   verbs: string[]
 }
 
+// Listable resource kind with informer state and the user's list permission
+export interface ResourceKind {
+  group: string
+  version: string
+  resource: string // Plural name (e.g., "deployments")
+  kind: string
+  namespaced: boolean
+  isCrd: boolean
+  watching: boolean // An informer is running for this type
+  synced: boolean
+  canList: boolean
+}
+
 // Helm release types
 export interface HelmRelease {
   name: string
@@ -285,6 +388,7 @@ export interface HelmRelease {
   resourceHealth?: 'healthy' | 'degraded' | 'unhealthy' | 'unknown'
   healthIssue?: string    // Primary issue if unhealthy (e.g., "OOMKilled")
   healthSummary?: string  // Brief summary like "2/3 pods ready"
+  healthMessage?: string  // Rollup like "Degraded: 1 deployment not ready"
 }
 
 export interface HelmRevision {
@@ -312,6 +416,10 @@ export interface HelmReleaseDetail {
   hooks?: HelmHook[]
   readme?: string
   dependencies?: ChartDependency[]
+  resourceHealth?: 'healthy' | 'degraded' | 'unhealthy' | 'unknown'
+  healthIssue?: string
+  healthSummary?: string
+  healthMessage?: string
 }
 
 export interface HelmHook {
@@ -367,6 +475,40 @@ export interface UpgradeInfo {
   error?: string
 }
 
+// A configured repository that has the release's chart
+export interface ChartSourceCandidate {
+  repository: string
+  url: string
+  hasVersion: boolean
+  chartUrl?: string
+  digest?: string
+  latestVersion?: string
+  metadataMismatch?: string
+}
+
+export interface SourceReachability {
+  reachable: boolean
+  url: string
+  statusCode?: number
+  error?: string
+}
+
+// Where a release's chart most likely came from
+export interface ChartProvenance {
+  chart: string
+  version: string
+  appVersion?: string
+  home?: string
+  sources?: string[]
+  sourceType: 'repository' | 'flux' | 'unknown'
+  resolved?: ChartSourceCandidate
+  candidates: ChartSourceCandidate[]
+  reachability?: SourceReachability
+  managedBy?: string
+  canUpgrade: boolean
+  message: string
+}
+
 // Batch upgrade info (map of "namespace/name" to UpgradeInfo)
 export interface BatchUpgradeInfo {
   releases: Record<string, UpgradeInfo>
@@ -384,6 +526,17 @@ export interface ValuesPreviewResponse {
   manifestDiff: string
 }
 
+export interface RollbackPreview {
+  currentRevision: number
+  targetRevision: number
+  currentChartVersion: string
+  targetChartVersion: string
+  manifestDiff: string
+  added?: HelmOwnedResource[]
+  removed?: HelmOwnedResource[]
+  changed?: HelmOwnedResource[]
+}
+
 // ============================================================================
 // Chart Browser Types
 // ============================================================================
@@ -431,6 +584,31 @@ export interface ChartSearchResult {
 }
 
 // Request body for installing a new chart
+export interface HelmTemplateRequest {
+  repository: string
+  chartName: string
+  version: string
+  releaseName?: string
+  namespace?: string
+  values?: Record<string, unknown>
+  includeCrds?: boolean
+}
+
+export interface HelmRenderedResource {
+  kind: string
+  name: string
+  namespace: string
+  source?: string  // Template the resource was rendered from
+  manifest: string
+}
+
+export interface HelmTemplateResponse {
+  manifest: string
+  chartVersion: string
+  resources: HelmRenderedResource[]
+  notes?: string
+}
+
 export interface InstallChartRequest {
   releaseName: string
   namespace: string
@@ -536,6 +714,32 @@ export interface TrafficEndpoint {
   labels?: Record<string, string>
   workload?: string
   port?: number
+  identity?: number // Cilium security identity (Hubble only)
+}
+
+// Cilium security identities and endpoints (GET /traffic/identities)
+export interface CiliumIdentity {
+  id: number
+  name: string // Reserved name or workload
+  namespace?: string
+  reserved?: boolean
+  labels: string[] // e.g. k8s:app=web, reserved:host
+  endpoints: number
+}
+
+export interface CiliumEndpoint {
+  name: string
+  namespace: string
+  identity: number
+  state?: string
+  ips?: string[]
+}
+
+export interface CiliumIdentities {
+  identities: CiliumIdentity[]
+  endpoints: CiliumEndpoint[]
+  listedAt: string
+  warning?: string
 }
 
 // Traffic flow between two endpoints
@@ -548,13 +752,34 @@ export interface TrafficFlow {
   httpMethod?: string
   httpPath?: string
   httpStatus?: number
+  latencyMs?: number // L7 response time (response flows only)
   bytesSent: number
   bytesRecv: number
   connections: number
+  count?: number // Flow events collapsed into this flow
   verdict: string // forwarded, dropped, error
+  direction?: 'ingress' | 'egress'
+  dropReason?: string // e.g. policy_denied
+  policy?: PolicyRef // Policy the drop is attributed to
+  tcpFlags?: TCPFlags
+  state?: 'new' | 'established' | 'closing' // TCP connection state, derived from tcpFlags
   lastSeen: string // ISO date string
 }
 
+export interface PolicyRef {
+  kind: string // CiliumNetworkPolicy, CiliumClusterwideNetworkPolicy
+  name: string
+  namespace?: string
+  inferred?: boolean // Matched by endpoint selector rather than reported by Cilium
+}
+
+export interface TCPFlags {
+  syn?: boolean
+  ack?: boolean
+  fin?: boolean
+  rst?: boolean
+}
+
 // Aggregated flow by service pair
 export interface AggregatedFlow {
   source: TrafficEndpoint
@@ -617,6 +842,17 @@ export interface TrafficSourcesResponse {
   detected: TrafficSourceStatus[]
   notDetected: string[]
   recommended?: TrafficRecommendation
+  capabilities: TrafficCapability[]
+  requirement?: string // Set when no backend is available
+}
+
+// One traffic backend's availability in the cluster
+export interface TrafficCapability {
+  backend: 'hubble' | 'istio' | 'caretta' | 'calico-flow-logs' | string
+  label: string
+  status: 'available' | 'unsupported' | 'disabled' | 'error' | 'not_installed'
+  active?: boolean
+  message?: string
 }
 
 // Response from GET /api/traffic/flows
@@ -626,6 +862,61 @@ export interface TrafficFlowsResponse {
   flows: TrafficFlow[]
   aggregated: AggregatedFlow[]
   warning?: string  // Non-fatal warning (e.g., query errors)
+  limit?: number  // Flows requested from the source
+  limitClamped?: boolean  // The requested limit exceeded the server's maximum
+}
+
+// A peer a workload exchanged flows with (pods grouped by workload)
+export interface TrafficPeer {
+  namespace?: string
+  name: string
+  kind: 'Workload' | 'Pod' | 'Service' | 'External' | string
+  flows: number
+  dropped: number
+  ports?: number[]
+  lastSeen: string
+}
+
+// Response from GET /api/traffic/workloads/{kind}/{namespace}/{name}
+export interface WorkloadTrafficResponse {
+  namespace: string
+  kind: string
+  name: string
+  selector: string  // Pod label selector the flows were filtered by
+  source: string
+  timestamp: string
+  since: string
+  limit?: number
+  limitClamped?: boolean
+  warning?: string
+  traffic: {
+    flows: number
+    dropped: number
+    dropReasons: Record<string, number>
+    inbound: TrafficPeer[]  // Busiest first
+    outbound: TrafficPeer[]
+  }
+}
+
+// Structured flow filter for POST /api/traffic/flows. Set fields are AND'd,
+// values within a field are OR'd. Namespaces, workloads, pods and identities match
+// either endpoint; ports match the destination port. Hubble only.
+export interface FlowFilterSpec {
+  namespaces?: string[]
+  workloads?: { namespace: string; name: string; kind?: string }[]
+  pods?: { namespace: string; selector: string }[]  // Label selectors, e.g. "app=web"
+  protocols?: string[]  // tcp, udp, sctp, icmp, icmpv4, icmpv6, http, dns, kafka
+  ports?: number[]
+  verdicts?: string[]  // forwarded, dropped, error, audit, redirected, traced, translated
+  identities?: number[]
+  directions?: ('ingress' | 'egress')[]
+  l7?: {
+    httpMethods?: string[]
+    httpPaths?: string[]  // Regexes
+    httpStatus?: string[]  // Codes or prefixes, e.g. "404", "5+"
+    dnsQueries?: string[]  // Regexes
+  }
+  exclude?: Omit<FlowFilterSpec, 'exclude'>  // Flows matching this are left out
 }
 
 // Wizard state for traffic setup
@@ -653,20 +944,94 @@ export type ExtendedMainView = MainView | 'traffic'
 export interface FileNode {
   name: string
   path: string
-  type: 'file' | 'dir' | 'symlink'
+  type: 'file' | 'dir' | 'symlink' | 'hardlink' | 'whiteout' // whiteout only in single-layer trees
   size?: number
+  totalSize?: number // Dirs only: aggregate size of descendant files
   permissions?: string
   mode?: number
   modTime?: string
   linkTarget?: string
   children?: FileNode[]
+  hasChildren?: boolean // Children omitted by a depth limit; fetch via /images/ls
+  opaque?: boolean      // Single-layer trees only: hides everything below it from lower layers
 }
 
 // Image layer information
 export interface LayerInfo {
+  index: number              // Position in the image, 0 = base layer
   digest: string
-  size: number
+  size: number               // Compressed size in the registry
+  uncompressedSize?: number  // Size on disk, known once cached
   mediaType: string
+  createdBy?: string         // Build step from the image history
+}
+
+// Image layers ranked by size, largest first
+export interface ImageLayers {
+  image: string
+  digest: string
+  platform: string
+  totalSize: number  // Total compressed size of all layers
+  cached: boolean    // Whether uncompressed sizes are known
+  layers: LayerInfo[]
+}
+
+// File tree of a single layer (GET /images/layer)
+export interface LayerFilesystem {
+  image: string
+  digest: string
+  layer: LayerInfo
+  root: FileNode
+  totalFiles: number
+  totalSize: number
+  deletions: number // Whiteouts, including opaque directories
+  truncated?: boolean
+}
+
+// One file's contents compared between two images
+export interface ImageFileDiff {
+  path: string
+  from: string
+  to: string
+  fromExists: boolean
+  toExists: boolean
+  identical: boolean
+  binary?: boolean   // Either side isn't text, so there is no diff
+  tooLarge?: boolean // Either side is over 1 MiB, so there is no diff
+  diff?: string      // Unified diff from `from` to `to`
+}
+
+// Startup check of a persisted image layer cache
+export interface ImageCacheValidation {
+  validatedAt: string
+  duration: string
+  kept: number          // Images whose metadata and layers checked out
+  removed: number       // Images dropped as expired, incomplete or corrupt
+  removedLayers: number // Layer files that failed their digest check
+}
+
+// On-disk image layer cache
+export interface ImageCacheStatus {
+  dir: string
+  persistent: boolean // Survives restarts (--persist-cache)
+  ttl: string
+  images: number
+  layers: number
+  size: number // Bytes used by the layer store
+  validation?: ImageCacheValidation
+}
+
+// Pull budget a registry last reported in its RateLimit headers (GET /api/images/rate-limits)
+export interface RegistryRateLimit {
+  registry: string
+  limit?: number // Pulls per window, when reported
+  remaining: number
+  windowSeconds?: number
+  resetsAt?: string // When pulls are available again, if the registry said
+  anonymous?: boolean // Counted per IP rather than per account
+  throttled: boolean // The last response was 429
+  observedAt: string
+  summary: string // e.g. "0 of 100 anonymous pulls remaining, resets in 20m"
 }
 
 // Complete image filesystem response
@@ -678,6 +1043,7 @@ export interface ImageFilesystem {
   totalFiles: number
   totalSize: number
   layers?: LayerInfo[]
+  truncated?: boolean
   error?: string
 }
 
@@ -691,4 +1057,255 @@ export interface ImageMetadata {
   cached: boolean      // Whether filesystem is already cached
   filesystem?: ImageFilesystem  // Included if cached
   authMethod: string   // "anonymous", "google", "credentials", etc.
+  signature?: ImageSignatureStatus  // Only when requested with ?signature=true
+}
+
+// One matching line of an image content search
+export interface ImageGrepMatch {
+  line: number // 1-based
+  text: string // Cut around the match when long
+}
+
+export interface ImageGrepFile {
+  path: string
+  size: number
+  matches: ImageGrepMatch[]
+  moreMatches?: boolean
+}
+
+// Files of an image whose content matches a regex; large and binary files are skipped
+export interface ImageGrepResult {
+  image: string
+  pattern: string
+  path: string
+  files: ImageGrepFile[]
+  totalMatches: number
+  filesSearched: number
+  skippedLarge: number
+  skippedBinary: number
+  truncated: boolean
+  maxFileSize: number
+}
+
+// One image of a batch metadata request
+export interface BatchImageRef {
+  image: string
+  namespace?: string
+  pod?: string // Pull secrets are discovered from the pod when none are given
+  pullSecrets?: string[]
+}
+
+// One item of a batch metadata response: metadata (without filesystem) or an error
+export interface BatchImageMetadataResult extends BatchImageRef {
+  metadata?: ImageMetadata
+  error?: string
+  code?: string // e.g. IMAGE_NOT_FOUND, IMAGE_UNAUTHORIZED
+}
+
+// Cosign signatures and attestations found for an image (presence only, not verified)
+export interface ImageSignatureStatus {
+  signed: boolean
+  attested: boolean
+  provenance: boolean         // An attestation has a SLSA provenance predicate
+  predicateTypes?: string[]
+  signer?: string             // Keyless certificate identity (email or URI)
+  signerIssuer?: string       // OIDC issuer of the keyless certificate
+  digest?: string             // Digest the signature was found for
+  error?: string
+}
+
+export interface ResolvedImageReference {
+  image: string
+  reference: string      // Fully-qualified, e.g. docker.io/library/nginx:latest
+  registry: string
+  repository: string
+  tag?: string
+  digest: string         // Digest the reference currently resolves to
+  pinnedDigest?: string  // Digest given in the reference, if any
+  tagMoved?: boolean     // Tag no longer points to the pinned digest
+  mediaType?: string
+  authMethod: string
+}
+
+export interface ClusterImage {
+  image: string
+  podCount: number
+  namespaces: string[]
+  digests?: string[]   // Running digests reported by container statuses
+  cached: boolean      // Whether the filesystem is in the layer cache
+  digest?: string      // What the reference resolves to, once known
+  platform?: string    // e.g. linux/arm64, once known
+  totalSize?: number   // Uncompressed size of the cached layers
+  compressedSize?: number // Compressed size of all layers per the registry
+  pending?: boolean    // Registry lookup still running in the background; refetch later
+  lookupError?: string // Why the registry lookup failed
+  workloads?: ImageWorkload[] // With workloads=true, most pods first
+}
+
+// A workload whose pods run an image
+export interface ImageWorkload {
+  namespace: string
+  kind: string         // Deployment, StatefulSet, DaemonSet, CronJob, Job, ... or Pod for bare pods
+  name: string
+  pods: number
+  instance?: string    // app.kubernetes.io/instance, e.g. a Helm release
+  managedBy?: string   // app.kubernetes.io/managed-by, e.g. Helm
+}
+
+export interface PodContainerImage {
+  container: string
+  type: 'container' | 'init' | 'ephemeral'
+  image: string
+  runningDigest?: string
+}
+
+export interface PodImages {
+  namespace: string
+  pod: string
+  containers: PodContainerImage[]
+  pullSecrets?: string[]
+}
+
+export interface ContainerImageDrift {
+  container: string
+  init?: boolean
+  ephemeral?: boolean     // Debug container added with kubectl debug
+  image: string
+  runningDigest?: string  // From the container status imageID
+  currentDigest?: string  // What the tag resolves to now
+  drifted: boolean
+  error?: string
+}
+
+export interface PodImageDrift {
+  namespace: string
+  pod: string
+  drifted: boolean
+  containers: ContainerImageDrift[]
+}
+
+// A pod container's image config, its pod spec overrides and the resulting startup (GET /images/startup)
+export interface ImageStartupConfig {
+  entrypoint?: string[]
+  cmd?: string[]
+  env?: string[] // KEY=VALUE
+  workingDir?: string
+  user?: string
+}
+
+export interface StartupEnvVar {
+  name: string
+  value?: string
+  valueFrom?: string // e.g. secretKeyRef:db/password; values from secrets etc. aren't read
+  source?: 'image' | 'pod'
+  overridden?: boolean // Set in the image and replaced by the pod spec
+}
+
+export interface PodStartupOverrides {
+  command?: string[]
+  args?: string[]
+  env?: StartupEnvVar[]
+  envFrom?: string[] // e.g. configMap:app-config
+  workingDir?: string
+  runAsUser?: number
+}
+
+export interface ContainerStartup {
+  namespace: string
+  pod: string
+  container: string
+  type: 'init' | 'container' | 'ephemeral'
+  image: string // Reference the config was read from
+  running: boolean // Pinned to the digest the container runs
+  digest?: string
+  imageConfig?: ImageStartupConfig
+  imageError?: string // Image config unavailable; resolved from the pod spec alone
+  overrides: PodStartupOverrides
+  resolved: {
+    command: string[] // Full argv; $(VAR) references left as written
+    commandSource: 'image' | 'pod'
+    argsSource?: 'image' | 'pod'
+    env: StartupEnvVar[]
+    workingDir?: string
+    user?: string
+  }
+}
+
+// Which credentials were tried for an image and what the registry said (GET /images/auth-check)
+export interface ImageAuthAttempt {
+  ok: boolean
+  statusCode?: number
+  code?: string // API error code, e.g. IMAGE_UNAUTHORIZED
+  error?: string
+}
+
+export interface ImagePullSecretCheck {
+  name: string
+  source: 'pod' | 'serviceAccount' | 'request'
+  found: boolean
+  type?: string
+  registries?: string[]
+  matches: boolean // Has credentials for the image's registry
+  problem?: string
+}
+
+export interface ImageCredentialSourceCheck {
+  source: 'pull-secrets' | 'cloud' | 'docker-config'
+  enabled: boolean
+  hasCredentials: boolean
+  used: boolean // First source with credentials
+}
+
+export interface ImageAuthCheck {
+  image: string
+  registry: string
+  registryType: string
+  namespace?: string
+  pod?: string
+  anonymous: ImageAuthAttempt
+  pullSecrets: ImagePullSecretCheck[]
+  sources: ImageCredentialSourceCheck[]
+  authenticated?: ImageAuthAttempt // Only tried when anonymous access fails
+  authMethod?: string
+  summary: string
+}
+
+// Running image digests compared across each workload's pods (GET /images/mismatches)
+export interface NamespaceImageReport {
+  namespace: string
+  mismatched: number // Workloads with mixed digests
+  workloads: WorkloadImages[] // Mismatched first
+}
+
+export interface WorkloadImages {
+  kind: string // Owning controller, or Pod for pods without one
+  name: string
+  podCount: number
+  mismatched: boolean
+  containers: ContainerImageGroup[]
+}
+
+export interface ContainerImageGroup {
+  container: string
+  init?: boolean
+  mismatched: boolean
+  variants: ImageVariant[] // Most pods first
+  pending?: string[]       // Pods without a running digest yet
+}
+
+export interface ImageVariant {
+  image: string
+  digest: string
+  pods: string[]
+  outlier?: boolean // Not what most of the workload's pods run
+}
+
+// ArgoCD Application status pushed by /argo/applications/{ns}/{name}/watch
+export interface ArgoAppStatus {
+  syncStatus?: string        // Synced, OutOfSync, Unknown
+  revision?: string
+  healthStatus?: string      // Healthy, Progressing, Degraded, Suspended, Missing, Unknown
+  healthMessage?: string
+  operationPhase?: string    // Running, Terminating, Succeeded, Failed, Error
+  operationMessage?: string
 }
@@ -0,0 +1,6 @@
+// Path prefix the app is served under (e.g. "/explorer" behind a reverse proxy).
+// The server injects a <base href> into index.html; empty when served at the root
+// or from the Vite dev server.
+export const BASE_PATH = (document.querySelector('base')?.getAttribute('href') ?? '').replace(/\/+$/, '')
+
+export const API_BASE = `${BASE_PATH}/api`
@@ -4,6 +4,8 @@ import tailwindcss from '@tailwindcss/vite'
 import path from 'path'
 
 export default defineConfig({
+  // Relative asset URLs so the build works under any --base-path
+  base: './',
   plugins: [tailwindcss(), react()],
   resolve: {
     alias: {
@@ -14,7 +16,7 @@ export default defineConfig({
     port: 9273,
     proxy: {
       '/api': {
-        target: 'http://localhost:9280',
+        target: 'http://127.0.0.1:9280',
         changeOrigin: true,
         ws: true, // WebSocket/SSE support
       },